@@ -0,0 +1,386 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Enemy AI as behaviour trees. Each kind's tree is composed from the small
+// reusable nodes below in enemyBehaviors; a new archetype is a new entry
+// there rather than another branch in the update loop. Trees are shared and
+// stateless, anything an enemy has to remember between frames lives in its
+// phase, phaseTimer and shotTimer.
+
+type btStatus int
+
+const (
+	btSuccess btStatus = iota
+	btFailure
+	btRunning
+)
+
+// btContext is what a node sees while ticking one enemy.
+type btContext struct {
+	g      *Game
+	index  int
+	e      *Enemy
+	target *Player
+	dt     float32
+
+	dx, dz float32 // from the enemy to the target
+	dist   float32
+}
+
+type btNode func(c *btContext) btStatus
+
+var enemyBehaviors = [enemyKindCount]btNode{
+	EnemyChaser:   approach(1),
+	EnemySplitter: approach(1),
+
+	EnemyRanged: sequence(
+		selector(
+			sequence(beyond(rangedKeepMax), approach(1)),
+			sequence(within(rangedKeepMin), flee(1)),
+			strafe(0.5),
+		),
+		cooldown(),
+		within(rangedKeepMax+4),
+		lineOfSight(),
+		shoot(rangedFireInterval),
+	),
+
+	EnemyKamikaze: selector(
+		sequence(inPhase(PhaseArming), wait(), detonate()),
+		sequence(within(kamikazeTriggerRange), setPhase(PhaseArming, kamikazeFuse)),
+		advance(1),
+	),
+
+	EnemyShielded: sequence(turnToward(shieldedTurnRate), walkForward()),
+
+	EnemyFlyer: selector(
+		sequence(inPhase(PhaseSwoop), glide(flyerSwoopSpeed, flyerHeight, flyerLowHeight, flyerSwoopTime),
+			setPhase(PhaseClimb, flyerClimbTime)),
+		sequence(inPhase(PhaseClimb), glide(1, flyerLowHeight, flyerHeight, flyerClimbTime), setPhase(PhaseChase, 0)),
+		sequence(within(flyerSwoopRange), lockHeading(), setPhase(PhaseSwoop, flyerSwoopTime)),
+		fly(flyerHeight),
+	),
+}
+
+// runBehavior ticks the behaviour tree of a normal enemy.
+func (g *Game) runBehavior(i int, target *Player, dt float32) {
+	e := &g.enemies[i]
+	c := btContext{g: g, index: i, e: e, target: target, dt: dt}
+	c.dx = target.position.X - e.position.X
+	c.dz = target.position.Z - e.position.Z
+	c.dist = float32(math.Sqrt(float64(c.dx*c.dx + c.dz*c.dz)))
+	enemyBehaviors[e.kind](&c)
+
+	// High flyers pass over the crowd
+	if e.active && flyerLow(e) {
+		c.separate()
+	}
+}
+
+// Separation steering: enemies closer than separationRange times their
+// combined radii push apart, harder the more they overlap, so a crowd
+// spreads around the players instead of stacking on one spot.
+const (
+	separationRange    = float32(1.3)
+	separationStrength = float32(6.0) // units/s at full overlap
+	separationQuery    = float32(3.0)
+)
+
+func (c *btContext) separate() {
+	g, e := c.g, c.e
+	g.neighbours = g.enemiesInRadius(e.position.X, e.position.Z, separationQuery, g.neighbours[:0])
+
+	var pushX, pushZ float32
+	for _, j := range g.neighbours {
+		if j == c.index {
+			continue
+		}
+		other := &g.enemies[j]
+		if !flyerLow(other) {
+			continue
+		}
+		dx := e.position.X - other.position.X
+		dz := e.position.Z - other.position.Z
+		dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
+		want := (e.size + other.size) / 2 * separationRange
+		if dist >= want {
+			continue
+		}
+		if dist < 0.01 {
+			// Exactly on top of each other, split by slot order
+			dx, dz, dist = 1, 0, 1
+			if c.index < j {
+				dx = -1
+			}
+		}
+		overlap := 1 - dist/want
+		pushX += dx / dist * overlap
+		pushZ += dz / dist * overlap
+	}
+	if pushX == 0 && pushZ == 0 {
+		return
+	}
+	c.moveEnemy(pushX, pushZ, separationStrength)
+}
+
+// --- Composites ---
+
+// sequence runs children in order until one doesn't succeed.
+func sequence(children ...btNode) btNode {
+	return func(c *btContext) btStatus {
+		for _, child := range children {
+			if s := child(c); s != btSuccess {
+				return s
+			}
+		}
+		return btSuccess
+	}
+}
+
+// selector runs children in order until one doesn't fail.
+func selector(children ...btNode) btNode {
+	return func(c *btContext) btStatus {
+		for _, child := range children {
+			if s := child(c); s != btFailure {
+				return s
+			}
+		}
+		return btFailure
+	}
+}
+
+// --- Conditions ---
+
+func within(dist float32) btNode {
+	return func(c *btContext) btStatus { return btIf(c.dist < dist) }
+}
+
+func beyond(dist float32) btNode {
+	return func(c *btContext) btStatus { return btIf(c.dist > dist) }
+}
+
+func inPhase(phase EnemyPhase) btNode {
+	return func(c *btContext) btStatus { return btIf(c.e.phase == phase) }
+}
+
+// lineOfSight succeeds when no obstacle stands between the enemy and its
+// target.
+func lineOfSight() btNode {
+	return func(c *btContext) btStatus {
+		steps := int(c.dist / 0.5)
+		for s := 1; s < steps; s++ {
+			t := float32(s) / float32(steps)
+			pos := rl.NewVector3(c.e.position.X+c.dx*t, c.e.position.Y, c.e.position.Z+c.dz*t)
+			if c.g.obstacleAt(pos, 0.1) >= 0 {
+				return btFailure
+			}
+		}
+		return btSuccess
+	}
+}
+
+func btIf(ok bool) btStatus {
+	if ok {
+		return btSuccess
+	}
+	return btFailure
+}
+
+// --- Movement ---
+
+func enemySpeed(e *Enemy) float32 {
+	return float32(math.Sqrt(float64(e.velocity.X*e.velocity.X + e.velocity.Z*e.velocity.Z)))
+}
+
+// moveEnemy steps the enemy along (dirX, dirZ) unless a wall is in the way.
+// On ice the step keeps some of the enemy's old momentum; flyers and bosses
+// ignore the floor.
+func (c *btContext) moveEnemy(dirX, dirZ, speed float32) {
+	newPos := rl.Vector3{
+		X: c.e.position.X + dirX*speed*c.dt,
+		Y: c.e.position.Y,
+		Z: c.e.position.Z + dirZ*speed*c.dt,
+	}
+	if !c.e.isBoss && c.e.kind != EnemyFlyer {
+		newPos = c.g.floorStep(&c.e.slide, c.e.position, newPos, c.dt)
+	}
+	if !c.g.CheckObstacleCollision(newPos, c.e.size/2) && c.g.structureAt(newPos, c.e.size/2) < 0 {
+		c.e.position = newPos
+	} else {
+		c.e.slide = rl.Vector3{}
+	}
+}
+
+// approach walks straight at the target and keeps the velocity pointed at
+// it.
+func approach(speedMul float32) btNode {
+	return func(c *btContext) btStatus {
+		speed := enemySpeed(c.e)
+		if c.dist > 0.1 {
+			c.e.velocity.X = c.dx / c.dist * speed
+			c.e.velocity.Z = c.dz / c.dist * speed
+		}
+		if speed > 0 {
+			c.moveEnemy(c.e.velocity.X/speed, c.e.velocity.Z/speed, speed*speedMul)
+		}
+		return btSuccess
+	}
+}
+
+// advance walks at the target without touching the velocity.
+func advance(speedMul float32) btNode {
+	return func(c *btContext) btStatus {
+		if c.dist < 0.1 {
+			return btSuccess
+		}
+		c.moveEnemy(c.dx/c.dist, c.dz/c.dist, enemySpeed(c.e)*speedMul)
+		return btSuccess
+	}
+}
+
+func flee(speedMul float32) btNode {
+	return func(c *btContext) btStatus {
+		if c.dist < 0.1 {
+			return btFailure
+		}
+		c.moveEnemy(-c.dx/c.dist, -c.dz/c.dist, enemySpeed(c.e)*speedMul)
+		return btSuccess
+	}
+}
+
+// strafe circles the target.
+func strafe(speedMul float32) btNode {
+	return func(c *btContext) btStatus {
+		if c.dist < 0.1 {
+			return btFailure
+		}
+		c.moveEnemy(-c.dz/c.dist, c.dx/c.dist, enemySpeed(c.e)*speedMul)
+		return btSuccess
+	}
+}
+
+// turnToward turns the facing toward the target at a limited rate.
+func turnToward(rate float32) btNode {
+	return func(c *btContext) btStatus {
+		want := float32(math.Atan2(float64(c.dz), float64(c.dx)))
+		turn := angleDiff(want, c.e.facing)
+		maxTurn := rate * c.dt
+		c.e.facing += float32(math.Max(float64(-maxTurn), math.Min(float64(maxTurn), float64(turn))))
+		return btSuccess
+	}
+}
+
+func walkForward() btNode {
+	return func(c *btContext) btStatus {
+		c.moveEnemy(float32(math.Cos(float64(c.e.facing))), float32(math.Sin(float64(c.e.facing))), enemySpeed(c.e))
+		return btSuccess
+	}
+}
+
+// fly heads for the target at height, ignoring walls.
+func fly(height float32) btNode {
+	return func(c *btContext) btStatus {
+		e := c.e
+		speed := enemySpeed(e)
+		if c.dist > 0.1 {
+			e.velocity.X = c.dx / c.dist * speed
+			e.velocity.Z = c.dz / c.dist * speed
+		}
+		e.position.X += e.velocity.X * c.dt
+		e.position.Z += e.velocity.Z * c.dt
+		e.position.Y += (height - e.position.Y) * float32(math.Min(1, float64(c.dt*3)))
+		c.g.keepOverFloor(e)
+		return btSuccess
+	}
+}
+
+// lockHeading points the velocity at the target and keeps it there.
+func lockHeading() btNode {
+	return func(c *btContext) btStatus {
+		if c.dist > 0.1 {
+			speed := enemySpeed(c.e)
+			c.e.velocity.X = c.dx / c.dist * speed
+			c.e.velocity.Z = c.dz / c.dist * speed
+		}
+		return btSuccess
+	}
+}
+
+// glide flies along the locked heading while moving from one height to
+// another, running until the phase timer is out.
+func glide(speedMul, fromY, toY, duration float32) btNode {
+	return func(c *btContext) btStatus {
+		e := c.e
+		e.phaseTimer -= c.dt
+		e.position.X += e.velocity.X * speedMul * c.dt
+		e.position.Z += e.velocity.Z * speedMul * c.dt
+		t := 1 - e.phaseTimer/duration
+		e.position.Y = fromY + (toY-fromY)*float32(math.Min(1, float64(t)))
+		c.g.keepOverFloor(e)
+		if e.phaseTimer > 0 {
+			return btRunning
+		}
+		return btSuccess
+	}
+}
+
+// keepOverFloor clamps wall-ignoring enemies to the floor.
+func (g *Game) keepOverFloor(e *Enemy) {
+	limit := g.stageHalf - 1
+	e.position.X = float32(math.Max(float64(-limit), math.Min(float64(limit), float64(e.position.X))))
+	e.position.Z = float32(math.Max(float64(-limit), math.Min(float64(limit), float64(e.position.Z))))
+}
+
+// --- Actions ---
+
+func setPhase(phase EnemyPhase, timer float32) btNode {
+	return func(c *btContext) btStatus {
+		c.e.phase = phase
+		c.e.phaseTimer = timer
+		return btSuccess
+	}
+}
+
+// wait runs until the phase timer is out.
+func wait() btNode {
+	return func(c *btContext) btStatus {
+		c.e.phaseTimer -= c.dt
+		if c.e.phaseTimer > 0 {
+			return btRunning
+		}
+		return btSuccess
+	}
+}
+
+// cooldown counts the shot timer down and succeeds once it is ready. The
+// timer stays ready until shoot resets it.
+func cooldown() btNode {
+	return func(c *btContext) btStatus {
+		c.e.shotTimer -= c.dt
+		return btIf(c.e.shotTimer <= 0)
+	}
+}
+
+func shoot(interval float32) btNode {
+	return func(c *btContext) btStatus {
+		if c.dist < 0.1 {
+			return btFailure
+		}
+		c.e.shotTimer = interval
+		c.g.spawnEnemyBullet(c.e.position, c.dx/c.dist, c.dz/c.dist)
+		c.g.enemyAttacked(c.e)
+		return btSuccess
+	}
+}
+
+func detonate() btNode {
+	return func(c *btContext) btStatus {
+		c.g.detonateKamikaze(c.index)
+		return btSuccess
+	}
+}
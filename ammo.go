@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Ammo and reloading (run modifier, on by default). Each weapon has a
+// magazine and a reserve; an empty magazine reloads automatically, the
+// reload key tops it up early. Enemies drop ammo crates that refill part
+// of every reserve. With the modifier off, weapons fire forever as before.
+
+// powerUpAmmo is the pType of ammo crates (0-2 are health, speed, fire rate).
+const powerUpAmmo = 3
+
+const (
+	ammoDropChance = float32(0.35) // share of power-up drops that are ammo
+	ammoCrateShare = float32(0.5)  // portion of max reserve a crate restores
+)
+
+// usesAmmo reports whether the weapon consumes ammo in this run.
+func (g *Game) usesAmmo(weapon WeaponType) bool {
+	return g.settings.modifiers.ammo && weaponDefs[weapon].magSize > 0
+}
+
+// fillAmmo gives the player full magazines and reserves.
+func (p *Player) fillAmmo() {
+	for w := WeaponType(0); w < weaponCount; w++ {
+		p.ammo[w] = weaponDefs[w].magSize
+		p.reserve[w] = weaponDefs[w].reserveMax
+	}
+	p.reloading = false
+	p.reloadTimer = 0
+}
+
+// startReload begins reloading the weapon in hand if it needs it and there
+// is something to load.
+func (g *Game) startReload(player *Player) {
+	w := player.weapon
+	def := weaponDefs[w]
+	if !g.usesAmmo(w) || player.reloading || player.ammo[w] >= def.magSize {
+		return
+	}
+	if def.reserveMax > 0 && player.reserve[w] == 0 {
+		return
+	}
+	player.reloading = true
+	player.reloadTimer = def.reloadTime
+}
+
+// updateReload counts down an active reload and moves rounds from the
+// reserve into the magazine when it finishes.
+func (g *Game) updateReload(player *Player, dt float32) {
+	if !player.reloading {
+		return
+	}
+	player.reloadTimer -= dt
+	if player.reloadTimer > 0 {
+		return
+	}
+
+	w := player.weapon
+	def := weaponDefs[w]
+	need := def.magSize - player.ammo[w]
+	if def.reserveMax > 0 {
+		need = int(math.Min(float64(need), float64(player.reserve[w])))
+		player.reserve[w] -= need
+	}
+	player.ammo[w] += need
+	player.reloading = false
+}
+
+// consumeAmmo spends one round for a shot. Returns false if the weapon
+// can't fire right now.
+func (g *Game) consumeAmmo(player *Player) bool {
+	w := player.weapon
+	if !g.usesAmmo(w) {
+		return true
+	}
+	if player.reloading {
+		return false
+	}
+	if player.ammo[w] == 0 {
+		g.startReload(player)
+		return false
+	}
+	player.ammo[w]--
+	if player.ammo[w] == 0 {
+		g.startReload(player)
+	}
+	return true
+}
+
+// pickUpAmmo refills part of every reserve.
+func (p *Player) pickUpAmmo() {
+	for w := WeaponType(0); w < weaponCount; w++ {
+		max := weaponDefs[w].reserveMax
+		if max == 0 {
+			continue
+		}
+		p.reserve[w] = int(math.Min(float64(max), float64(p.reserve[w])+math.Ceil(float64(float32(max)*ammoCrateShare))))
+	}
+}
+
+// ammoLabel is the HUD text for the weapon in hand.
+func (g *Game) ammoLabel(player Player) string {
+	w := player.weapon
+	if !g.usesAmmo(w) {
+		return ""
+	}
+	if player.reloading {
+		return "RELOADING"
+	}
+	if weaponDefs[w].reserveMax == 0 {
+		return fmt.Sprintf("%d", player.ammo[w])
+	}
+	return fmt.Sprintf("%d/%d", player.ammo[w], player.reserve[w])
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Skeletal animation. A .glb or .gltf player, enemy or boss model may carry
+// animation clips; they are loaded next to the model and picked by name:
+// idle, walk (or run), attack (or shoot) and death (or die). Players idle
+// or walk with isMoving and play attack when they fire; enemies walk, play
+// attack when they shoot or hit, and a killed enemy leaves a corpse that
+// plays death before it disappears. Models without clips, and every clip a
+// model lacks, keep drawing the static pose.
+//
+// Models are shared between everything drawn with them and raylib poses
+// the shared mesh, so each draw poses it first. ModelAnims remembers the
+// pose it last applied, and enemies walk in step on the game clock, so a
+// crowd of walkers costs one pose update rather than one each.
+type AnimClip int
+
+const (
+	ClipIdle AnimClip = iota
+	ClipWalk
+	ClipAttack
+	ClipDeath
+	clipCount
+)
+
+// clipAliases are the clip names matched, in order, case-insensitively
+var clipAliases = [clipCount][]string{
+	ClipIdle:   {"idle"},
+	ClipWalk:   {"walk", "run"},
+	ClipAttack: {"attack", "shoot", "fire"},
+	ClipDeath:  {"death", "die"},
+}
+
+const (
+	animFPS      = float32(30)
+	maxCorpses   = 16
+	corpseLinger = float32(0.6) // seconds a corpse stays on its last frame
+)
+
+type ModelAnims struct {
+	anims []rl.ModelAnimation
+	clips [clipCount]int // index into anims, -1 = missing
+
+	posed      bool
+	posedClip  AnimClip
+	posedFrame int32
+}
+
+// Animator is one drawn thing's place in its clips. A one-shot clip
+// (attack) plays over the looping base until it ends; death holds its
+// last frame.
+type Animator struct {
+	oneShot AnimClip // ClipIdle = none
+	time    float32
+}
+
+type Corpse struct {
+	position rl.Vector3
+	yaw      float32
+	scale    float32
+	color    rl.Color
+	boss     bool
+	time     float32
+}
+
+// loadModelAnims loads the clips of the model at assets/models/<name>.glb
+// or .gltf. nil when the model has none.
+func loadModelAnims(name string, model rl.Model) *ModelAnims {
+	path := ""
+	for _, ext := range []string{".glb", ".gltf"} {
+		if p := "assets/models/" + name + ext; fileExists(p) {
+			path = p
+			break
+		}
+	}
+	if path == "" || model.MeshCount == 0 {
+		return nil
+	}
+	anims := rl.LoadModelAnimations(path)
+	if len(anims) == 0 {
+		return nil
+	}
+	m := &ModelAnims{anims: anims}
+	for c := range m.clips {
+		m.clips[c] = -1
+	}
+	for i := range anims {
+		if !rl.IsModelAnimationValid(model, anims[i]) {
+			continue
+		}
+		clipName := strings.ToLower(animName(&anims[i]))
+		for c, aliases := range clipAliases {
+			if m.clips[c] >= 0 {
+				continue
+			}
+			for _, alias := range aliases {
+				if strings.Contains(clipName, alias) {
+					m.clips[c] = i
+					break
+				}
+			}
+		}
+	}
+	// A single unnamed clip is taken as the idle loop
+	if m.clips[ClipIdle] < 0 && rl.IsModelAnimationValid(model, anims[0]) {
+		m.clips[ClipIdle] = 0
+	}
+	found := 0
+	for _, i := range m.clips {
+		if i >= 0 {
+			found++
+		}
+	}
+	fmt.Printf("✓ Loaded: %d animations for %s (%d clips used)\n", len(anims), name, found)
+	return m
+}
+
+func animName(a *rl.ModelAnimation) string {
+	n := 0
+	for n < len(a.Name) && a.Name[n] != 0 {
+		n++
+	}
+	return string(a.Name[:n])
+}
+
+func (m *ModelAnims) has(c AnimClip) bool {
+	return m != nil && m.clips[c] >= 0
+}
+
+// length is a clip's duration in seconds.
+func (m *ModelAnims) length(c AnimClip) float32 {
+	if !m.has(c) {
+		return 0
+	}
+	return float32(m.anims[m.clips[c]].FrameCount) / animFPS
+}
+
+// pose puts the model in clip at time t, looping or holding the last
+// frame. Returns false when the model has no such clip.
+func (m *ModelAnims) pose(model rl.Model, c AnimClip, t float32, loop bool) bool {
+	if !m.has(c) {
+		return false
+	}
+	anim := m.anims[m.clips[c]]
+	if anim.FrameCount <= 0 {
+		return false
+	}
+	frame := int32(t * animFPS)
+	if loop {
+		frame %= anim.FrameCount
+	} else {
+		frame = min(frame, anim.FrameCount-1)
+	}
+	if m.posed && m.posedClip == c && m.posedFrame == frame {
+		return true
+	}
+	rl.UpdateModelAnimation(model, anim, frame)
+	m.posed, m.posedClip, m.posedFrame = true, c, frame
+	return true
+}
+
+func (m *ModelAnims) unload() {
+	if m != nil {
+		rl.UnloadModelAnimations(m.anims)
+	}
+}
+
+// play starts a one-shot clip from its first frame.
+func (a *Animator) play(c AnimClip) {
+	a.oneShot, a.time = c, 0
+}
+
+// advance moves the animator on and ends a finished one-shot.
+func (a *Animator) advance(m *ModelAnims, dt float32) {
+	a.time += dt
+	if a.oneShot != ClipIdle && a.oneShot != ClipDeath && a.time >= m.length(a.oneShot) {
+		a.oneShot, a.time = ClipIdle, 0
+	}
+}
+
+// poseAnimator poses a model for an animator over a looping base clip,
+// walking base clips timed by clock. Falls back to idle, then to the
+// static pose.
+func (m *ModelAnims) poseAnimator(model rl.Model, a *Animator, base AnimClip, clock float32) {
+	if m == nil {
+		return
+	}
+	if a.oneShot != ClipIdle && m.pose(model, a.oneShot, a.time, false) {
+		return
+	}
+	if !m.pose(model, base, clock, true) {
+		m.pose(model, ClipIdle, clock, true)
+	}
+}
+
+func (g *Game) loadModelAnimations() {
+	if g.modelsLoaded {
+		g.playerAnims = loadModelAnims("player", g.playerModel)
+		g.enemyAnims = loadModelAnims("enemy", g.enemyModel)
+		g.bossAnims = loadModelAnims("boss", g.bossModel)
+	}
+}
+
+func (g *Game) unloadModelAnimations() {
+	g.playerAnims.unload()
+	g.enemyAnims.unload()
+	g.bossAnims.unload()
+	g.playerAnims, g.enemyAnims, g.bossAnims = nil, nil, nil
+}
+
+// animsFor is the clip set of an enemy's model; the final boss model has
+// none.
+func (g *Game) animsFor(e *Enemy) *ModelAnims {
+	if !e.isBoss {
+		return g.enemyAnims
+	}
+	if g.bossFight.archetype == BossFinal && g.finalBossLoaded {
+		return nil
+	}
+	return g.bossAnims
+}
+
+// updateAnimations moves every animator on. Runs once per simulated frame.
+func (g *Game) updateAnimations(dt float32) {
+	for i := range g.players {
+		g.players[i].anim.advance(g.playerAnims, dt)
+	}
+	for i := range g.enemies {
+		if e := &g.enemies[i]; e.active {
+			e.anim.advance(g.animsFor(e), dt)
+		}
+	}
+	kept := g.corpses[:0]
+	for _, c := range g.corpses {
+		c.time += dt
+		anims := g.enemyAnims
+		if c.boss {
+			anims = g.bossAnims
+		}
+		if c.time < anims.length(ClipDeath)+corpseLinger {
+			kept = append(kept, c)
+		}
+	}
+	g.corpses = kept
+}
+
+// enemyAttacked plays an enemy's attack clip.
+func (g *Game) enemyAttacked(e *Enemy) {
+	if g.animsFor(e).has(ClipAttack) {
+		e.anim.play(ClipAttack)
+	}
+}
+
+// addCorpse leaves a killed enemy behind to play its death clip, when its
+// model has one.
+func (g *Game) addCorpse(e *Enemy) {
+	anims := g.animsFor(e)
+	if !e.hasModel || !anims.has(ClipDeath) {
+		return
+	}
+	if len(g.corpses) >= maxCorpses {
+		g.corpses = append(g.corpses[:0], g.corpses[1:]...)
+	}
+	g.corpses = append(g.corpses, Corpse{
+		position: e.position,
+		yaw:      e.modelYawOffsetDeg,
+		scale:    e.modelScale,
+		color:    g.enemyColor(e),
+		boss:     e.isBoss,
+	})
+}
+
+func (g *Game) drawCorpses() {
+	for i := range g.corpses {
+		c := &g.corpses[i]
+		model, anims := g.enemyModel, g.enemyAnims
+		if c.boss {
+			model, anims = g.bossModel, g.bossAnims
+		}
+		anims.pose(model, ClipDeath, c.time, false)
+		rl.DrawModelEx(model, c.position, rl.NewVector3(0, 1, 0), c.yaw, rl.NewVector3(c.scale, c.scale, c.scale), c.color)
+	}
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Arcade cabinet mode (-arcade). The game boots into an attract loop that
+// alternates the title and the high score table. Coins add credits, 1P/2P
+// START spend them, a qualifying score forces initials entry and any screen
+// left alone for too long drops back to attract. The menu and settings are
+// never shown. Key mapping, pricing, credits and scores live in
+// save/arcade.json so they survive a power cycle.
+const (
+	arcadeFile      = saveDir + "/arcade.json"
+	arcadeScores    = 10
+	arcadeNameLen   = 3
+	attractPageTime = float32(8.0)
+)
+
+// ArcadeConfig is the operator-editable part of arcade.json.
+type ArcadeConfig struct {
+	CoinKey        int32 `json:"coinKey"`
+	Start1Key      int32 `json:"start1Key"`
+	Start2Key      int32 `json:"start2Key"`
+	CoinsPerCredit int   `json:"coinsPerCredit"`
+	IdleSeconds    int   `json:"idleSeconds"`
+}
+
+type ArcadeScore struct {
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	Level int    `json:"level"`
+}
+
+// Arcade is the cabinet state. The exported fields are what gets saved.
+type Arcade struct {
+	Config  ArcadeConfig  `json:"config"`
+	Credits int           `json:"credits"`
+	Coins   int           `json:"coins"` // toward the next credit
+	Total   int           `json:"total"` // coin counter for the operator
+	Scores  []ArcadeScore `json:"scores"`
+
+	idle      float32
+	pageTimer float32
+	page      int
+	name      []byte
+	cursor    int
+	after     GameState // where initials entry returns to
+}
+
+func defaultArcadeConfig() ArcadeConfig {
+	return ArcadeConfig{
+		CoinKey:        rl.KeyInsert,
+		Start1Key:      rl.KeyOne,
+		Start2Key:      rl.KeyTwo,
+		CoinsPerCredit: 1,
+		IdleSeconds:    45,
+	}
+}
+
+func loadArcade() *Arcade {
+	a := &Arcade{Config: defaultArcadeConfig()}
+	data, err := os.ReadFile(arcadeFile)
+	if err == nil {
+		if err := json.Unmarshal(data, a); err != nil {
+			fmt.Println("Warning: Could not read arcade settings:", err)
+		}
+	}
+	a.Config.CoinsPerCredit = max(a.Config.CoinsPerCredit, 1)
+	a.Config.IdleSeconds = max(a.Config.IdleSeconds, 10)
+	// Write the file back so operators have something to edit
+	a.save()
+	return a
+}
+
+func (a *Arcade) save() {
+	os.MkdirAll(saveDir, os.ModePerm)
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		fmt.Println("Warning: Could not encode arcade settings:", err)
+		return
+	}
+	if err := writeFileAtomic(arcadeFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save arcade settings:", err)
+	}
+}
+
+func (g *Game) startArcade() {
+	g.arcade = loadArcade()
+	g.state = StateAttract
+	fmt.Printf("✓ Arcade mode, %d credits\n", g.arcade.Credits)
+}
+
+// updateArcade handles coins and the idle timer on every screen. Called
+// every frame before the state update.
+func (g *Game) updateArcade(dt float32) {
+	a := g.arcade
+	if a == nil {
+		return
+	}
+	if g.state == StateMenu || g.state == StateSettings || g.state == StateControls {
+		g.state = StateAttract
+	}
+
+	if rl.IsKeyPressed(a.Config.CoinKey) {
+		a.Coins++
+		a.Total++
+		if a.Coins >= a.Config.CoinsPerCredit {
+			a.Coins = 0
+			a.Credits++
+		}
+		a.save()
+		g.playUISound(g.sounds.powerup)
+	}
+
+	// Nobody playing: waiting screens time out, a run in progress doesn't
+	if rl.GetKeyPressed() != 0 || g.pressedPad() >= 0 || g.state == StatePlaying || g.state == StateAttract {
+		a.idle = 0
+		return
+	}
+	a.idle += dt
+	if a.idle < float32(a.Config.IdleSeconds) {
+		return
+	}
+	a.idle = 0
+	if g.state == StateNameEntry {
+		g.submitArcadeName()
+	}
+	g.enterAttract()
+}
+
+func (g *Game) enterAttract() {
+	g.state = StateAttract
+	g.arcade.page = 0
+	g.arcade.pageTimer = 0
+}
+
+// UpdateAttract flips between the attract pages and starts a run when a
+// player presses START with enough credits. Co-op costs two credits.
+func (g *Game) UpdateAttract(dt float32) {
+	a := g.arcade
+	a.pageTimer += dt
+	if a.pageTimer >= attractPageTime {
+		a.pageTimer = 0
+		a.page = (a.page + 1) % 2
+	}
+
+	switch {
+	case rl.IsKeyPressed(a.Config.Start1Key) && a.Credits >= 1:
+		a.Credits--
+		a.save()
+		g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, false, false, false
+		g.StartGame(false)
+	case rl.IsKeyPressed(a.Config.Start2Key) && a.Credits >= 2:
+		a.Credits -= 2
+		a.save()
+		g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, false, false, false
+		g.StartGame(true)
+	}
+}
+
+// UpdateArcadeGameOver replaces the game over keys: continuing costs as
+// much as starting the same run and ESC goes back to attract.
+func (g *Game) UpdateArcadeGameOver() {
+	a := g.arcade
+	cost := 1
+	if g.coopMode {
+		cost = 2
+	}
+	if rl.IsKeyPressed(a.Config.Start1Key) && a.Credits >= cost {
+		a.Credits -= cost
+		a.save()
+		g.ResetGame()
+		g.state = StatePlaying
+		return
+	}
+	if rl.IsKeyPressed(rl.KeyEscape) {
+		g.enterAttract()
+	}
+}
+
+// arcadeQualifies reports whether score makes the high score table.
+func (a *Arcade) arcadeQualifies(score int) bool {
+	if score <= 0 {
+		return false
+	}
+	return len(a.Scores) < arcadeScores || score > a.Scores[len(a.Scores)-1].Score
+}
+
+// arcadeGameOver starts initials entry when the run made the table.
+func (g *Game) arcadeGameOver() {
+	a := g.arcade
+	if a == nil || !a.arcadeQualifies(g.score) {
+		return
+	}
+	a.name = []byte(strings.Repeat("A", arcadeNameLen))
+	a.cursor = 0
+	a.idle = 0
+	a.after = g.state
+	g.state = StateNameEntry
+}
+
+// UpdateNameEntry edits the initials arcade style: UP/DOWN change the
+// letter, LEFT/RIGHT move, typing also works and ENTER confirms. There is
+// no way to skip it.
+func (g *Game) UpdateNameEntry() {
+	a := g.arcade
+	letter := &a.name[a.cursor]
+	switch {
+	case rl.IsKeyPressed(rl.KeyUp):
+		*letter = 'A' + (*letter-'A'+1)%26
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyDown):
+		*letter = 'A' + (*letter-'A'+25)%26
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyLeft):
+		a.cursor = max(a.cursor-1, 0)
+	case rl.IsKeyPressed(rl.KeyRight):
+		a.cursor = min(a.cursor+1, arcadeNameLen-1)
+	case rl.IsKeyPressed(rl.KeyEnter):
+		g.playUISound(g.sounds.uiSelect)
+		g.submitArcadeName()
+		g.state = a.after
+		return
+	}
+
+	if ch := g.charPressed(); ch != 0 {
+		upper := strings.ToUpper(string(rune(ch)))
+		if len(upper) == 1 && upper[0] >= 'A' && upper[0] <= 'Z' {
+			a.name[a.cursor] = upper[0]
+			a.cursor = min(a.cursor+1, arcadeNameLen-1)
+		}
+	}
+}
+
+func (g *Game) submitArcadeName() {
+	a := g.arcade
+	a.Scores = append(a.Scores, ArcadeScore{Name: string(a.name), Score: g.score, Level: g.level})
+	slices.SortStableFunc(a.Scores, func(x, y ArcadeScore) int { return cmp.Compare(y.Score, x.Score) })
+	if len(a.Scores) > arcadeScores {
+		a.Scores = a.Scores[:arcadeScores]
+	}
+	a.save()
+}
+
+func (g *Game) DrawAttract() {
+	rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+	a := g.arcade
+	centerX := int32(screenWidth / 2)
+
+	if a.page == 0 {
+		drawText("3D SHOOTER", centerX-200, 140, 70, rl.Gold)
+		drawText("CO-OP EDITION", centerX-180, 220, 35, rl.Yellow)
+		drawText("1 CREDIT: 1 PLAYER   2 CREDITS: 2 PLAYERS", centerX-measureText("1 CREDIT: 1 PLAYER   2 CREDITS: 2 PLAYERS", 24)/2, 340, 24, rl.LightGray)
+	} else {
+		g.drawArcadeScores(120)
+	}
+
+	// Blink the call to action
+	if int(rl.GetTime()*2)%2 == 0 {
+		text := "INSERT COIN"
+		switch {
+		case a.Credits >= 2:
+			text = "PRESS 1P OR 2P START"
+		case a.Credits == 1:
+			text = "PRESS 1P START"
+		}
+		drawText(text, centerX-measureText(text, 40)/2, screenHeight-200, 40, rl.White)
+	}
+	g.drawCredits()
+}
+
+func (g *Game) drawArcadeScores(y int32) {
+	centerX := int32(screenWidth / 2)
+	drawText("HIGH SCORES", centerX-measureText("HIGH SCORES", 50)/2, y, 50, rl.Gold)
+	for i, s := range g.arcade.Scores {
+		row := y + 80 + int32(i)*38
+		color := rl.White
+		if i == 0 {
+			color = rl.Yellow
+		}
+		drawText(fmt.Sprintf("%2d.", i+1), centerX-240, row, 30, color)
+		drawText(s.Name, centerX-160, row, 30, color)
+		drawText(fmt.Sprintf("%8d", s.Score), centerX-40, row, 30, color)
+		drawText(fmt.Sprintf("LV %d", s.Level), centerX+140, row, 30, rl.LightGray)
+	}
+	if len(g.arcade.Scores) == 0 {
+		drawText("NO SCORES YET", centerX-measureText("NO SCORES YET", 30)/2, y+100, 30, rl.Gray)
+	}
+}
+
+func (g *Game) DrawNameEntry() {
+	a := g.arcade
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 200))
+	centerX := int32(screenWidth / 2)
+	drawText("NEW HIGH SCORE!", centerX-measureText("NEW HIGH SCORE!", 50)/2, screenHeight/2-160, 50, rl.Gold)
+	score := fmt.Sprintf("%d", g.score)
+	drawText(score, centerX-measureText(score, 40)/2, screenHeight/2-95, 40, rl.White)
+	drawText("ENTER YOUR INITIALS", centerX-measureText("ENTER YOUR INITIALS", 28)/2, screenHeight/2-30, 28, rl.LightGray)
+
+	for i, ch := range a.name {
+		x := centerX - arcadeNameLen*35 + int32(i)*70
+		color := rl.White
+		if i == a.cursor {
+			color = rl.Yellow
+			rl.DrawRectangle(x-5, screenHeight/2+80, 60, 5, rl.Yellow)
+		}
+		drawText(string(ch), x+8, screenHeight/2+15, 60, color)
+	}
+	drawText("UP/DOWN: letter   LEFT/RIGHT: move   ENTER: done", centerX-measureText("UP/DOWN: letter   LEFT/RIGHT: move   ENTER: done", 20)/2, screenHeight/2+120, 20, rl.Gray)
+}
+
+// DrawArcadeGameOver replaces the restart hints on the game over screen.
+func (g *Game) DrawArcadeGameOver() {
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 180))
+	drawText("GAME OVER!", screenWidth/2-180, screenHeight/2-100, 60, rl.Red)
+	drawText(fmt.Sprintf("Final Score: %d", g.score), screenWidth/2-150, screenHeight/2-20, 35, rl.White)
+	drawText(fmt.Sprintf("Max Level: %d", g.level), screenWidth/2-120, screenHeight/2+25, 28, rl.Yellow)
+
+	text := "INSERT COIN TO CONTINUE"
+	if g.arcade.Credits >= 2 || g.arcade.Credits == 1 && !g.coopMode {
+		text = "PRESS 1P START TO CONTINUE"
+	}
+	drawText(text, screenWidth/2-measureText(text, 28)/2, screenHeight/2+80, 28, rl.Green)
+	g.drawCredits()
+}
+
+// drawCredits shows the credit count along the bottom, like a cabinet.
+func (g *Game) drawCredits() {
+	if g.arcade == nil {
+		return
+	}
+	text := fmt.Sprintf("CREDITS %d", g.arcade.Credits)
+	drawText(text, screenWidth/2-measureText(text, 22)/2, screenHeight-30, 22, rl.LightGray)
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Armory. Every run that ends pays out scrap based on its score and level,
+// kept in save/armory.json. Between runs scrap buys small permanent buffs
+// and other starting weapons. Buffs and the chosen weapon are applied by
+// armoryMutator when a run starts; like mastery perks they are off in
+// daily runs so everyone gets the same run.
+const (
+	armoryFile        = saveDir + "/armory.json"
+	scrapPerScore     = 200 // score per scrap
+	scrapPerLevel     = 3
+	scrapVictoryBonus = 50
+)
+
+// ArmoryBuff is a permanent buff bought in levels. Level n+1 costs
+// cost*(n+1).
+type ArmoryBuff struct {
+	id       string // save key
+	name     string
+	desc     string // per level
+	maxLevel int
+	cost     int
+	apply    func(p *Player, level int)
+}
+
+var armoryBuffs = []ArmoryBuff{
+	{id: "health", name: "Toughness", desc: "+10 max health", maxLevel: 5, cost: 40, apply: func(p *Player, level int) {
+		p.stats.maxHealth += 10 * level
+	}},
+	{id: "speed", name: "Agility", desc: "+0.5 speed", maxLevel: 3, cost: 60, apply: func(p *Player, level int) {
+		p.stats.speed += 0.5 * float32(level)
+	}},
+	{id: "crit", name: "Keen Eye", desc: "+1% crit chance", maxLevel: 5, cost: 50, apply: func(p *Player, level int) {
+		p.stats.critChance += 0.01 * float32(level)
+	}},
+	{id: "damage", name: "Hollow Points", desc: "+1 damage", maxLevel: 1, cost: 300, apply: func(p *Player, level int) {
+		p.stats.damage += level
+	}},
+}
+
+// Starting weapons that can be bought; the Blaster is always unlocked.
+var armoryWeapons = []struct {
+	weapon WeaponType
+	cost   int
+}{
+	{WeaponBlaster, 0},
+	{WeaponShotgun, 150},
+	{WeaponHoming, 200},
+	{WeaponRocket, 250},
+	{WeaponLaser, 250},
+}
+
+// Armory is what armory.json stores. Buffs and weapons are keyed by name
+// so reordering the tables doesn't move anyone's purchases.
+type Armory struct {
+	Scrap   int             `json:"scrap"`
+	Buffs   map[string]int  `json:"buffs"`
+	Weapons map[string]bool `json:"weapons"`
+	Start   string          `json:"start"` // starting weapon, "" = Blaster
+}
+
+type ArmoryView struct {
+	row    int // buffs, then weapons
+	earned int // scrap from the last run, for the game over screen
+	note   string
+}
+
+func (g *Game) loadArmory() {
+	g.armory = Armory{Buffs: map[string]int{}, Weapons: map[string]bool{}}
+	data, err := os.ReadFile(armoryFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &g.armory); err != nil {
+		fmt.Println("Warning: Could not read armory:", err)
+	}
+	if g.armory.Buffs == nil {
+		g.armory.Buffs = map[string]int{}
+	}
+	if g.armory.Weapons == nil {
+		g.armory.Weapons = map[string]bool{}
+	}
+}
+
+func (g *Game) saveArmory() {
+	os.MkdirAll(filepath.Dir(armoryFile), os.ModePerm)
+	data, err := json.MarshalIndent(g.armory, "", "  ")
+	if err != nil {
+		fmt.Println("Warning: Could not encode armory:", err)
+		return
+	}
+	if err := writeFileAtomic(armoryFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save armory:", err)
+	}
+}
+
+// awardScrap pays out for the run that just ended. Runs on a pasted build
+// don't pay: a code can claim any upgrades.
+func (g *Game) awardScrap(victory bool) {
+	g.armoryView.earned = 0
+	if g.playback != nil || g.arcade != nil || g.loadoutRun {
+		return
+	}
+	scrap := g.score/scrapPerScore + g.level*scrapPerLevel
+	if victory {
+		scrap += scrapVictoryBonus
+	}
+	g.armoryView.earned = scrap
+	g.armory.Scrap += scrap
+	g.saveArmory()
+}
+
+func (a *Armory) weaponUnlocked(w WeaponType) bool {
+	return w == WeaponBlaster || a.Weapons[weaponDefs[w].name]
+}
+
+// startWeapon is the chosen starting weapon, if it is still unlocked.
+func (a *Armory) startWeapon() WeaponType {
+	for _, aw := range armoryWeapons {
+		if weaponDefs[aw.weapon].name == a.Start && a.weaponUnlocked(aw.weapon) {
+			return aw.weapon
+		}
+	}
+	return WeaponBlaster
+}
+
+func buffCost(b ArmoryBuff, level int) int {
+	return b.cost * (level + 1)
+}
+
+// armoryMutator applies bought buffs and the starting weapon. It runs
+// after difficulty so the health buff adds to the difficulty's health.
+// Arcade cabinets neither earn nor use scrap.
+type armoryMutator struct {
+	BaseMutator
+}
+
+func (armoryMutator) Name() string { return "Armory" }
+
+func (armoryMutator) OnRunStart(g *Game) {
+	start := g.armory.startWeapon()
+	for i := range g.players {
+		p := &g.players[i]
+		for _, b := range armoryBuffs {
+			if level := g.armory.Buffs[b.id]; level > 0 {
+				b.apply(p, min(level, b.maxLevel))
+			}
+		}
+		p.health = p.stats.maxHealth
+		if start != WeaponBlaster {
+			p.weapons = []WeaponType{WeaponBlaster, start}
+			p.weapon = start
+		}
+	}
+}
+
+// UpdateArmory: up/down pick a row, ENTER buys a buff level, buys a
+// weapon or makes an owned weapon the starting one, ESC goes back.
+func (g *Game) UpdateArmory() {
+	v := &g.armoryView
+	rows := len(armoryBuffs) + len(armoryWeapons)
+	switch {
+	case rl.IsKeyPressed(rl.KeyUp):
+		v.row = (v.row + rows - 1) % rows
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyDown):
+		v.row = (v.row + 1) % rows
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyEnter):
+		g.armoryBuy(v.row)
+	case rl.IsKeyPressed(rl.KeyEscape):
+		v.note = ""
+		g.playUISound(g.sounds.uiSelect)
+		g.state = StateMenu
+	}
+}
+
+func (g *Game) armoryBuy(row int) {
+	a := &g.armory
+	v := &g.armoryView
+	if row < len(armoryBuffs) {
+		b := armoryBuffs[row]
+		level := a.Buffs[b.id]
+		switch cost := buffCost(b, level); {
+		case level >= b.maxLevel:
+			v.note = b.name + " is maxed"
+			return
+		case a.Scrap < cost:
+			v.note = fmt.Sprintf("Need %d more scrap", cost-a.Scrap)
+			return
+		default:
+			a.Scrap -= cost
+			a.Buffs[b.id] = level + 1
+			v.note = fmt.Sprintf("%s level %d", b.name, level+1)
+		}
+	} else {
+		aw := armoryWeapons[row-len(armoryBuffs)]
+		name := weaponDefs[aw.weapon].name
+		if !a.weaponUnlocked(aw.weapon) {
+			if a.Scrap < aw.cost {
+				v.note = fmt.Sprintf("Need %d more scrap", aw.cost-a.Scrap)
+				return
+			}
+			a.Scrap -= aw.cost
+			a.Weapons[name] = true
+		}
+		a.Start = name
+		v.note = "Runs start with the " + name
+	}
+	g.playUISound(g.sounds.uiSelect)
+	g.saveArmory()
+}
+
+func (g *Game) DrawArmory() {
+	rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+	a := &g.armory
+	v := &g.armoryView
+	centerX := int32(screenWidth / 2)
+	drawText("ARMORY", centerX-measureText("ARMORY", 50)/2, 70, 50, rl.Gold)
+	scrap := fmt.Sprintf("Scrap: %d", a.Scrap)
+	drawText(scrap, centerX-measureText(scrap, 30)/2, 140, 30, rl.White)
+
+	x := centerX - 450
+	y := int32(210)
+	drawText("Buffs", x, y, 24, rl.LightGray)
+	y += 40
+	for i, b := range armoryBuffs {
+		level := a.Buffs[b.id]
+		color := rl.White
+		if i == v.row {
+			color = rl.Yellow
+			rl.DrawRectangle(x-10, y-8, 900, 44, rl.NewColor(255, 255, 0, 40))
+		}
+		drawText(b.name, x, y, 28, color)
+		drawText(b.desc, x+260, y, 24, rl.LightGray)
+		drawText(fmt.Sprintf("%d/%d", level, b.maxLevel), x+560, y, 28, color)
+		if level < b.maxLevel {
+			drawText(fmt.Sprintf("%d scrap", buffCost(b, level)), x+700, y, 24, rl.Gold)
+		}
+		y += 50
+	}
+
+	y += 20
+	drawText("Starting weapon", x, y, 24, rl.LightGray)
+	y += 40
+	start := a.startWeapon()
+	for i, aw := range armoryWeapons {
+		row := len(armoryBuffs) + i
+		def := weaponDefs[aw.weapon]
+		color := def.color
+		if row == v.row {
+			rl.DrawRectangle(x-10, y-8, 900, 44, rl.NewColor(255, 255, 0, 40))
+		}
+		drawText(def.name, x, y, 28, color)
+		switch {
+		case aw.weapon == start:
+			drawText("SELECTED", x+560, y, 24, rl.Lime)
+		case a.weaponUnlocked(aw.weapon):
+			drawText("owned", x+560, y, 24, rl.LightGray)
+		default:
+			drawText(fmt.Sprintf("%d scrap", aw.cost), x+700, y, 24, rl.Gold)
+		}
+		y += 50
+	}
+
+	if v.note != "" {
+		drawText(v.note, centerX-measureText(v.note, 24)/2, screenHeight-150, 24, rl.Gold)
+	}
+	hint := "Scrap is earned at the end of every run. Daily runs play without buffs."
+	drawText(hint, centerX-measureText(hint, 20)/2, screenHeight-110, 20, rl.LightGray)
+	back := "UP/DOWN choose  ENTER buy/select  ESC back"
+	drawText(back, centerX-measureText(back, 20)/2, screenHeight-70, 20, rl.LightGray)
+}
+
+// drawScrapEarned shows the run's payout on the game over and victory
+// screens.
+func (g *Game) drawScrapEarned(y int32) {
+	if g.armoryView.earned <= 0 {
+		return
+	}
+	text := fmt.Sprintf("+%d scrap (%d total)", g.armoryView.earned, g.armory.Scrap)
+	drawText(text, screenWidth/2-measureText(text, 25)/2, y, 25, rl.Gold)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Asset integrity. Release builds (tools/release) ship assets/manifest.json
+// with the size and SHA-256 of every file next to the binary. The first
+// time a build starts, and again whenever the manifest changes, every file
+// is checked; problems are listed on a screen before the menu instead of
+// the game quietly falling back to cubes and silence. A passing check is
+// remembered in save/assets_ok. Development trees have no manifest and
+// skip the check.
+const (
+	assetManifestFile = "assets/manifest.json"
+	assetOKFile       = saveDir + "/assets_ok"
+	assetProblemRows  = 14
+	windowIconFile    = "assets/icon/icon.png" // also used by the release tool
+)
+
+// version is set by the release tool with -ldflags "-X main.version=...".
+var version = "dev"
+
+type AssetManifest struct {
+	Version string                   `json:"version"`
+	Files   map[string]ManifestEntry `json:"files"` // keyed by path under assets/
+}
+
+type ManifestEntry struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type AssetProblem struct {
+	Path    string
+	Missing bool // otherwise the size or hash is wrong
+}
+
+type AssetCheck struct {
+	problems []AssetProblem
+	back     GameState // state to continue into
+}
+
+// locateAssets changes to the directory holding assets/ when the game is
+// started from somewhere else: a desktop shortcut, or inside a macOS .app
+// bundle where assets live in Contents/Resources.
+func locateAssets() {
+	if fileExists("assets") {
+		return
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	dir := filepath.Dir(exe)
+	for _, d := range []string{dir, filepath.Join(dir, "..", "Resources")} {
+		if fileExists(filepath.Join(d, "assets")) {
+			os.Chdir(d)
+			return
+		}
+	}
+}
+
+// checkAssets verifies the installed assets against the manifest. Returns
+// nil when there is no manifest or this manifest already passed.
+func checkAssets() []AssetProblem {
+	data, err := os.ReadFile(assetManifestFile)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	stamp := hex.EncodeToString(sum[:])
+	if ok, err := os.ReadFile(assetOKFile); err == nil && string(ok) == stamp {
+		return nil
+	}
+
+	var manifest AssetManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		fmt.Println("Warning: Bad asset manifest:", err)
+		return []AssetProblem{{Path: "manifest.json"}}
+	}
+	var problems []AssetProblem
+	for path, want := range manifest.Files {
+		if p, ok := checkAssetFile(path, want); !ok {
+			problems = append(problems, p)
+		}
+	}
+	slices.SortFunc(problems, func(a, b AssetProblem) int { return cmp.Compare(a.Path, b.Path) })
+
+	if len(problems) == 0 {
+		os.MkdirAll(saveDir, os.ModePerm)
+		if err := writeFileAtomic(assetOKFile, []byte(stamp), 0644); err != nil {
+			fmt.Println("Warning: Could not save asset check:", err)
+		}
+		fmt.Printf("✓ Assets verified: %d files\n", len(manifest.Files))
+	}
+	return problems
+}
+
+func checkAssetFile(path string, want ManifestEntry) (AssetProblem, bool) {
+	f, err := os.Open(filepath.Join("assets", filepath.FromSlash(path)))
+	if err != nil {
+		return AssetProblem{Path: path, Missing: true}, false
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil || n != want.Size || hex.EncodeToString(h.Sum(nil)) != want.SHA256 {
+		return AssetProblem{Path: path}, false
+	}
+	return AssetProblem{}, true
+}
+
+// showAssetProblems puts the problem screen in front of whatever the game
+// would have started with.
+func (g *Game) showAssetProblems(problems []AssetProblem) {
+	g.assetCheck = AssetCheck{problems: problems, back: g.state}
+	g.state = StateAssetCheck
+}
+
+// UpdateAssetCheck: ENTER plays anyway (the check runs again next start),
+// ESC quits.
+func (g *Game) UpdateAssetCheck() {
+	switch {
+	case rl.IsKeyPressed(rl.KeyEnter):
+		g.state = g.assetCheck.back
+		g.assetCheck = AssetCheck{}
+	case rl.IsKeyPressed(rl.KeyEscape):
+		g.quit = true
+	}
+}
+
+func (g *Game) DrawAssetCheck() {
+	centerX := int32(screenWidth / 2)
+	title := "SOME GAME FILES ARE DAMAGED"
+	drawText(title, centerX-measureText(title, 50)/2, 120, 50, rl.Orange)
+	info := "The game can still run, but models or sounds may be missing."
+	drawText(info, centerX-measureText(info, 24)/2, 200, 24, rl.LightGray)
+	fix := "Reinstalling or re-extracting the download usually fixes this."
+	drawText(fix, centerX-measureText(fix, 24)/2, 235, 24, rl.LightGray)
+
+	problems := g.assetCheck.problems
+	y := int32(310)
+	for i, p := range problems {
+		if i == assetProblemRows {
+			more := fmt.Sprintf("...and %d more", len(problems)-i)
+			drawText(more, centerX-300, y, 24, rl.Gray)
+			break
+		}
+		status, color := "damaged", rl.Yellow
+		if p.Missing {
+			status, color = "missing", rl.Red
+		}
+		drawText(status, centerX-300, y, 24, color)
+		drawText("assets/"+p.Path, centerX-160, y, 24, rl.White)
+		y += 34
+	}
+
+	hint := "ENTER play anyway  ESC quit"
+	drawText(hint, centerX-measureText(hint, 24)/2, screenHeight-90, 24, rl.White)
+	drawText(version, 20, screenHeight-30, 16, rl.DarkGray)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Asset memory accounting. Every texture, mesh and sound loaded through the
+// cache is registered with an estimate of its size. Assets are reference
+// counted: core assets keep their reference for the whole session, while
+// streamed ones (stage chunks, voice lines) release it when they are no
+// longer needed. Released assets stay resident so coming back is free, and
+// are only unloaded, least recently used first, once usage goes over the
+// budget.
+const defaultAssetBudgetMB = 256
+
+type AssetKind int
+
+const (
+	AssetTexture AssetKind = iota
+	AssetMesh
+	AssetSound
+	assetKindCount
+)
+
+var assetKindNames = [assetKindCount]string{"Textures", "Meshes", "Sounds"}
+
+type assetEntry struct {
+	bytes    [assetKindCount]int64
+	refs     int
+	lastUsed int64
+
+	model   rl.Model
+	texture rl.Texture2D
+	sound   rl.Sound
+	isModel bool
+	isTex   bool
+}
+
+type AssetCache struct {
+	entries   map[string]*assetEntry
+	budget    int64 // bytes
+	used      [assetKindCount]int64
+	clock     int64 // bumped on every access, orders entries for LRU
+	evictions int
+}
+
+func NewAssetCache(budgetMB int) *AssetCache {
+	return &AssetCache{
+		entries: map[string]*assetEntry{},
+		budget:  int64(budgetMB) << 20,
+	}
+}
+
+// acquire returns the resident entry for path with one more reference, or
+// nil when it has to be loaded.
+func (c *AssetCache) acquire(path string) *assetEntry {
+	e, ok := c.entries[path]
+	if !ok {
+		return nil
+	}
+	e.refs++
+	c.clock++
+	e.lastUsed = c.clock
+	return e
+}
+
+func (c *AssetCache) add(path string, e *assetEntry) {
+	e.refs = 1
+	c.clock++
+	e.lastUsed = c.clock
+	c.entries[path] = e
+	for kind, n := range e.bytes {
+		c.used[kind] += n
+	}
+	c.trim()
+}
+
+func (c *AssetCache) loadModel(path string) rl.Model {
+	if e := c.acquire(path); e != nil {
+		return e.model
+	}
+	e := &assetEntry{model: rl.LoadModel(path), isModel: true}
+	e.bytes[AssetMesh], e.bytes[AssetTexture] = modelBytes(e.model)
+	c.add(path, e)
+	return e.model
+}
+
+func (c *AssetCache) loadTexture(path string) rl.Texture2D {
+	if e := c.acquire(path); e != nil {
+		return e.texture
+	}
+	e := &assetEntry{texture: rl.LoadTexture(path), isTex: true}
+	e.bytes[AssetTexture] = textureBytes(e.texture)
+	c.add(path, e)
+	return e.texture
+}
+
+func (c *AssetCache) loadSound(path string) rl.Sound {
+	if e := c.acquire(path); e != nil {
+		return e.sound
+	}
+	e := &assetEntry{sound: rl.LoadSound(path)}
+	e.bytes[AssetSound] = int64(e.sound.FrameCount) * int64(e.sound.Stream.Channels) * int64(e.sound.Stream.SampleSize/8)
+	c.add(path, e)
+	return e.sound
+}
+
+// release drops a reference. The asset stays resident until evicted.
+func (c *AssetCache) release(path string) {
+	e, ok := c.entries[path]
+	if !ok || e.refs == 0 {
+		return
+	}
+	e.refs--
+	c.clock++
+	e.lastUsed = c.clock
+	c.trim()
+}
+
+// setBudget changes the budget and evicts right away if needed.
+func (c *AssetCache) setBudget(budgetMB int) {
+	c.budget = int64(budgetMB) << 20
+	c.trim()
+}
+
+func (c *AssetCache) total() int64 {
+	var sum int64
+	for _, n := range c.used {
+		sum += n
+	}
+	return sum
+}
+
+// trim unloads unreferenced assets, oldest first, until usage fits the
+// budget. Referenced assets are never touched, so usage can stay above
+// budget if everything resident is in use.
+func (c *AssetCache) trim() {
+	for c.total() > c.budget {
+		var oldestPath string
+		var oldest *assetEntry
+		for path, e := range c.entries {
+			if e.refs == 0 && (oldest == nil || e.lastUsed < oldest.lastUsed) {
+				oldestPath, oldest = path, e
+			}
+		}
+		if oldest == nil {
+			return
+		}
+
+		switch {
+		case oldest.isModel:
+			rl.UnloadModel(oldest.model)
+		case oldest.isTex:
+			rl.UnloadTexture(oldest.texture)
+		default:
+			rl.UnloadSound(oldest.sound)
+		}
+		for kind, n := range oldest.bytes {
+			c.used[kind] -= n
+		}
+		delete(c.entries, oldestPath)
+		c.evictions++
+	}
+}
+
+// dropSounds unloads every sound, referenced or not. Used when the audio
+// device is reopened and the old sounds belong to the closed one.
+func (c *AssetCache) dropSounds() {
+	for path, e := range c.entries {
+		if e.isModel || e.isTex {
+			continue
+		}
+		rl.UnloadSound(e.sound)
+		for kind, n := range e.bytes {
+			c.used[kind] -= n
+		}
+		delete(c.entries, path)
+	}
+}
+
+// unloadAll unloads everything, referenced or not. Only for shutdown.
+func (c *AssetCache) unloadAll() {
+	for path, e := range c.entries {
+		switch {
+		case e.isModel:
+			rl.UnloadModel(e.model)
+		case e.isTex:
+			rl.UnloadTexture(e.texture)
+		default:
+			rl.UnloadSound(e.sound)
+		}
+		delete(c.entries, path)
+	}
+	c.used = [assetKindCount]int64{}
+}
+
+// unused counts resident assets nobody holds a reference to.
+func (c *AssetCache) unused() int {
+	n := 0
+	for _, e := range c.entries {
+		if e.refs == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// modelBytes estimates GPU memory for a model's meshes and albedo textures.
+func modelBytes(m rl.Model) (meshes, textures int64) {
+	for _, mesh := range m.GetMeshes() {
+		// positions, normals and texcoords as float32, 16-bit indices
+		meshes += int64(mesh.VertexCount)*(3+3+2)*4 + int64(mesh.TriangleCount)*3*2
+	}
+	for _, mat := range m.GetMaterials() {
+		// ID 1 is raylib's shared default texture
+		if mp := mat.GetMap(rl.MapAlbedo); mp != nil && mp.Texture.ID > 1 {
+			textures += textureBytes(mp.Texture)
+		}
+	}
+	return meshes, textures
+}
+
+func textureBytes(t rl.Texture2D) int64 {
+	bytes := int64(t.Width) * int64(t.Height) * 4
+	if t.Mipmaps > 1 {
+		bytes = bytes * 4 / 3
+	}
+	return bytes
+}
+
+func formatMB(bytes int64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Mixer buses. Every sound plays on a bus with its own volume setting.
+// Ducking briefly lowers a bus so important sounds cut through: explosions
+// duck the music and the announcer ducks sound effects while it talks.
+type Bus int
+
+const (
+	BusMusic Bus = iota
+	BusSFX
+	BusUI
+	BusVoice
+	busCount
+)
+
+var busNames = [busCount]string{"Music", "SFX", "UI", "Voice"}
+
+const (
+	explosionDuckDepth = float32(0.5)  // music gain drop on explosions
+	explosionDuckHold  = float32(0.25) // seconds before recovering
+	voiceDuckDepth     = float32(0.6)  // SFX gain drop while the announcer talks
+	lowHealthDuckDepth = float32(0.5)  // music gain drop while a player is low
+	heartbeatShare     = float32(0.25) // health share below which the heartbeat plays
+	duckRelease        = float32(1.5)  // gain recovered per second
+)
+
+// Mixer holds the runtime ducking state; user volumes live in Settings.
+type Mixer struct {
+	gain [busCount]float32 // 1 = not ducked
+	hold [busCount]float32
+
+	voice       Sfx
+	voicePath   string // announcer line currently playing
+	voicePaused bool
+}
+
+func newMixer() Mixer {
+	var m Mixer
+	for b := range m.gain {
+		m.gain[b] = 1
+	}
+	return m
+}
+
+// duck lowers a bus by depth and keeps it there for hold seconds.
+func (g *Game) duck(bus Bus, depth, hold float32) {
+	m := &g.mixer
+	m.gain[bus] = float32(math.Min(float64(m.gain[bus]), float64(1-depth)))
+	m.hold[bus] = float32(math.Max(float64(m.hold[bus]), float64(hold)))
+}
+
+// busVolume is the effective volume of a bus after ducking.
+func (g *Game) busVolume(bus Bus) float32 {
+	return g.settings.volumes[bus] * g.mixer.gain[bus]
+}
+
+// updateMixer recovers ducked buses and follows the announcer line.
+func (g *Game) updateMixer(dt float32) {
+	m := &g.mixer
+
+	if m.voicePath != "" {
+		switch {
+		case g.paused():
+			// Hold the line until the game resumes
+			if !m.voicePaused {
+				rl.PauseSound(m.voice.sound)
+				m.voicePaused = true
+			}
+		case m.voicePaused:
+			rl.ResumeSound(m.voice.sound)
+			m.voicePaused = false
+		case rl.IsSoundPlaying(m.voice.sound):
+			g.duck(BusSFX, voiceDuckDepth, 0.1)
+		default:
+			// Done talking: the line may be evicted from memory later
+			g.assets.release(m.voicePath)
+			m.voicePath = ""
+		}
+	}
+
+	for b := range m.gain {
+		if m.hold[b] > 0 {
+			m.hold[b] -= dt
+			continue
+		}
+		m.gain[b] = float32(math.Min(1, float64(m.gain[b]+duckRelease*dt)))
+	}
+}
+
+// updateHeartbeat watches every player's health during play. While any
+// of them is below heartbeatShare the heartbeat loops and the music is
+// held down; both recover once everyone is healed, dead or out of the run.
+func (g *Game) updateHeartbeat() {
+	low := false
+	for i := range g.players {
+		p := &g.players[i]
+		p.lowHealth = g.state == StatePlaying && p.health > 0 &&
+			float32(p.health) < float32(p.stats.maxHealth)*heartbeatShare
+		low = low || p.lowHealth
+	}
+
+	beat := g.sounds.heartbeat
+	if !low {
+		if beat.loaded() && rl.IsSoundPlaying(beat.sound) {
+			rl.StopSound(beat.sound)
+		}
+		return
+	}
+	g.duck(BusMusic, lowHealthDuckDepth, 0.1)
+	if beat.loaded() && !rl.IsSoundPlaying(beat.sound) {
+		g.playSound(beat)
+	}
+}
+
+// playOnBus plays a sound at its bus volume. While paused only UI sounds
+// get through.
+func (g *Game) playOnBus(bus Bus, sound Sfx) {
+	if g.paused() && bus != BusUI {
+		return
+	}
+	if !g.sounds.enabled || !g.settings.soundEnabled || !sound.loaded() {
+		return
+	}
+	voice, ok := sound.freeVoice()
+	if !ok {
+		return // as many playing as allowed
+	}
+	rl.SetSoundVolume(voice, g.busVolume(bus)*sound.volume)
+	rl.PlaySound(voice)
+	if sound.duck > 0 {
+		g.duck(BusSFX, sound.duck, sound.length())
+	}
+}
+
+func (g *Game) playUISound(sound Sfx) {
+	g.playOnBus(BusUI, sound)
+}
+
+// playExplosion plays the explosion sound and ducks the music under it.
+func (g *Game) playExplosion() {
+	g.playSound(g.sounds.explosion)
+	g.duck(BusMusic, explosionDuckDepth, explosionDuckHold)
+}
+
+// announce plays the announcer line event voice/<line> (by default
+// assets/sounds/voice/<line>.wav) if it exists. Lines load on demand
+// through the asset cache and are released once heard. A new line
+// interrupts the previous one.
+func (g *Game) announce(line string) {
+	if !g.sounds.enabled || !g.settings.soundEnabled {
+		return
+	}
+	path, volume := g.audio.resolve(g.audio.Sounds, "voice/"+line, ".wav")
+	if !fileExists(path) {
+		return
+	}
+
+	m := &g.mixer
+	if m.voicePath != "" {
+		rl.StopSound(m.voice.sound)
+		g.assets.release(m.voicePath)
+		m.voicePaused = false
+	}
+	m.voice = Sfx{sound: g.assets.loadSound(path), volume: volume}
+	m.voicePath = path
+	g.playOnBus(BusVoice, m.voice)
+}
+
+// Music tracks fade in and out instead of stopping, and pause rather than
+// stop when silent so they resume from the same position. Switching
+// between menu and game crossfades the two over musicFadeTime; the fade
+// level is heard through an equal-power curve so the mix doesn't dip in
+// the middle.
+const (
+	musicFadeTime    = float32(1.5)  // seconds from silent to full
+	pausedMusicLevel = float32(0.35) // game music dips while paused or picking upgrades
+)
+
+type MusicTrack struct {
+	stream  rl.Music
+	volume  float32 // from the audio manifest
+	fade    float32 // 0 = silent, 1 = full bus volume
+	started bool
+	paused  bool
+}
+
+func (s *SoundSystem) musicTracks() []*MusicTrack {
+	tracks := []*MusicTrack{&s.menuBGM, &s.gameBGM, &s.bossBGM}
+	for l := range s.gameLayers {
+		tracks = append(tracks, &s.gameLayers[l])
+	}
+	return tracks
+}
+
+func (t *MusicTrack) loaded() bool {
+	return t.stream.CtxType != 0
+}
+
+// fadeTo moves the track's fade level toward level, starting or resuming
+// the stream once audible and pausing it when it reaches silence.
+func (t *MusicTrack) fadeTo(level, dt float32) {
+	if !t.loaded() {
+		return
+	}
+
+	step := dt / musicFadeTime
+	if t.fade < level {
+		t.fade = float32(math.Min(float64(level), float64(t.fade+step)))
+	} else {
+		t.fade = float32(math.Max(float64(level), float64(t.fade-step)))
+	}
+
+	switch {
+	case t.fade > 0 && !t.started:
+		rl.PlayMusicStream(t.stream)
+		t.started = true
+	case t.fade > 0 && t.paused:
+		rl.ResumeMusicStream(t.stream)
+		t.paused = false
+	case t.fade == 0 && t.started && !t.paused:
+		rl.PauseMusicStream(t.stream)
+		t.paused = true
+	}
+
+	if t.started && !t.paused {
+		rl.UpdateMusicStream(t.stream)
+	}
+}
+
+// gain is the volume multiplier for the current fade level.
+func (t *MusicTrack) gain() float32 {
+	return float32(math.Sin(float64(t.fade) * math.Pi / 2))
+}
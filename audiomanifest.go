@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Audio manifest. assets/audio.json maps the game's sound events (shoot,
+// ui_move, surfaces/step_goo, voice/boss, menu_bgm, ...) to files under
+// assets/, with a default volume per sound, and lets one event borrow
+// another's sound through an alias. Changing, rebalancing or sharing a
+// sound is an edit to the manifest. An event the manifest doesn't list
+// plays sounds/<event>.wav (music: sounds/<event>.mp3) if it exists, which
+// is also all a tree without the manifest gets.
+//
+// A sound can play over itself up to max times at once (each instance is
+// a raylib sound alias); further plays while all are busy are dropped, so
+// a big fight doesn't stack dozens of hits into clipping. A sound with a
+// duck depth is an important cue: the other effects dip under it while it
+// plays.
+const (
+	audioManifestFile   = "assets/audio.json"
+	defaultSfxInstances = 4
+)
+
+type AudioManifest struct {
+	Sounds  map[string]AudioEntry `json:"sounds"`
+	Music   map[string]AudioEntry `json:"music"`
+	Aliases map[string]string     `json:"aliases"` // event -> event whose sound it plays
+}
+
+type AudioEntry struct {
+	File   string  `json:"file"`   // under assets/
+	Volume float32 `json:"volume"` // 0-1 on top of the bus, 0 or missing = 1
+	Max    int     `json:"max"`    // instances at once, 0 = defaultSfxInstances
+	Duck   float32 `json:"duck"`   // SFX gain drop while it plays, 0 = none
+}
+
+// Sfx is a loaded sound event.
+type Sfx struct {
+	sound  rl.Sound
+	volume float32
+	voices []rl.Sound // sound and its aliases; empty = sound alone
+	duck   float32
+}
+
+// freeVoice returns an instance of the sound that isn't playing.
+func (s Sfx) freeVoice() (rl.Sound, bool) {
+	if len(s.voices) == 0 {
+		return s.sound, !rl.IsSoundPlaying(s.sound)
+	}
+	for _, v := range s.voices {
+		if !rl.IsSoundPlaying(v) {
+			return v, true
+		}
+	}
+	return rl.Sound{}, false
+}
+
+// length is the sound's duration in seconds.
+func (s Sfx) length() float32 {
+	if s.sound.Stream.SampleRate == 0 {
+		return 0
+	}
+	return float32(s.sound.FrameCount) / float32(s.sound.Stream.SampleRate)
+}
+
+func (s Sfx) loaded() bool {
+	return s.sound.FrameCount > 0
+}
+
+// loadAudioManifest reads assets/audio.json. A missing file leaves every
+// event on its default path.
+func loadAudioManifest() AudioManifest {
+	var m AudioManifest
+	data, err := os.ReadFile(audioManifestFile)
+	if err != nil {
+		return m
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		fmt.Println("Warning: Could not read", audioManifestFile+":", err)
+		return AudioManifest{}
+	}
+	return m
+}
+
+// entry follows aliases and returns event's entry, with defaults filled in.
+func (m *AudioManifest) entry(entries map[string]AudioEntry, event, ext string) AudioEntry {
+	seen := map[string]bool{}
+	for !seen[event] {
+		seen[event] = true
+		target, ok := m.Aliases[event]
+		if !ok {
+			break
+		}
+		event = target
+	}
+	e := entries[event]
+	if e.File == "" {
+		e.File = "sounds/" + event + ext
+	}
+	if e.Volume <= 0 || e.Volume > 1 {
+		e.Volume = 1
+	}
+	if e.Max <= 0 {
+		e.Max = defaultSfxInstances
+	}
+	e.File = filepath.Join("assets", e.File)
+	return e
+}
+
+// resolve returns the file path and volume of event.
+func (m *AudioManifest) resolve(entries map[string]AudioEntry, event, ext string) (string, float32) {
+	e := m.entry(entries, event, ext)
+	return e.File, e.Volume
+}
+
+// loadSfx loads a sound event through the asset cache, with an alias per
+// extra instance. Missing files give a silent Sfx.
+func (g *Game) loadSfx(event string) Sfx {
+	e := g.audio.entry(g.audio.Sounds, event, ".wav")
+	if !fileExists(e.File) {
+		return Sfx{}
+	}
+	s := Sfx{sound: g.assets.loadSound(e.File), volume: e.Volume, duck: e.Duck}
+	if s.loaded() && e.Max > 1 {
+		s.voices = append(s.voices, s.sound)
+		for len(s.voices) < e.Max {
+			alias := rl.LoadSoundAlias(s.sound)
+			s.voices = append(s.voices, alias)
+			g.sounds.aliases = append(g.sounds.aliases, alias)
+		}
+	}
+	return s
+}
+
+// unloadSfxAliases frees the extra instances. Called before the sounds
+// themselves are unloaded.
+func (g *Game) unloadSfxAliases() {
+	for _, alias := range g.sounds.aliases {
+		rl.UnloadSoundAlias(alias)
+	}
+	g.sounds.aliases = nil
+}
+
+// loadMusic opens a music event's stream, or leaves the track unloaded.
+func (g *Game) loadMusic(event string, track *MusicTrack) {
+	path, volume := g.audio.resolve(g.audio.Music, event, ".mp3")
+	if fileExists(path) {
+		track.stream = rl.LoadMusicStream(path)
+		track.volume = volume
+	}
+}
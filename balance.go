@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// Balance math. Everything that turns levels, difficulty and upgrades into
+// numbers lives here as plain functions so it can be checked without a
+// window: balance_test.go compares the derived tables with balanceGolden
+// and -update-balance rewrites the golden. A diff in that file is a
+// balance change and should be reviewed as one.
+const balanceGolden = "balance/golden.txt"
+
+const (
+	killsPerLevel   = 20
+	upgradeInterval = 3 // upgrade screen every 3 levels
+)
+
+// Energy modifier: shots and skills share one pool. Firing drains
+// energyFireDrain per second of continuous fire whatever the weapon or fire
+// rate, so upgrades and weapons change damage, not how long the pool lasts.
+const (
+	maxEnergy        = float32(100.0)
+	energyRegen      = float32(25.0) // per second
+	energyRegenDelay = float32(0.6)  // seconds after spending before regen
+	energyFireDrain  = float32(30.0) // per second of continuous fire
+	skillEnergy      = float32(40.0)
+)
+
+func basePlayerStats() PlayerStats {
+	return PlayerStats{
+		maxHealth:  100,
+		damage:     1,
+		speed:      12.0,
+		fireRate:   0.15,
+		critChance: 0.05,
+		statPoints: 0,
+	}
+}
+
+// difficultyStart returns the starting spawn interval and player max HP
+// (0 = Easy, 1 = Normal, 2 = Hard).
+func difficultyStart(difficulty int) (float32, int) {
+	switch difficulty {
+	case 0:
+		return 2.0, 150
+	case 2:
+		return 1.0, 75
+	}
+	return 1.5, 100
+}
+
+// upgradeStats applies one stat upgrade from the upgrade screen.
+func upgradeStats(s PlayerStats, choice int) PlayerStats {
+	switch choice {
+	case 0:
+		s.maxHealth += 20
+	case 1:
+		s.damage++
+	case 2:
+		s.speed += 2.0
+	case 3:
+		s.fireRate = float32(math.Max(float64(s.fireRate-0.02), 0.05))
+	case 4:
+		s.critChance = float32(math.Min(float64(s.critChance+0.05), 0.5))
+	}
+	return s
+}
+
+func enemyHealth(level int) int { return 1 + (level-1)/3 }
+
+func bossHealth(level int) int { return 50 + level*10 }
+
+// spawnIntervalFor is the spawn interval after levelling up to level.
+func spawnIntervalFor(level int) float32 {
+	return float32(math.Max(0.5, float64(1.5-float32(level)*0.05)))
+}
+
+// killsForLevel is how many normal kills reach a level, ignoring the levels
+// bosses give.
+func killsForLevel(level int) int { return (level - 1) * killsPerLevel }
+
+func isUpgradeLevel(level int) bool { return level%upgradeInterval == 1 && level > 1 }
+
+// shotDamage is the damage of one pellet before falloff and crits.
+func shotDamage(s PlayerStats, w WeaponType) int {
+	return int(math.Max(1, math.Round(float64(float32(s.damage)*weaponDefs[w].damageMul))))
+}
+
+// weaponDPS is the expected point-blank damage per second of a weapon,
+// counting every pellet and the crit chance (crits deal triple damage).
+// Beams tick every beamTick regardless of fire rate, until they overheat.
+func weaponDPS(s PlayerStats, w WeaponType) float32 {
+	def := weaponDefs[w]
+	crit := 1 + 2*s.critChance
+	if def.beam {
+		return float32(shotDamage(s, w)) * crit / beamTick
+	}
+	perShot := float32(shotDamage(s, w) * def.pellets)
+	return perShot * crit / (s.fireRate * def.fireRateMul)
+}
+
+// shotEnergy is the energy one shot (or beam tick) costs.
+func shotEnergy(s PlayerStats, w WeaponType) float32 {
+	def := weaponDefs[w]
+	if def.beam {
+		return energyFireDrain * beamTick
+	}
+	return energyFireDrain * s.fireRate * def.fireRateMul
+}
+
+// --- Golden tables ---
+
+func balanceReport() string {
+	var b strings.Builder
+
+	b.WriteString("# DPS after N fire-rate upgrades\n")
+	b.WriteString("upgrades")
+	for w := WeaponType(0); w < weaponCount; w++ {
+		fmt.Fprintf(&b, " | %s", weaponDefs[w].name)
+	}
+	b.WriteString("\n")
+	for n := 0; n <= 6; n++ {
+		s := basePlayerStats()
+		for i := 0; i < n; i++ {
+			s = upgradeStats(s, 3)
+		}
+		fmt.Fprintf(&b, "%d", n)
+		for w := WeaponType(0); w < weaponCount; w++ {
+			fmt.Fprintf(&b, " | %.2f", weaponDPS(s, w))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n# Difficulty: start values\n")
+	b.WriteString("difficulty | spawn interval | player HP\n")
+	for d, name := range []string{"Easy", "Normal", "Hard"} {
+		interval, maxHealth := difficultyStart(d)
+		fmt.Fprintf(&b, "%s | %.2f | %d\n", name, interval, maxHealth)
+	}
+
+	b.WriteString("\n# Level curve\n")
+	b.WriteString("level | kills | enemy HP | boss HP | spawn interval | upgrade\n")
+	for _, level := range []int{1, 2, 4, 7, 10, 11, 20, 21, 30, 31, 40} {
+		fmt.Fprintf(&b, "%d | %d | %d | %d | %.2f | %v\n", level, killsForLevel(level), enemyHealth(level),
+			bossHealth(level), spawnIntervalFor(level), isUpgradeLevel(level))
+	}
+
+	b.WriteString("\n# Energy per shot at base stats\n")
+	b.WriteString("weapon | energy | shots from full\n")
+	for w := WeaponType(0); w < weaponCount; w++ {
+		cost := shotEnergy(basePlayerStats(), w)
+		fmt.Fprintf(&b, "%s | %.2f | %d\n", weaponDefs[w].name, cost, int(maxEnergy/cost))
+	}
+	skill := skillEnergy
+	fmt.Fprintf(&b, "skill | %.2f | %d\n", skill, int(maxEnergy/skill))
+
+	b.WriteString("\n# Weapon evolution XP\n")
+	b.WriteString("weapon | XP | evolves into\n")
+	for w := WeaponType(0); w < baseWeaponCount; w++ {
+		def := weaponDefs[w]
+		if def.evolveXP == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "%s | %d | %s\n", def.name, def.evolveXP, weaponDefs[def.evolvesInto].name)
+	}
+	return b.String()
+}
+
+// writeBalanceGolden rewrites the golden file from the current balance.
+func writeBalanceGolden() bool {
+	if err := os.WriteFile(balanceGolden, []byte(balanceReport()), 0644); err != nil {
+		fmt.Println("Warning: Could not write balance golden:", err)
+		return false
+	}
+	fmt.Println("✓ Updated:", balanceGolden)
+	return true
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestBalanceGolden fails on any change to the derived balance tables.
+// After an intended balance change, run the game with -update-balance and
+// review the diff of balance/golden.txt.
+func TestBalanceGolden(t *testing.T) {
+	data, err := os.ReadFile(balanceGolden)
+	if err != nil {
+		t.Fatalf("no balance golden, run with -update-balance: %v", err)
+	}
+	want := strings.Split(string(data), "\n")
+	got := strings.Split(balanceReport(), "\n")
+	for i := 0; i < len(want) || i < len(got); i++ {
+		var w, g string
+		if i < len(want) {
+			w = want[i]
+		}
+		if i < len(got) {
+			g = got[i]
+		}
+		if w != g {
+			t.Errorf("line %d\n  golden: %s\n  now:    %s", i+1, w, g)
+		}
+	}
+}
+
+func TestWeaponDPS(t *testing.T) {
+	fastest := basePlayerStats()
+	for i := 0; i < 6; i++ {
+		fastest = upgradeStats(fastest, 3)
+	}
+	tests := []struct {
+		name   string
+		stats  PlayerStats
+		weapon WeaponType
+		want   float32
+	}{
+		{"blaster base", basePlayerStats(), WeaponBlaster, 7.33},
+		{"shotgun pellets", basePlayerStats(), WeaponShotgun, 18.33},
+		{"rocket base", basePlayerStats(), WeaponRocket, 3.67},
+		{"laser beam ignores fire rate", basePlayerStats(), WeaponLaser, 11.00},
+		{"blaster fire rate floor", fastest, WeaponBlaster, 22.00},
+		{"laser beam at fire rate floor", fastest, WeaponLaser, 11.00},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := weaponDPS(tt.stats, tt.weapon); math.Abs(float64(got-tt.want)) > 0.01 {
+				t.Errorf("weaponDPS = %.2f, want %.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnemyHealth(t *testing.T) {
+	tests := []struct{ level, want int }{
+		{1, 1},
+		{3, 1},
+		{4, 2},
+		{30, 10},
+		{31, 11},
+	}
+	for _, tt := range tests {
+		if got := enemyHealth(tt.level); got != tt.want {
+			t.Errorf("enemyHealth(%d) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestKillsForLevel(t *testing.T) {
+	tests := []struct{ level, want int }{
+		{1, 0},
+		{2, killsPerLevel},
+		{11, 10 * killsPerLevel},
+	}
+	for _, tt := range tests {
+		if got := killsForLevel(tt.level); got != tt.want {
+			t.Errorf("killsForLevel(%d) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestIsUpgradeLevel(t *testing.T) {
+	tests := []struct {
+		level int
+		want  bool
+	}{
+		{1, false}, // the run starts there
+		{2, false},
+		{3, false},
+		{4, true},
+		{7, true},
+		{10, true},
+		{11, false},
+	}
+	for _, tt := range tests {
+		if got := isUpgradeLevel(tt.level); got != tt.want {
+			t.Errorf("isUpgradeLevel(%d) = %v, want %v", tt.level, got, tt.want)
+		}
+	}
+}
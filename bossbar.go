@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Boss health bar across the top of the screen, with the boss name and a
+// tick at each health fraction where the fight changes phase.
+const (
+	bossBarWidth  = 800
+	bossBarHeight = 22
+	bossBarY      = 42
+	bossBarLowY   = 130 // below the streamer HUD
+)
+
+// bossPhaseMarks lists the health fractions where the current boss changes
+// phase, highest first.
+func (g *Game) bossPhaseMarks() []float32 {
+	if def := g.bossFight.def; def != nil {
+		var marks []float32
+		for _, phase := range def.Phases[1:] {
+			marks = append(marks, phase.Below)
+		}
+		return marks
+	}
+	switch g.bossFight.archetype {
+	case BossSummoner, BossArtillery:
+		return []float32{0.5}
+	case BossFinal:
+		marks := make([]float32, 0, finalBossPhaseCount-1)
+		for p := 1; p < finalBossPhaseCount; p++ {
+			marks = append(marks, 1-float32(p)/finalBossPhaseCount)
+		}
+		return marks
+	}
+	return nil
+}
+
+func (g *Game) drawBossBar() {
+	if !g.bossActive {
+		return
+	}
+	boss := &g.enemies[g.bossFight.index]
+	if !boss.active || !boss.isBoss {
+		return
+	}
+	x, y := int32(screenWidth/2-bossBarWidth/2), int32(bossBarY)
+	if g.settings.streamerMode {
+		y = bossBarLowY
+	}
+	left := float32(boss.health) / float32(boss.maxHealth)
+
+	name := strings.ToUpper(g.bossName())
+	drawText(name, x, y-26, 22, rl.White)
+	hp := fmt.Sprintf("%d / %d", max(boss.health, 0), boss.maxHealth)
+	drawText(hp, x+bossBarWidth-measureText(hp, 18), y-22, 18, rl.LightGray)
+
+	rl.DrawRectangle(x-2, y-2, bossBarWidth+4, bossBarHeight+4, rl.NewColor(0, 0, 0, 180))
+	rl.DrawRectangle(x, y, bossBarWidth, bossBarHeight, rl.DarkGray)
+	rl.DrawRectangle(x, y, int32(bossBarWidth*max(left, 0)), bossBarHeight, rl.Red)
+	for _, mark := range g.bossPhaseMarks() {
+		tick := x + int32(bossBarWidth*mark)
+		color := rl.White
+		if left <= mark {
+			color = rl.Gray // phase already reached
+		}
+		rl.DrawRectangle(tick-1, y-4, 3, bossBarHeight+8, color)
+	}
+	rl.DrawRectangleLines(x, y, bossBarWidth, bossBarHeight, rl.White)
+}
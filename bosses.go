@@ -0,0 +1,450 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Scripted boss fights. Every assets/bosses/*.json describes one boss as a
+// list of phases; a phase starts once the boss's health drops below its
+// "below" fraction and has a movement pattern plus an attack sequence that
+// repeats every "cycle" seconds. A definition can be limited to some stages
+// with "stages"; boss levels take the matching definitions in file name
+// order. The directory is polled while playing, so saving a file reloads it
+// in the running game. Stages without a definition get the built-in
+// archetype for the stage (bosstypes.go).
+const (
+	bossDir          = "assets/bosses"
+	bossPollInterval = float32(1.0)
+)
+
+type BossDef struct {
+	Name      string      `json:"name"`
+	Size      float32     `json:"size"`
+	HealthMul float32     `json:"healthMul"`
+	Color     [3]uint8    `json:"color"`
+	Stages    []string    `json:"stages"` // stage names, empty = any
+	Phases    []BossPhase `json:"phases"`
+}
+
+type BossPhase struct {
+	Below    float32      `json:"below"` // health fraction that starts the phase
+	Cycle    float32      `json:"cycle"` // seconds before the attacks repeat
+	Movement BossMovement `json:"movement"`
+	Attacks  []BossAttack `json:"attacks"`
+}
+
+// BossMovement is "approach" (close in to radius), "orbit" (circle the
+// player at radius) or "hold" (stand still).
+type BossMovement struct {
+	Type   string  `json:"type"`
+	Speed  float32 `json:"speed"`
+	Radius float32 `json:"radius"`
+}
+
+// BossAttack fires at time At into the cycle (patterns.go):
+//   - "aimed" shoots count bullets fanned over spread degrees at the nearest player
+//   - "ring" shoots count bullets all around, minus a gap of gap degrees
+//   - "spiral" shoots count arms every 0.15s for duration seconds, turning spin degrees/s
+//   - "burst" shoots volleys aimed fans of count, interval seconds apart
+//   - "spawn" calls in count enemies of kind
+//   - "aoe" marks count circles of radius on and around the players that
+//     land duration seconds later (telegraph.go)
+type BossAttack struct {
+	At       float32 `json:"at"`
+	Type     string  `json:"type"`
+	Count    int     `json:"count"`
+	Spread   float32 `json:"spread"`
+	Speed    float32 `json:"speed"`
+	Damage   int     `json:"damage"`
+	Kind     string  `json:"kind"`
+	Gap      float32 `json:"gap"`
+	Duration float32 `json:"duration"`
+	Spin     float32 `json:"spin"`
+	Volleys  int     `json:"volleys"`
+	Interval float32 `json:"interval"`
+	Radius   float32 `json:"radius"`
+}
+
+// BossFight is the state of the scripted boss currently on the field.
+type BossFight struct {
+	def   *BossDef
+	index int // enemy slot
+	phase int
+	clock float32 // time into the current cycle
+	step  int     // next attack in the cycle
+
+	// Built-in archetypes (bosstypes.go)
+	archetype    BossArchetype
+	state        int
+	timer        float32
+	dirX, dirZ   float32
+	patternTimer float32 // time to the next bullet pattern
+	patternStep  int
+}
+
+type bossLibrary struct {
+	defs     []BossDef
+	modTimes map[string]time.Time
+	poll     float32
+}
+
+// loadBossDefs reads every definition in bossDir. A broken file is skipped
+// with a warning so one typo doesn't take the other bosses down.
+func loadBossDefs() ([]BossDef, map[string]time.Time) {
+	files, _ := filepath.Glob(filepath.Join(bossDir, "*.json"))
+	slices.Sort(files)
+
+	var defs []BossDef
+	modTimes := map[string]time.Time{}
+	for _, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			modTimes[file] = info.ModTime()
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Println("Warning: Could not read boss:", err)
+			continue
+		}
+		var def BossDef
+		if err := json.Unmarshal(data, &def); err != nil {
+			fmt.Printf("Warning: Could not parse boss %s: %v\n", file, err)
+			continue
+		}
+		if err := def.validate(); err != nil {
+			fmt.Printf("Warning: Invalid boss %s: %v\n", file, err)
+			continue
+		}
+		defs = append(defs, def)
+	}
+	return defs, modTimes
+}
+
+// validate fills in defaults and rejects definitions the runtime can't play.
+func (d *BossDef) validate() error {
+	if len(d.Phases) == 0 {
+		return fmt.Errorf("no phases")
+	}
+	if d.Size <= 0 {
+		d.Size = 4
+	}
+	if d.HealthMul <= 0 {
+		d.HealthMul = 1
+	}
+	if d.Color == [3]uint8{} {
+		d.Color = [3]uint8{150, 0, 150}
+	}
+	for _, stage := range d.Stages {
+		if !slices.ContainsFunc(stageNames[:], func(s string) bool { return strings.EqualFold(s, stage) }) {
+			return fmt.Errorf("unknown stage %q", stage)
+		}
+	}
+	for p := range d.Phases {
+		phase := &d.Phases[p]
+		if p == 0 && phase.Below == 0 {
+			phase.Below = 1
+		}
+		if phase.Cycle <= 0 {
+			return fmt.Errorf("phase %d: cycle must be positive", p+1)
+		}
+		switch phase.Movement.Type {
+		case "approach", "orbit", "hold":
+		default:
+			return fmt.Errorf("phase %d: unknown movement %q", p+1, phase.Movement.Type)
+		}
+		for a := range phase.Attacks {
+			atk := &phase.Attacks[a]
+			switch atk.Type {
+			case "aimed", "ring", "spiral", "burst":
+				if atk.Speed <= 0 {
+					atk.Speed = enemyBulletSpeed
+				}
+				if atk.Damage <= 0 {
+					atk.Damage = enemyBulletDamage
+				}
+				if atk.Gap == 0 {
+					atk.Gap = ringGapDefault
+				}
+				if atk.Duration <= 0 {
+					atk.Duration = 2
+				}
+				if atk.Spin == 0 {
+					atk.Spin = spiralSpinSpeed
+				}
+				atk.Volleys = max(atk.Volleys, 1)
+				if atk.Interval <= 0 {
+					atk.Interval = 0.5
+				}
+			case "aoe":
+				atk.Count = max(atk.Count, 1)
+				if atk.Radius <= 0 {
+					atk.Radius = telegraphRadius
+				}
+				if atk.Duration <= 0 {
+					atk.Duration = telegraphDelay
+				}
+				if atk.Damage <= 0 {
+					atk.Damage = telegraphDamage
+				}
+			case "spawn":
+				if _, ok := enemyKindByName(atk.Kind); !ok {
+					return fmt.Errorf("phase %d: unknown enemy kind %q", p+1, atk.Kind)
+				}
+			default:
+				return fmt.Errorf("phase %d: unknown attack %q", p+1, atk.Type)
+			}
+			atk.Count = max(atk.Count, 1)
+		}
+		// The runtime walks attacks in time order
+		slices.SortStableFunc(phase.Attacks, func(a, b BossAttack) int { return cmp.Compare(a.At, b.At) })
+	}
+	return nil
+}
+
+func enemyKindByName(name string) (EnemyKind, bool) {
+	for k := EnemyKind(0); k < enemyKindCount; k++ {
+		if strings.EqualFold(enemyKindNames[k], name) {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+func (g *Game) loadBosses() {
+	g.bossLib.defs, g.bossLib.modTimes = loadBossDefs()
+	if len(g.bossLib.defs) > 0 {
+		fmt.Printf("✓ Loaded: %d boss definitions\n", len(g.bossLib.defs))
+	}
+}
+
+// pollBossDefs reloads the definitions when a file in bossDir was added,
+// removed or saved. A boss already fighting picks up its new definition.
+func (g *Game) pollBossDefs(dt float32) {
+	lib := &g.bossLib
+	lib.poll -= dt
+	if lib.poll > 0 {
+		return
+	}
+	lib.poll = bossPollInterval
+
+	files, _ := filepath.Glob(filepath.Join(bossDir, "*.json"))
+	changed := len(files) != len(lib.modTimes)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err == nil && !info.ModTime().Equal(lib.modTimes[file]) {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+
+	name := ""
+	if g.bossFight.def != nil {
+		name = g.bossFight.def.Name
+	}
+	g.loadBosses()
+	fmt.Println("✓ Reloaded:", bossDir)
+
+	if name == "" {
+		return
+	}
+	g.bossFight.def = nil
+	for i := range lib.defs {
+		if lib.defs[i].Name == name {
+			g.bossFight.def = &lib.defs[i]
+			g.bossFight.phase = min(g.bossFight.phase, len(lib.defs[i].Phases)-1)
+			g.bossFight.clock, g.bossFight.step = 0, 0
+		}
+	}
+}
+
+// bossDefFor picks the definition for the boss at this level on stage, or
+// nil for the stage's built-in archetype.
+func (g *Game) bossDefFor(level int, stage StageType) *BossDef {
+	var matching []*BossDef
+	for i := range g.bossLib.defs {
+		def := &g.bossLib.defs[i]
+		if len(def.Stages) == 0 || slices.ContainsFunc(def.Stages, func(s string) bool {
+			return strings.EqualFold(s, stageNames[stage])
+		}) {
+			matching = append(matching, def)
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+	n := max(level/5-1, 0)
+	return matching[n%len(matching)]
+}
+
+// updateBoss moves the boss and runs its attacks.
+func (g *Game) updateBoss(i int, target *Player, dt float32) {
+	fight := &g.bossFight
+	e := &g.enemies[i]
+	if fight.index != i {
+		g.updateDefaultBoss(e, target, dt)
+		return
+	}
+	if fight.def == nil {
+		g.updateArchetypeBoss(e, target, dt)
+		return
+	}
+	def := fight.def
+
+	// Phases only move forward
+	ratio := float32(e.health) / float32(e.maxHealth)
+	for fight.phase+1 < len(def.Phases) && ratio <= def.Phases[fight.phase+1].Below {
+		fight.phase++
+		fight.clock, fight.step = 0, 0
+		g.CreateExplosion(e.position, e.color, 30)
+		g.playSound(g.sounds.boss)
+	}
+	phase := &def.Phases[fight.phase]
+
+	g.moveBoss(e, target, phase.Movement, dt)
+
+	fight.clock += dt
+	for fight.step < len(phase.Attacks) && phase.Attacks[fight.step].At <= fight.clock {
+		g.bossAttack(e, target, phase.Attacks[fight.step])
+		fight.step++
+	}
+	if fight.clock >= phase.Cycle {
+		fight.clock -= phase.Cycle
+		fight.step = 0
+	}
+}
+
+func (g *Game) moveBoss(e *Enemy, target *Player, m BossMovement, dt float32) {
+	dx := target.position.X - e.position.X
+	dz := target.position.Z - e.position.Z
+	dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
+	if dist < 0.01 {
+		return
+	}
+
+	var moveX, moveZ float32
+	switch m.Type {
+	case "approach":
+		if dist > m.Radius {
+			moveX, moveZ = dx/dist, dz/dist
+		}
+	case "orbit":
+		// Tangent plus a pull back onto the circle
+		pull := float32(0)
+		if m.Radius > 0 {
+			pull = (dist - m.Radius) / m.Radius
+		}
+		moveX = -dz/dist + dx/dist*pull
+		moveZ = dx/dist + dz/dist*pull
+		if l := float32(math.Sqrt(float64(moveX*moveX + moveZ*moveZ))); l > 1 {
+			moveX /= l
+			moveZ /= l
+		}
+	}
+
+	newPos := rl.Vector3{
+		X: e.position.X + moveX*m.Speed*dt,
+		Y: e.position.Y,
+		Z: e.position.Z + moveZ*m.Speed*dt,
+	}
+	if !g.CheckObstacleCollision(newPos, e.size/2) {
+		e.position = newPos
+	}
+	e.facing = float32(math.Atan2(float64(dz), float64(dx)))
+}
+
+func (g *Game) bossAttack(e *Enemy, target *Player, atk BossAttack) {
+	g.addLight(e.position, e.color, 8, 0.4)
+	switch atk.Type {
+	case "aimed":
+		aim := float32(math.Atan2(float64(target.position.Z-e.position.Z), float64(target.position.X-e.position.X)))
+		g.fireFan(e.position, aim, atk.Count, atk.Spread*math.Pi/180, atk.Speed, atk.Damage)
+
+	case "ring":
+		g.fireRing(e.position, atk.Count, atk.Speed, atk.Damage, g.rng.Float32()*2*math.Pi, atk.Gap)
+
+	case "spiral":
+		g.startSpiral(g.bossFight.index, atk.Count, atk.Duration, spiralInterval, atk.Spin, atk.Speed, atk.Damage)
+
+	case "burst":
+		g.startBurst(g.bossFight.index, atk.Volleys, atk.Count, atk.Interval, atk.Spread, atk.Speed, atk.Damage)
+
+	case "aoe":
+		g.telegraphAtPlayers(atk.Count, atk.Radius, atk.Duration, atk.Damage)
+
+	case "spawn":
+		kind, _ := enemyKindByName(atk.Kind)
+		spawned := 0
+		for slot := range g.enemies {
+			if spawned == atk.Count {
+				break
+			}
+			if g.enemies[slot].active {
+				continue
+			}
+			angle := 2 * math.Pi * float64(spawned) / float64(atk.Count)
+			pos := rl.NewVector3(
+				e.position.X+float32(math.Cos(angle))*(e.size+1),
+				0.75,
+				e.position.Z+float32(math.Sin(angle))*(e.size+1),
+			)
+			if g.CheckObstacleCollision(pos, 0.5) {
+				pos = rl.NewVector3(e.position.X, 0.75, e.position.Z)
+			}
+			health := enemyHealth(g.level)
+			g.spawnEnemyAt(slot, pos, kind, float32(3.0+g.rng.Float64()*2+float64(g.level)*0.2), 1.0, health)
+			g.CreateExplosion(pos, e.color, 6)
+			spawned++
+		}
+	}
+}
+
+// updateDefaultBoss is the built-in boss: chase the player, then circle
+// once close.
+func (g *Game) updateDefaultBoss(e *Enemy, target *Player, dt float32) {
+	dx := target.position.X - e.position.X
+	dz := target.position.Z - e.position.Z
+	dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
+
+	if dist > 5.0 { // ถ้าไกล ก็เข้าใกล้
+		speed := float32(6.0 + float64(g.level)*0.3)
+		newPos := rl.Vector3{
+			X: e.position.X + (dx/dist)*speed*dt,
+			Y: e.position.Y,
+			Z: e.position.Z + (dz/dist)*speed*dt,
+		}
+
+		if !g.CheckObstacleCollision(newPos, e.size/2) {
+			e.position = newPos
+		}
+	} else { // ถ้าใกล้แล้ว ก็วนรอบ
+		angle := g.gameTime * 1.0
+		radius := float32(8.0)
+		targetX := target.position.X + float32(math.Cos(float64(angle)))*radius
+		targetZ := target.position.Z + float32(math.Sin(float64(angle)))*radius
+
+		dx = targetX - e.position.X
+		dz = targetZ - e.position.Z
+		speed := float32(8.0)
+
+		newPos := rl.Vector3{
+			X: e.position.X + dx*speed*dt*0.1,
+			Y: e.position.Y,
+			Z: e.position.Z + dz*speed*dt*0.1,
+		}
+
+		if !g.CheckObstacleCollision(newPos, e.size/2) {
+			e.position = newPos
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Boss Rush (main menu). Only bosses, one per stage at the level it would
+// normally appear, with an upgrade pick and a short breather in between.
+// Normal spawning and nests are off. The fastest clears per difficulty are
+// kept in save/bossrush.json.
+const (
+	bossRushFile  = saveDir + "/bossrush.json"
+	bossRushBreak = float32(3.0) // seconds between the upgrade pick and the next boss
+	bossRushKept  = 5            // fastest clears kept per difficulty
+)
+
+// bossRushLevels picks the level of each fight, which sets its stage and
+// boss health.
+var bossRushLevels = [...]int{5, 15, 25, 35}
+
+var difficultyNames = [...]string{"easy", "normal", "hard"}
+
+type BossRushTime struct {
+	Time float32 `json:"time"`
+	Date string  `json:"date"`
+}
+
+// BossRush tracks the current rush run.
+type BossRush struct {
+	fight   int     // index into bossRushLevels
+	pause   float32 // breather before the next boss
+	cleared bool
+	newBest bool
+	times   map[string][]BossRushTime
+}
+
+// startBossRush starts a solo boss rush. Rush runs use a random seed and
+// don't count toward seed records.
+func (g *Game) startBossRush() {
+	g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, true, false, false
+	g.StartGame(false)
+	g.seeded = false
+}
+
+// resetBossRush puts a fresh run at the first boss. Called from ResetGame
+// before the stage is built.
+func (g *Game) resetBossRush() {
+	g.rush.fight = 0
+	g.rush.pause = bossRushBreak
+	g.rush.cleared = false
+	g.rush.newBest = false
+	g.level = bossRushLevels[0]
+	// Held until the breather runs out
+	g.bossSpawned = true
+}
+
+// updateBossRush counts down the breather and lets the boss spawn.
+func (g *Game) updateBossRush(dt float32) {
+	if !g.bossRushMode || g.rush.pause <= 0 {
+		return
+	}
+	g.rush.pause -= dt
+	if g.rush.pause <= 0 {
+		g.bossSpawned = false
+	}
+}
+
+// bossRushKill moves on to the next boss, or ends the run after the last.
+func (g *Game) bossRushKill() {
+	g.rush.fight++
+	if g.rush.fight == len(bossRushLevels) {
+		g.rush.cleared = true
+		g.recordBossRush()
+		g.awardScrap(true)
+		g.state = StateGameOver
+		g.announce("game_over")
+		if g.score > g.highScore {
+			g.highScore = g.score
+		}
+		return
+	}
+
+	// Clear out minions and stray shots before the next arena
+	for i := range g.enemies {
+		g.enemies[i].active = false
+	}
+	for i := range g.enemyBullets {
+		g.enemyBullets[i].active = false
+	}
+	for i := range g.bossShells {
+		g.bossShells[i].active = false
+	}
+	g.level = bossRushLevels[g.rush.fight]
+	g.GenerateStage()
+	g.rush.pause = bossRushBreak
+	g.bossSpawned = true
+	g.startUpgradeBreak()
+}
+
+func (g *Game) loadBossRushTimes() {
+	g.rush.times = map[string][]BossRushTime{}
+	data, err := os.ReadFile(bossRushFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &g.rush.times); err != nil {
+		fmt.Println("Warning: Could not read boss rush times:", err)
+		g.rush.times = map[string][]BossRushTime{}
+	}
+}
+
+// recordBossRush keeps the clear if it is among the fastest.
+func (g *Game) recordBossRush() {
+	key := difficultyNames[g.recordDifficulty]
+	times := g.rush.times[key]
+	g.rush.newBest = len(times) == 0 || g.gameTime < times[0].Time
+
+	times = append(times, BossRushTime{Time: g.gameTime, Date: time.Now().Format("2006-01-02")})
+	slices.SortStableFunc(times, func(a, b BossRushTime) int { return cmp.Compare(a.Time, b.Time) })
+	if len(times) > bossRushKept {
+		times = times[:bossRushKept]
+	}
+	g.rush.times[key] = times
+
+	os.MkdirAll(saveDir, os.ModePerm)
+	data, err := json.MarshalIndent(g.rush.times, "", "  ")
+	if err != nil {
+		fmt.Println("Warning: Could not encode boss rush times:", err)
+		return
+	}
+	if err := writeFileAtomic(bossRushFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save boss rush times:", err)
+	}
+}
+
+// formatRushTime prints seconds as m:ss.s.
+func formatRushTime(t float32) string {
+	return fmt.Sprintf("%d:%04.1f", int(t)/60, t-float32(int(t)/60*60))
+}
+
+// drawBossRushHUD shows the fight count and the clock, and counts down the
+// breather in the middle of the screen.
+func (g *Game) drawBossRushHUD() {
+	if !g.bossRushMode {
+		return
+	}
+	text := fmt.Sprintf("BOSS %d/%d  %s", g.rush.fight+1, len(bossRushLevels), formatRushTime(g.gameTime))
+	drawText(text, 260, 75, 18, rl.Orange)
+
+	if g.rush.pause > 0 && !g.bossActive {
+		count := fmt.Sprintf("NEXT BOSS IN %d", int(g.rush.pause)+1)
+		drawText(count, screenWidth/2-measureText(count, 40)/2, screenHeight/2-120, 40, rl.Orange)
+	}
+}
+
+// drawBossRushResult replaces the level and kill lines on the game over
+// screen.
+func (g *Game) drawBossRushResult(y int32) {
+	centerX := int32(screenWidth / 2)
+	text := fmt.Sprintf("Bosses Beaten: %d/%d", g.rush.fight, len(bossRushLevels))
+	color := rl.Yellow
+	if g.rush.cleared {
+		text = "Clear Time: " + formatRushTime(g.gameTime)
+		color = rl.Gold
+	}
+	drawText(text, centerX-measureText(text, 28)/2, y, 28, color)
+
+	best := "No clear yet"
+	if times := g.rush.times[difficultyNames[g.recordDifficulty]]; len(times) > 0 {
+		best = "Fastest: " + formatRushTime(times[0].Time)
+	}
+	if g.rush.newBest {
+		best = "NEW FASTEST CLEAR!"
+	}
+	drawText(best, centerX-measureText(best, 25)/2, y+35, 25, rl.Gold)
+}
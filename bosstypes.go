@@ -0,0 +1,344 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Built-in boss archetypes, picked by stage when no scripted definition
+// claims the stage. Each has its own movement and attack loop driven by
+// bossFight.state and bossFight.timer.
+type BossArchetype int
+
+const (
+	BossClassic   BossArchetype = iota // chase and circle
+	BossCharger                        // winds up and rams across the arena
+	BossSummoner                       // keeps away and calls in minions
+	BossArtillery                      // shells the players from range
+	BossFinal                          // the level 100 boss, see finalboss.go
+)
+
+type bossArchetypeDef struct {
+	name      string
+	sizeMul   float32
+	healthMul float32
+	color     rl.Color
+}
+
+var bossArchetypes = [...]bossArchetypeDef{
+	BossClassic:   {"Brute", 1, 1, rl.NewColor(150, 0, 150, 255)},
+	BossCharger:   {"Charger", 1.1, 1.2, rl.NewColor(200, 60, 30, 255)},
+	BossSummoner:  {"Summoner", 0.9, 0.8, rl.NewColor(60, 160, 90, 255)},
+	BossArtillery: {"Artillery", 1.2, 1, rl.NewColor(90, 100, 120, 255)},
+	BossFinal:     {"Overlord", 1.5, 3, rl.NewColor(180, 20, 40, 255)},
+}
+
+var stageBossArchetypes = [...]BossArchetype{
+	StageBasic:  BossCharger,
+	StageMaze:   BossSummoner,
+	StageHazard: BossClassic,
+	StageArena:  BossArtillery,
+	StageNight:  BossCharger, // out of the dark
+}
+
+// Boss states shared by the archetypes
+const (
+	bossStalk = iota
+	bossWindup
+	bossCharge
+	bossStunned
+)
+
+const bruteInterval = float32(4.0)
+
+// Charger
+const (
+	chargerStalkTime  = float32(2.5)
+	chargerWindupTime = float32(0.8)
+	chargerChargeTime = float32(1.3)
+	chargerStunTime   = float32(1.2)
+	chargerStalkSpeed = float32(4.0)
+	chargerSpeed      = float32(26.0)
+)
+
+// Summoner
+const (
+	summonerKeepDistance  = float32(15.0)
+	summonerSpeed         = float32(5.0)
+	summonInterval        = float32(4.5)
+	summonCount           = 3
+	summonerBurstInterval = float32(3.0)
+)
+
+// Artillery
+const (
+	maxBossShells        = 16
+	artilleryInterval    = float32(2.5)
+	artilleryVolley      = 3
+	artilleryScatter     = float32(4.0)
+	artilleryFlightTime  = float32(1.4)
+	artilleryRadius      = float32(3.0)
+	artilleryDamage      = 25
+	artillerySpeed       = float32(2.5)
+	artillerySpiralEvery = float32(6.0)
+)
+
+// BossShell is an artillery round on its way to a marked spot.
+type BossShell struct {
+	from, target rl.Vector3
+	timer        float32
+	active       bool
+}
+
+func (g *Game) updateArchetypeBoss(e *Enemy, target *Player, dt float32) {
+	switch g.bossFight.archetype {
+	case BossCharger:
+		g.updateCharger(e, target, dt)
+	case BossSummoner:
+		g.updateSummoner(e, target, dt)
+	case BossArtillery:
+		g.updateArtillery(e, target, dt)
+	case BossFinal:
+		g.updateFinalBoss(e, target, dt)
+	default:
+		g.updateDefaultBoss(e, target, dt)
+		// Cycle a spiral, a ring with a gap and a ground slam
+		if g.bossFight.patternDue(dt, bruteInterval) {
+			switch g.bossFight.patternStep % 3 {
+			case 0:
+				g.startSpiral(g.bossFight.index, 3, 2.4, spiralInterval, spiralSpinSpeed, 9, enemyBulletDamage)
+			case 1:
+				g.fireRing(e.position, 24, 10, enemyBulletDamage, g.rng.Float32()*2*math.Pi, ringGapDefault)
+			case 2:
+				g.telegraphAtPlayers(len(g.players)+2, telegraphRadius, telegraphDelay, telegraphDamage)
+			}
+		}
+	}
+}
+
+// patternDue counts the pattern timer down and reports when the next
+// pattern should fire, restarting the timer at every.
+func (f *BossFight) patternDue(dt, every float32) bool {
+	f.patternTimer -= dt
+	if f.patternTimer > 0 {
+		return false
+	}
+	f.patternTimer = every
+	f.patternStep++
+	return true
+}
+
+// updateCharger stalks the target, stops to wind up, then charges in a
+// straight line until the charge runs out or it hits a wall, which stuns it
+// and leaves it open.
+func (g *Game) updateCharger(e *Enemy, target *Player, dt float32) {
+	fight := &g.bossFight
+	fight.timer -= dt
+	dx := target.position.X - e.position.X
+	dz := target.position.Z - e.position.Z
+	dist := float32(math.Max(0.01, math.Sqrt(float64(dx*dx+dz*dz))))
+
+	switch fight.state {
+	case bossStalk:
+		g.moveBossBy(e, dx/dist*chargerStalkSpeed*dt, dz/dist*chargerStalkSpeed*dt)
+		e.facing = float32(math.Atan2(float64(dz), float64(dx)))
+		if fight.timer <= 0 {
+			fight.state, fight.timer = bossWindup, chargerWindupTime
+		}
+
+	case bossWindup:
+		// Track the target until the last moment, then commit
+		fight.dirX, fight.dirZ = dx/dist, dz/dist
+		e.facing = float32(math.Atan2(float64(dz), float64(dx)))
+		if fight.timer <= 0 {
+			fight.state, fight.timer = bossCharge, chargerChargeTime
+		}
+
+	case bossCharge:
+		if !g.moveBossBy(e, fight.dirX*chargerSpeed*dt, fight.dirZ*chargerSpeed*dt) {
+			g.CreateExplosion(e.position, rl.Gray, 30)
+			g.playExplosion()
+			// The impact throws debris out in a ring
+			g.fireRing(e.position, 20, 11, enemyBulletDamage, g.rng.Float32()*2*math.Pi, ringGapDefault)
+			fight.state, fight.timer = bossStunned, chargerStunTime
+			return
+		}
+		if fight.timer <= 0 {
+			fight.state, fight.timer = bossStalk, chargerStalkTime
+		}
+
+	case bossStunned:
+		if fight.timer <= 0 {
+			fight.state, fight.timer = bossStalk, chargerStalkTime
+		}
+	}
+}
+
+// updateSummoner keeps its distance, strafing around the target, and calls
+// in a ring of minions from the stage's spawn table, faster once hurt.
+func (g *Game) updateSummoner(e *Enemy, target *Player, dt float32) {
+	fight := &g.bossFight
+	dx := target.position.X - e.position.X
+	dz := target.position.Z - e.position.Z
+	dist := float32(math.Max(0.01, math.Sqrt(float64(dx*dx+dz*dz))))
+
+	// Back off when close, circle otherwise
+	away := (summonerKeepDistance - dist) / summonerKeepDistance
+	moveX := -dz/dist - dx/dist*away*2
+	moveZ := dx/dist - dz/dist*away*2
+	if l := float32(math.Sqrt(float64(moveX*moveX + moveZ*moveZ))); l > 0 {
+		g.moveBossBy(e, moveX/l*summonerSpeed*dt, moveZ/l*summonerSpeed*dt)
+	}
+	e.facing = float32(math.Atan2(float64(dz), float64(dx)))
+
+	// Aimed bursts to keep players moving between summons
+	if fight.patternDue(dt, summonerBurstInterval) {
+		g.startBurst(fight.index, 3, 3, 0.4, 25, 13, enemyBulletDamage)
+	}
+
+	fight.timer -= dt
+	if fight.timer > 0 {
+		return
+	}
+	fight.timer = summonInterval
+	if e.health*2 < e.maxHealth {
+		fight.timer *= 0.6
+	}
+	g.summonAround(e, summonCount)
+}
+
+// summonAround spawns up to count random enemies in a ring around the boss.
+func (g *Game) summonAround(e *Enemy, count int) {
+	spawned := 0
+	for slot := range g.enemies {
+		if spawned == count {
+			break
+		}
+		if g.enemies[slot].active {
+			continue
+		}
+		angle := 2*math.Pi*float64(spawned)/float64(count) + g.rng.Float64()
+		pos := rl.NewVector3(
+			e.position.X+float32(math.Cos(angle))*(e.size+1),
+			0.75,
+			e.position.Z+float32(math.Sin(angle))*(e.size+1),
+		)
+		if g.CheckObstacleCollision(pos, 0.5) {
+			continue
+		}
+		g.spawnRandomEnemy(slot, pos)
+		g.CreateExplosion(pos, e.color, 8)
+		spawned++
+	}
+}
+
+// updateArtillery drifts slowly around the edge of the fight and fires
+// volleys of shells at and around the players. Impact points are marked on
+// the floor for the whole flight.
+func (g *Game) updateArtillery(e *Enemy, target *Player, dt float32) {
+	fight := &g.bossFight
+	dx := target.position.X - e.position.X
+	dz := target.position.Z - e.position.Z
+	dist := float32(math.Max(0.01, math.Sqrt(float64(dx*dx+dz*dz))))
+	g.moveBossBy(e, -dz/dist*artillerySpeed*dt, dx/dist*artillerySpeed*dt)
+	e.facing = float32(math.Atan2(float64(dz), float64(dx)))
+
+	// Once hurt it adds a slow two-armed spiral
+	if e.health*2 < e.maxHealth && fight.patternDue(dt, artillerySpiralEvery) {
+		g.startSpiral(fight.index, 2, 3, spiralInterval, spiralSpinSpeed*0.7, 8, enemyBulletDamage)
+	}
+
+	fight.timer -= dt
+	if fight.timer > 0 {
+		return
+	}
+	fight.timer = artilleryInterval
+
+	for n := 0; n < artilleryVolley; n++ {
+		p := &g.players[n%len(g.players)]
+		aim := p.position
+		if n >= len(g.players) {
+			aim.X += (g.rng.Float32()*2 - 1) * artilleryScatter
+			aim.Z += (g.rng.Float32()*2 - 1) * artilleryScatter
+		}
+		g.fireBossShell(e.position, aim)
+	}
+	g.playSound(g.sounds.shoot)
+}
+
+func (g *Game) fireBossShell(from, target rl.Vector3) {
+	for i := range g.bossShells {
+		if g.bossShells[i].active {
+			continue
+		}
+		target.Y = 0
+		g.bossShells[i] = BossShell{from: from, target: target, timer: artilleryFlightTime, active: true}
+		return
+	}
+}
+
+func (g *Game) updateBossShells(dt float32) {
+	for i := range g.bossShells {
+		s := &g.bossShells[i]
+		if !s.active {
+			continue
+		}
+		s.timer -= dt
+		if s.timer > 0 {
+			continue
+		}
+		s.active = false
+		g.aoeDamage(s.target, artilleryRadius, artilleryDamage)
+	}
+}
+
+// shellPosition is where a shell is along its arc.
+func (s *BossShell) shellPosition() rl.Vector3 {
+	t := 1 - s.timer/artilleryFlightTime
+	pos := rl.Vector3Lerp(s.from, s.target, t)
+	pos.Y += float32(math.Sin(float64(t)*math.Pi)) * 12
+	return pos
+}
+
+func (g *Game) drawBossShells() {
+	for i := range g.bossShells {
+		s := &g.bossShells[i]
+		if !s.active {
+			continue
+		}
+		// Target marker fills in as the shell comes down
+		drawFloorMarker(s.target, artilleryRadius, 1-s.timer/artilleryFlightTime)
+		rl.DrawSphere(s.shellPosition(), 0.4, rl.DarkGray)
+	}
+}
+
+// moveBossBy moves the boss unless it would run into an obstacle or further
+// off the floor, and reports whether it moved. Bosses spawn at the edge, so
+// moving back in is always allowed.
+func (g *Game) moveBossBy(e *Enemy, dx, dz float32) bool {
+	newPos := rl.Vector3{X: e.position.X + dx, Y: e.position.Y, Z: e.position.Z + dz}
+	limit := g.stageHalf - e.size/2
+	outward := func(from, to float32) bool {
+		return math.Abs(float64(to)) > float64(limit) && math.Abs(float64(to)) > math.Abs(float64(from))
+	}
+	if g.CheckObstacleCollision(newPos, e.size/2) || outward(e.position.X, newPos.X) || outward(e.position.Z, newPos.Z) {
+		return false
+	}
+	e.position = newPos
+	return true
+}
+
+// bossTelegraphing reports whether the boss is winding up an attack, so it
+// can be drawn flashing.
+func (g *Game) bossTelegraphing() bool {
+	return g.bossFight.def == nil && g.bossFight.archetype == BossCharger && g.bossFight.state == bossWindup
+}
+
+// bossName is the name of the boss on the field.
+func (g *Game) bossName() string {
+	if g.bossFight.def != nil {
+		return g.bossFight.def.Name
+	}
+	return bossArchetypes[g.bossFight.archetype].name
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Camera framing: follows the players, stays inside the stage, pulls back
+// when many enemies are close and lets the mouse wheel override the
+// distance for a few seconds.
+//
+// Screen shake is a layer on top: impacts add trauma, which decays over
+// time, and the framed camera is offset and rolled by smooth noise scaled
+// by trauma squared, so small hits barely register and big ones jolt. The
+// Screen Shake setting scales it down or off.
+const (
+	cameraBaseDistance  = float32(30.0)
+	cameraMinDistance   = float32(18.0)
+	cameraMaxDistance   = float32(48.0)
+	cameraDensityRadius = float32(25.0) // enemies within this range count toward density
+	cameraPerEnemy      = float32(0.3)  // extra distance per nearby enemy
+	cameraManualHold    = float32(4.0)  // seconds a manual zoom overrides auto framing
+	cameraSmoothing     = float32(3.0)
+	cameraViewMargin    = float32(0.85) // share of the view a player may use before the camera follows
+	defaultStageHalf    = float32(30.0) // the 60x60 floor
+
+	traumaDecay    = float32(1.2) // trauma lost per second
+	shakeMaxOffset = float32(1.2) // world units at full trauma
+	shakeMaxRoll   = float32(0.05)
+	shakeFrequency = float64(18)
+
+	// Trauma per kind of impact
+	blastTrauma     = float32(0.3)
+	bossTrauma      = float32(0.6)
+	hurtTrauma      = float32(0.25)
+	hurtTraumaPerHP = float32(0.01)
+)
+
+var (
+	shakeLevels     = []float32{0, 0.5, 1}
+	shakeLevelNames = []string{"OFF", "LOW", "FULL"}
+)
+
+// addTrauma shakes the camera; trauma adds up to at most 1.
+func (g *Game) addTrauma(amount float32) {
+	g.trauma = min(1, g.trauma+amount)
+}
+
+// shakeNoise is smooth noise in [-1, 1] that differs per seed.
+func shakeNoise(t float64, seed float64) float32 {
+	return float32(math.Sin(t*shakeFrequency+seed)*0.6 + math.Sin(t*shakeFrequency*2.3+seed*1.7)*0.4)
+}
+
+// applyShake offsets the framed camera by the current trauma.
+func (g *Game) applyShake(dt float32) {
+	if g.trauma <= 0 {
+		return
+	}
+	g.trauma = max(0, g.trauma-traumaDecay*dt)
+	amount := g.trauma * g.trauma * shakeLevels[g.settings.shake]
+	if amount <= 0 {
+		return
+	}
+	t := float64(g.gameTime)
+	offset := rl.NewVector3(shakeNoise(t, 0), shakeNoise(t, 10)*0.5, shakeNoise(t, 20))
+	offset = rl.Vector3Scale(offset, shakeMaxOffset*amount)
+	g.camera.Position = rl.Vector3Add(g.camera.Position, offset)
+	g.camera.Target = rl.Vector3Add(g.camera.Target, offset)
+
+	roll := float64(shakeNoise(t, 30) * shakeMaxRoll * amount)
+	g.camera.Up = rl.NewVector3(float32(math.Sin(roll)), float32(math.Cos(roll)), 0)
+}
+
+// autoCameraDistance picks a distance from enemy density and player spread.
+func (g *Game) autoCameraDistance(centerX, centerZ float32) float32 {
+	nearby := 0
+	for i := range g.enemies {
+		if !g.enemies[i].active {
+			continue
+		}
+		dx := g.enemies[i].position.X - centerX
+		dz := g.enemies[i].position.Z - centerZ
+		if dx*dx+dz*dz < cameraDensityRadius*cameraDensityRadius {
+			nearby++
+		}
+	}
+
+	// Keep both co-op players in frame
+	spread := float32(0)
+	for _, player := range g.players {
+		dx := player.position.X - centerX
+		dz := player.position.Z - centerZ
+		spread = float32(math.Max(float64(spread), math.Sqrt(float64(dx*dx+dz*dz))))
+	}
+
+	distance := cameraBaseDistance + float32(nearby)*cameraPerEnemy + spread*0.5
+	return rl.Clamp(distance, cameraMinDistance, cameraMaxDistance)
+}
+
+func (g *Game) updateCamera(dt float32) {
+	var centerX, centerZ float32
+	for _, player := range g.players {
+		centerX += player.position.X
+		centerZ += player.position.Z
+	}
+	centerX /= float32(len(g.players))
+	centerZ /= float32(len(g.players))
+
+	// Manual zoom with the mouse wheel temporarily overrides auto framing
+	if wheel := rl.GetMouseWheelMove(); wheel != 0 {
+		if g.manualZoomTimer <= 0 {
+			g.manualDistance = g.cameraDistance
+		}
+		g.manualDistance = rl.Clamp(g.manualDistance-wheel*2, cameraMinDistance, cameraMaxDistance)
+		g.manualZoomTimer = cameraManualHold
+	}
+
+	target := g.autoCameraDistance(centerX, centerZ)
+	if g.manualZoomTimer > 0 {
+		g.manualZoomTimer -= dt
+		target = g.manualDistance
+	}
+	if g.cameraDistance == 0 {
+		g.cameraDistance = target
+	}
+	g.cameraDistance += (target - g.cameraDistance) * float32(math.Min(1, float64(cameraSmoothing*dt)))
+
+	// Keep the view from drifting past the stage edge: the visible ground
+	// extends roughly half the camera distance around the target. The
+	// players are brought back into view afterwards if this pushed them out.
+	limit := g.stageHalf - g.cameraDistance*0.5
+	if limit < 0 {
+		limit = 0
+	}
+	centerX = rl.Clamp(centerX, -limit, limit)
+	centerZ = rl.Clamp(centerZ, -limit, limit)
+
+	distance := g.cameraDistance
+	centerX, centerZ = g.keepPlayersInView(centerX, centerZ, distance)
+	g.camera.Position = rl.NewVector3(
+		centerX+distance*0.707,
+		distance*0.707,
+		centerZ+distance*0.707,
+	)
+	g.camera.Target = rl.NewVector3(centerX, 0, centerZ)
+	g.camera.Up = rl.NewVector3(0, 1, 0)
+	g.applyShake(dt)
+}
+
+// The camera sits at +X/+Z of its target looking down the diagonal, so the
+// screen's vertical runs along dx+dz over the ground and its horizontal
+// along dx-dz. viewDiagonal returns how far the ground view reaches from
+// the target along dx+dz: near is toward the camera (the bottom of the
+// screen), far is away from it and much longer.
+func (g *Game) viewDiagonal(distance float32) (near, far float32) {
+	k := float64(distance) * 0.707
+	t := math.Sqrt2 * math.Tan(float64(g.camera.Fovy)*math.Pi/360)
+	near = float32(3 * t * k / (1 + t))
+	far = float32(math.Inf(-1))
+	if t < 1 {
+		far = float32(-3 * t * k / (1 - t))
+	}
+	return near, far
+}
+
+// viewAcross is the half width of the ground view in dx-dz at diagonal
+// offset s from the target; the view widens with depth.
+func (g *Game) viewAcross(distance, s float32) float32 {
+	k := float64(distance) * 0.707
+	t := math.Tan(float64(g.camera.Fovy) * math.Pi / 360)
+	aspect := float64(screenWidth) / float64(screenHeight)
+	return float32(math.Sqrt2 * t * aspect * (3*k - float64(s)) / math.Sqrt(3))
+}
+
+// keepPlayersInView moves the camera target the least it takes to bring
+// every player inside the view (with a margin). Players win over the stage
+// edge clamp.
+func (g *Game) keepPlayersInView(centerX, centerZ, distance float32) (float32, float32) {
+	near, far := g.viewDiagonal(distance)
+	near, far = near*cameraViewMargin, far*cameraViewMargin
+	fit := func(v, lo, hi float32) float32 {
+		if lo > hi {
+			return (lo + hi) / 2
+		}
+		return rl.Clamp(v, lo, hi)
+	}
+
+	sum := centerX + centerZ
+	lo, hi := float32(math.Inf(-1)), float32(math.Inf(1))
+	for _, player := range g.players {
+		s := player.position.X + player.position.Z
+		lo, hi = max(lo, s-near), min(hi, s-far)
+	}
+	sum = fit(sum, lo, hi)
+
+	diff := centerX - centerZ
+	lo, hi = float32(math.Inf(-1)), float32(math.Inf(1))
+	for _, player := range g.players {
+		across := g.viewAcross(distance, player.position.X+player.position.Z-sum) * cameraViewMargin
+		d := player.position.X - player.position.Z
+		lo, hi = max(lo, d-across), min(hi, d+across)
+	}
+	diff = fit(diff, lo, hi)
+
+	return (sum + diff) / 2, (sum - diff) / 2
+}
+
+// drawStageLetterbox blacks out everything beyond the playable area.
+func (g *Game) drawStageLetterbox() {
+	half := g.stageHalf
+	band := float32(200.0)
+	void := rl.NewColor(5, 5, 12, 255)
+
+	rl.DrawPlane(rl.NewVector3(0, -0.01, -half-band/2), rl.NewVector2(2*half+2*band, band), void)
+	rl.DrawPlane(rl.NewVector3(0, -0.01, half+band/2), rl.NewVector2(2*half+2*band, band), void)
+	rl.DrawPlane(rl.NewVector3(-half-band/2, -0.01, 0), rl.NewVector2(band, 2*half), void)
+	rl.DrawPlane(rl.NewVector3(half+band/2, -0.01, 0), rl.NewVector2(band, 2*half), void)
+}
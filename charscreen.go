@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Character screen (TAB during a run). Every level gained gives each
+// player a stat point to put into one of the upgrade stats; in co-op each
+// player spends their own. A point is one upgradeStats step, the same as a
+// common upgrade, and counts toward loadout codes. The run waits while the
+// screen is open.
+const statPointsPerLevel = 1
+
+type CharacterView struct {
+	player int // index into players
+	row    int // upgrade kind
+}
+
+var statRowNames = [upgradeKinds]string{"Max Health", "Damage", "Speed", "Fire Rate", "Crit Chance"}
+
+// statRowValues shows a stat's current value and what one point adds.
+func statRowValues(s PlayerStats, kind int) (string, string) {
+	switch kind {
+	case 0:
+		return fmt.Sprintf("%d", s.maxHealth), "+20"
+	case 1:
+		return fmt.Sprintf("%d", s.damage), "+1"
+	case 2:
+		return fmt.Sprintf("%.1f", effectiveSpeed(s.speed)), "+2"
+	case 3:
+		return fmt.Sprintf("%.2fs", s.fireRate), "-0.02s"
+	}
+	return fmt.Sprintf("%.0f%%", s.critChance*100), "+5%"
+}
+
+// awardStatPoints is called for every level gained.
+func (g *Game) awardStatPoints() {
+	for i := range g.players {
+		g.players[i].stats.statPoints += statPointsPerLevel
+	}
+}
+
+// spendStatPoint puts one of the player's points into a stat.
+func (p *Player) spendStatPoint(kind int) bool {
+	if p.stats.statPoints <= 0 {
+		return false
+	}
+	before := p.stats.maxHealth
+	p.stats = upgradeStats(p.stats, kind)
+	p.stats.statPoints--
+	p.upgrades[kind]++
+	// Extra max health comes filled in
+	p.health += p.stats.maxHealth - before
+	return true
+}
+
+func (g *Game) openCharacter() {
+	g.charView.row = 0
+	g.state = StateCharacter
+	g.playUISound(g.sounds.uiSelect)
+}
+
+// UpdateCharacter: up/down pick a stat, ENTER spends a point, 1/2 switch
+// player in co-op, TAB or ESC go back to the run.
+func (g *Game) UpdateCharacter() {
+	v := &g.charView
+	v.player = min(v.player, len(g.players)-1)
+	switch {
+	case rl.IsKeyPressed(rl.KeyUp):
+		v.row = (v.row + upgradeKinds - 1) % upgradeKinds
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyDown):
+		v.row = (v.row + 1) % upgradeKinds
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyOne):
+		v.player = 0
+	case rl.IsKeyPressed(rl.KeyTwo) && len(g.players) > 1:
+		v.player = 1
+	case rl.IsKeyPressed(rl.KeyEnter):
+		if g.players[v.player].stats.statPoints > 0 {
+			g.recordReplayEvent(ReplayEvent{Kind: EventStatPoint, Player: v.player, Value: v.row})
+			g.players[v.player].spendStatPoint(v.row)
+			g.playUISound(g.sounds.uiSelect)
+		}
+	case rl.IsKeyPressed(rl.KeyTab) || rl.IsKeyPressed(rl.KeyEscape):
+		g.state = StatePlaying
+	}
+}
+
+func (g *Game) DrawCharacter() {
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 210))
+	v := &g.charView
+	player := &g.players[min(v.player, len(g.players)-1)]
+	centerX := int32(screenWidth / 2)
+
+	title := "CHARACTER"
+	if len(g.players) > 1 {
+		title = fmt.Sprintf("CHARACTER - P%d", player.id+1)
+	}
+	drawText(title, centerX-measureText(title, 50)/2, 120, 50, player.color)
+	points := fmt.Sprintf("Stat points: %d", player.stats.statPoints)
+	drawText(points, centerX-measureText(points, 28)/2, 190, 28, rl.White)
+
+	for kind := 0; kind < upgradeKinds; kind++ {
+		y := int32(270 + kind*70)
+		value, step := statRowValues(player.stats, kind)
+		color := rl.White
+		if kind == v.row {
+			color = rl.Yellow
+			rl.DrawRectangle(centerX-320, y-12, 640, 54, rl.NewColor(255, 255, 0, 40))
+		}
+		drawText(statRowNames[kind], centerX-300, y, 30, color)
+		drawText(value, centerX+60, y, 30, color)
+		if player.stats.statPoints > 0 {
+			drawText(step, centerX+200, y, 30, rl.Lime)
+		}
+	}
+
+	// Synergies the build has completed
+	if combos := player.activeSynergies(); len(combos) > 0 {
+		y := int32(270 + upgradeKinds*70 + 20)
+		for _, s := range combos {
+			line := s.name + " - " + s.desc
+			drawText(line, centerX-measureText(line, 24)/2, y, 24, rl.Gold)
+			y += 32
+		}
+	}
+
+	hint := "UP/DOWN choose  ENTER spend  TAB back"
+	if len(g.players) > 1 {
+		hint = "UP/DOWN choose  ENTER spend  1/2 player  TAB back"
+	}
+	drawText(hint, centerX-measureText(hint, 20)/2, screenHeight-90, 20, rl.LightGray)
+}
+
+// drawStatPointHint reminds players with unspent points about TAB.
+func (g *Game) drawStatPointHint() {
+	points := 0
+	for _, p := range g.players {
+		points += p.stats.statPoints
+	}
+	if points == 0 {
+		return
+	}
+	text := fmt.Sprintf("TAB: %d stat points to spend", points)
+	drawText(text, screenWidth/2-measureText(text, 20)/2, screenHeight-40, 20, rl.Lime)
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Kill combo. Every kill within comboWindow of the last one extends the
+// streak, and each comboStep kills in a row add one to the score
+// multiplier, up to comboMaxMult. The window restarts with every kill and
+// the streak is lost when it runs out or when any player takes damage.
+// The counter sits at the top of the screen with a bar showing how much
+// of the window is left.
+const (
+	comboWindow  = float32(2.5)
+	comboStep    = 5 // kills per multiplier step
+	comboMaxMult = 5
+	comboBarW    = 200
+	comboY       = 80
+	comboLowY    = bossBarLowY + bossBarHeight + 16 // under the streamer HUD and the lowered boss bar
+)
+
+type Combo struct {
+	kills int
+	timer float32 // window left before the streak drops
+}
+
+// multiplier is the score multiplier for the current streak.
+func (c Combo) multiplier() int {
+	return min(1+c.kills/comboStep, comboMaxMult)
+}
+
+// comboKill extends the streak and returns the multiplier the kill
+// scores at. Called from KillEnemy.
+func (g *Game) comboKill() int {
+	g.combo.kills++
+	g.combo.timer = comboWindow
+	return g.combo.multiplier()
+}
+
+// breakCombo drops the streak. Called when a player is hurt.
+func (g *Game) breakCombo() {
+	g.combo = Combo{}
+}
+
+func (g *Game) updateCombo(dt float32) {
+	if g.combo.kills == 0 {
+		return
+	}
+	if g.combo.timer -= dt; g.combo.timer <= 0 {
+		g.breakCombo()
+	}
+}
+
+// drawCombo shows the streak once it is worth mentioning, pulsing on
+// each kill, with the window left as a bar underneath.
+func (g *Game) drawCombo() {
+	if g.combo.kills < 2 {
+		return
+	}
+	mult := g.combo.multiplier()
+	color := rl.Yellow
+	switch {
+	case mult >= comboMaxMult:
+		color = rl.Magenta
+	case mult > 1:
+		color = rl.Orange
+	}
+	left := g.combo.timer / comboWindow
+	pulse := 1 + 0.25*float32(math.Max(0, float64(left)*4-3)) // swells just after a kill
+	size := int32(36 * pulse)
+
+	text := fmt.Sprintf("%d COMBO  x%d", g.combo.kills, mult)
+	centerX := int32(screenWidth / 2)
+	top := int32(comboY)
+	if g.settings.streamerMode {
+		top = comboLowY
+	}
+	drawText(text, centerX-measureText(text, size)/2, top, size, color)
+
+	y := top + 44
+	rl.DrawRectangle(centerX-comboBarW/2-1, y-1, comboBarW+2, 8, rl.NewColor(0, 0, 0, 180))
+	rl.DrawRectangle(centerX-comboBarW/2, y, int32(comboBarW*left), 6, color)
+}
@@ -0,0 +1,443 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Key bindings per player, stored in save/controls.json together with the
+// hold/toggle mode of each action.
+const controlsFile = saveDir + "/controls.json"
+
+// Binding maps an action to keyboard keys, mouse buttons and buttons on
+// the player's controller.
+type Binding struct {
+	Keys   []int32 `json:"keys,omitempty"`
+	Mouse  []int32 `json:"mouse,omitempty"`
+	Pad    []int32 `json:"pad,omitempty"`
+	Toggle bool    `json:"toggle,omitempty"`
+}
+
+// Controls holds the bindings of both local players.
+type Controls struct {
+	players [2][actionCount]Binding
+}
+
+var actionNames = [actionCount]string{
+	ActionMoveUp:       "moveUp",
+	ActionMoveDown:     "moveDown",
+	ActionMoveLeft:     "moveLeft",
+	ActionMoveRight:    "moveRight",
+	ActionFire:         "fire",
+	ActionAimLock:      "aimLock",
+	ActionShootUp:      "shootUp",
+	ActionShootDown:    "shootDown",
+	ActionShootLeft:    "shootLeft",
+	ActionShootRight:   "shootRight",
+	ActionSkill1:       "skill1",
+	ActionSkill2:       "skill2",
+	ActionSkill3:       "skill3",
+	ActionSprint:       "sprint",
+	ActionSwitchWeapon: "switchWeapon",
+	ActionReload:       "reload",
+	ActionGrenade:      "grenade",
+	ActionDash:         "dash",
+}
+
+var actionLabels = [actionCount]string{
+	ActionMoveUp:       "Move Up",
+	ActionMoveDown:     "Move Down",
+	ActionMoveLeft:     "Move Left",
+	ActionMoveRight:    "Move Right",
+	ActionFire:         "Fire",
+	ActionAimLock:      "Aim-Lock Nearest",
+	ActionShootUp:      "Shoot Up",
+	ActionShootDown:    "Shoot Down",
+	ActionShootLeft:    "Shoot Left",
+	ActionShootRight:   "Shoot Right",
+	ActionSkill1:       "Skill 1",
+	ActionSkill2:       "Skill 2",
+	ActionSkill3:       "Skill 3",
+	ActionSprint:       "Sprint",
+	ActionSwitchWeapon: "Switch Weapon",
+	ActionReload:       "Reload",
+	ActionGrenade:      "Throw Grenade",
+	ActionDash:         "Dash",
+}
+
+// holdableActions can be switched between hold and toggle.
+var holdableActions = map[Action]bool{
+	ActionFire:    true,
+	ActionAimLock: true,
+	ActionSprint:  true,
+}
+
+func keys(k ...int32) []int32 { return k }
+
+// defaultControls reproduces the original hard-coded layout.
+func defaultControls() Controls {
+	var c Controls
+
+	p1 := &c.players[0]
+	p1[ActionMoveUp] = Binding{Keys: keys(rl.KeyW)}
+	p1[ActionMoveDown] = Binding{Keys: keys(rl.KeyS)}
+	p1[ActionMoveLeft] = Binding{Keys: keys(rl.KeyA)}
+	p1[ActionMoveRight] = Binding{Keys: keys(rl.KeyD)}
+	p1[ActionFire] = Binding{Keys: keys(rl.KeySpace), Mouse: keys(int32(rl.MouseLeftButton))}
+	p1[ActionAimLock] = Binding{Mouse: keys(int32(rl.MouseRightButton))}
+	p1[ActionSkill1] = Binding{Keys: keys(rl.KeyQ)}
+	p1[ActionSkill2] = Binding{Keys: keys(rl.KeyE)}
+	p1[ActionSkill3] = Binding{Keys: keys(rl.KeyF)}
+	p1[ActionSprint] = Binding{Keys: keys(rl.KeyLeftShift)}
+	p1[ActionSwitchWeapon] = Binding{Keys: keys(rl.KeyX)}
+	p1[ActionReload] = Binding{Keys: keys(rl.KeyR)}
+	p1[ActionGrenade] = Binding{Keys: keys(rl.KeyG)}
+	p1[ActionDash] = Binding{Keys: keys(rl.KeyLeftControl)}
+
+	p2 := &c.players[1]
+	p2[ActionMoveUp] = Binding{Keys: keys(rl.KeyUp)}
+	p2[ActionMoveDown] = Binding{Keys: keys(rl.KeyDown)}
+	p2[ActionMoveLeft] = Binding{Keys: keys(rl.KeyLeft)}
+	p2[ActionMoveRight] = Binding{Keys: keys(rl.KeyRight)}
+	// NumPad 0 fires, NumPad Enter aims at the nearest enemy
+	p2[ActionFire] = Binding{Keys: keys(rl.KeyKp0)}
+	p2[ActionAimLock] = Binding{Keys: keys(rl.KeyKpEnter)}
+	p2[ActionShootUp] = Binding{Keys: keys(rl.KeyKp8)}
+	p2[ActionShootDown] = Binding{Keys: keys(rl.KeyKp2)}
+	p2[ActionShootLeft] = Binding{Keys: keys(rl.KeyKp4)}
+	p2[ActionShootRight] = Binding{Keys: keys(rl.KeyKp6)}
+	// NumPad 2 shoots down, so the skills sit on the keys around it
+	p2[ActionSkill1] = Binding{Keys: keys(rl.KeyKp1, rl.KeyOne)}
+	p2[ActionSkill2] = Binding{Keys: keys(rl.KeyKp3, rl.KeyTwo)}
+	p2[ActionSkill3] = Binding{Keys: keys(rl.KeyKpDecimal, rl.KeyThree)}
+	p2[ActionSprint] = Binding{Keys: keys(rl.KeyRightShift)}
+	p2[ActionSwitchWeapon] = Binding{Keys: keys(rl.KeyKp5)}
+	p2[ActionReload] = Binding{Keys: keys(rl.KeyKp7)}
+	p2[ActionGrenade] = Binding{Keys: keys(rl.KeyKp9)}
+	p2[ActionDash] = Binding{Keys: keys(rl.KeyRightControl)}
+
+	// Both players use the same controller layout on their own pad
+	// (movement also follows the left stick, the right stick aims)
+	for p := range c.players {
+		pad := &c.players[p]
+		pad[ActionMoveUp].Pad = keys(rl.GamepadButtonLeftFaceUp)
+		pad[ActionMoveDown].Pad = keys(rl.GamepadButtonLeftFaceDown)
+		pad[ActionMoveLeft].Pad = keys(rl.GamepadButtonLeftFaceLeft)
+		pad[ActionMoveRight].Pad = keys(rl.GamepadButtonLeftFaceRight)
+		pad[ActionFire].Pad = keys(rl.GamepadButtonRightTrigger2)
+		pad[ActionAimLock].Pad = keys(rl.GamepadButtonLeftTrigger2)
+		pad[ActionSkill1].Pad = keys(rl.GamepadButtonRightFaceDown)
+		pad[ActionSkill2].Pad = keys(rl.GamepadButtonRightFaceRight)
+		pad[ActionSkill3].Pad = keys(rl.GamepadButtonRightFaceUp)
+		pad[ActionReload].Pad = keys(rl.GamepadButtonRightFaceLeft)
+		pad[ActionSprint].Pad = keys(rl.GamepadButtonLeftTrigger1)
+		pad[ActionSwitchWeapon].Pad = keys(rl.GamepadButtonRightTrigger1)
+		pad[ActionGrenade].Pad = keys(rl.GamepadButtonRightThumb)
+		pad[ActionDash].Pad = keys(rl.GamepadButtonLeftThumb)
+	}
+
+	return c
+}
+
+func (c *Controls) binding(playerID int, action Action) Binding {
+	if playerID < 0 || playerID >= len(c.players) {
+		return Binding{}
+	}
+	return c.players[playerID][action]
+}
+
+func (g *Game) loadControls() {
+	g.controls = defaultControls()
+
+	data, err := os.ReadFile(controlsFile)
+	if err != nil {
+		return
+	}
+
+	var saved []map[string]Binding
+	if err := json.Unmarshal(data, &saved); err != nil {
+		fmt.Println("Warning: Could not read controls:", err)
+		return
+	}
+	for pIdx := range saved {
+		if pIdx >= len(g.controls.players) {
+			break
+		}
+		for a := Action(0); a < actionCount; a++ {
+			if b, ok := saved[pIdx][actionNames[a]]; ok {
+				// Files from before controller support keep the default pad layout
+				if b.Pad == nil {
+					b.Pad = g.controls.players[pIdx][a].Pad
+				}
+				g.controls.players[pIdx][a] = b
+			}
+		}
+	}
+}
+
+func (g *Game) saveControls() {
+	saved := make([]map[string]Binding, len(g.controls.players))
+	for pIdx := range g.controls.players {
+		saved[pIdx] = map[string]Binding{}
+		for a := Action(0); a < actionCount; a++ {
+			saved[pIdx][actionNames[a]] = g.controls.players[pIdx][a]
+		}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		fmt.Println("Warning: Could not encode controls:", err)
+		return
+	}
+	os.MkdirAll(saveDir, os.ModePerm)
+	if err := writeFileAtomic(controlsFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save controls:", err)
+	}
+}
+
+// keyName returns a short display name for a raylib key code, as printed
+// on the key in the active keyboard layout.
+func keyName(key int32) string {
+	if legend, ok := layoutLegends[activeLayout][key]; ok {
+		return legend
+	}
+
+	switch {
+	case key >= rl.KeyA && key <= rl.KeyZ:
+		return string(rune('A' + key - rl.KeyA))
+	case key >= rl.KeyZero && key <= rl.KeyNine:
+		return string(rune('0' + key - rl.KeyZero))
+	case key >= rl.KeyKp0 && key <= rl.KeyKp9:
+		return fmt.Sprintf("Num%d", key-rl.KeyKp0)
+	case key >= rl.KeyF1 && key <= rl.KeyF12:
+		return fmt.Sprintf("F%d", key-rl.KeyF1+1)
+	}
+
+	switch key {
+	case rl.KeySpace:
+		return "Space"
+	case rl.KeyEnter:
+		return "Enter"
+	case rl.KeyTab:
+		return "Tab"
+	case rl.KeyBackspace:
+		return "Backspace"
+	case rl.KeyUp:
+		return "Up"
+	case rl.KeyDown:
+		return "Down"
+	case rl.KeyLeft:
+		return "Left"
+	case rl.KeyRight:
+		return "Right"
+	case rl.KeyLeftShift, rl.KeyRightShift:
+		return "Shift"
+	case rl.KeyLeftControl, rl.KeyRightControl:
+		return "Ctrl"
+	case rl.KeyLeftAlt, rl.KeyRightAlt:
+		return "Alt"
+	case rl.KeyComma:
+		return ","
+	case rl.KeyPeriod:
+		return "."
+	case rl.KeySlash:
+		return "/"
+	case rl.KeySemicolon:
+		return ";"
+	case rl.KeyApostrophe:
+		return "'"
+	case rl.KeyLeftBracket:
+		return "["
+	case rl.KeyRightBracket:
+		return "]"
+	case rl.KeyMinus:
+		return "-"
+	case rl.KeyEqual:
+		return "="
+	case rl.KeyKpEnter:
+		return "NumEnter"
+	case rl.KeyKpAdd:
+		return "Num+"
+	case rl.KeyKpSubtract:
+		return "Num-"
+	case rl.KeyKpDecimal:
+		return "Num."
+	}
+	return fmt.Sprintf("Key%d", key)
+}
+
+func mouseName(button int32) string {
+	switch rl.MouseButton(button) {
+	case rl.MouseLeftButton:
+		return "LMB"
+	case rl.MouseRightButton:
+		return "RMB"
+	case rl.MouseMiddleButton:
+		return "MMB"
+	}
+	return fmt.Sprintf("Mouse%d", button)
+}
+
+// bindingLabel lists every input bound to an action, e.g. "Space/LMB".
+func bindingLabel(b Binding) string {
+	var names []string
+	for _, key := range b.Keys {
+		names = append(names, keyName(key))
+	}
+	for _, button := range b.Mouse {
+		names = append(names, mouseName(button))
+	}
+	for _, button := range b.Pad {
+		names = append(names, padButtonName(button))
+	}
+	if len(names) == 0 {
+		return "-"
+	}
+	return strings.Join(names, "/")
+}
+
+// --- Controls screen ---
+
+// UpdateControls handles the bindings screen: UP/DOWN select, LEFT/RIGHT
+// switch hold/toggle, ENTER rebinds, TAB switches player. The row after
+// the actions picks a preset with LEFT/RIGHT and applies it with ENTER.
+func (g *Game) UpdateControls(dt float32) {
+	rows := int(actionCount) + 2 // + Preset, Back
+	presetRow := int(actionCount)
+
+	if g.rebinding {
+		if rl.IsKeyPressed(rl.KeyEscape) {
+			g.rebinding = false
+			return
+		}
+		action := Action(g.controlsSelection)
+		b := &g.controls.players[g.controlsPlayer][action]
+		if key := rl.GetKeyPressed(); key != 0 {
+			b.Keys = keys(key)
+			b.Mouse = nil
+			g.rebinding = false
+			g.saveControls()
+			return
+		}
+		for _, button := range []rl.MouseButton{rl.MouseLeftButton, rl.MouseRightButton, rl.MouseMiddleButton} {
+			if rl.IsMouseButtonPressed(button) {
+				b.Keys = nil
+				b.Mouse = keys(int32(button))
+				g.rebinding = false
+				g.saveControls()
+				return
+			}
+		}
+		// A controller button only replaces the pad binding, and only the
+		// player's own controller counts
+		if button := g.padButtonPressed(g.controlsPlayer); button >= 0 {
+			b.Pad = keys(button)
+			g.rebinding = false
+			g.saveControls()
+		}
+		return
+	}
+
+	if rl.IsKeyPressed(rl.KeyUp) {
+		g.controlsSelection--
+		if g.controlsSelection < 0 {
+			g.controlsSelection = rows - 1
+		}
+	}
+	if rl.IsKeyPressed(rl.KeyDown) {
+		g.controlsSelection++
+		if g.controlsSelection >= rows {
+			g.controlsSelection = 0
+		}
+	}
+	if rl.IsKeyPressed(rl.KeyTab) {
+		g.controlsPlayer = 1 - g.controlsPlayer
+	}
+
+	back := g.controlsSelection == rows-1
+	if g.controlsSelection == presetRow {
+		if rl.IsKeyPressed(rl.KeyLeft) {
+			g.controlsPreset = (g.controlsPreset + len(controlPresets) - 1) % len(controlPresets)
+		}
+		if rl.IsKeyPressed(rl.KeyRight) {
+			g.controlsPreset = (g.controlsPreset + 1) % len(controlPresets)
+		}
+		if rl.IsKeyPressed(rl.KeyEnter) {
+			g.applyPreset(g.controlsPlayer, g.controlsPreset)
+		}
+	} else if !back {
+		action := Action(g.controlsSelection)
+		if holdableActions[action] && (rl.IsKeyPressed(rl.KeyLeft) || rl.IsKeyPressed(rl.KeyRight)) {
+			b := &g.controls.players[g.controlsPlayer][action]
+			b.Toggle = !b.Toggle
+			g.saveControls()
+		}
+		if rl.IsKeyPressed(rl.KeyEnter) {
+			g.rebinding = true
+		}
+	}
+
+	if rl.IsKeyPressed(rl.KeyDelete) {
+		g.controls.players[g.controlsPlayer] = defaultControls().players[g.controlsPlayer]
+		g.saveControls()
+	}
+
+	if rl.IsKeyPressed(rl.KeyEscape) || (back && rl.IsKeyPressed(rl.KeyEnter)) {
+		g.state = StateSettings
+	}
+}
+
+func (g *Game) DrawControls() {
+	rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+
+	centerX := int32(screenWidth / 2)
+
+	drawText(fmt.Sprintf("CONTROLS - PLAYER %d", g.controlsPlayer+1), centerX-260, 50, 45, rl.Gold)
+
+	startY := int32(130)
+	for a := Action(0); a <= actionCount+1; a++ {
+		y := startY + int32(a)*40
+		color := rl.White
+
+		if int(a) == g.controlsSelection {
+			color = rl.Yellow
+			rl.DrawRectangle(centerX-420, y-5, 840, 36, rl.NewColor(255, 255, 0, 50))
+			drawText(">", centerX-460, y, 26, rl.Yellow)
+		}
+
+		if a == actionCount {
+			drawText("Preset", centerX-400, y, 26, color)
+			drawText(fmt.Sprintf("< %s >", controlPresets[g.controlsPreset].name), centerX, y, 26, rl.SkyBlue)
+			continue
+		}
+		if a == actionCount+1 {
+			drawText("Back", centerX-400, y, 26, color)
+			continue
+		}
+
+		b := g.controls.players[g.controlsPlayer][a]
+		drawText(actionLabels[a], centerX-400, y, 26, color)
+
+		label := bindingLabel(b)
+		if g.rebinding && int(a) == g.controlsSelection {
+			label = "press a key..."
+		}
+		drawText(label, centerX, y, 26, rl.Lime)
+
+		if holdableActions[a] {
+			mode := "HOLD"
+			if b.Toggle {
+				mode = "TOGGLE"
+			}
+			drawText(mode, centerX+280, y, 26, rl.SkyBlue)
+		}
+	}
+
+	drawText("UP/DOWN: select | ENTER: rebind/apply preset | LEFT/RIGHT: hold/toggle | TAB: switch player | DEL: reset",
+		centerX-540, screenHeight-80, 20, rl.LightGray)
+	drawText("Press ESC or select Back to return", centerX-200, screenHeight-50, 20, rl.LightGray)
+}
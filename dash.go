@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Dash. A short burst of speed in the move direction (or where the player
+// is aiming when standing still) with a few invulnerable frames. While the
+// dash runs it overrides normal movement; the path is walked in small
+// steps so the player stops at the first obstacle instead of being pushed
+// through it or not moving at all.
+const (
+	dashSpeed    = float32(45.0) // units per second
+	dashTime     = float32(0.15)
+	dashIFrames  = float32(0.25)
+	dashCooldown = float32(1.0)
+	dashSubsteps = 4
+	dashRadius   = float32(0.9) // same as the player's collision radius
+)
+
+// Dash is a player's dash state.
+type Dash struct {
+	timer    float32 // time left in the current dash
+	cooldown float32
+	iframes  float32 // time left untouchable
+	dirX     float32
+	dirZ     float32
+}
+
+func (p *Player) dashing() bool { return p.dash.timer > 0 }
+
+// startDash begins a dash if it is off cooldown.
+func (g *Game) startDash(player *Player) {
+	if player.dash.cooldown > 0 || player.dashing() {
+		return
+	}
+	dirX, dirZ := float32(0), float32(0)
+	if g.actionDown(player, ActionMoveUp) {
+		dirZ--
+	}
+	if g.actionDown(player, ActionMoveDown) {
+		dirZ++
+	}
+	if g.actionDown(player, ActionMoveLeft) {
+		dirX--
+	}
+	if g.actionDown(player, ActionMoveRight) {
+		dirX++
+	}
+	if dirX == 0 && dirZ == 0 {
+		dirX, dirZ = float32(math.Cos(float64(player.angle))), float32(math.Sin(float64(player.angle)))
+	}
+	l := float32(math.Sqrt(float64(dirX*dirX + dirZ*dirZ)))
+	player.dash = Dash{
+		timer:    dashTime,
+		cooldown: dashCooldown,
+		iframes:  dashIFrames,
+		dirX:     dirX / l,
+		dirZ:     dirZ / l,
+	}
+	g.CreateExplosion(player.position, player.color, 6)
+	g.playSound(g.sounds.skill)
+}
+
+// dashStep returns where the dash takes the player this frame, stopping
+// short of obstacles and structures.
+func (g *Game) dashStep(player *Player, dt float32) rl.Vector3 {
+	step := dashSpeed * min(dt, player.dash.timer) / dashSubsteps
+	pos := player.position
+	for i := 0; i < dashSubsteps; i++ {
+		next := rl.NewVector3(pos.X+player.dash.dirX*step, pos.Y, pos.Z+player.dash.dirZ*step)
+		if g.CheckObstacleCollision(next, dashRadius) || g.structureAt(next, dashRadius) >= 0 {
+			player.dash.timer = 0
+			return pos
+		}
+		pos = next
+	}
+	player.dash.timer -= dt
+	return pos
+}
+
+// updateDash counts down the cooldown and invulnerability.
+func (g *Game) updateDash(player *Player, dt float32) {
+	if player.dash.cooldown > 0 {
+		player.dash.cooldown -= dt
+	}
+	if player.dash.iframes > 0 {
+		player.dash.iframes -= dt
+	}
+}
+
+// drawDashGhost leaves a fading afterimage behind a dashing player.
+func (g *Game) drawDashGhost(player Player) {
+	if !player.dashing() {
+		return
+	}
+	for i := 1; i <= 3; i++ {
+		back := float32(i) * 0.6
+		pos := rl.NewVector3(player.position.X-player.dash.dirX*back, player.position.Y, player.position.Z-player.dash.dirZ*back)
+		rl.DrawSphere(pos, 0.7, rl.Fade(player.color, 0.4/float32(i)))
+	}
+}
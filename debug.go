@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Debug overlay (F3): frame timing, asset memory usage and mixer ducking.
+// F4 toggles the performance overlay (perf.go). With -debug the overlay
+// also opens the entity inspector (inspector.go).
+
+func (g *Game) updateDebug(dt float32) {
+	g.perf.record(dt)
+	if rl.IsKeyPressed(rl.KeyF3) {
+		g.showDebug = !g.showDebug
+	}
+	if rl.IsKeyPressed(rl.KeyF4) {
+		g.showPerf = !g.showPerf
+	}
+	g.updateInspector()
+	g.updateHeatmapView()
+}
+
+func (g *Game) drawDebugOverlay() {
+	if !g.showDebug {
+		return
+	}
+
+	x, y := int32(10), int32(screenHeight-300)
+	rl.DrawRectangle(x-5, y-5, 360, 290, rl.NewColor(0, 0, 0, 180))
+
+	line := func(text string, color rl.Color) {
+		drawText(text, x, y, 18, color)
+		y += 22
+	}
+
+	line(fmt.Sprintf("FPS %d  (%.2f ms)", rl.GetFPS(), rl.GetFrameTime()*1000), rl.Green)
+	l := g.latency
+	line(fmt.Sprintf("Input->present %.1f ms (max %.1f)", l.avgMs, l.maxMs), rl.SkyBlue)
+	line(fmt.Sprintf("Pacing %s  wait %.1f ms", pacingNames[g.settings.pacing], l.waited*1000), rl.LightGray)
+
+	a := g.assets
+	usedColor := rl.White
+	if a.total() > a.budget {
+		usedColor = rl.Red
+	}
+	line(fmt.Sprintf("Assets %s / %s", formatMB(a.total()), formatMB(a.budget)), usedColor)
+	for kind := AssetKind(0); kind < assetKindCount; kind++ {
+		line(fmt.Sprintf("  %-8s %s", assetKindNames[kind], formatMB(a.used[kind])), rl.LightGray)
+	}
+	line(fmt.Sprintf("Resident %d  (unused %d)", len(a.entries), a.unused()), rl.LightGray)
+	line(fmt.Sprintf("Evicted %d", a.evictions), rl.LightGray)
+	line(fmt.Sprintf("Stage chunks %d / %d", g.chunks.loaded, len(g.chunks.chunks)), rl.LightGray)
+
+	mix := "Duck"
+	for b := Bus(0); b < busCount; b++ {
+		mix += fmt.Sprintf(" %s %.0f%%", busNames[b], g.mixer.gain[b]*100)
+	}
+	line(mix, rl.LightGray)
+}
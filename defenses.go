@@ -0,0 +1,471 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Player-built defenses. Every upgrade break adds build points, and B on the
+// upgrade screen opens build mode: the cursor snaps to a grid, right mouse
+// (or TAB) opens a radial menu to pick a structure and left click or ENTER
+// places it. A spot is refused if it overlaps anything or would wall a
+// player off from the stage edge, where enemies come from. Barricades soak
+// up enemies and their shots; turrets shoot the nearest enemy in sight.
+// Structures are cleared when the stage changes.
+const (
+	maxStructures       = 12
+	buildGrid           = float32(2.0)
+	buildPointsPerBreak = 3
+	structureContactDPS = float32(12.0) // damage per second from each enemy touching it
+	turretRange         = float32(14.0)
+	turretInterval      = float32(0.5)
+	turretTracerTime    = float32(0.08)
+	radialMenuRadius    = float32(90.0)
+)
+
+type StructureKind int
+
+const (
+	StructureBarricade StructureKind = iota
+	StructureTurret
+	structureKindCount
+)
+
+type structureDef struct {
+	name   string
+	cost   int
+	health int
+	height float32
+	color  rl.Color
+}
+
+var structureDefs = [structureKindCount]structureDef{
+	StructureBarricade: {"Barricade", 1, 60, 1.4, rl.NewColor(150, 110, 60, 255)},
+	StructureTurret:    {"Turret", 2, 30, 1.0, rl.NewColor(80, 140, 200, 255)},
+}
+
+type Structure struct {
+	kind     StructureKind
+	position rl.Vector3
+	health   float32
+	timer    float32
+	aim      float32
+	tracer   rl.Vector3 // end of the last shot
+	flash    float32
+	active   bool
+}
+
+// BuildMode is the state of the build screen.
+type BuildMode struct {
+	points   int
+	cursor   rl.Vector3
+	selected StructureKind
+	radial   bool
+	hovered  StructureKind
+	reason   string // why the cursor spot can't be built on, "" if it can
+}
+
+// startUpgradeBreak pauses for an upgrade pick and hands out build and
+// skill points.
+func (g *Game) startUpgradeBreak() {
+	g.state = StateUpgrade
+	g.drawUpgradeOffer()
+	g.build.points += buildPointsPerBreak
+	g.awardSkillPoints()
+}
+
+func (g *Game) clearStructures() {
+	for i := range g.structures {
+		g.structures[i].active = false
+	}
+}
+
+// snapToBuildGrid puts pos in the middle of its grid cell.
+func snapToBuildGrid(pos rl.Vector3) rl.Vector3 {
+	snap := func(v float32) float32 {
+		return float32(math.Floor(float64(v/buildGrid)))*buildGrid + buildGrid/2
+	}
+	return rl.NewVector3(snap(pos.X), 0, snap(pos.Z))
+}
+
+// structureAt returns the structure overlapping a circle at pos, or -1.
+func (g *Game) structureAt(pos rl.Vector3, radius float32) int {
+	half := buildGrid / 2
+	for i := range g.structures {
+		s := &g.structures[i]
+		if !s.active {
+			continue
+		}
+		if pos.X+radius > s.position.X-half && pos.X-radius < s.position.X+half &&
+			pos.Z+radius > s.position.Z-half && pos.Z-radius < s.position.Z+half {
+			return i
+		}
+	}
+	return -1
+}
+
+// mouseFloorPoint is where the mouse ray meets the floor.
+func (g *Game) mouseFloorPoint() (rl.Vector3, bool) {
+	ray := rl.GetScreenToWorldRay(rl.GetMousePosition(), g.camera)
+	if ray.Direction.Y >= -0.001 {
+		return rl.Vector3{}, false
+	}
+	t := -ray.Position.Y / ray.Direction.Y
+	return rl.NewVector3(ray.Position.X+ray.Direction.X*t, 0, ray.Position.Z+ray.Direction.Z*t), true
+}
+
+// buildBlocked explains why a structure can't go at pos, or returns "".
+func (g *Game) buildBlocked(pos rl.Vector3, kind StructureKind) string {
+	half := buildGrid / 2
+	switch {
+	case g.build.points < structureDefs[kind].cost:
+		return "Not enough build points"
+	case g.freeStructureSlot() < 0:
+		return "Structure limit reached"
+	case float32(math.Abs(float64(pos.X)))+half > g.stageHalf || float32(math.Abs(float64(pos.Z)))+half > g.stageHalf:
+		return "Outside the stage"
+	case g.CheckObstacleCollision(pos, half*0.9) || g.structureAt(pos, half*0.9) >= 0 || g.nestAt(pos, half) >= 0:
+		return "Blocked"
+	}
+	for i := range g.players {
+		dx := g.players[i].position.X - pos.X
+		dz := g.players[i].position.Z - pos.Z
+		if dx*dx+dz*dz < buildGrid*buildGrid {
+			return "Too close to a player"
+		}
+	}
+	for i := range g.enemies {
+		e := &g.enemies[i]
+		if e.active && float32(math.Abs(float64(e.position.X-pos.X))) < half+e.size/2 &&
+			float32(math.Abs(float64(e.position.Z-pos.Z))) < half+e.size/2 {
+			return "An enemy is in the way"
+		}
+	}
+	if !g.playersReachEdge(pos) {
+		return "Would wall a player in"
+	}
+	return ""
+}
+
+// playersReachEdge flood-fills the build grid with an extra structure at
+// blocked and reports whether every player can still walk to the edge of
+// the stage.
+func (g *Game) playersReachEdge(blocked rl.Vector3) bool {
+	cells := int(g.stageHalf * 2 / buildGrid)
+	cellOf := func(v float32) int {
+		return min(max(int((v+g.stageHalf)/buildGrid), 0), cells-1)
+	}
+	center := func(c int) float32 {
+		return -g.stageHalf + float32(c)*buildGrid + buildGrid/2
+	}
+	blockX, blockZ := cellOf(blocked.X), cellOf(blocked.Z)
+	walkable := func(x, z int) bool {
+		if x == blockX && z == blockZ {
+			return false
+		}
+		pos := rl.NewVector3(center(x), 0, center(z))
+		return !g.CheckObstacleCollision(pos, buildGrid*0.3) && g.structureAt(pos, buildGrid*0.3) < 0
+	}
+
+	for i := range g.players {
+		seen := make([]bool, cells*cells)
+		start := [2]int{cellOf(g.players[i].position.X), cellOf(g.players[i].position.Z)}
+		queue := [][2]int{start}
+		seen[start[0]*cells+start[1]] = true
+		reached := false
+		for len(queue) > 0 && !reached {
+			c := queue[0]
+			queue = queue[1:]
+			if c[0] == 0 || c[1] == 0 || c[0] == cells-1 || c[1] == cells-1 {
+				reached = true
+				break
+			}
+			for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+				n := [2]int{c[0] + d[0], c[1] + d[1]}
+				if seen[n[0]*cells+n[1]] || !walkable(n[0], n[1]) {
+					continue
+				}
+				seen[n[0]*cells+n[1]] = true
+				queue = append(queue, n)
+			}
+		}
+		if !reached {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Game) freeStructureSlot() int {
+	for i := range g.structures {
+		if !g.structures[i].active {
+			return i
+		}
+	}
+	return -1
+}
+
+// openBuildMode switches from the upgrade screen to build mode with the
+// cursor next to player 1.
+func (g *Game) openBuildMode() {
+	g.build.cursor = snapToBuildGrid(rl.NewVector3(g.players[0].position.X+buildGrid, 0, g.players[0].position.Z))
+	g.build.radial = false
+	g.state = StateBuild
+}
+
+func (g *Game) UpdateBuild() {
+	b := &g.build
+
+	// Mouse moves the cursor, arrows step it a cell at a time
+	if !b.radial {
+		if delta := rl.GetMouseDelta(); delta.X != 0 || delta.Y != 0 {
+			if pos, ok := g.mouseFloorPoint(); ok {
+				b.cursor = snapToBuildGrid(pos)
+			}
+		}
+		if rl.IsKeyPressed(rl.KeyLeft) {
+			b.cursor.X -= buildGrid
+		}
+		if rl.IsKeyPressed(rl.KeyRight) {
+			b.cursor.X += buildGrid
+		}
+		if rl.IsKeyPressed(rl.KeyUp) {
+			b.cursor.Z -= buildGrid
+		}
+		if rl.IsKeyPressed(rl.KeyDown) {
+			b.cursor.Z += buildGrid
+		}
+	}
+
+	// Radial menu: hold to open, point at an option, let go to pick it
+	if rl.IsMouseButtonPressed(rl.MouseButtonRight) || rl.IsKeyPressed(rl.KeyTab) {
+		b.radial = true
+		b.hovered = b.selected
+	}
+	if b.radial {
+		center := rl.GetWorldToScreen(b.cursor, g.camera)
+		d := rl.Vector2Subtract(rl.GetMousePosition(), center)
+		if d.X*d.X+d.Y*d.Y > 20*20 {
+			b.hovered = radialOptionAt(float32(math.Atan2(float64(d.Y), float64(d.X))))
+		}
+		if rl.IsKeyPressed(rl.KeyLeft) || rl.IsKeyPressed(rl.KeyRight) {
+			b.hovered = (b.hovered + 1) % structureKindCount
+		}
+		if rl.IsMouseButtonReleased(rl.MouseButtonRight) || rl.IsKeyReleased(rl.KeyTab) {
+			b.selected = b.hovered
+			b.radial = false
+			g.playUISound(g.sounds.uiMove)
+		}
+		return
+	}
+
+	b.reason = g.buildBlocked(b.cursor, b.selected)
+	if rl.IsMouseButtonPressed(rl.MouseButtonLeft) || rl.IsKeyPressed(rl.KeyEnter) {
+		if b.reason != "" {
+			g.playUISound(g.sounds.uiMove)
+		} else {
+			g.recordReplayEvent(ReplayEvent{Kind: EventStructure, Value: int(b.selected), X: b.cursor.X, Z: b.cursor.Z})
+			g.placeStructure(b.selected, b.cursor)
+			g.playUISound(g.sounds.uiSelect)
+		}
+	}
+
+	if rl.IsKeyPressed(rl.KeyB) || rl.IsKeyPressed(rl.KeyEscape) {
+		g.state = StateUpgrade
+	}
+}
+
+// radialOptionAt maps a screen angle to the radial menu option under it.
+// Options are spread evenly starting at the top.
+func radialOptionAt(angle float32) StructureKind {
+	step := 2 * math.Pi / float64(structureKindCount)
+	a := math.Mod(float64(angle)+math.Pi/2+step/2+4*math.Pi, 2*math.Pi)
+	return StructureKind(int(a/step) % int(structureKindCount))
+}
+
+func (g *Game) placeStructure(kind StructureKind, pos rl.Vector3) {
+	slot := g.freeStructureSlot()
+	if slot < 0 {
+		return
+	}
+	def := structureDefs[kind]
+	g.structures[slot] = Structure{kind: kind, position: pos, health: float32(def.health), active: true}
+	g.build.points -= def.cost
+	g.CreateExplosion(rl.NewVector3(pos.X, 0.5, pos.Z), def.color, 8)
+}
+
+// updateStructures lets enemies wear structures down and fires turrets.
+func (g *Game) updateStructures(dt float32) {
+	half := buildGrid / 2
+	for i := range g.structures {
+		s := &g.structures[i]
+		if !s.active {
+			continue
+		}
+
+		for j := range g.enemies {
+			e := &g.enemies[j]
+			if !e.active {
+				continue
+			}
+			reach := half + e.size/2 + 0.3
+			if float32(math.Abs(float64(e.position.X-s.position.X))) < reach &&
+				float32(math.Abs(float64(e.position.Z-s.position.Z))) < reach {
+				s.health -= structureContactDPS * dt
+			}
+		}
+		if s.health <= 0 {
+			g.destroyStructure(i)
+			continue
+		}
+
+		if s.kind == StructureTurret {
+			g.updateTurret(s, dt)
+		}
+	}
+}
+
+// turretDamage keeps turrets useful as enemy health grows.
+func turretDamage(level int) int { return 1 + level/5 }
+
+func (g *Game) updateTurret(s *Structure, dt float32) {
+	s.flash -= dt
+	s.timer -= dt
+	if s.timer > 0 {
+		return
+	}
+
+	muzzle := rl.NewVector3(s.position.X, 1.2, s.position.Z)
+	target, bestDist := -1, turretRange*turretRange
+	for i := range g.enemies {
+		e := &g.enemies[i]
+		if !e.active {
+			continue
+		}
+		dx := e.position.X - muzzle.X
+		dz := e.position.Z - muzzle.Z
+		if d := dx*dx + dz*dz; d < bestDist && g.clearShot(muzzle, e.position) {
+			target, bestDist = i, d
+		}
+	}
+	if target < 0 {
+		return
+	}
+
+	s.timer = turretInterval
+	e := &g.enemies[target]
+	s.aim = float32(math.Atan2(float64(e.position.Z-muzzle.Z), float64(e.position.X-muzzle.X)))
+	s.tracer = e.position
+	s.flash = turretTracerTime
+	g.playSound(g.sounds.shoot)
+	g.damageEnemy(target, turretDamage(g.level), -1)
+}
+
+// clearShot reports whether nothing solid lies between from and to.
+func (g *Game) clearShot(from, to rl.Vector3) bool {
+	dx, dz := to.X-from.X, to.Z-from.Z
+	dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
+	for d := float32(1.0); d < dist-0.5; d += 0.5 {
+		p := rl.NewVector3(from.X+dx/dist*d, from.Y, from.Z+dz/dist*d)
+		if g.CheckObstacleCollision(p, 0.1) {
+			return false
+		}
+	}
+	return true
+}
+
+// damageStructure is for enemy shots that hit a structure.
+func (g *Game) damageStructure(index int, damage int) {
+	s := &g.structures[index]
+	s.health -= float32(damage)
+	if s.health <= 0 {
+		g.destroyStructure(index)
+	}
+}
+
+func (g *Game) destroyStructure(index int) {
+	s := &g.structures[index]
+	s.active = false
+	g.CreateExplosion(rl.NewVector3(s.position.X, 0.5, s.position.Z), structureDefs[s.kind].color, 20)
+	g.playExplosion()
+}
+
+func (g *Game) drawStructures() {
+	size := buildGrid * 0.9
+	for i := range g.structures {
+		s := &g.structures[i]
+		if !s.active {
+			continue
+		}
+		def := structureDefs[s.kind]
+		// Darken as it takes damage
+		color := rl.ColorBrightness(def.color, -0.5*(1-s.health/float32(def.health)))
+
+		switch s.kind {
+		case StructureBarricade:
+			pos := rl.NewVector3(s.position.X, def.height/2, s.position.Z)
+			rl.DrawCube(pos, size, def.height, size, color)
+			rl.DrawCubeWires(pos, size, def.height, size, rl.Brown)
+		case StructureTurret:
+			rl.DrawCylinder(s.position, size*0.4, size*0.45, def.height, 12, color)
+			head := rl.NewVector3(s.position.X, def.height+0.2, s.position.Z)
+			rl.DrawSphere(head, 0.4, rl.LightGray)
+			barrel := rl.NewVector3(head.X+float32(math.Cos(float64(s.aim)))*0.9, head.Y, head.Z+float32(math.Sin(float64(s.aim)))*0.9)
+			rl.DrawLine3D(head, barrel, rl.White)
+			if s.flash > 0 {
+				rl.DrawLine3D(barrel, s.tracer, rl.Yellow)
+			}
+		}
+	}
+
+	if g.state != StateBuild {
+		return
+	}
+	// Ghost of the selected structure at the cursor
+	b := &g.build
+	def := structureDefs[b.selected]
+	ghost := rl.Fade(rl.Green, 0.4)
+	if b.reason != "" {
+		ghost = rl.Fade(rl.Red, 0.4)
+	}
+	pos := rl.NewVector3(b.cursor.X, def.height/2, b.cursor.Z)
+	rl.DrawCube(pos, size, def.height, size, ghost)
+	rl.DrawCubeWires(pos, buildGrid, def.height, buildGrid, rl.White)
+}
+
+// DrawBuild draws the build mode overlay and the radial menu.
+func (g *Game) DrawBuild() {
+	b := &g.build
+	centerX := int32(screenWidth / 2)
+	drawText("BUILD DEFENSES", centerX-measureText("BUILD DEFENSES", 40)/2, 40, 40, rl.Gold)
+	points := fmt.Sprintf("Build points: %d   Selected: %s (%d)", b.points, structureDefs[b.selected].name, structureDefs[b.selected].cost)
+	drawText(points, centerX-measureText(points, 22)/2, 90, 22, rl.White)
+	if b.reason != "" {
+		drawText(b.reason, centerX-measureText(b.reason, 22)/2, 120, 22, rl.Red)
+	}
+	hint := "Click/ENTER: place   Right mouse/TAB: pick structure   B: back to upgrades"
+	drawText(hint, centerX-measureText(hint, 20)/2, screenHeight-50, 20, rl.LightGray)
+
+	if !b.radial {
+		return
+	}
+	center := rl.GetWorldToScreen(b.cursor, g.camera)
+	rl.DrawCircleV(center, radialMenuRadius+40, rl.Fade(rl.Black, 0.6))
+	step := 2 * math.Pi / float64(structureKindCount)
+	for k := StructureKind(0); k < structureKindCount; k++ {
+		angle := float64(k)*step - math.Pi/2
+		x := center.X + float32(math.Cos(angle))*radialMenuRadius
+		y := center.Y + float32(math.Sin(angle))*radialMenuRadius
+		def := structureDefs[k]
+		color := rl.LightGray
+		if k == b.hovered {
+			color = rl.Yellow
+			rl.DrawCircleV(rl.NewVector2(x, y), 36, rl.Fade(rl.Yellow, 0.3))
+		}
+		label := fmt.Sprintf("%s (%d)", def.name, def.cost)
+		rl.DrawCircleV(rl.NewVector2(x, y), 14, def.color)
+		drawText(label, int32(x)-measureText(label, 18)/2, int32(y)+18, 18, color)
+	}
+}
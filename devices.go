@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Device changes, checked once per frame from the main loop.
+//
+//   - Minimizing the window pauses a run. It stays paused after the window
+//     comes back, and GPU resources made at runtime are rebuilt.
+//   - Moving to another monitor or changing its mode pauses the run and
+//     rebuilds the same resources: the scaled scene target and its
+//     sharpening shader (resscale.go), the post effects, the Night
+//     darkness shader and the lighting shader.
+//   - The audio device is reopened, with all sounds and music reloaded,
+//     when it isn't ready (it failed to open or was closed) or when music
+//     that should be playing stops advancing, which is how a device that
+//     went away shows up. A missing device is retried every few seconds.
+const (
+	audioRetryInterval = float32(3.0)
+	audioStallTime     = float32(1.5) // seconds of frozen music before reopening
+)
+
+type DeviceWatch struct {
+	minimized bool
+	monitor   int
+	monitorW  int
+	monitorH  int
+	refresh   int
+
+	audioRetry  float32
+	musicPlayed float32 // last play position of the audible track
+	musicStall  float32 // how long it has not moved
+}
+
+// newDeviceWatch records the display the game starts on.
+func newDeviceWatch() DeviceWatch {
+	var d DeviceWatch
+	d.monitor, d.monitorW, d.monitorH, d.refresh = currentDisplay()
+	return d
+}
+
+func currentDisplay() (monitor, w, h, refresh int) {
+	monitor = rl.GetCurrentMonitor()
+	return monitor, rl.GetMonitorWidth(monitor), rl.GetMonitorHeight(monitor), rl.GetMonitorRefreshRate(monitor)
+}
+
+func (g *Game) watchDevices(dt float32) {
+	d := &g.devices
+
+	minimized := rl.IsWindowMinimized()
+	switch {
+	case minimized && !d.minimized:
+		g.pauseForDevice("window minimized")
+	case !minimized && d.minimized:
+		g.reloadGPUResources()
+	}
+	d.minimized = minimized
+
+	if monitor, w, h, refresh := currentDisplay(); monitor != d.monitor || w != d.monitorW || h != d.monitorH || refresh != d.refresh {
+		d.monitor, d.monitorW, d.monitorH, d.refresh = monitor, w, h, refresh
+		g.pauseForDevice("display changed")
+		g.reloadGPUResources()
+	}
+
+	g.watchAudio(dt)
+}
+
+// pauseForDevice pauses a run in progress.
+func (g *Game) pauseForDevice(reason string) {
+	if g.state != StatePlaying {
+		return
+	}
+	fmt.Println("Paused:", reason)
+	g.state = StatePaused
+}
+
+// reloadGPUResources frees everything made on the GPU at runtime. The
+// scaled target, post effects and darkness shader come back on the next
+// frame that draws them; the lighting shader is loaded again here and put
+// back on the models.
+func (g *Game) reloadGPUResources() {
+	g.res.release()
+	g.night.release()
+	g.lights.release()
+	g.post.release()
+	g.loadLighting()
+}
+
+func (g *Game) watchAudio(dt float32) {
+	d := &g.devices
+	if !rl.IsAudioDeviceReady() {
+		d.audioRetry -= dt
+		if d.audioRetry <= 0 {
+			d.audioRetry = audioRetryInterval
+			g.reopenAudio()
+		}
+		return
+	}
+
+	// Music only advances in Update, which a frozen simulation skips
+	track := g.audibleTrack()
+	if track == nil || g.simFrozen() {
+		d.musicStall = 0
+		return
+	}
+	played := rl.GetMusicTimePlayed(track.stream)
+	if played != d.musicPlayed {
+		d.musicPlayed, d.musicStall = played, 0
+		return
+	}
+	d.musicStall += dt
+	if d.musicStall >= audioStallTime {
+		fmt.Println("Warning: Audio stopped, reopening the audio device")
+		d.musicStall = 0
+		g.reopenAudio()
+	}
+}
+
+// audibleTrack returns the music track that should be advancing, or nil.
+func (g *Game) audibleTrack() *MusicTrack {
+	for _, t := range g.sounds.musicTracks() {
+		if t.loaded() && t.started && !t.paused && t.fade > 0 {
+			return t
+		}
+	}
+	return nil
+}
+
+// reopenAudio closes the audio device and loads every sound again on a
+// fresh one. Music starts over from silence and fades back in.
+func (g *Game) reopenAudio() {
+	for _, t := range g.sounds.musicTracks() {
+		if t.loaded() {
+			rl.UnloadMusicStream(t.stream)
+		}
+	}
+	g.unloadSfxAliases()
+	g.assets.dropSounds()
+	g.mixer.voicePath, g.mixer.voicePaused = "", false
+	if rl.IsAudioDeviceReady() {
+		rl.CloseAudioDevice()
+	}
+
+	g.sounds = SoundSystem{}
+	g.loadSounds()
+}
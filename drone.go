@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Drone companion. Picking the drone on the upgrade screen gives every
+// player a drone, or levels theirs up. A drone orbits its owner and shoots
+// at the nearest enemy in range; each level fires faster and harder. Its
+// shots count as the owner's.
+const (
+	droneMaxLevel     = 5
+	droneOrbitRadius  = float32(2.2)
+	droneOrbitSpeed   = float32(2.5) // radians per second
+	droneHeight       = float32(2.0)
+	droneRange        = float32(18.0)
+	droneBaseInterval = float32(1.2) // seconds between shots at level 1
+	droneIntervalStep = float32(0.15)
+	droneBulletSpeed  = float32(30.0)
+)
+
+// upgradeDrone is the ApplyUpgrade choice that deploys or levels drones.
+const upgradeDrone = upgradeEvolve + 1
+
+// Drone is a companion tied to the player with id owner.
+type Drone struct {
+	owner    int
+	level    int
+	angle    float32 // position on the orbit
+	position rl.Vector3
+	timer    float32
+}
+
+// droneFor returns the player's drone, or nil.
+func (g *Game) droneFor(playerID int) *Drone {
+	for i := range g.drones {
+		if g.drones[i].owner == playerID {
+			return &g.drones[i]
+		}
+	}
+	return nil
+}
+
+// droneUpgradeLabel is the upgrade screen line for the drone, or "" when
+// every drone is at max level.
+func (g *Game) droneUpgradeLabel() string {
+	d := g.droneFor(g.players[0].id)
+	switch {
+	case d == nil:
+		return "Drone: deploy a companion"
+	case d.level < droneMaxLevel:
+		return fmt.Sprintf("Drone: level %d -> %d", d.level, d.level+1)
+	}
+	return ""
+}
+
+// levelDrone deploys the player's drone or levels it up.
+func (g *Game) levelDrone(player *Player) {
+	if d := g.droneFor(player.id); d != nil {
+		d.level = min(d.level+1, droneMaxLevel)
+		return
+	}
+	g.drones = append(g.drones, Drone{owner: player.id, level: 1, position: player.position})
+}
+
+func (d *Drone) interval() float32 {
+	return droneBaseInterval - droneIntervalStep*float32(d.level-1)
+}
+
+func (d *Drone) damage(owner *Player) int {
+	return max(1, int(float32(owner.stats.damage)*(0.5+0.25*float32(d.level))))
+}
+
+func (g *Game) updateDrones(dt float32) {
+	for i := range g.drones {
+		d := &g.drones[i]
+		if d.owner >= len(g.players) {
+			continue
+		}
+		owner := &g.players[d.owner]
+		d.angle += droneOrbitSpeed * dt
+		d.position = rl.NewVector3(
+			owner.position.X+droneOrbitRadius*float32(math.Cos(float64(d.angle))),
+			droneHeight,
+			owner.position.Z+droneOrbitRadius*float32(math.Sin(float64(d.angle))),
+		)
+
+		d.timer -= dt
+		if d.timer > 0 {
+			continue
+		}
+		target := g.nearestEnemyTo(d.position, droneRange)
+		if target < 0 {
+			continue
+		}
+		e := &g.enemies[target]
+		angle := float32(math.Atan2(float64(e.position.Z-d.position.Z), float64(e.position.X-d.position.X)))
+		if g.spawnBulletFrom(owner, d.position, angle, droneBulletSpeed, d.damage(owner), WeaponBlaster) != nil {
+			d.timer = d.interval()
+		}
+	}
+}
+
+// nearestEnemyTo returns the closest active enemy within maxDist of pos,
+// or -1.
+func (g *Game) nearestEnemyTo(pos rl.Vector3, maxDist float32) int {
+	best, bestD := -1, maxDist*maxDist
+	for i := range g.enemies {
+		if !g.enemies[i].active {
+			continue
+		}
+		dx, dz := g.enemies[i].position.X-pos.X, g.enemies[i].position.Z-pos.Z
+		if d := dx*dx + dz*dz; d < bestD {
+			best, bestD = i, d
+		}
+	}
+	return best
+}
+
+func (g *Game) drawDrones() {
+	for _, d := range g.drones {
+		if d.owner >= len(g.players) {
+			continue
+		}
+		color := g.players[d.owner].color
+		size := 0.35 + 0.05*float32(d.level)
+		rl.DrawCube(d.position, size, size*0.5, size, color)
+		rl.DrawCubeWires(d.position, size, size*0.5, size, rl.White)
+		rl.DrawSphere(rl.NewVector3(d.position.X, d.position.Y-size*0.3, d.position.Z), size*0.25, rl.SkyBlue)
+	}
+}
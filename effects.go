@@ -0,0 +1,200 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Effect attachments. An ongoing state on a player or enemy (overshield,
+// final stand, a buff, later burning or frozen) is shown by an attachment
+// that follows its owner until it is detached, runs out or the owner dies,
+// instead of a particle burst that is gone before anyone sees it. The code
+// that owns the state calls attach/detach at its events; pulseEffect
+// flashes an attachment for things like the shield soaking a hit.
+//
+// An owner has at most one attachment of each kind; attaching again
+// refreshes it. Timed attachments flicker for their last effectWarnTime
+// seconds. Attachments aren't saved: restoreRun rebuilds them from the
+// player state.
+type EffectKind int
+
+const (
+	EffectShield  EffectKind = iota // overshield bubble
+	EffectBerserk                   // final stand aura
+	EffectGlow                      // short buff glow, tinted per attachment
+	EffectBurn                      // flames (no source yet)
+	EffectFreeze                    // ice shell (no source yet)
+)
+
+const (
+	effectWarnTime  = float32(3.0)
+	effectPulseTime = float32(0.25)
+	buffGlowTime    = float32(1.5)
+)
+
+// EffectOwner is a player or an enemy slot.
+type EffectOwner struct {
+	index int
+	enemy bool
+}
+
+func playerOwner(p *Player) EffectOwner { return EffectOwner{index: p.id} }
+func enemyOwner(index int) EffectOwner  { return EffectOwner{index: index, enemy: true} }
+
+type Attachment struct {
+	owner     EffectOwner
+	kind      EffectKind
+	color     rl.Color
+	remaining float32 // 0 = until detached
+	age       float32
+	pulse     float32
+}
+
+// attach adds an attachment or refreshes the owner's existing one of that
+// kind. duration 0 keeps it until detach.
+func (g *Game) attach(owner EffectOwner, kind EffectKind, duration float32, color rl.Color) {
+	if a := g.attachmentOf(owner, kind); a != nil {
+		a.remaining, a.color = duration, color
+		return
+	}
+	g.attachments = append(g.attachments, Attachment{owner: owner, kind: kind, color: color, remaining: duration})
+}
+
+func (g *Game) detach(owner EffectOwner, kind EffectKind) {
+	for i := range g.attachments {
+		if g.attachments[i].owner == owner && g.attachments[i].kind == kind {
+			g.attachments = append(g.attachments[:i], g.attachments[i+1:]...)
+			return
+		}
+	}
+}
+
+// pulseEffect flashes an attachment, if the owner has one of that kind.
+func (g *Game) pulseEffect(owner EffectOwner, kind EffectKind) {
+	if a := g.attachmentOf(owner, kind); a != nil {
+		a.pulse = effectPulseTime
+	}
+}
+
+func (g *Game) attachmentOf(owner EffectOwner, kind EffectKind) *Attachment {
+	for i := range g.attachments {
+		if g.attachments[i].owner == owner && g.attachments[i].kind == kind {
+			return &g.attachments[i]
+		}
+	}
+	return nil
+}
+
+// attachedTo returns where an attachment's owner is, or false once the
+// owner is gone.
+func (g *Game) attachedTo(a *Attachment) (rl.Vector3, float32, bool) {
+	if a.owner.enemy {
+		if a.owner.index >= len(g.enemies) || !g.enemies[a.owner.index].active {
+			return rl.Vector3{}, 0, false
+		}
+		e := &g.enemies[a.owner.index]
+		return e.position, e.size, true
+	}
+	if a.owner.index >= len(g.players) || g.players[a.owner.index].health <= 0 {
+		return rl.Vector3{}, 0, false
+	}
+	return g.players[a.owner.index].position, 1, true
+}
+
+// updateAttachments ages attachments and drops the finished ones. Runs once
+// per simulated frame.
+func (g *Game) updateAttachments(dt float32) {
+	kept := g.attachments[:0]
+	for _, a := range g.attachments {
+		if _, _, ok := g.attachedTo(&a); !ok {
+			continue
+		}
+		a.age += dt
+		a.pulse = max(0, a.pulse-dt)
+		if a.remaining > 0 {
+			a.remaining -= dt
+			if a.remaining <= 0 {
+				continue
+			}
+		}
+		kept = append(kept, a)
+	}
+	g.attachments = kept
+}
+
+// detachAll drops every attachment of an owner, so a reused enemy slot
+// doesn't inherit them.
+func (g *Game) detachAll(owner EffectOwner) {
+	kept := g.attachments[:0]
+	for _, a := range g.attachments {
+		if a.owner != owner {
+			kept = append(kept, a)
+		}
+	}
+	g.attachments = kept
+}
+
+// reattachPlayerEffects rebuilds the player attachments from their state
+// after a run is restored.
+func (g *Game) reattachPlayerEffects() {
+	g.attachments = g.attachments[:0]
+	for i := range g.players {
+		p := &g.players[i]
+		if p.shield > 0 {
+			g.attach(playerOwner(p), EffectShield, p.shieldTimer, rl.SkyBlue)
+		}
+		if p.finalStand > 0 {
+			g.attach(playerOwner(p), EffectBerserk, p.finalStand, rl.Red)
+		}
+	}
+}
+
+func (g *Game) drawAttachments() {
+	for i := range g.attachments {
+		a := &g.attachments[i]
+		pos, size, ok := g.attachedTo(a)
+		if !ok {
+			continue
+		}
+		alpha := float32(1)
+		if a.remaining > 0 && a.remaining < effectWarnTime && math.Sin(float64(a.age)*20) > 0 {
+			alpha = 0.3
+		}
+		flash := a.pulse / effectPulseTime
+		drawAttachment(a, pos, size, alpha, flash)
+	}
+}
+
+// drawAttachment draws one attachment around pos. size is the owner's
+// size (1 for players); flash runs 1..0 after a pulse.
+func drawAttachment(a *Attachment, pos rl.Vector3, size, alpha, flash float32) {
+	t := float64(a.age)
+	switch a.kind {
+	case EffectShield:
+		radius := 1.3*size + flash*0.3
+		rl.DrawSphereWires(pos, radius, 8, 12, rl.Fade(a.color, (0.35+flash*0.5)*alpha))
+		if flash > 0 {
+			rl.DrawSphere(pos, radius, rl.Fade(a.color, 0.25*flash))
+		}
+	case EffectBerserk:
+		pulse := 0.5 + 0.5*float32(math.Sin(t*12))
+		rl.DrawSphereWires(pos, 1.6*size+pulse*0.3, 8, 12, rl.Fade(a.color, (0.4+pulse*0.4)*alpha))
+		rl.DrawCylinder(rl.NewVector3(pos.X, 0.02, pos.Z), 2.2*size, 2.2*size, 0.01, 24, rl.Fade(a.color, 0.25*alpha))
+	case EffectGlow:
+		pulse := 0.5 + 0.5*float32(math.Sin(t*8))
+		rl.DrawCylinder(rl.NewVector3(pos.X, 0.02, pos.Z), 1.4*size, 1.4*size, 0.01, 20, rl.Fade(a.color, (0.2+pulse*0.2)*alpha))
+		rl.DrawCylinderWires(rl.NewVector3(pos.X, 0, pos.Z), 1.1*size, 0.6*size, 2.2*size, 10, rl.Fade(a.color, 0.35*alpha))
+	case EffectBurn:
+		// Flickering tongues around the owner
+		for k := 0; k < 5; k++ {
+			angle := float64(k)*2*math.Pi/5 + t*3
+			height := 0.6 + 0.4*float32(math.Abs(math.Sin(t*9+float64(k))))
+			base := rl.NewVector3(pos.X+float32(math.Cos(angle))*0.6*size, pos.Y-0.3, pos.Z+float32(math.Sin(angle))*0.6*size)
+			rl.DrawCylinder(base, 0.18, 0, height*size, 6, rl.Fade(a.color, 0.8*alpha))
+		}
+	case EffectFreeze:
+		rl.DrawCube(pos, 1.6*size, 1.8*size, 1.6*size, rl.Fade(a.color, 0.25*alpha))
+		rl.DrawCubeWires(pos, 1.6*size, 1.8*size, 1.6*size, rl.Fade(rl.White, 0.6*alpha))
+	}
+}
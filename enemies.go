@@ -0,0 +1,321 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// EnemyKind is the behaviour of a normal (non-boss) enemy.
+type EnemyKind int
+
+const (
+	EnemyChaser   EnemyKind = iota // runs straight at the nearest player
+	EnemyRanged                    // keeps its distance and shoots
+	EnemySplitter                  // chases, splits into smaller chasers on death
+	EnemyKamikaze                  // rushes in, flashes and blows up next to a player
+	EnemyShielded                  // frontal shield, only hurt from behind or by skills
+	EnemyFlyer                     // hovers over walls, swoops down to attack
+	enemyKindCount
+)
+
+var enemyKindNames = [enemyKindCount]string{"Chaser", "Ranged", "Splitter", "Kamikaze", "Shielded", "Flyer"}
+
+// EnemyPhase is a step in an enemy's behaviour. Most kinds only chase;
+// kamikazes arm before detonating and flyers swoop and climb back up.
+type EnemyPhase int
+
+const (
+	PhaseChase EnemyPhase = iota
+	PhaseArming
+	PhaseSwoop
+	PhaseClimb
+)
+
+// Ranged enemies
+const (
+	rangedMinLevel     = 3
+	rangedKeepMin      = float32(9.0) // backs off when closer than this
+	rangedKeepMax      = float32(14.0)
+	rangedFireInterval = float32(2.0)
+	maxEnemyBullets    = 100
+	enemyBulletSpeed   = float32(12.0)
+	enemyBulletDamage  = 10
+	enemyBulletLife    = float32(3.0)
+	enemyBulletSize    = float32(0.25)
+)
+
+// Splitters
+const (
+	splitterMinLevel   = 5
+	splitterExtraSize  = float32(0.4)
+	splitterChildScale = float32(0.55) // child size relative to the parent
+	splitterChildSpeed = float32(1.5)  // child speed relative to the parent
+)
+
+// Kamikazes
+const (
+	kamikazeMinLevel       = 7
+	kamikazeSpeedMul       = float32(1.4)
+	kamikazeTriggerRange   = float32(3.5) // starts arming this close to a player
+	kamikazeFuse           = float32(0.8)
+	kamikazeBlastRadius    = float32(4.5)
+	kamikazeDamage         = 35 // at the centre, half at the edge
+	kamikazeEnemyKnockback = float32(5.0)
+)
+
+// Shielded enemies
+const (
+	shieldedMinLevel  = 9
+	shieldedSpeedMul  = float32(0.6)
+	shieldedTurnRate  = float32(1.5)         // rad/s, slow enough to flank
+	shieldHalfAngle   = float32(math.Pi / 3) // shield covers +-60 degrees
+	shieldedExtraLife = 2
+)
+
+// Flyers
+const (
+	flyerMinLevel   = 11           // first maze stage
+	flyerHeight     = float32(3.8) // above the tallest walls
+	flyerLowHeight  = float32(0.9) // bottom of a swoop
+	flyerHitHeight  = float32(1.8) // touches players below this
+	flyerSwoopRange = float32(6.0)
+	flyerSwoopTime  = float32(0.6)
+	flyerClimbTime  = float32(1.2)
+	flyerSwoopSpeed = float32(2.2) // speed multiplier while diving
+	flyerAimRadius  = float32(3.0) // bullets this close to a flyer climb to it
+	bulletClimbRate = float32(10.0)
+	groundBulletY   = float32(1.0)
+)
+
+type EnemyBullet struct {
+	position rl.Vector3
+	velocity rl.Vector3
+	active   bool
+	damage   int
+	lifetime float32
+}
+
+// splitEnemy spawns 2-3 smaller, faster chasers where a splitter died.
+// Children are plain chasers, so they do not split again.
+func (g *Game) splitEnemy(parent Enemy) {
+	children := 2 + g.rng.Intn(2)
+	speed := float32(math.Sqrt(float64(parent.velocity.X*parent.velocity.X+parent.velocity.Z*parent.velocity.Z))) * splitterChildSpeed
+	size := parent.size * splitterChildScale
+
+	spawned := 0
+	for i := range g.enemies {
+		if spawned == children {
+			break
+		}
+		if g.enemies[i].active {
+			continue
+		}
+		// Spread the children around the death position
+		angle := float64(spawned)/float64(children)*2*math.Pi + g.rng.Float64()*0.5
+		pos := parent.position
+		pos.X += float32(math.Cos(angle)) * parent.size * 0.6
+		pos.Z += float32(math.Sin(angle)) * parent.size * 0.6
+		if g.CheckObstacleCollision(pos, size/2) {
+			pos = parent.position
+		}
+
+		g.spawnEnemyAt(i, pos, EnemyChaser, speed, size, 1)
+		g.enemies[i].color = parent.color
+		spawned++
+	}
+}
+
+func (g *Game) spawnEnemyBullet(from rl.Vector3, dirX, dirZ float32) {
+	g.fireEnemyBullet(from, dirX, dirZ, enemyBulletSpeed, enemyBulletDamage)
+}
+
+func (g *Game) fireEnemyBullet(from rl.Vector3, dirX, dirZ, speed float32, damage int) {
+	for i := range g.enemyBullets {
+		if g.enemyBullets[i].active {
+			continue
+		}
+		g.enemyBullets[i] = EnemyBullet{
+			position: rl.NewVector3(from.X, 0.75, from.Z),
+			velocity: rl.NewVector3(dirX*speed, 0, dirZ*speed),
+			active:   true,
+			damage:   damage,
+			lifetime: enemyBulletLife,
+		}
+		return
+	}
+}
+
+// updateEnemyBullets moves enemy shots and checks them against walls and
+// players.
+func (g *Game) updateEnemyBullets(dt float32) {
+	for i := range g.enemyBullets {
+		b := &g.enemyBullets[i]
+		if !b.active {
+			continue
+		}
+
+		b.lifetime -= dt
+		b.position.X += b.velocity.X * dt
+		b.position.Z += b.velocity.Z * dt
+		if b.lifetime <= 0 {
+			b.active = false
+			continue
+		}
+		if hit := g.obstacleAt(b.position, enemyBulletSize); hit >= 0 {
+			b.active = false
+			g.CreateExplosion(b.position, rl.Purple, 4)
+			g.playImpact(hit)
+			continue
+		}
+		if hit := g.structureAt(b.position, enemyBulletSize); hit >= 0 {
+			b.active = false
+			g.CreateExplosion(b.position, rl.Purple, 4)
+			g.damageStructure(hit, b.damage)
+			continue
+		}
+
+		for pIdx := range g.players {
+			player := &g.players[pIdx]
+			dx := player.position.X - b.position.X
+			dz := player.position.Z - b.position.Z
+			if dx*dx+dz*dz < 0.8*0.8 {
+				b.active = false
+				g.damagePlayer(player, b.damage)
+				break
+			}
+		}
+	}
+}
+
+func (g *Game) drawEnemyBullets() {
+	for i := range g.enemyBullets {
+		if g.enemyBullets[i].active {
+			rl.DrawSphere(g.enemyBullets[i].position, enemyBulletSize, rl.Magenta)
+		}
+	}
+}
+
+// detonateKamikaze blows the enemy up: players in the blast take damage and
+// other enemies are thrown outward. A self-destruct scores nothing.
+func (g *Game) detonateKamikaze(index int) {
+	e := &g.enemies[index]
+	e.active = false
+	center := e.position
+
+	g.CreateExplosion(center, rl.Orange, 25)
+	g.playExplosion()
+
+	for pIdx := range g.players {
+		player := &g.players[pIdx]
+		dx := player.position.X - center.X
+		dz := player.position.Z - center.Z
+		dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
+		if dist < kamikazeBlastRadius {
+			closeness := 1 - dist/kamikazeBlastRadius
+			g.damagePlayer(player, int(float32(kamikazeDamage)*(0.5+0.5*closeness)))
+		}
+	}
+
+	g.blastHits = g.enemiesInRadius(center.X, center.Z, kamikazeBlastRadius, g.blastHits[:0])
+	for _, i := range g.blastHits {
+		other := &g.enemies[i]
+		if i == index || other.isBoss {
+			continue
+		}
+		dx := other.position.X - center.X
+		dz := other.position.Z - center.Z
+		dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
+		if dist < 0.01 {
+			continue
+		}
+		push := kamikazeEnemyKnockback * (1 - dist/kamikazeBlastRadius)
+		newPos := other.position
+		newPos.X += dx / dist * push
+		newPos.Z += dz / dist * push
+		if !g.CheckObstacleCollision(newPos, other.size/2) {
+			other.position = newPos
+		}
+	}
+}
+
+// enemyColor is the colour an enemy is drawn in; arming kamikazes flash
+// faster as the fuse runs out, a boss winding up flashes too.
+func (g *Game) enemyColor(e *Enemy) rl.Color {
+	if e.isBoss && g.bossTelegraphing() && int(g.gameTime*12)%2 == 0 {
+		return rl.White
+	}
+	if e.kind == EnemyKamikaze && e.phase == PhaseArming {
+		rate := 6 + (kamikazeFuse-e.phaseTimer)*20
+		if int(g.gameTime*rate)%2 == 0 {
+			return rl.White
+		}
+	}
+	return e.color
+}
+
+// angleDiff returns a-b wrapped to [-Pi, Pi].
+func angleDiff(a, b float32) float32 {
+	d := math.Mod(float64(a-b)+math.Pi, 2*math.Pi)
+	if d < 0 {
+		d += 2 * math.Pi
+	}
+	return float32(d - math.Pi)
+}
+
+// shieldBlocks reports whether damage coming from (fromX, fromZ) hits the
+// enemy's shield. Only shielded enemies have one.
+func (g *Game) shieldBlocks(index int, fromX, fromZ float32) bool {
+	e := &g.enemies[index]
+	if e.kind != EnemyShielded {
+		return false
+	}
+	from := float32(math.Atan2(float64(fromZ-e.position.Z), float64(fromX-e.position.X)))
+	return float32(math.Abs(float64(angleDiff(from, e.facing)))) < shieldHalfAngle
+}
+
+// deflect shows a hit bouncing off a shield.
+func (g *Game) deflect(pos rl.Vector3) {
+	g.CreateExplosion(pos, rl.SkyBlue, 4)
+	g.playSound(g.sounds.impacts[SurfaceMetal])
+}
+
+// drawShield draws the shield as a disc in front of the enemy.
+func drawShield(e *Enemy) {
+	dirX := float32(math.Cos(float64(e.facing)))
+	dirZ := float32(math.Sin(float64(e.facing)))
+	offset := e.size*0.6 + 0.1
+	front := rl.NewVector3(e.position.X+dirX*offset, e.position.Y, e.position.Z+dirZ*offset)
+	back := rl.NewVector3(front.X-dirX*0.15, front.Y, front.Z-dirZ*0.15)
+	rl.DrawCylinderEx(back, front, e.size*0.8, e.size*0.8, 12, rl.NewColor(120, 200, 255, 200))
+}
+
+// flyerLow reports whether an enemy is low enough to touch players.
+func flyerLow(e *Enemy) bool {
+	return e.kind != EnemyFlyer || e.position.Y < flyerHitHeight
+}
+
+func (g *Game) anyFlyers() bool {
+	for i := range g.enemies {
+		if g.enemies[i].active && g.enemies[i].kind == EnemyFlyer {
+			return true
+		}
+	}
+	return false
+}
+
+// matchBulletHeight lets a bullet climb toward a flyer near its path, so
+// shots aimed at a flyer (or its shadow) still connect. Bullets sink back to
+// the ground height otherwise.
+func (g *Game) matchBulletHeight(b *Bullet, dt float32) {
+	targetY := groundBulletY
+	g.blastHits = g.enemiesInRadius(b.position.X, b.position.Z, flyerAimRadius, g.blastHits[:0])
+	for _, i := range g.blastHits {
+		if g.enemies[i].kind == EnemyFlyer {
+			targetY = float32(math.Max(float64(targetY), float64(g.enemies[i].position.Y)))
+		}
+	}
+	step := bulletClimbRate * dt
+	diff := targetY - b.position.Y
+	b.position.Y += float32(math.Max(float64(-step), math.Min(float64(step), float64(diff))))
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Energy (run modifier). Shooting and skills draw from one pool that
+// refills after a short pause, so holding fire down leaves nothing for a
+// skill. It replaces magazines, so turning it on turns Ammo & Reload off.
+// The numbers are in balance.go.
+
+// spendEnergy takes cost from the player's pool. Returns false, spending
+// nothing, if there isn't enough. Always succeeds with the modifier off.
+func (g *Game) spendEnergy(player *Player, cost float32) bool {
+	if !g.settings.modifiers.energy {
+		return true
+	}
+	if player.energy < cost {
+		return false
+	}
+	player.energy -= cost
+	player.energyWait = energyRegenDelay
+	return true
+}
+
+// hasEnergy reports whether the player could pay cost right now.
+func (g *Game) hasEnergy(player *Player, cost float32) bool {
+	return !g.settings.modifiers.energy || player.energy >= cost
+}
+
+func (g *Game) updateEnergy(player *Player, dt float32) {
+	if !g.settings.modifiers.energy {
+		return
+	}
+	if player.energyWait > 0 {
+		player.energyWait -= dt
+		return
+	}
+	player.energy = float32(math.Min(float64(maxEnergy), float64(player.energy+energyRegen*dt)))
+}
+
+// drawEnergyBar draws the pool under a player's health bar, dimmed while
+// it can't pay for a skill.
+func (g *Game) drawEnergyBar(player Player, x, y int32) {
+	if !g.settings.modifiers.energy {
+		return
+	}
+	color := rl.Gold
+	if player.energy < skillEnergy {
+		color = rl.Orange
+	}
+	rl.DrawRectangle(x, y, 380, 5, rl.DarkGray)
+	rl.DrawRectangle(x, y, int32(380*player.energy/maxEnergy), 5, color)
+	// Mark how much a skill costs
+	rl.DrawRectangle(x+int32(380*skillEnergy/maxEnergy), y, 2, 5, rl.White)
+}
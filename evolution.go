@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Weapon evolution. Kills feed XP to the weapon that landed the last hit;
+// once a base weapon reaches its evolveXP the upgrade screen offers to
+// evolve it (Shotgun -> Auto-Shotgun and so on). XP lives on the Player and
+// lasts for the run, even if the weapon is swapped away and picked up again.
+
+const bossWeaponXP = 10 // XP for a boss kill (normal kills give 1)
+
+// creditHit remembers who last hit an enemy with which weapon.
+func (g *Game) creditHit(index, playerID int, weapon WeaponType) {
+	e := &g.enemies[index]
+	e.hitCredited = true
+	e.lastHitBy = playerID
+	e.lastWeapon = weapon
+}
+
+// awardWeaponXP gives kill XP to the weapon credited with the enemy.
+func (g *Game) awardWeaponXP(e *Enemy) {
+	if !e.hitCredited || e.lastHitBy < 0 || e.lastHitBy >= len(g.players) {
+		return
+	}
+	player := &g.players[e.lastHitBy]
+	def := weaponDefs[e.lastWeapon]
+	if def.evolveXP == 0 || player.weaponXP[e.lastWeapon] >= def.evolveXP {
+		return
+	}
+
+	xp := 1
+	if e.isBoss {
+		xp = bossWeaponXP
+	}
+	player.weaponXP[e.lastWeapon] = int(math.Min(float64(def.evolveXP), float64(player.weaponXP[e.lastWeapon]+xp)))
+	if player.weaponXP[e.lastWeapon] == def.evolveXP {
+		g.CreateExplosion(player.position, rl.Gold, 12)
+		g.announce("evolution_ready")
+	}
+}
+
+// readyEvolutions lists the carried weapons that can evolve now.
+func (p *Player) readyEvolutions() []WeaponType {
+	var ready []WeaponType
+	for _, w := range p.weapons {
+		def := weaponDefs[w]
+		if def.evolveXP > 0 && p.weaponXP[w] >= def.evolveXP {
+			ready = append(ready, w)
+		}
+	}
+	return ready
+}
+
+// anyEvolutionReady reports whether the upgrade screen should offer evolving.
+func (g *Game) anyEvolutionReady() bool {
+	for i := range g.players {
+		if len(g.players[i].readyEvolutions()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// evolveWeapons replaces every ready weapon with its evolved form.
+func (p *Player) evolveWeapons() {
+	for _, w := range p.readyEvolutions() {
+		evolved := weaponDefs[w].evolvesInto
+		for i := range p.weapons {
+			if p.weapons[i] == w {
+				p.weapons[i] = evolved
+			}
+		}
+		if p.weapon == w {
+			p.weapon = evolved
+			p.reloading = false
+		}
+		p.ammo[evolved] = weaponDefs[evolved].magSize
+		p.reserve[evolved] = weaponDefs[evolved].reserveMax
+	}
+}
+
+// baseWeapon returns the weapon an evolved form came from (itself for base
+// weapons).
+func baseWeapon(w WeaponType) WeaponType {
+	for base := WeaponType(0); base < baseWeaponCount; base++ {
+		if weaponDefs[base].evolveXP > 0 && weaponDefs[base].evolvesInto == w {
+			return base
+		}
+	}
+	return w
+}
+
+// evolutionLabels describes the pending evolutions for the upgrade screen.
+func (g *Game) evolutionLabels() []string {
+	var labels []string
+	for i := range g.players {
+		for _, w := range g.players[i].readyEvolutions() {
+			label := fmt.Sprintf("%s -> %s", weaponDefs[w].name, weaponDefs[weaponDefs[w].evolvesInto].name)
+			if g.coopMode {
+				label += fmt.Sprintf(" (P%d)", i+1)
+			}
+			labels = append(labels, label)
+		}
+	}
+	return labels
+}
+
+// drawWeaponXP draws the evolution progress bar of a weapon.
+func drawWeaponXP(player Player, x, y int32) {
+	def := weaponDefs[player.weapon]
+	if def.evolveXP == 0 {
+		return
+	}
+	progress := float32(player.weaponXP[player.weapon]) / float32(def.evolveXP)
+	color := rl.SkyBlue
+	if progress >= 1 {
+		color = rl.Gold
+	}
+	rl.DrawRectangle(x, y, 100, 3, rl.DarkGray)
+	rl.DrawRectangle(x, y, int32(100*progress), 3, color)
+}
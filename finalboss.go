@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// The final boss. The level 100 boss is always the Overlord, whatever the
+// stage or the scripted definitions say, and beating it wins the run. It
+// fights in three phases by health: strafing bullet patterns, then charges
+// with ground slams, then a slow advance with everything at once. Its model
+// slot is assets/models/finalboss.glb (or .gltf/.fbx/.obj), falling back to
+// the normal boss model.
+const (
+	finalBossLevel        = 100
+	finalBossModelPath    = "assets/models/finalboss"
+	finalPatternInterval  = float32(3.0)
+	finalSlamInterval     = float32(4.0)
+	finalSummonInterval   = float32(6.0)
+	finalStrafeSpeed      = float32(7.0)
+	finalKeepDistance     = float32(14.0)
+	finalPhaseBreakRing   = 36
+	victoryScrollSpeed    = float32(40.0) // pixels per second
+	victoryContinueDelay  = float32(2.0)  // seconds before the ending can be skipped
+	finalBossPhaseCount   = 3
+	finalBossPhaseStagger = float32(1.0) // seconds the boss holds still when a phase starts
+)
+
+var victoryLines = []string{
+	"The Overlord falls.",
+	"",
+	"One hundred levels of steel and fire,",
+	"and the arena finally goes quiet.",
+	"",
+	"The machines that poured through the gates",
+	"have nobody left to answer to.",
+	"",
+	"Thanks for playing.",
+}
+
+// loadFinalBossModel fills the final boss model slot if a model exists.
+func (g *Game) loadFinalBossModel() {
+	for _, ext := range []string{".glb", ".gltf", ".fbx", ".obj"} {
+		path := finalBossModelPath + ext
+		if !fileExists(path) {
+			continue
+		}
+		g.finalBossModel = g.assets.loadModel(path)
+		g.finalBossLoaded = true
+		fmt.Println("✓ Loaded:", path)
+		return
+	}
+}
+
+// finalBossPhase is the phase for the boss's remaining health.
+func finalBossPhase(e *Enemy) int {
+	left := float32(e.health) / float32(e.maxHealth)
+	return min(int((1-left)*finalBossPhaseCount), finalBossPhaseCount-1)
+}
+
+func (g *Game) updateFinalBoss(e *Enemy, target *Player, dt float32) {
+	fight := &g.bossFight
+
+	if phase := finalBossPhase(e); phase != fight.phase {
+		// Phase break: clear the old patterns and blast the players back
+		fight.phase = phase
+		fight.state, fight.timer = bossStunned, finalBossPhaseStagger
+		fight.patternTimer = finalBossPhaseStagger + 1
+		g.clearEmitters()
+		g.fireRing(e.position, finalPhaseBreakRing, 12, enemyBulletDamage, g.rng.Float32()*2*math.Pi, ringGapDefault)
+		g.CreateExplosion(e.position, e.color, 40)
+		g.playSound(g.sounds.boss)
+	}
+	if fight.state == bossStunned && fight.phase > 0 && fight.timer > 0 {
+		fight.timer -= dt
+		return
+	}
+
+	switch fight.phase {
+	case 0:
+		// Strafe at range, alternating aimed bursts and spirals
+		g.strafeBoss(e, target, finalStrafeSpeed, finalKeepDistance, dt)
+		if fight.patternDue(dt, finalPatternInterval) {
+			if fight.patternStep%2 == 0 {
+				g.startBurst(fight.index, 4, 5, 0.35, 40, 14, enemyBulletDamage)
+			} else {
+				g.startSpiral(fight.index, 4, 2.5, spiralInterval, spiralSpinSpeed, 10, enemyBulletDamage)
+			}
+		}
+
+	case 1:
+		// Charges like the Charger, with slams to drive players out of cover
+		if fight.state == bossStunned {
+			fight.state, fight.timer = bossStalk, chargerStalkTime
+		}
+		g.updateCharger(e, target, dt)
+		if fight.patternDue(dt, finalSlamInterval) {
+			g.telegraphAtPlayers(len(g.players)*2+1, telegraphRadius, telegraphDelay, telegraphDamage)
+		}
+
+	default:
+		// Slow advance with every attack on shorter timers
+		g.updateDefaultBoss(e, target, dt*0.25)
+		if fight.patternDue(dt, finalPatternInterval*0.8) {
+			switch fight.patternStep % 3 {
+			case 0:
+				g.startSpiral(fight.index, 5, 3, spiralInterval, spiralSpinSpeed*1.2, 10, enemyBulletDamage)
+			case 1:
+				g.telegraphAtPlayers(len(g.players)*2+2, telegraphRadius, telegraphDelay*0.8, telegraphDamage)
+			case 2:
+				g.fireRing(e.position, 28, 12, enemyBulletDamage, g.aimAt(e.position), ringGapDefault)
+			}
+		}
+		fight.timer -= dt
+		if fight.timer <= 0 {
+			fight.timer = finalSummonInterval
+			g.summonAround(e, 3)
+		}
+	}
+}
+
+// strafeBoss circles the target, backing off inside keep distance.
+func (g *Game) strafeBoss(e *Enemy, target *Player, speed, keep, dt float32) {
+	dx := target.position.X - e.position.X
+	dz := target.position.Z - e.position.Z
+	dist := float32(math.Max(0.01, math.Sqrt(float64(dx*dx+dz*dz))))
+	away := (keep - dist) / keep
+	moveX := -dz/dist - dx/dist*away*2
+	moveZ := dx/dist - dz/dist*away*2
+	if l := float32(math.Sqrt(float64(moveX*moveX + moveZ*moveZ))); l > 0 {
+		g.moveBossBy(e, moveX/l*speed*dt, moveZ/l*speed*dt)
+	}
+	e.facing = float32(math.Atan2(float64(dz), float64(dx)))
+}
+
+// winRun ends the run in victory after the final boss dies.
+func (g *Game) winRun() {
+	g.state = StateVictory
+	g.victoryTime = 0
+	g.announce("victory")
+	if g.score > g.highScore && !g.loadoutRun {
+		g.highScore = g.score
+	}
+	g.finishSeededRun()
+	g.saveMastery()
+	g.awardScrap(true)
+	g.arcadeGameOver()
+}
+
+func (g *Game) UpdateVictory(dt float32) {
+	g.victoryTime += dt
+	if g.victoryTime < victoryContinueDelay {
+		return
+	}
+	if rl.IsKeyPressed(rl.KeyEnter) || rl.IsKeyPressed(rl.KeyEscape) {
+		g.playUISound(g.sounds.uiSelect)
+		if g.arcade != nil {
+			g.enterAttract()
+		} else {
+			g.state = StateMenu
+		}
+	}
+}
+
+// DrawVictory is the ending: the epilogue scrolls up over the final stats.
+func (g *Game) DrawVictory() {
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 200))
+	centerX := int32(screenWidth / 2)
+
+	drawText("VICTORY!", centerX-measureText("VICTORY!", 80)/2, 80, 80, rl.Gold)
+	stats := fmt.Sprintf("Score: %d   Kills: %d   Time: %s", g.score, g.enemiesKilled, formatRushTime(g.gameTime))
+	drawText(stats, centerX-measureText(stats, 28)/2, 180, 28, rl.White)
+	g.drawScrapEarned(225)
+
+	// Epilogue rises from the bottom and settles in the middle
+	top := float32(screenHeight) - g.victoryTime*victoryScrollSpeed
+	top = float32(math.Max(float64(top), 280))
+	for i, line := range victoryLines {
+		y := int32(top) + int32(i)*40
+		if y > screenHeight-120 {
+			break
+		}
+		drawText(line, centerX-measureText(line, 28)/2, y, 28, rl.LightGray)
+	}
+
+	if g.victoryTime >= victoryContinueDelay {
+		drawText("Press ENTER to continue", centerX-measureText("Press ENTER to continue", 24)/2, screenHeight-80, 24, rl.Green)
+	}
+	g.drawRunStats(40, 120)
+	g.drawCredits()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Final stand. Once per run, the first hit that would kill a solo player
+// leaves them on 1 HP and untouchable for a few seconds instead, hitting
+// twice as hard. Whoever is still standing when it runs out gets a quarter
+// of their health back. Co-op has no final stand.
+const (
+	finalStandTime      = float32(5.0)
+	finalStandDamageMul = 2
+	finalStandHeal      = 0.25 // fraction of max health restored at the end
+	finalStandDuck      = float32(0.7)
+)
+
+// tryFinalStand saves a player from a killing hit if they still have their
+// final stand. Called from damagePlayer before the run ends.
+func (g *Game) tryFinalStand(player *Player) bool {
+	if g.coopMode || player.standUsed {
+		return false
+	}
+	player.standUsed = true
+	player.finalStand = finalStandTime
+	player.health = 1
+	g.attach(playerOwner(player), EffectBerserk, finalStandTime, rl.Red)
+
+	g.CreateExplosion(player.position, rl.Red, 40)
+	g.playSound(g.sounds.boss)
+	g.duck(BusMusic, finalStandDuck, finalStandTime)
+	g.announce("final_stand")
+	return true
+}
+
+func (g *Game) updateFinalStand(player *Player, dt float32) {
+	if player.finalStand <= 0 {
+		return
+	}
+	player.finalStand -= dt
+	if player.finalStand > 0 {
+		return
+	}
+	player.finalStand = 0
+	g.detach(playerOwner(player), EffectBerserk)
+	player.health = max(player.health, int(float32(player.stats.maxHealth)*finalStandHeal))
+	g.CreateExplosion(player.position, rl.Gold, 30)
+	g.playSound(g.sounds.powerup)
+}
+
+// berserkDamage scales a player's damage while their final stand is on.
+func berserkDamage(player *Player, damage int) int {
+	if player.finalStand > 0 {
+		return damage * finalStandDamageMul
+	}
+	return damage
+}
+
+// drawFinalStand edges the screen in red and counts the window down.
+func (g *Game) drawFinalStand() {
+	for _, player := range g.players {
+		if player.finalStand <= 0 {
+			continue
+		}
+		pulse := 0.5 + 0.5*float32(math.Sin(float64(g.gameTime)*8))
+		edge := rl.Fade(rl.Red, 0.35+pulse*0.25)
+		clear := rl.Fade(rl.Red, 0)
+		const depth = 120
+		rl.DrawRectangleGradientV(0, 0, screenWidth, depth, edge, clear)
+		rl.DrawRectangleGradientV(0, screenHeight-depth, screenWidth, depth, clear, edge)
+		rl.DrawRectangleGradientH(0, 0, depth, screenHeight, edge, clear)
+		rl.DrawRectangleGradientH(screenWidth-depth, 0, depth, screenHeight, clear, edge)
+
+		text := fmt.Sprintf("FINAL STAND %.1f", player.finalStand)
+		drawText(text, screenWidth/2-measureText(text, 44)/2, 140, 44, rl.Red)
+		return
+	}
+}
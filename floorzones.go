@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Floor zones: patches of floor that change how things move on them. Ice
+// keeps momentum: whoever walks on it only slowly turns their speed toward
+// where they want to go, and slides on after letting go. Conveyors carry
+// everything standing on them along their direction, walking or not.
+//
+// Movement is otherwise straight from input to position, so players and
+// walking enemies carry a slide velocity: off the ice it is just their
+// last step, which is what they skate onto the ice with. Running into a
+// wall stops the slide. Flyers and bosses ignore the floor.
+type FloorZoneKind int
+
+const (
+	ZoneIce FloorZoneKind = iota
+	ZoneConveyor
+)
+
+var floorZoneNames = [...]string{"ice", "conveyor"}
+
+const (
+	maxFloorZones = 6
+	iceGrip       = float32(1.8) // share of the wanted velocity picked up per second on ice
+	conveyorSpeed = float32(4.0)
+)
+
+type FloorZone struct {
+	kind     FloorZoneKind
+	position rl.Vector3 // centre on the floor
+	size     rl.Vector2 // width along X, depth along Z
+	dir      rl.Vector2 // conveyors: unit direction of travel
+	active   bool
+}
+
+func (g *Game) clearFloorZones() {
+	for i := range g.floorZones {
+		g.floorZones[i].active = false
+	}
+}
+
+// addFloorZone takes a free zone slot. false when all are in use.
+func (g *Game) addFloorZone(z FloorZone) bool {
+	for i := range g.floorZones {
+		if !g.floorZones[i].active {
+			z.active = true
+			z.position.Y = 0
+			if l := rl.Vector2Length(z.dir); l > 0 {
+				z.dir = rl.Vector2Scale(z.dir, 1/l)
+			}
+			g.floorZones[i] = z
+			return true
+		}
+	}
+	return false
+}
+
+// placeBasicIce lays a couple of ice patches on the open floor.
+func (g *Game) placeBasicIce() {
+	for n := 0; n < 2; n++ {
+		angle := g.rng.Float64() * 2 * math.Pi
+		distance := 10.0 + g.rng.Float64()*10
+		g.addFloorZone(FloorZone{
+			kind:     ZoneIce,
+			position: rl.NewVector3(float32(math.Cos(angle)*distance), 0, float32(math.Sin(angle)*distance)),
+			size:     rl.NewVector2(6+g.rng.Float32()*4, 6+g.rng.Float32()*4),
+		})
+	}
+}
+
+// placeArenaConveyors runs two belts the opposite way across the arena.
+func (g *Game) placeArenaConveyors() {
+	if g.rng.Intn(2) == 0 {
+		g.addFloorZone(FloorZone{kind: ZoneConveyor, position: rl.NewVector3(0, 0, -7), size: rl.NewVector2(26, 3), dir: rl.NewVector2(1, 0)})
+		g.addFloorZone(FloorZone{kind: ZoneConveyor, position: rl.NewVector3(0, 0, 7), size: rl.NewVector2(26, 3), dir: rl.NewVector2(-1, 0)})
+	} else {
+		g.addFloorZone(FloorZone{kind: ZoneConveyor, position: rl.NewVector3(-7, 0, 0), size: rl.NewVector2(3, 26), dir: rl.NewVector2(0, -1)})
+		g.addFloorZone(FloorZone{kind: ZoneConveyor, position: rl.NewVector3(7, 0, 0), size: rl.NewVector2(3, 26), dir: rl.NewVector2(0, 1)})
+	}
+}
+
+// floorZoneAt returns the zone under pos, or nil. Zones don't overlap
+// in the built-in stages; with a layout the first listed wins.
+func (g *Game) floorZoneAt(pos rl.Vector3) *FloorZone {
+	for i := range g.floorZones {
+		z := &g.floorZones[i]
+		if z.active &&
+			float32(math.Abs(float64(pos.X-z.position.X))) < z.size.X/2 &&
+			float32(math.Abs(float64(pos.Z-z.position.Z))) < z.size.Y/2 {
+			return z
+		}
+	}
+	return nil
+}
+
+// floorStep turns a wanted move from pos to want into the actual move on
+// the floor under pos, keeping slide up to date.
+func (g *Game) floorStep(slide *rl.Vector3, pos, want rl.Vector3, dt float32) rl.Vector3 {
+	if dt <= 0 {
+		return want
+	}
+	wantVel := rl.NewVector3((want.X-pos.X)/dt, 0, (want.Z-pos.Z)/dt)
+	if z := g.floorZoneAt(pos); z == nil || z.kind != ZoneIce {
+		*slide = wantVel
+		return want
+	}
+	k := min(1, iceGrip*dt)
+	slide.X += (wantVel.X - slide.X) * k
+	slide.Z += (wantVel.Z - slide.Z) * k
+	return rl.NewVector3(pos.X+slide.X*dt, want.Y, pos.Z+slide.Z*dt)
+}
+
+// updateFloorZones lets the conveyors carry players and grounded enemies.
+// Runs once per simulated frame, after everyone has moved.
+func (g *Game) updateFloorZones(dt float32) {
+	carry := func(pos *rl.Vector3, radius float32) {
+		z := g.floorZoneAt(*pos)
+		if z == nil || z.kind != ZoneConveyor {
+			return
+		}
+		next := rl.NewVector3(pos.X+z.dir.X*conveyorSpeed*dt, pos.Y, pos.Z+z.dir.Y*conveyorSpeed*dt)
+		if !g.CheckObstacleCollision(next, radius) && g.structureAt(next, radius) < 0 {
+			*pos = next
+		}
+	}
+	for i := range g.players {
+		if p := &g.players[i]; p.health > 0 {
+			carry(&p.position, 0.9)
+			g.clampPlayerToStageBounds(p, 0.9)
+		}
+	}
+	for i := range g.enemies {
+		e := &g.enemies[i]
+		if e.active && !e.isBoss && e.kind != EnemyFlyer && !g.isDummy(i) {
+			carry(&e.position, e.size/2)
+		}
+	}
+}
+
+// parseFloorZone builds a zone from a stage layout entry.
+func parseFloorZone(l LayoutZone) (FloorZone, error) {
+	z := FloorZone{
+		position: rl.NewVector3(l.At[0], 0, l.At[1]),
+		size:     rl.NewVector2(l.Size[0], l.Size[1]),
+		dir:      rl.NewVector2(l.Dir[0], l.Dir[1]),
+	}
+	switch l.Type {
+	case floorZoneNames[ZoneIce]:
+		z.kind = ZoneIce
+	case floorZoneNames[ZoneConveyor]:
+		z.kind = ZoneConveyor
+		if l.Dir == [2]float32{} {
+			return z, fmt.Errorf("conveyor needs a direction")
+		}
+	default:
+		return z, fmt.Errorf("unknown type %q", l.Type)
+	}
+	if l.Size[0] <= 0 || l.Size[1] <= 0 {
+		return z, fmt.Errorf("size must be positive")
+	}
+	return z, nil
+}
+
+func (g *Game) drawFloorZones() {
+	for i := range g.floorZones {
+		z := &g.floorZones[i]
+		if !z.active {
+			continue
+		}
+		centre := rl.NewVector3(z.position.X, 0.02, z.position.Z)
+		switch z.kind {
+		case ZoneIce:
+			rl.DrawCube(centre, z.size.X, 0.02, z.size.Y, rl.NewColor(170, 220, 255, 110))
+			rl.DrawCubeWires(centre, z.size.X, 0.02, z.size.Y, rl.NewColor(220, 240, 255, 180))
+		case ZoneConveyor:
+			rl.DrawCube(centre, z.size.X, 0.02, z.size.Y, rl.NewColor(45, 45, 45, 255))
+			rl.DrawCubeWires(centre, z.size.X, 0.02, z.size.Y, rl.Yellow)
+			g.drawConveyorChevrons(z)
+		}
+	}
+}
+
+// drawConveyorChevrons draws arrows scrolling along the belt.
+func (g *Game) drawConveyorChevrons(z *FloorZone) {
+	const spacing = float32(2.0)
+	length := float32(math.Abs(float64(z.dir.X)))*z.size.X + float32(math.Abs(float64(z.dir.Y)))*z.size.Y
+	width := float32(math.Abs(float64(z.dir.Y)))*z.size.X + float32(math.Abs(float64(z.dir.X)))*z.size.Y
+	side := rl.NewVector2(-z.dir.Y, z.dir.X)
+	offset := float32(math.Mod(float64(g.gameTime*conveyorSpeed), float64(spacing)))
+	for d := -length/2 + offset; d < length/2; d += spacing {
+		tip := rl.NewVector3(z.position.X+z.dir.X*d, 0.05, z.position.Z+z.dir.Y*d)
+		back := d - spacing*0.4
+		for _, s := range []float32{-1, 1} {
+			wing := rl.NewVector3(
+				z.position.X+z.dir.X*back+side.X*s*width*0.35,
+				0.05,
+				z.position.Z+z.dir.Y*back+side.Y*s*width*0.35,
+			)
+			rl.DrawLine3D(wing, tip, rl.Gold)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Gamepads. Each player can have one controller on top of their keyboard
+// bindings. Controllers are polled every frame so they can be plugged in
+// or pulled out at runtime: losing a player's controller mid-run pauses
+// the game and opens the assignment dialog for that player.
+const (
+	maxGamepads  = 4
+	stickDead    = float32(0.35) // left stick movement threshold
+	aimStickDead = float32(0.5)  // right stick aim/fire threshold
+)
+
+type PadState struct {
+	connected [maxGamepads]bool
+	assigned  [2]int // gamepad per player, -1 = keyboard only
+	lost      int    // player whose controller disconnected, -1 = none
+	assigning int    // player picked in the assignment dialog, -1 = closed
+}
+
+func newPadState() PadState {
+	return PadState{assigned: [2]int{0, 1}, lost: -1, assigning: -1}
+}
+
+// playerPad returns the connected controller of a player, or -1.
+func (g *Game) playerPad(playerID int) int32 {
+	if playerID < 0 || playerID >= len(g.pads.assigned) {
+		return -1
+	}
+	pad := g.pads.assigned[playerID]
+	if pad < 0 || !g.pads.connected[pad] {
+		return -1
+	}
+	return int32(pad)
+}
+
+// updatePads detects controllers connecting and disconnecting.
+func (g *Game) updatePads() {
+	for i := 0; i < maxGamepads; i++ {
+		now := rl.IsGamepadAvailable(int32(i))
+		was := g.pads.connected[i]
+		g.pads.connected[i] = now
+
+		switch {
+		case now && !was:
+			fmt.Printf("🎮 Controller %d connected: %s\n", i, rl.GetGamepadName(int32(i)))
+			if g.pads.lost >= 0 && g.pads.assigned[g.pads.lost] == i {
+				// The missing controller came back
+				g.pads.lost = -1
+				g.pads.assigning = -1
+			}
+		case !now && was:
+			fmt.Printf("🎮 Controller %d disconnected\n", i)
+			for p := range g.players {
+				if p < len(g.pads.assigned) && g.pads.assigned[p] == i && g.state == StatePlaying {
+					g.state = StatePaused
+					g.pads.lost = p
+					g.pads.assigning = p
+				}
+			}
+		}
+	}
+}
+
+// padStickAction maps the left stick and d-pad onto the move actions.
+func padStickAction(pad int32, action Action) bool {
+	x := rl.GetGamepadAxisMovement(pad, rl.GamepadAxisLeftX)
+	y := rl.GetGamepadAxisMovement(pad, rl.GamepadAxisLeftY)
+	switch action {
+	case ActionMoveUp:
+		return y < -stickDead
+	case ActionMoveDown:
+		return y > stickDead
+	case ActionMoveLeft:
+		return x < -stickDead
+	case ActionMoveRight:
+		return x > stickDead
+	}
+	return false
+}
+
+// padAim returns the right stick direction when it is pushed far enough.
+// Aiming with the stick also fires (twin-stick).
+func (g *Game) padAim(player *Player) (float32, bool) {
+	pad := g.playerPad(player.id)
+	if pad < 0 {
+		return 0, false
+	}
+	x := rl.GetGamepadAxisMovement(pad, rl.GamepadAxisRightX)
+	y := rl.GetGamepadAxisMovement(pad, rl.GamepadAxisRightY)
+	if x*x+y*y < aimStickDead*aimStickDead {
+		return 0, false
+	}
+	return float32(math.Atan2(float64(y), float64(x))), true
+}
+
+// padPausePressed reports a Start press on any player's controller.
+func (g *Game) padPausePressed() bool {
+	for p := range g.players {
+		if pad := g.playerPad(p); pad >= 0 && rl.IsGamepadButtonPressed(pad, rl.GamepadButtonMiddleRight) {
+			return true
+		}
+	}
+	return false
+}
+
+// pressedPad returns the first connected controller with a button pressed
+// this frame, or -1.
+func (g *Game) pressedPad() int {
+	for i := 0; i < maxGamepads; i++ {
+		if !g.pads.connected[i] {
+			continue
+		}
+		for button := int32(rl.GamepadButtonLeftFaceUp); button <= rl.GamepadButtonRightThumb; button++ {
+			if rl.IsGamepadButtonPressed(int32(i), button) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// padButtonPressed returns the button pressed this frame on a player's own
+// controller, or -1.
+func (g *Game) padButtonPressed(playerID int) int32 {
+	pad := g.playerPad(playerID)
+	if pad < 0 {
+		return -1
+	}
+	for button := int32(rl.GamepadButtonLeftFaceUp); button <= rl.GamepadButtonRightThumb; button++ {
+		if rl.IsGamepadButtonPressed(pad, button) {
+			return button
+		}
+	}
+	return -1
+}
+
+// UpdatePadAssign runs the assignment dialog: a button press on any
+// controller gives it to the selected player (swapping with whoever had
+// it), TAB switches player, K leaves the player on keyboard only and
+// ENTER closes the dialog.
+func (g *Game) UpdatePadAssign() {
+	p := g.pads.assigning
+
+	if pad := g.pressedPad(); pad >= 0 {
+		for other := range g.pads.assigned {
+			if other != p && g.pads.assigned[other] == pad {
+				g.pads.assigned[other] = g.pads.assigned[p]
+			}
+		}
+		g.pads.assigned[p] = pad
+		g.pads.lost = -1
+		g.pads.assigning = -1
+		g.playUISound(g.sounds.uiSelect)
+		return
+	}
+
+	if rl.IsKeyPressed(rl.KeyK) {
+		g.pads.assigned[p] = -1
+		g.pads.lost = -1
+		g.pads.assigning = -1
+		g.playUISound(g.sounds.uiSelect)
+		return
+	}
+	if rl.IsKeyPressed(rl.KeyTab) && len(g.players) > 1 {
+		g.pads.assigning = 1 - p
+		g.playUISound(g.sounds.uiMove)
+	}
+	if rl.IsKeyPressed(rl.KeyEnter) {
+		g.pads.assigning = -1
+	}
+}
+
+func (g *Game) padLabel(playerID int) string {
+	pad := g.pads.assigned[playerID]
+	switch {
+	case pad < 0:
+		return "Keyboard"
+	case !g.pads.connected[pad]:
+		return fmt.Sprintf("Controller %d (disconnected)", pad+1)
+	}
+	return fmt.Sprintf("Controller %d - %s", pad+1, rl.GetGamepadName(int32(pad)))
+}
+
+// DrawPadAssign draws the assignment dialog over the pause screen.
+func (g *Game) DrawPadAssign() {
+	centerX := int32(screenWidth / 2)
+	y := int32(screenHeight/2 - 180)
+
+	rl.DrawRectangle(centerX-450, y-30, 900, 360, rl.NewColor(0, 0, 0, 220))
+	if g.pads.lost >= 0 {
+		drawText(fmt.Sprintf("P%d CONTROLLER DISCONNECTED", g.pads.lost+1), centerX-330, y, 40, rl.Red)
+	} else {
+		drawText("ASSIGN CONTROLLERS", centerX-230, y, 40, rl.Gold)
+	}
+
+	for p := range g.players {
+		rowY := y + 80 + int32(p)*45
+		color := rl.White
+		if p == g.pads.assigning {
+			color = rl.Yellow
+			drawText(">", centerX-420, rowY, 28, rl.Yellow)
+		}
+		drawText(fmt.Sprintf("P%d: %s", p+1, g.padLabel(p)), centerX-390, rowY, 28, color)
+	}
+
+	drawText(fmt.Sprintf("Press any button on a controller to give it to P%d", g.pads.assigning+1), centerX-390, y+200, 22, rl.LightGray)
+	drawText("K: keyboard only | TAB: switch player | ENTER: close", centerX-390, y+235, 22, rl.LightGray)
+}
+
+// padButtonName returns a short display name for a gamepad button.
+func padButtonName(button int32) string {
+	switch button {
+	case rl.GamepadButtonRightFaceDown:
+		return "Pad A"
+	case rl.GamepadButtonRightFaceRight:
+		return "Pad B"
+	case rl.GamepadButtonRightFaceLeft:
+		return "Pad X"
+	case rl.GamepadButtonRightFaceUp:
+		return "Pad Y"
+	case rl.GamepadButtonLeftTrigger1:
+		return "LB"
+	case rl.GamepadButtonLeftTrigger2:
+		return "LT"
+	case rl.GamepadButtonRightTrigger1:
+		return "RB"
+	case rl.GamepadButtonRightTrigger2:
+		return "RT"
+	case rl.GamepadButtonLeftFaceUp:
+		return "D-Up"
+	case rl.GamepadButtonLeftFaceDown:
+		return "D-Down"
+	case rl.GamepadButtonLeftFaceLeft:
+		return "D-Left"
+	case rl.GamepadButtonLeftFaceRight:
+		return "D-Right"
+	case rl.GamepadButtonLeftThumb:
+		return "L3"
+	case rl.GamepadButtonRightThumb:
+		return "R3"
+	case rl.GamepadButtonMiddleLeft:
+		return "Back"
+	case rl.GamepadButtonMiddleRight:
+		return "Start"
+	}
+	return fmt.Sprintf("Pad%d", button)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Ghost replay: player positions are sampled during seeded runs and stored
+// with the seed record, then drawn as a translucent "ghost" on later attempts.
+const ghostSampleEvery = float32(0.1)
+
+// GhostFrame is one sampled player position.
+type GhostFrame struct {
+	X     float32 `json:"x"`
+	Z     float32 `json:"z"`
+	Angle float32 `json:"a"`
+}
+
+// recordGhost appends a position sample for every player when due.
+func (g *Game) recordGhost() {
+	if !g.seeded {
+		return
+	}
+	if len(g.ghost) != len(g.players) {
+		g.ghost = make([][]GhostFrame, len(g.players))
+	}
+
+	for pIdx, player := range g.players {
+		for float32(len(g.ghost[pIdx]))*ghostSampleEvery <= g.gameTime {
+			g.ghost[pIdx] = append(g.ghost[pIdx], GhostFrame{
+				X:     player.position.X,
+				Z:     player.position.Z,
+				Angle: player.angle,
+			})
+		}
+	}
+}
+
+// ghostFrameAt interpolates a recorded track at time t. ok is false once the
+// recorded run has ended.
+func ghostFrameAt(track []GhostFrame, t float32) (GhostFrame, bool) {
+	if len(track) == 0 {
+		return GhostFrame{}, false
+	}
+
+	pos := t / ghostSampleEvery
+	idx := int(pos)
+	if idx >= len(track)-1 {
+		return track[len(track)-1], idx < len(track)
+	}
+
+	frac := pos - float32(idx)
+	a, b := track[idx], track[idx+1]
+	return GhostFrame{
+		X:     a.X + (b.X-a.X)*frac,
+		Z:     a.Z + (b.Z-a.Z)*frac,
+		Angle: b.Angle,
+	}, true
+}
+
+// drawGhosts renders the best run's players at the current run time.
+func (g *Game) drawGhosts() {
+	best, ok := g.bestRecord()
+	if !ok {
+		return
+	}
+
+	for pIdx, track := range best.Ghost {
+		frame, alive := ghostFrameAt(track, g.gameTime)
+		if !alive {
+			continue
+		}
+
+		ghostColor := rl.Fade(rl.SkyBlue, 0.35)
+		position := rl.NewVector3(frame.X, 0.5, frame.Z)
+
+		if g.modelsLoaded && g.playerModel.MeshCount > 0 {
+			scale := DefaultPlayerScale
+			if pIdx == 1 {
+				scale = DefaultPlayer2Scale
+			}
+			angleDeg := frame.Angle*180.0/math.Pi + DefaultPlayerYawOffsetDeg
+			modelPos := position
+			modelPos.Y += 0.5
+
+			rl.DrawModelEx(
+				g.playerModel,
+				modelPos,
+				rl.NewVector3(0, 1, 0),
+				angleDeg,
+				rl.NewVector3(scale, scale, scale),
+				ghostColor)
+		} else {
+			rl.DrawCube(position, 1.2, 1.8, 1.2, ghostColor)
+		}
+		rl.DrawCubeWires(position, 1.2, 1.8, 1.2, rl.Fade(rl.White, 0.3))
+	}
+}
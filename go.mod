@@ -2,11 +2,12 @@ module shooter
 
 go 1.25.1
 
+require github.com/gen2brain/raylib-go/raylib v0.55.1
+
 require (
 	github.com/ebitengine/gomobile v0.0.0-20250923094054-ea854a63cce1 // indirect
 	github.com/ebitengine/hideconsole v1.0.0 // indirect
 	github.com/ebitengine/purego v0.9.0 // indirect
-	github.com/gen2brain/raylib-go/raylib v0.55.1 // indirect
 	github.com/hajimehoshi/ebiten/v2 v2.9.3 // indirect
 	github.com/jezek/xgb v1.1.1 // indirect
 	golang.org/x/exp v0.0.0-20240506185415-9bf2ced13842 // indirect
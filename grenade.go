@@ -0,0 +1,207 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Grenades are thrown in an arc, bounce off the floor and walls, and
+// explode when their fuse runs out. The blast damages enemies and destroys
+// crates (obsType 2). Unlike bullets they move in 3D under gravity.
+const (
+	maxGrenades      = 10
+	grenadeFuse      = float32(1.6)
+	grenadeGravity   = float32(25.0)
+	grenadeThrowXZ   = float32(13.0) // horizontal throw speed
+	grenadeThrowY    = float32(9.0)  // upward throw speed
+	grenadeRadius    = float32(5.0)  // blast radius
+	grenadeDamageMul = 4             // blast damage = damage stat x this
+	grenadeCooldown  = float32(5.0)
+	grenadeSize      = float32(0.3)
+	grenadeBounce    = float32(0.4) // speed kept on bounce
+)
+
+// obsCrate is a destructible obstacle.
+const obsCrate = 2
+
+type Grenade struct {
+	position rl.Vector3
+	velocity rl.Vector3
+	fuse     float32
+	active   bool
+	playerId int
+	damage   int
+}
+
+// ThrowGrenade lobs a grenade in the player's aim direction.
+func (g *Game) ThrowGrenade(player *Player) {
+	if player.grenadeCooldown > 0 {
+		return
+	}
+	for i := range g.grenades {
+		if g.grenades[i].active {
+			continue
+		}
+		pos := player.position
+		pos.Y = 1.2
+		g.grenades[i] = Grenade{
+			position: pos,
+			velocity: rl.NewVector3(
+				float32(math.Cos(float64(player.angle)))*grenadeThrowXZ,
+				grenadeThrowY,
+				float32(math.Sin(float64(player.angle)))*grenadeThrowXZ,
+			),
+			fuse:     grenadeFuse,
+			active:   true,
+			playerId: player.id,
+			damage:   player.stats.damage * grenadeDamageMul,
+		}
+		player.grenadeCooldown = grenadeCooldown
+		g.playSound(g.sounds.shoot)
+		return
+	}
+}
+
+// updateGrenades moves grenades under gravity and detonates expired fuses.
+func (g *Game) updateGrenades(dt float32) {
+	for i := range g.grenades {
+		gr := &g.grenades[i]
+		if !gr.active {
+			continue
+		}
+
+		gr.fuse -= dt
+		if gr.fuse <= 0 {
+			g.explodeGrenade(gr)
+			continue
+		}
+
+		gr.velocity.Y -= grenadeGravity * dt
+
+		// Move one axis at a time so walls reflect the right component
+		next := gr.position
+		next.X += gr.velocity.X * dt
+		if g.grenadeBlocked(next) || float32(math.Abs(float64(next.X))) > g.stageHalf {
+			gr.velocity.X = -gr.velocity.X * grenadeBounce
+			next.X = gr.position.X
+		}
+		next.Z += gr.velocity.Z * dt
+		if g.grenadeBlocked(next) || float32(math.Abs(float64(next.Z))) > g.stageHalf {
+			gr.velocity.Z = -gr.velocity.Z * grenadeBounce
+			next.Z = gr.position.Z
+		}
+		next.Y += gr.velocity.Y * dt
+		if next.Y < grenadeSize {
+			next.Y = grenadeSize
+			gr.velocity.Y = -gr.velocity.Y * grenadeBounce
+			gr.velocity.X *= 0.6
+			gr.velocity.Z *= 0.6
+		}
+		gr.position = next
+	}
+}
+
+// grenadeBlocked reports whether an obstacle is in the way at pos. Grenades
+// fly over anything lower than they are.
+func (g *Game) grenadeBlocked(pos rl.Vector3) bool {
+	hit := g.obstacleAt(pos, grenadeSize)
+	if hit < 0 {
+		return false
+	}
+	return pos.Y-grenadeSize < g.obstacleTop(hit)
+}
+
+// explodeGrenade damages enemies in the blast and destroys crates.
+func (g *Game) explodeGrenade(gr *Grenade) {
+	gr.active = false
+	center := gr.position
+
+	g.CreateExplosion(center, rl.Orange, 15)
+	g.playExplosion()
+	g.addTrauma(blastTrauma)
+	g.destroyObstaclesInRadius(center, grenadeRadius)
+	g.damageNestsInRadius(center, grenadeRadius, gr.damage)
+
+	g.blastHits = g.enemiesInBlast(center.X, center.Z, grenadeRadius, g.blastHits[:0])
+	for _, i := range g.blastHits {
+		e := &g.enemies[i]
+		dx := e.position.X - center.X
+		dz := e.position.Z - center.Z
+		closeness := 1 - float32(math.Sqrt(float64(dx*dx+dz*dz)))/grenadeRadius
+		damage := int(math.Max(1, math.Round(float64(float32(gr.damage)*(0.5+0.5*closeness)))))
+
+		if g.shieldBlocks(i, center.X, center.Z) {
+			continue
+		}
+
+		// Not a weapon kill: no weapon XP
+		e.hitCredited = false
+		g.damageEnemy(i, damage, gr.playerId)
+	}
+}
+
+// destroyObstaclesInRadius breaks every crate the blast reaches.
+func (g *Game) destroyObstaclesInRadius(center rl.Vector3, radius float32) {
+	for i := range g.obstacles {
+		obs := &g.obstacles[i]
+		if !obs.active || obs.obsType != obsCrate {
+			continue
+		}
+		// Distance from the blast to the closest point of the box
+		dx := float32(math.Max(0, math.Abs(float64(center.X-obs.position.X))-float64(obs.size.X/2)))
+		dz := float32(math.Max(0, math.Abs(float64(center.Z-obs.position.Z))-float64(obs.size.Z/2)))
+		if dx*dx+dz*dz <= radius*radius {
+			obs.active = false
+			g.CreateExplosion(obs.position, rl.Brown, 12)
+		}
+	}
+}
+
+// scatterCrates places destructible crates in free obstacle slots, away
+// from the spawn point and off the portals.
+func (g *Game) scatterCrates(count int) {
+	for slot := range g.obstacles {
+		if count == 0 {
+			return
+		}
+		if g.obstacles[slot].active {
+			continue
+		}
+		for try := 0; try < 10; try++ {
+			angle := g.rng.Float64() * 2 * math.Pi
+			distance := 6.0 + g.rng.Float64()*16
+			pos := rl.NewVector3(float32(math.Cos(angle)*distance), 0.8, float32(math.Sin(angle)*distance))
+			if g.CheckObstacleCollision(pos, 1.5) || g.portalAt(pos, 1.5) >= 0 {
+				continue
+			}
+			g.obstacles[slot] = Obstacle{
+				position: pos,
+				size:     rl.NewVector3(1.6, 1.6, 1.6),
+				active:   true,
+				obsType:  obsCrate,
+			}
+			count--
+			break
+		}
+	}
+}
+
+func (g *Game) drawGrenades() {
+	for i := range g.grenades {
+		gr := g.grenades[i]
+		if !gr.active {
+			continue
+		}
+		color := rl.DarkGreen
+		// Blink faster as the fuse runs out
+		blink := float32(4)
+		if gr.fuse < grenadeFuse/2 {
+			blink = 12
+		}
+		if int(gr.fuse*blink)%2 == 0 {
+			color = rl.Red
+		}
+		rl.DrawSphere(gr.position, grenadeSize, color)
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Damage heatmap. Where players get hurt and where they die is counted
+// across all runs on a coarse grid over the floor, one grid per stage
+// layout (or per stage type for the built-in generators), and kept in
+// save/heatmap.json. With -debug, F8 lays the current stage's grid over
+// the floor: red where damage piles up, a white marker on cells where runs
+// ended. A hot spot next to a spawn zone or in a choke point is the thing
+// to look for when tuning a custom stage.
+const (
+	heatmapFile = saveDir + "/heatmap.json"
+	heatCell    = float32(2.0) // world units per grid cell
+	heatCells   = int(2 * defaultStageHalf / heatCell)
+)
+
+// HeatGrid is one stage's record. Damage is HP lost per cell.
+type HeatGrid struct {
+	Damage []int `json:"damage"`
+	Deaths []int `json:"deaths"`
+}
+
+func newHeatGrid() *HeatGrid {
+	return &HeatGrid{Damage: make([]int, heatCells*heatCells), Deaths: make([]int, heatCells*heatCells)}
+}
+
+// heatCellAt returns the grid index of a floor position.
+func heatCellAt(pos rl.Vector3) int {
+	cell := func(v float32) int {
+		return min(max(int((v+defaultStageHalf)/heatCell), 0), heatCells-1)
+	}
+	return cell(pos.Z)*heatCells + cell(pos.X)
+}
+
+// heatKey names the grid for the stage being played.
+func (g *Game) heatKey() string {
+	if name := g.layoutName(); name != "" {
+		return name
+	}
+	return stageDirNames[g.currentStage]
+}
+
+func (g *Game) loadHeatmap() {
+	g.heatmap = map[string]*HeatGrid{}
+	data, err := os.ReadFile(heatmapFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &g.heatmap); err != nil {
+		fmt.Println("Warning: Could not read heatmap:", err)
+		g.heatmap = map[string]*HeatGrid{}
+		return
+	}
+	for key, grid := range g.heatmap {
+		if grid == nil || len(grid.Damage) != heatCells*heatCells || len(grid.Deaths) != heatCells*heatCells {
+			delete(g.heatmap, key) // a different grid size; start that stage over
+		}
+	}
+}
+
+func (g *Game) saveHeatmap() {
+	if g.playback != nil || !g.heatmapDirty {
+		return
+	}
+	os.MkdirAll(filepath.Dir(heatmapFile), os.ModePerm)
+	data, err := json.Marshal(g.heatmap)
+	if err != nil {
+		fmt.Println("Warning: Could not encode heatmap:", err)
+		return
+	}
+	if err := writeFileAtomic(heatmapFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save heatmap:", err)
+		return
+	}
+	g.heatmapDirty = false
+}
+
+// recordHeat counts damage taken, or a death, at pos. Called from
+// damagePlayer.
+func (g *Game) recordHeat(pos rl.Vector3, damage int, died bool) {
+	if g.playback != nil || g.practiceMode || g.heatmap == nil {
+		return
+	}
+	key := g.heatKey()
+	grid := g.heatmap[key]
+	if grid == nil {
+		grid = newHeatGrid()
+		g.heatmap[key] = grid
+	}
+	cell := heatCellAt(pos)
+	grid.Damage[cell] += damage
+	if died {
+		grid.Deaths[cell]++
+	}
+	g.heatmapDirty = true
+}
+
+func (g *Game) updateHeatmapView() {
+	if g.debugTools && rl.IsKeyPressed(rl.KeyF8) {
+		g.showHeatmap = !g.showHeatmap
+	}
+}
+
+// heatmapShown reports whether the overlay is drawn this frame.
+func (g *Game) heatmapShown() bool {
+	return g.debugTools && g.showHeatmap && (g.state == StatePlaying || g.state == StatePaused)
+}
+
+// drawHeatmap colours the floor by the current stage's grid (call inside
+// 3D mode). Cells are scaled against the hottest one.
+func (g *Game) drawHeatmap() {
+	if !g.heatmapShown() {
+		return
+	}
+	grid := g.heatmap[g.heatKey()]
+	if grid == nil {
+		return
+	}
+	hottest := 0
+	for _, d := range grid.Damage {
+		hottest = max(hottest, d)
+	}
+	for i := range grid.Damage {
+		x := -defaultStageHalf + (float32(i%heatCells)+0.5)*heatCell
+		z := -defaultStageHalf + (float32(i/heatCells)+0.5)*heatCell
+		if d := grid.Damage[i]; d > 0 {
+			heat := float32(d) / float32(hottest)
+			color := rl.NewColor(255, uint8(200*(1-heat)), 0, uint8(60+160*heat))
+			rl.DrawCube(rl.NewVector3(x, 0.03, z), heatCell*0.95, 0.02, heatCell*0.95, color)
+		}
+		if grid.Deaths[i] > 0 {
+			h := min(float32(grid.Deaths[i])*0.3, 3)
+			rl.DrawCube(rl.NewVector3(x, h/2, z), 0.3, h, 0.3, rl.White)
+		}
+	}
+}
+
+// drawHeatmapLabel names the grid on screen with its totals.
+func (g *Game) drawHeatmapLabel() {
+	if !g.heatmapShown() {
+		return
+	}
+	key := g.heatKey()
+	damage, deaths := 0, 0
+	if grid := g.heatmap[key]; grid != nil {
+		for i := range grid.Damage {
+			damage += grid.Damage[i]
+			deaths += grid.Deaths[i]
+		}
+	}
+	text := fmt.Sprintf("HEATMAP %s: %d damage, %d deaths  (F8 hide)", key, damage, deaths)
+	drawText(text, screenWidth/2-measureText(text, 20)/2, screenHeight-40, 20, rl.Orange)
+}
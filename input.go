@@ -0,0 +1,250 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Action is a bindable player input.
+type Action int
+
+const (
+	ActionMoveUp Action = iota
+	ActionMoveDown
+	ActionMoveLeft
+	ActionMoveRight
+	ActionFire
+	ActionAimLock
+	ActionShootUp
+	ActionShootDown
+	ActionShootLeft
+	ActionShootRight
+	ActionSkill1
+	ActionSkill2
+	ActionSkill3
+	ActionSprint
+	ActionSwitchWeapon
+	ActionReload
+	ActionGrenade
+	ActionDash
+	actionCount
+)
+
+// --- Binding queries ---
+//
+// Every query goes through the replay (replay.go): a replay being verified
+// answers it, otherwise the live answer is recorded for daily runs.
+
+// actionDown reports whether any key or button bound to the action is held.
+func (g *Game) actionDown(player *Player, action Action) bool {
+	if in := g.playbackInput(player); in != nil {
+		return in.Down.has(action)
+	}
+	down := g.liveActionDown(player, action)
+	if in := g.recordingInput(player); in != nil && down {
+		in.Down.set(action)
+	}
+	return down
+}
+
+func (g *Game) liveActionDown(player *Player, action Action) bool {
+	b := g.controls.binding(player.id, action)
+	for _, key := range b.Keys {
+		if rl.IsKeyDown(key) {
+			return true
+		}
+	}
+	for _, button := range b.Mouse {
+		if rl.IsMouseButtonDown(rl.MouseButton(button)) {
+			return true
+		}
+	}
+	if pad := g.playerPad(player.id); pad >= 0 {
+		for _, button := range b.Pad {
+			if rl.IsGamepadButtonDown(pad, button) {
+				return true
+			}
+		}
+		return padStickAction(pad, action)
+	}
+	return false
+}
+
+// actionPressed reports whether the action was pressed this frame.
+func (g *Game) actionPressed(player *Player, action Action) bool {
+	if in := g.playbackInput(player); in != nil {
+		return in.Pressed.has(action)
+	}
+	pressed := g.liveActionPressed(player, action)
+	if in := g.recordingInput(player); in != nil && pressed {
+		in.Pressed.set(action)
+	}
+	return pressed
+}
+
+func (g *Game) liveActionPressed(player *Player, action Action) bool {
+	b := g.controls.binding(player.id, action)
+	for _, key := range b.Keys {
+		if rl.IsKeyPressed(key) {
+			return true
+		}
+	}
+	for _, button := range b.Mouse {
+		if rl.IsMouseButtonPressed(rl.MouseButton(button)) {
+			return true
+		}
+	}
+	if pad := g.playerPad(player.id); pad >= 0 {
+		for _, button := range b.Pad {
+			if rl.IsGamepadButtonPressed(pad, button) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// actionActive resolves hold/toggle mode: held actions are active while
+// down, toggle actions flip on each press (see updateToggles).
+func (g *Game) actionActive(player *Player, action Action) bool {
+	if in := g.playbackInput(player); in != nil {
+		return in.Active.has(action)
+	}
+	active := g.actionDown(player, action)
+	if g.controls.binding(player.id, action).Toggle {
+		active = player.toggled[action]
+	}
+	if in := g.recordingInput(player); in != nil && active {
+		in.Active.set(action)
+	}
+	return active
+}
+
+// aimInput returns the right stick aim (stick is true; it also fires) or,
+// with mouse set, the mouse aim.
+func (g *Game) aimInput(player *Player, mouse bool) (angle float32, stick, ok bool) {
+	if in := g.playbackInput(player); in != nil {
+		return in.Aim, in.Stick, in.Stick || mouse
+	}
+	switch ang, padOK := g.padAim(player); {
+	case padOK:
+		angle, stick = ang, true
+	case mouse:
+		angle = g.mouseAim(player)
+	default:
+		return 0, false, false
+	}
+	if in := g.recordingInput(player); in != nil {
+		in.Aim, in.Stick = angle, stick
+	}
+	return angle, stick, true
+}
+
+// mouseAim is the angle from the player to the mouse cursor on screen.
+func (g *Game) mouseAim(player *Player) float32 {
+	// คำนวณมุมหันจากตำแหน่งเมาส์
+	mousePos := rl.GetMousePosition()
+	screenPos := rl.GetWorldToScreen(player.position, g.camera)
+
+	// คำนวณมุมระหว่างตำแหน่ง player กับเมาส์
+	dx := mousePos.X - screenPos.X
+	dy := mousePos.Y - screenPos.Y
+	return float32(math.Atan2(float64(dy), float64(dx)))
+}
+
+// updateToggles flips toggle-mode actions that were pressed this frame.
+func (g *Game) updateToggles(player *Player) {
+	for a := Action(0); a < actionCount; a++ {
+		if g.controls.binding(player.id, a).Toggle && g.actionPressed(player, a) {
+			player.toggled[a] = !player.toggled[a]
+		}
+	}
+}
+
+// nearestEnemyAngle returns the aim angle toward the closest active enemy.
+func (g *Game) nearestEnemyAngle(player *Player) (float32, bool) {
+	var nearest *Enemy
+	minD := float32(1e6)
+	for i := range g.enemies {
+		if !g.enemies[i].active {
+			continue
+		}
+		dx := g.enemies[i].position.X - player.position.X
+		dz := g.enemies[i].position.Z - player.position.Z
+		d := float32(math.Sqrt(float64(dx*dx + dz*dz)))
+		if d < minD {
+			minD = d
+			nearest = &g.enemies[i]
+		}
+	}
+	if nearest == nil {
+		return 0, false
+	}
+	return float32(math.Atan2(float64(nearest.position.Z-player.position.Z), float64(nearest.position.X-player.position.X))), true
+}
+
+// --- Input buffering ---
+//
+// Actions pressed shortly before they become legal (skill still cooling
+// down) are queued and fired as soon as they are allowed, instead of being
+// dropped.
+
+// snapInputBuffer rounds the buffer setting to the 50 ms steps the
+// settings menu offers, so float drift can't leave "off" as 0 ms.
+func snapInputBuffer(v float32) float32 {
+	return float32(math.Round(float64(v)*20) / 20)
+}
+
+// QueuedAction is the most recent buffered press for a player.
+type QueuedAction struct {
+	kind      Action
+	pressedAt float32
+	pending   bool
+}
+
+// requestAction fires the action now if legal, otherwise buffers it.
+func (g *Game) requestAction(player *Player, kind Action) {
+	if g.actionReady(player, kind) {
+		g.performAction(player, kind)
+		player.queued.pending = false
+		return
+	}
+
+	if g.settings.inputBuffer <= 0 {
+		return
+	}
+	player.queued = QueuedAction{kind: kind, pressedAt: g.gameTime, pending: true}
+}
+
+// flushInputBuffer fires a buffered action once it becomes legal, or drops
+// it when the buffer window has passed.
+func (g *Game) flushInputBuffer(player *Player) {
+	q := &player.queued
+	if !q.pending {
+		return
+	}
+	if g.gameTime-q.pressedAt > g.settings.inputBuffer {
+		q.pending = false
+		return
+	}
+	if g.actionReady(player, q.kind) {
+		q.pending = false
+		g.performAction(player, q.kind)
+	}
+}
+
+func (g *Game) actionReady(player *Player, kind Action) bool {
+	switch kind {
+	case ActionSkill1, ActionSkill2, ActionSkill3:
+		return player.skills[int(kind-ActionSkill1)].ready && g.hasEnergy(player, skillEnergy)
+	}
+	return false
+}
+
+func (g *Game) performAction(player *Player, kind Action) {
+	switch kind {
+	case ActionSkill1, ActionSkill2, ActionSkill3:
+		g.UseSkill(player, int(kind-ActionSkill1))
+	}
+}
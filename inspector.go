@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Entity inspector, available with -debug while the F3 overlay is open.
+// Middle-click picks the player, enemy or projectile under the mouse; its
+// fields are shown live in a panel. [ and ] pick a field, - and = change
+// it, BACKSPACE drops the selection.
+
+type InspectKind int
+
+const (
+	InspectNone InspectKind = iota
+	InspectPlayer
+	InspectEnemy
+	InspectBullet
+	InspectEnemyBullet
+)
+
+var inspectKindNames = [...]string{"", "Player", "Enemy", "Bullet", "Enemy Bullet"}
+
+type Inspector struct {
+	kind  InspectKind
+	index int
+	field int
+}
+
+// inspectField is one row of the panel; step is nil for read-only fields.
+type inspectField struct {
+	name string
+	get  func() string
+	step func(dir int)
+}
+
+func (g *Game) inspectorActive() bool {
+	return g.debugTools && g.showDebug && (g.state == StatePlaying || g.state == StatePaused)
+}
+
+func (g *Game) updateInspector() {
+	if !g.inspectorActive() {
+		return
+	}
+
+	if rl.IsMouseButtonPressed(rl.MouseMiddleButton) {
+		g.inspector = g.pickEntity()
+	}
+	if rl.IsKeyPressed(rl.KeyBackspace) {
+		g.inspector = Inspector{}
+	}
+
+	fields := g.inspectFields()
+	if len(fields) == 0 {
+		g.inspector = Inspector{}
+		return
+	}
+	if rl.IsKeyPressed(rl.KeyLeftBracket) {
+		g.inspector.field = (g.inspector.field + len(fields) - 1) % len(fields)
+	}
+	if rl.IsKeyPressed(rl.KeyRightBracket) {
+		g.inspector.field = (g.inspector.field + 1) % len(fields)
+	}
+	g.inspector.field = min(g.inspector.field, len(fields)-1)
+
+	if f := fields[g.inspector.field]; f.step != nil {
+		if rl.IsKeyPressed(rl.KeyMinus) {
+			f.step(-1)
+		}
+		if rl.IsKeyPressed(rl.KeyEqual) {
+			f.step(1)
+		}
+	}
+}
+
+// pickEntity returns the closest entity under the mouse cursor.
+func (g *Game) pickEntity() Inspector {
+	ray := rl.GetScreenToWorldRay(rl.GetMousePosition(), g.camera)
+	best := Inspector{}
+	bestDist := float32(1e9)
+
+	try := func(kind InspectKind, index int, pos rl.Vector3, radius float32) {
+		hit := rl.GetRayCollisionSphere(ray, pos, radius)
+		if hit.Hit && hit.Distance < bestDist {
+			bestDist = hit.Distance
+			best = Inspector{kind: kind, index: index}
+		}
+	}
+
+	for i := range g.players {
+		try(InspectPlayer, i, g.players[i].position, 1.0)
+	}
+	for i := range g.enemies {
+		if g.enemies[i].active {
+			try(InspectEnemy, i, g.enemies[i].position, g.enemies[i].size*0.7)
+		}
+	}
+	// Projectiles are tiny; give them a bigger target
+	for i := range g.bullets {
+		if g.bullets[i].active {
+			try(InspectBullet, i, g.bullets[i].position, 0.6)
+		}
+	}
+	for i := range g.enemyBullets {
+		if g.enemyBullets[i].active {
+			try(InspectEnemyBullet, i, g.enemyBullets[i].position, 0.6)
+		}
+	}
+	return best
+}
+
+// inspectFields lists the fields of the selected entity, or nothing if the
+// selection is gone (enemy killed, bullet expired).
+func (g *Game) inspectFields() []inspectField {
+	ins := g.inspector
+	switch ins.kind {
+	case InspectPlayer:
+		if ins.index >= len(g.players) {
+			return nil
+		}
+		p := &g.players[ins.index]
+		return []inspectField{
+			intField("health", &p.health, 10),
+			intField("maxHealth", &p.stats.maxHealth, 10),
+			intField("damage", &p.stats.damage, 1),
+			floatField("speed", &p.stats.speed, 1),
+			floatField("fireRate", &p.stats.fireRate, 0.01),
+			floatField("critChance", &p.stats.critChance, 0.05),
+			floatField("stamina", &p.stamina, 0.1),
+			{"weapon", func() string { return weaponDefs[p.weapon].name }, func(dir int) {
+				p.weapon = WeaponType((int(p.weapon) + dir + int(weaponCount)) % int(weaponCount))
+			}},
+			vecField("position", p.position),
+		}
+
+	case InspectEnemy:
+		if ins.index >= len(g.enemies) || !g.enemies[ins.index].active {
+			return nil
+		}
+		e := &g.enemies[ins.index]
+		return []inspectField{
+			intField("health", &e.health, 1),
+			intField("maxHealth", &e.maxHealth, 1),
+			{"kind", func() string { return enemyKindNames[e.kind] }, func(dir int) {
+				e.kind = EnemyKind((int(e.kind) + dir + int(enemyKindCount)) % int(enemyKindCount))
+			}},
+			{"phase", func() string { return fmt.Sprint(e.phase) }, nil},
+			floatField("size", &e.size, 0.1),
+			{"speed", func() string { return fmt.Sprintf("%.2f", rl.Vector3Length(e.velocity)) }, func(dir int) {
+				scale := 1 + 0.25*float32(dir)
+				e.velocity.X *= scale
+				e.velocity.Z *= scale
+			}},
+			floatField("facing", &e.facing, 0.25),
+			{"target", func() string { return fmt.Sprintf("P%d", g.nearestPlayerIndex(e.position)+1) }, nil},
+			{"lastHit", func() string {
+				if !e.hitCredited {
+					return "-"
+				}
+				return fmt.Sprintf("P%d %s", e.lastHitBy+1, weaponDefs[e.lastWeapon].name)
+			}, nil},
+			vecField("position", e.position),
+			vecField("velocity", e.velocity),
+		}
+
+	case InspectBullet:
+		if ins.index >= len(g.bullets) || !g.bullets[ins.index].active {
+			return nil
+		}
+		b := &g.bullets[ins.index]
+		return []inspectField{
+			intField("damage", &b.damage, 1),
+			{"weapon", func() string { return weaponDefs[b.weapon].name }, nil},
+			{"owner", func() string { return fmt.Sprintf("P%d", b.playerId+1) }, nil},
+			intField("target", &b.target, 1),
+			{"speed", func() string { return fmt.Sprintf("%.2f", rl.Vector3Length(b.velocity)) }, func(dir int) {
+				scale := 1 + 0.25*float32(dir)
+				b.velocity.X *= scale
+				b.velocity.Z *= scale
+			}},
+			vecField("position", b.position),
+		}
+
+	case InspectEnemyBullet:
+		if ins.index >= len(g.enemyBullets) || !g.enemyBullets[ins.index].active {
+			return nil
+		}
+		b := &g.enemyBullets[ins.index]
+		return []inspectField{
+			intField("damage", &b.damage, 1),
+			floatField("lifetime", &b.lifetime, 0.5),
+			vecField("position", b.position),
+			vecField("velocity", b.velocity),
+		}
+	}
+	return nil
+}
+
+func intField(name string, v *int, step int) inspectField {
+	return inspectField{name, func() string { return fmt.Sprint(*v) }, func(dir int) { *v += dir * step }}
+}
+
+func floatField(name string, v *float32, step float32) inspectField {
+	return inspectField{name, func() string { return fmt.Sprintf("%.2f", *v) }, func(dir int) { *v += float32(dir) * step }}
+}
+
+func vecField(name string, v rl.Vector3) inspectField {
+	return inspectField{name, func() string { return fmt.Sprintf("%.1f, %.1f, %.1f", v.X, v.Y, v.Z) }, nil}
+}
+
+// nearestPlayerIndex is the player enemies at pos chase.
+func (g *Game) nearestPlayerIndex(pos rl.Vector3) int {
+	best, bestDist := 0, float32(1e9)
+	for i := range g.players {
+		dx := g.players[i].position.X - pos.X
+		dz := g.players[i].position.Z - pos.Z
+		if d := dx*dx + dz*dz; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	return best
+}
+
+// inspectedPosition is where the selection is, for the 3D marker.
+func (g *Game) inspectedPosition() (rl.Vector3, float32, bool) {
+	if len(g.inspectFields()) == 0 {
+		return rl.Vector3{}, 0, false
+	}
+	i := g.inspector.index
+	switch g.inspector.kind {
+	case InspectPlayer:
+		return g.players[i].position, 1.2, true
+	case InspectEnemy:
+		return g.enemies[i].position, g.enemies[i].size, true
+	case InspectBullet:
+		return g.bullets[i].position, 0.5, true
+	case InspectEnemyBullet:
+		return g.enemyBullets[i].position, 0.5, true
+	}
+	return rl.Vector3{}, 0, false
+}
+
+// drawInspectorMarker rings the selected entity (call inside 3D mode).
+func (g *Game) drawInspectorMarker() {
+	if !g.inspectorActive() {
+		return
+	}
+	if pos, radius, ok := g.inspectedPosition(); ok {
+		rl.DrawSphereWires(pos, radius, 6, 10, rl.Yellow)
+	}
+}
+
+func (g *Game) drawInspector() {
+	if !g.inspectorActive() {
+		return
+	}
+
+	fields := g.inspectFields()
+	x, y := int32(380), int32(screenHeight-250)
+	if len(fields) == 0 {
+		drawText("Middle-click an entity to inspect it", x, y+215, 18, rl.LightGray)
+		return
+	}
+
+	height := int32(len(fields)*22 + 40)
+	top := int32(screenHeight-10) - height
+	rl.DrawRectangle(x-5, top-5, 320, height+5, rl.NewColor(0, 0, 0, 180))
+	drawText(fmt.Sprintf("%s #%d", inspectKindNames[g.inspector.kind], g.inspector.index), x, top, 18, rl.Yellow)
+	y = top + 24
+	for i, f := range fields {
+		color := rl.LightGray
+		if f.step == nil {
+			color = rl.Gray
+		}
+		if i == g.inspector.field {
+			color = rl.Yellow
+			drawText(">", x-2, y, 18, color)
+		}
+		drawText(fmt.Sprintf("%-10s %s", f.name, f.get()), x+12, y, 18, color)
+		y += 22
+	}
+	drawText("[ ] field  - = edit  BKSP clear", x, y, 14, rl.Gray)
+}
@@ -0,0 +1,147 @@
+package main
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+// Input latency. raylib polls input at the end of EndDrawing, right after
+// the buffer swap, so on a vsynced 60 Hz display the simulation works on
+// input that is a whole frame old by the time its picture shows. The frame
+// is paced here instead of by raylib's limiter: beginFrameInput waits, then
+// polls input again right before the update.
+//
+//   - Standard pacing starts a frame every 1/60 s, like raylib did.
+//   - Low latency pacing starts the frame as late as the recent worst frame
+//     cost allows, so the update sees input from just before the swap. It
+//     gives up the slack the standard pacing keeps: a frame that runs
+//     longer than predicted misses its vblank instead of absorbing it.
+//
+// Polling twice would drop key/button press edges, wheel movement and
+// typed characters that arrived with the first poll, so the second poll is
+// skipped on frames that have any. Typed characters are drained to find
+// out, and handed out again by charPressed.
+//
+// The debug overlay (F3) shows the measured input-to-present time: from
+// the input poll the update used to the end of the buffer swap.
+const (
+	frameBudget      = 1.0 / 60 // seconds
+	latencyMargin    = 0.001    // seconds kept spare before the deadline
+	workDecay        = 0.995    // per frame, how fast the work estimate relaxes
+	latencySmoothing = float32(0.1)
+	lastKeyCode      = 348 // KEY_MENU, the highest raylib key code
+	lastMouseButton  = 6
+)
+
+// FramePacing is the frame pacing mode.
+type FramePacing int
+
+const (
+	PacingStandard FramePacing = iota
+	PacingLowLatency
+	pacingCount
+)
+
+var pacingNames = [pacingCount]string{"Standard", "Low Latency"}
+
+type LatencyStats struct {
+	wake      float64 // when the current frame started after pacing
+	sampledAt float64 // when this frame's input was polled
+	presented float64 // when the last frame finished presenting
+	work      float64 // recent worst input-to-present time, seconds
+	waited    float64 // pacing wait before sampling, last frame
+	avgMs     float32 // input-to-present, smoothed
+	maxMs     float32 // input-to-present, worst in the last second
+	windowMax float32
+	window    float64
+	chars     []int32 // typed characters from the first poll, for charPressed
+}
+
+// beginFrameInput paces the frame and samples input. Runs at the top of
+// every frame, before the update.
+func (g *Game) beginFrameInput() {
+	l := &g.latency
+	now := rl.GetTime()
+	wake := l.wake + frameBudget
+	if g.settings.pacing == PacingLowLatency {
+		wake = l.presented + frameBudget - l.work - latencyMargin
+	}
+	l.waited = 0
+	if wake > now {
+		l.waited = wake - now
+		rl.WaitTime(l.waited)
+	}
+	// A late frame restarts the cadence instead of rushing to catch up
+	l.wake = max(wake, now)
+
+	l.chars = l.chars[:0]
+	for ch := rl.GetCharPressed(); ch != 0; ch = rl.GetCharPressed() {
+		l.chars = append(l.chars, ch)
+	}
+	if len(l.chars) > 0 || inputEdgePending() {
+		l.sampledAt = l.presented
+		return
+	}
+	rl.PollInputEvents()
+	l.sampledAt = rl.GetTime()
+}
+
+// endFrameInput runs after EndDrawing has presented the frame.
+func (g *Game) endFrameInput() {
+	l := &g.latency
+	now := rl.GetTime()
+	l.presented = now
+
+	work := now - l.sampledAt
+	// Plan for the recent slow frames so the wait rarely overshoots
+	l.work = max(work, l.work*workDecay)
+
+	ms := float32(work * 1000)
+	if l.avgMs == 0 {
+		l.avgMs = ms
+	}
+	l.avgMs += (ms - l.avgMs) * latencySmoothing
+	l.windowMax = max(l.windowMax, ms)
+	l.window += float64(rl.GetFrameTime())
+	if l.window >= 1 {
+		l.maxMs, l.windowMax, l.window = l.windowMax, 0, 0
+	}
+}
+
+// charPressed is rl.GetCharPressed for the update: the next typed
+// character, 0 when there are none.
+func (g *Game) charPressed() int32 {
+	if l := &g.latency; len(l.chars) > 0 {
+		ch := l.chars[0]
+		l.chars = l.chars[1:]
+		return ch
+	}
+	return rl.GetCharPressed()
+}
+
+// inputEdgePending reports whether the last poll saw any key or button go
+// down or up, or the wheel move, which another poll would hide from
+// IsKeyPressed, GetMouseWheelMove and friends.
+func inputEdgePending() bool {
+	if wheel := rl.GetMouseWheelMoveV(); wheel.X != 0 || wheel.Y != 0 {
+		return true
+	}
+	for key := int32(1); key <= lastKeyCode; key++ {
+		if rl.IsKeyPressed(key) || rl.IsKeyReleased(key) {
+			return true
+		}
+	}
+	for b := rl.MouseButton(0); b <= lastMouseButton; b++ {
+		if rl.IsMouseButtonPressed(b) || rl.IsMouseButtonReleased(b) {
+			return true
+		}
+	}
+	for pad := int32(0); pad < maxGamepads; pad++ {
+		if !rl.IsGamepadAvailable(pad) {
+			continue
+		}
+		for b := int32(rl.GamepadButtonLeftFaceUp); b <= int32(rl.GamepadButtonRightThumb); b++ {
+			if rl.IsGamepadButtonPressed(pad, b) || rl.IsGamepadButtonReleased(pad, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
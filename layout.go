@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Keyboard layouts. Bindings store raylib key codes, which are physical key
+// positions named after the US layout (GLFW translates scancodes, not
+// characters), so WASD stays under the same fingers on AZERTY and QWERTZ.
+// The layout only changes how keys are named on screen: the physical W key
+// is labelled "Z" on AZERTY.
+type KeyboardLayout int
+
+const (
+	LayoutQWERTY KeyboardLayout = iota
+	LayoutAZERTY
+	LayoutQWERTZ
+	layoutCount
+)
+
+var layoutNames = [layoutCount]string{"QWERTY", "AZERTY", "QWERTZ"}
+
+// layoutLegends maps physical keys to the legend printed on them where it
+// differs from US QWERTY.
+var layoutLegends = [layoutCount]map[int32]string{
+	LayoutAZERTY: {
+		rl.KeyQ:         "A",
+		rl.KeyA:         "Q",
+		rl.KeyW:         "Z",
+		rl.KeyZ:         "W",
+		rl.KeySemicolon: "M",
+		rl.KeyM:         ",",
+		rl.KeyComma:     ";",
+		rl.KeyPeriod:    ":",
+		rl.KeySlash:     "!",
+	},
+	LayoutQWERTZ: {
+		rl.KeyY:            "Z",
+		rl.KeyZ:            "Y",
+		rl.KeySemicolon:    "Ö",
+		rl.KeyApostrophe:   "Ä",
+		rl.KeyLeftBracket:  "Ü",
+		rl.KeyMinus:        "ß",
+		rl.KeySlash:        "-",
+		rl.KeyRightBracket: "+",
+	},
+}
+
+// activeLayout names keys in prompts; detected at startup, changeable in
+// Settings.
+var activeLayout = detectKeyboardLayout()
+
+// detectKeyboardLayout guesses the layout from the X keyboard or locale
+// environment, falling back to QWERTY.
+func detectKeyboardLayout() KeyboardLayout {
+	for _, env := range []string{"XKB_DEFAULT_LAYOUT", "LC_ALL", "LC_CTYPE", "LANG"} {
+		value := strings.ToLower(os.Getenv(env))
+		if value == "" {
+			continue
+		}
+		fields := strings.FieldsFunc(value, func(r rune) bool { return r == '_' || r == '-' || r == '.' })
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "fr", "be":
+			return LayoutAZERTY
+		case "de", "at", "ch", "cs", "cz", "sk", "hu", "sl", "hr":
+			return LayoutQWERTZ
+		}
+		return LayoutQWERTY
+	}
+	return LayoutQWERTY
+}
+
+// moveKeysLabel names the movement keys in WASD order, e.g. "ZQSD" on AZERTY.
+func (g *Game) moveKeysLabel(playerID int) string {
+	var names []string
+	short := true
+	for _, a := range []Action{ActionMoveUp, ActionMoveLeft, ActionMoveDown, ActionMoveRight} {
+		b := g.controls.binding(playerID, a)
+		if len(b.Keys) == 0 {
+			continue
+		}
+		name := keyName(b.Keys[0])
+		short = short && len([]rune(name)) == 1
+		names = append(names, name)
+	}
+	if short {
+		return strings.Join(names, "")
+	}
+	return strings.Join(names, "/")
+}
+
+// skillKeysLabel names the first key of each skill, e.g. "Q/E/F".
+func (g *Game) skillKeysLabel(playerID int) string {
+	var names []string
+	for a := ActionSkill1; a <= ActionSkill3; a++ {
+		if b := g.controls.binding(playerID, a); len(b.Keys) > 0 {
+			names = append(names, keyName(b.Keys[0]))
+		}
+	}
+	return strings.Join(names, "/")
+}
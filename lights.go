@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Dynamic point lights. Explosions, skills and boss attacks light up the
+// walls and models around them for a moment, and shots in flight carry a
+// small light of their own. The scene is otherwise unlit flat colour, so
+// the lighting shader only ever adds: with no lights about everything
+// looks exactly as it did.
+//
+// Flashes live in a fixed pool; a new one takes a free slot or the one
+// closest to going out. Every frame the flashes, then as many shots as
+// there is room for, are uploaded as the shader's lights. Models get the
+// shader through their materials; walls and other primitives are drawn
+// inside litPrimitives.
+const (
+	maxLights      = 16 // must match the shader
+	maxFlashLights = 10
+	shotLightRange = float32(3.0)
+	blastLightLife = float32(0.35)
+)
+
+const lightingVS = `#version 330
+in vec3 vertexPosition;
+in vec2 vertexTexCoord;
+in vec3 vertexNormal;
+in vec4 vertexColor;
+uniform mat4 mvp;
+uniform mat4 matModel;
+out vec3 fragPosition;
+out vec2 fragTexCoord;
+out vec4 fragColor;
+out vec3 fragNormal;
+
+void main() {
+    fragPosition = vec3(matModel * vec4(vertexPosition, 1.0));
+    fragTexCoord = vertexTexCoord;
+    fragColor = vertexColor;
+    fragNormal = normalize(mat3(matModel) * vertexNormal);
+    gl_Position = mvp * vec4(vertexPosition, 1.0);
+}
+`
+
+// lightingFS adds each light's colour, falling off to nothing at its
+// radius. lights[i] is the position and radius; a radius of 0 is an
+// unused slot.
+const lightingFS = `#version 330
+in vec3 fragPosition;
+in vec2 fragTexCoord;
+in vec4 fragColor;
+in vec3 fragNormal;
+uniform sampler2D texture0;
+uniform vec4 colDiffuse;
+uniform vec4 lights[16];
+uniform vec3 lightColors[16];
+out vec4 finalColor;
+
+void main() {
+    vec4 base = texture(texture0, fragTexCoord) * colDiffuse * fragColor;
+    vec3 n = normalize(fragNormal);
+    vec3 added = vec3(0.0);
+    for (int i = 0; i < 16; i++) {
+        if (lights[i].w <= 0.0) continue;
+        vec3 d = lights[i].xyz - fragPosition;
+        float dist = length(d);
+        float fall = clamp(1.0 - dist / lights[i].w, 0.0, 1.0);
+        float facing = 0.3 + 0.7 * max(dot(n, d / max(dist, 0.001)), 0.0);
+        added += lightColors[i] * fall * fall * facing;
+    }
+    finalColor = vec4(base.rgb * (1.0 + added) + added * 0.15, base.a);
+}
+`
+
+type PointLight struct {
+	position rl.Vector3
+	color    rl.Color
+	radius   float32
+	life     float32
+	maxLife  float32
+}
+
+type Lights struct {
+	flashes [maxFlashLights]PointLight
+
+	shader    rl.Shader
+	lightsLoc int32
+	colorsLoc int32
+	loaded    bool
+}
+
+// loadLighting loads the lighting shader and puts it on every model.
+func (g *Game) loadLighting() {
+	l := &g.lights
+	l.shader = rl.LoadShaderFromMemory(lightingVS, lightingFS)
+	if !rl.IsShaderValid(l.shader) {
+		fmt.Println("Warning: lighting shader failed to compile, drawing unlit")
+		return
+	}
+	l.lightsLoc = rl.GetShaderLocation(l.shader, "lights")
+	l.colorsLoc = rl.GetShaderLocation(l.shader, "lightColors")
+	l.loaded = true
+
+	models := []rl.Model{g.playerModel, g.enemyModel, g.bossModel, g.finalBossModel}
+	models = append(models, g.weaponModels[:]...)
+	models = append(models, g.propModels[:]...)
+	for _, m := range models {
+		for i, mats := 0, m.GetMaterials(); i < len(mats); i++ {
+			mats[i].Shader = l.shader
+		}
+	}
+}
+
+// release frees the shader. Called after the models are unloaded.
+func (l *Lights) release() {
+	if l.loaded {
+		rl.UnloadShader(l.shader)
+		l.loaded = false
+	}
+}
+
+// addLight flashes a point light at pos.
+func (g *Game) addLight(pos rl.Vector3, color rl.Color, radius, life float32) {
+	slot := 0
+	for i := range g.lights.flashes {
+		if g.lights.flashes[i].life < g.lights.flashes[slot].life {
+			slot = i
+		}
+	}
+	g.lights.flashes[slot] = PointLight{position: pos, color: color, radius: radius, life: life, maxLife: life}
+}
+
+func (g *Game) updateLights(dt float32) {
+	for i := range g.lights.flashes {
+		if f := &g.lights.flashes[i]; f.life > 0 {
+			f.life -= dt
+		}
+	}
+}
+
+// uploadLights hands this frame's lights to the shader. Called once per
+// frame before the 3D pass.
+func (g *Game) uploadLights() {
+	l := &g.lights
+	if !l.loaded {
+		return
+	}
+	lights := make([]float32, 0, maxLights*4)
+	colors := make([]float32, 0, maxLights*3)
+	add := func(pos rl.Vector3, color rl.Color, radius, strength float32) {
+		if len(lights) < cap(lights) {
+			lights = append(lights, pos.X, pos.Y, pos.Z, radius)
+			colors = append(colors,
+				float32(color.R)/255*strength, float32(color.G)/255*strength, float32(color.B)/255*strength)
+		}
+	}
+	for i := range l.flashes {
+		if f := &l.flashes[i]; f.life > 0 {
+			add(f.position, f.color, f.radius, 1.5*f.life/f.maxLife)
+		}
+	}
+	for i := range g.bullets {
+		if b := &g.bullets[i]; b.active {
+			add(b.position, g.bulletColor(b.playerId), shotLightRange, 0.8)
+		}
+	}
+	for i := range g.enemyBullets {
+		if g.enemyBullets[i].active {
+			add(g.enemyBullets[i].position, rl.Magenta, shotLightRange, 0.8)
+		}
+	}
+	for len(lights) < cap(lights) {
+		lights = append(lights, 0, 0, 0, 0)
+		colors = append(colors, 0, 0, 0)
+	}
+	rl.SetShaderValueV(l.shader, l.lightsLoc, lights, rl.ShaderUniformVec4, maxLights)
+	rl.SetShaderValueV(l.shader, l.colorsLoc, colors, rl.ShaderUniformVec3, maxLights)
+}
+
+// litPrimitives draws cubes, spheres and the like with the lighting.
+// Models set the model matrix and diffuse colour per draw, so both are
+// reset for the primitives, which arrive already in world space.
+func (g *Game) litPrimitives(draw func()) {
+	l := &g.lights
+	if !l.loaded {
+		draw()
+		return
+	}
+	rl.SetShaderValueMatrix(l.shader, l.shader.GetLocation(rl.ShaderLocMatrixModel), rl.MatrixIdentity())
+	rl.SetShaderValue(l.shader, l.shader.GetLocation(rl.ShaderLocColorDiffuse), []float32{1, 1, 1, 1}, rl.ShaderUniformVec4)
+	rl.BeginShaderMode(l.shader)
+	draw()
+	rl.EndShaderMode()
+}
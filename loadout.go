@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/base32"
+	"fmt"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Loadout codes. A player's build (stat upgrades taken, weapons carried and
+// the one in hand) packs into a 64-bit field and prints as base32 in groups
+// of five. The game over screen shows the code and C copies it;
+// Ctrl+V on the main menu (or -loadout) starts a solo run with a pasted
+// build so a friend's build can be tried out.
+//
+// Layout from the low bit: 4 bits version, 5x5 bits upgrade counts,
+// weaponCount bits of carried weapons, 4 bits weapon in hand, 6 bits
+// checksum.
+const (
+	loadoutVersion    = 1
+	upgradeKinds      = 5 // stat upgrades on the upgrade screen
+	maxUpgradeCount   = 31
+	loadoutGroupChars = 5
+)
+
+var loadoutEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type Loadout struct {
+	upgrades [upgradeKinds]int
+	weapons  []WeaponType
+	weapon   WeaponType
+}
+
+func playerLoadout(p *Player) Loadout {
+	return Loadout{upgrades: p.upgrades, weapons: p.weapons, weapon: p.weapon}
+}
+
+func (l Loadout) bits() uint64 {
+	var v uint64 = loadoutVersion
+	shift := 4
+	for _, n := range l.upgrades {
+		v |= uint64(min(n, maxUpgradeCount)) << shift
+		shift += 5
+	}
+	for _, w := range l.weapons {
+		v |= 1 << (shift + int(w))
+	}
+	shift += int(weaponCount)
+	v |= uint64(l.weapon) << shift
+	shift += 4
+	return v | loadoutChecksum(v)<<shift
+}
+
+// loadoutChecksum catches most typos in a pasted code.
+func loadoutChecksum(v uint64) uint64 {
+	sum := uint64(0)
+	for ; v > 0; v >>= 6 {
+		sum = (sum*7 + v&63) % 61
+	}
+	return sum
+}
+
+// Code formats the loadout in dash-separated groups.
+func (l Loadout) Code() string {
+	v := l.bits()
+	raw := make([]byte, 8)
+	for i := range raw {
+		raw[i] = byte(v >> (8 * i))
+	}
+	// Trailing zero bytes carry nothing
+	for len(raw) > 1 && raw[len(raw)-1] == 0 {
+		raw = raw[:len(raw)-1]
+	}
+	text := loadoutEncoding.EncodeToString(raw)
+
+	var groups []string
+	for len(text) > loadoutGroupChars {
+		groups = append(groups, text[:loadoutGroupChars])
+		text = text[loadoutGroupChars:]
+	}
+	return strings.Join(append(groups, text), "-")
+}
+
+// ParseLoadout reads a code back, ignoring case, dashes and spaces.
+func ParseLoadout(code string) (Loadout, error) {
+	code = strings.ToUpper(strings.NewReplacer("-", "", " ", "", "\n", "").Replace(code))
+	raw, err := loadoutEncoding.DecodeString(code)
+	if err != nil || len(raw) == 0 || len(raw) > 8 {
+		return Loadout{}, fmt.Errorf("not a loadout code")
+	}
+	var v uint64
+	for i, b := range raw {
+		v |= uint64(b) << (8 * i)
+	}
+
+	if v&15 != loadoutVersion {
+		return Loadout{}, fmt.Errorf("unknown loadout version %d", v&15)
+	}
+	shift := 4
+	var l Loadout
+	for i := range l.upgrades {
+		l.upgrades[i] = int(v >> shift & 31)
+		shift += 5
+	}
+	for w := WeaponType(0); w < weaponCount; w++ {
+		if v>>(shift+int(w))&1 == 1 {
+			l.weapons = append(l.weapons, w)
+		}
+	}
+	shift += int(weaponCount)
+	l.weapon = WeaponType(v >> shift & 15)
+	shift += 4
+
+	body := v & (1<<shift - 1)
+	if v>>shift != loadoutChecksum(body) {
+		return Loadout{}, fmt.Errorf("loadout code has a typo")
+	}
+	if l.weapon >= weaponCount || len(l.weapons) == 0 {
+		return Loadout{}, fmt.Errorf("loadout has no weapon")
+	}
+	return l, nil
+}
+
+// applyLoadout rebuilds a fresh player's stats and arsenal from a loadout.
+func (g *Game) applyLoadout(p *Player, l Loadout) {
+	_, maxHealth := difficultyStart(g.runDifficulty)
+	p.stats = basePlayerStats()
+	p.stats.maxHealth = maxHealth
+	for choice, n := range l.upgrades {
+		for i := 0; i < n; i++ {
+			p.stats = upgradeStats(p.stats, choice)
+		}
+	}
+	p.upgrades = l.upgrades
+	p.health = p.stats.maxHealth
+	p.weapons = append([]WeaponType(nil), l.weapons...)
+	p.weapon = l.weapon
+	if !p.hasWeapon(l.weapon) {
+		p.weapon = l.weapons[0]
+	}
+	p.fillAmmo()
+}
+
+func (p *Player) hasWeapon(w WeaponType) bool {
+	for _, have := range p.weapons {
+		if have == w {
+			return true
+		}
+	}
+	return false
+}
+
+// startWithLoadout starts a solo run on the pasted build. Builds change the
+// run, so it doesn't count toward seed records, the high score or scrap.
+func (g *Game) startWithLoadout(code string) bool {
+	l, err := ParseLoadout(code)
+	if err != nil {
+		fmt.Println("Warning: Could not load build:", err)
+		g.loadoutError = err.Error()
+		return false
+	}
+	g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, false, false, false
+	g.StartGame(false)
+	g.seeded = false
+	g.applyLoadout(&g.players[0], l)
+	g.loadoutRun = true
+	g.loadoutError = ""
+	return true
+}
+
+// drawLoadoutCodes shows each player's build code on the game over screen.
+func (g *Game) drawLoadoutCodes(y int32) {
+	for i := range g.players {
+		text := fmt.Sprintf("P%d Build: %s", i+1, playerLoadout(&g.players[i]).Code())
+		drawText(text, screenWidth/2-measureText(text, 22)/2, y, 22, rl.SkyBlue)
+		y += 28
+	}
+	drawText("Press C to copy P1 build", screenWidth/2-measureText("Press C to copy P1 build", 18)/2, y, 18, rl.Gray)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Boss loot. Every boss drops a loot chest where it died. The player who
+// walks over it picks one of three rewards from the loot table in a small
+// overlay: a rare (double) stat upgrade, a weapon they don't carry yet, or
+// a full heal. The game waits while they choose.
+
+// powerUpLoot is the pType of boss loot chests.
+const powerUpLoot = 5
+
+const rareUpgradeSteps = 2 // a rare upgrade counts as this many normal ones
+
+type LootKind int
+
+const (
+	LootRareUpgrade LootKind = iota
+	LootWeapon
+	LootFullHeal
+	lootKindCount
+)
+
+// LootChoice is one reward on offer.
+type LootChoice struct {
+	kind   LootKind
+	stat   int // upgrade choice for rare upgrades
+	weapon WeaponType
+}
+
+// BossLoot is the chest being opened.
+type BossLoot struct {
+	player  int // index into players
+	choices [lootKindCount]LootChoice
+}
+
+// dropBossLoot puts a loot chest where the boss died. Called from the
+// KillEnemy boss branch.
+func (g *Game) dropBossLoot(pos rl.Vector3) {
+	for i := range g.powerUps {
+		if g.powerUps[i].active {
+			continue
+		}
+		g.powerUps[i] = PowerUp{position: rl.NewVector3(pos.X, 1, pos.Z), pType: powerUpLoot, active: true}
+		return
+	}
+}
+
+// rollBossLoot fills the loot table for a player: one of each kind.
+func (g *Game) rollBossLoot(player *Player) [lootKindCount]LootChoice {
+	var missing []WeaponType
+	for w := WeaponType(1); w < baseWeaponCount; w++ {
+		if !slices.ContainsFunc(player.weapons, func(c WeaponType) bool { return baseWeapon(c) == w }) {
+			missing = append(missing, w)
+		}
+	}
+	weapon := g.rollWeaponDrop()
+	if len(missing) > 0 {
+		weapon = missing[g.rng.Intn(len(missing))]
+	}
+	return [lootKindCount]LootChoice{
+		LootRareUpgrade: {kind: LootRareUpgrade, stat: g.rng.Intn(upgradeKinds)},
+		LootWeapon:      {kind: LootWeapon, weapon: weapon},
+		LootFullHeal:    {kind: LootFullHeal},
+	}
+}
+
+// openBossLoot shows the loot overlay for the player who touched the chest.
+func (g *Game) openBossLoot(pIdx int) {
+	g.loot = BossLoot{player: pIdx, choices: g.rollBossLoot(&g.players[pIdx])}
+	g.state = StateLoot
+}
+
+func (c LootChoice) label() string {
+	switch c.kind {
+	case LootRareUpgrade:
+		return "Rare: " + upgradeNames[c.stat] + fmt.Sprintf(" x%d", rareUpgradeSteps)
+	case LootWeapon:
+		return "Weapon: " + weaponDefs[c.weapon].name
+	default:
+		return "Full Heal"
+	}
+}
+
+func (g *Game) applyLoot(c LootChoice) {
+	player := &g.players[g.loot.player]
+	switch c.kind {
+	case LootRareUpgrade:
+		for n := 0; n < rareUpgradeSteps; n++ {
+			player.stats = upgradeStats(player.stats, c.stat)
+		}
+		player.upgrades[c.stat] += rareUpgradeSteps
+	case LootWeapon:
+		g.pickUpWeapon(player, c.weapon)
+	case LootFullHeal:
+		player.health = player.stats.maxHealth
+	}
+	g.CreateExplosion(player.position, rl.Gold, 20)
+	g.playSound(g.sounds.powerup)
+	g.state = StatePlaying
+}
+
+func (g *Game) UpdateLoot() {
+	keys := [lootKindCount]int32{rl.KeyOne, rl.KeyTwo, rl.KeyThree}
+	for i, key := range keys {
+		if rl.IsKeyPressed(key) {
+			g.recordReplayEvent(ReplayEvent{Kind: EventLoot, Value: i})
+			g.applyLoot(g.loot.choices[i])
+			return
+		}
+	}
+}
+
+// drawLootChest draws a boss loot chest with a glow so it stands out from
+// normal power-ups.
+func (g *Game) drawLootChest(pos rl.Vector3) {
+	glow := 0.5 + 0.5*float32(math.Sin(float64(g.gameTime)*4))
+	rl.DrawCube(pos, 1.2, 0.9, 0.9, rl.Gold)
+	rl.DrawCubeWires(pos, 1.2, 0.9, 0.9, rl.Orange)
+	rl.DrawSphereWires(pos, 1.1+glow*0.2, 6, 8, rl.Fade(rl.Gold, 0.3+glow*0.3))
+}
+
+func (g *Game) DrawLoot() {
+	centerX := int32(screenWidth / 2)
+	top := int32(screenHeight/2 - 120)
+	rl.DrawRectangle(centerX-300, top, 600, 240, rl.NewColor(0, 0, 0, 200))
+	rl.DrawRectangleLines(centerX-300, top, 600, 240, rl.Gold)
+
+	title := "BOSS LOOT"
+	if len(g.players) > 1 {
+		title = fmt.Sprintf("BOSS LOOT - P%d", g.loot.player+1)
+	}
+	drawText(title, centerX-measureText(title, 36)/2, top+15, 36, rl.Gold)
+	for i, c := range g.loot.choices {
+		drawText(fmt.Sprintf("[%d] %s", i+1, c.label()), centerX-260, top+75+int32(i)*45, 28, rl.White)
+	}
+}
@@ -1,10 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"math"
 	"math/rand"
 	"os"
+	"strings"
 	"time"
 
 	rl "github.com/gen2brain/raylib-go/raylib"
@@ -20,6 +22,18 @@ const (
 	StatePaused
 	StateUpgrade
 	StateGameOver
+	StateControls
+	StateBuild
+	StateAttract   // arcade mode only
+	StateNameEntry // arcade mode only
+	StateVictory
+	StateLoot
+	StateSkillTree
+	StateMastery
+	StateCharacter
+	StateArmory
+	StateAssetCheck // release builds with damaged files (assetcheck.go)
+	StateSandbox    // practice range stat panel (practice.go)
 )
 
 // Stage Types - เปลี่ยนทุก 20 level
@@ -30,8 +44,12 @@ const (
 	StageMaze
 	StageHazard
 	StageArena
+	StageNight
+	stageCount
 )
 
+var stageNames = [...]string{"Basic", "Maze", "Hazard", "Arena", "Night"}
+
 // Data structures
 type Player struct {
 	position rl.Vector3
@@ -55,35 +73,109 @@ type Player struct {
 	tiltAngle float32 // มุมเอียงซ้าย-ขวา
 
 	// เพิ่มฟิลด์สำหรับแอนิเมชั่น
-	animTime    float32
+	animTime    float32 // walk cycle; drives the bob and footsteps (surfaces.go)
 	isMoving    bool
 	walkBobAmp  float32 // ความสูงของการกระเด้ง
 	walkBobFreq float32 // ความเร็วของการกระเด้ง
+
+	queued  QueuedAction      // buffered action waiting to become legal
+	toggled [actionCount]bool // on/off state of toggle-mode actions
+
+	// Weapons carried and the one in hand
+	weapons []WeaponType
+	weapon  WeaponType
+
+	// Laser beam state
+	beamFiring bool
+	beamTimer  float32
+	beamEnd    rl.Vector3
+	heat       float32
+	overheated bool
+
+	// Ammo modifier: rounds in the magazine and reserve per weapon
+	ammo        [weaponCount]int
+	reserve     [weaponCount]int
+	reloading   bool
+	reloadTimer float32
+
+	grenadeCooldown float32
+
+	weaponXP [weaponCount]int  // evolution progress, kept for the whole run
+	upgrades [upgradeKinds]int // stat upgrades taken, for loadout codes
+	owned    []int             // upgradePool entries taken, in order
+	traits   Trait             // from owned upgrades (synergy.go)
+
+	// Sprint modifier
+	stamina     float32
+	staminaWait float32 // recovery delay before stamina regenerates
+	exhausted   bool
+	energy      float32
+	energyWait  float32 // pause before energy refills
+
+	finalStand float32 // time left in the final stand
+	standUsed  bool
+	lowHealth  bool // below heartbeatShare of max health (audio.go)
+
+	shield      int     // overshield HP, taken before health
+	shieldTimer float32 // time until the overshield wears off
+
+	dash Dash
+
+	portalCooldown float32    // portals.go
+	slide          rl.Vector3 // velocity carried over ice (floorzones.go)
+	anim           Animator   // anim.go
+
+	unlocked    [skillNodeCount]bool // skill tree nodes taken this run
+	skillPoints int
 }
 
 type Enemy struct {
-	position  rl.Vector3
-	velocity  rl.Vector3
-	active    bool
-	health    int
-	maxHealth int
-	size      float32
-	color     rl.Color
-	isBoss    bool
-	model     rl.Model
-	hasModel  bool
+	position   rl.Vector3
+	velocity   rl.Vector3
+	active     bool
+	health     int
+	maxHealth  int
+	size       float32
+	color      rl.Color
+	isBoss     bool
+	kind       EnemyKind
+	phase      EnemyPhase
+	phaseTimer float32 // time left in the current phase
+	facing     float32 // shielded: direction the shield points
+	shotTimer  float32 // ranged: time until the next shot
+	model      rl.Model
+	hasModel   bool
 
 	// Added: per-enemy model scale and yaw offset (set on spawn)
 	modelScale        float32
 	modelYawOffsetDeg float32
+
+	// Last weapon hit, for weapon XP on kill
+	hitCredited bool
+	lastHitBy   int
+	lastWeapon  WeaponType
+
+	portalCooldown float32    // portals.go
+	slide          rl.Vector3 // velocity carried over ice (floorzones.go)
+	anim           Animator   // anim.go
 }
 
 type Bullet struct {
 	position rl.Vector3
 	velocity rl.Vector3
+	origin   rl.Vector3 // where it was fired, for range and falloff
 	active   bool
 	damage   int
 	playerId int
+	weapon   WeaponType
+	kind     ProjectileKind
+	target   int // homing: enemy index being chased, -1 = none
+	crit     bool
+	pierce   int              // enemies it can still pass through
+	hit      [pierceCount]int // enemies it has passed through, so overlaps don't hit twice
+	hits     int
+	tracked  bool // fired from a weapon; skill and drone shots don't count toward accuracy
+	landed   bool // has hit an enemy, so further hits aren't counted again
 }
 
 type Particle struct {
@@ -99,16 +191,19 @@ type PowerUp struct {
 	active   bool
 	pType    int
 	rotation float32
+	weapon   WeaponType // weapon pickups only
 }
 
 type Obstacle struct {
 	position rl.Vector3
 	size     rl.Vector3
 	active   bool
-	obsType  int // 0=wall, 1=hazard
+	obsType  int          // 0=wall, 1=hazard, 2=crate (destructible), 3=crusher, 4=laser
+	motion   HazardMotion // crushers and lasers only (movinghazards.go)
 }
 
 type Skill struct {
+	id          SkillID
 	name        string
 	cooldown    float32
 	maxCooldown float32
@@ -125,25 +220,40 @@ type PlayerStats struct {
 }
 
 type SoundSystem struct {
-	shoot       rl.Sound
-	explosion   rl.Sound
-	hit         rl.Sound
-	powerup     rl.Sound
-	skill       rl.Sound
-	boss        rl.Sound
-	menuBGM     rl.Music
-	gameBGM     rl.Music
+	shoot       Sfx
+	explosion   Sfx
+	hit         Sfx
+	powerup     Sfx
+	skill       Sfx
+	boss        Sfx
+	uiMove      Sfx
+	uiSelect    Sfx
+	shieldBreak Sfx
+	heartbeat   Sfx               // loops while a player is low on health
+	aliases     []rl.Sound        // extra instances of every Sfx (audiomanifest.go)
+	steps       [surfaceCount]Sfx // footsteps per surface
+	impacts     [surfaceCount]Sfx // bullet impacts per surface
+	menuBGM     MusicTrack
+	gameBGM     MusicTrack
+	bossBGM     MusicTrack                  // boss fights; game BGM plays on without it
+	gameLayers  [musicLayerCount]MusicTrack // adaptive stems over game BGM (musiclayers.go)
 	enabled     bool
-	menuPlaying bool
-	gamePlaying bool
 }
 
 type Settings struct {
 	soundEnabled bool
 	musicEnabled bool
-	soundVolume  float32
-	musicVolume  float32
-	difficulty   int // 0=Easy, 1=Normal, 2=Hard
+	volumes      [busCount]float32 // per mixer bus (audio.go)
+	difficulty   int               // 0=Easy, 1=Normal, 2=Hard
+	inputBuffer  float32           // seconds an early press stays queued (0 = off)
+	modifiers    RunModifiers
+	streamerMode bool        // hide the seed, big HUD, stats files (streamer.go)
+	palette      int         // index into palettes (palette.go)
+	dynamicRes   bool        // scale the 3D scene to hold 60 FPS (resscale.go)
+	pacing       FramePacing // latency.go
+	autosave     int         // index into autosaveIntervals (profile.go)
+	postFX       bool        // bloom and the low health vignette (postfx.go)
+	shake        int         // index into shakeLevels (camera.go)
 }
 
 // Constants
@@ -151,7 +261,7 @@ const (
 	screenWidth   = 1800
 	screenHeight  = 1028
 	maxEnemies    = 80  // เพิ่มจำนวน
-	maxBullets    = 20  // เพิ่มจำนวน
+	maxBullets    = 150 // spread shots need many pellets
 	maxParticles  = 200 // ลดลงเพื่อ performance
 	maxPowerUps   = 5
 	maxObstacles  = 30
@@ -178,6 +288,8 @@ type Game struct {
 	players           []Player
 	enemies           []Enemy
 	bullets           []Bullet
+	grenades          []Grenade
+	enemyBullets      []EnemyBullet
 	particles         []Particle
 	powerUps          []PowerUp
 	obstacles         []Obstacle
@@ -192,15 +304,144 @@ type Game struct {
 	gameTime          float32
 	bossActive        bool
 	bossSpawned       bool
-	upgradeChoice     int
+	upgradeOffer      [upgradeOfferSize]int // indices into upgradePool (upgrades.go)
 	coopMode          bool
 	menuSelection     int
 	settingsSelection int
+	settingsInRun     bool // settings opened from the pause menu (pause.go)
+	pauseSelection    int
 	currentStage      StageType
 	playerModel       rl.Model
 	enemyModel        rl.Model
 	bossModel         rl.Model
+	finalBossModel    rl.Model
+	finalBossLoaded   bool
+	weaponModels      [weaponCount]rl.Model // pickup models, optional
+	playerAnims       *ModelAnims           // animation clips, nil without (anim.go)
+	enemyAnims        *ModelAnims
+	bossAnims         *ModelAnims
 	modelsLoaded      bool
+
+	// Seeded runs: gameplay randomness comes from rng so a seed replays the same run
+	rng              *rand.Rand
+	rngSource        *countingSource // counts draws for suspended runs
+	seed             int64
+	fixedSeed        int64 // from -seed, 0 = random
+	seeded           bool
+	loadoutRun       bool // started from a pasted build code (loadout.go)
+	runDifficulty    int  // difficulty the run is being played on
+	recordDifficulty int  // lowest difficulty the run was played on; records go under it
+	daily            bool
+	bossRushMode     bool
+	rush             BossRush
+	possessMode      bool
+	possess          Possessor
+	practiceMode     bool
+	practice         Practice
+	runStats         RunStats
+	night            Night  // Night stage lighting (night.go)
+	lights           Lights // dynamic point lights (lights.go)
+	post             PostFX
+	audio            AudioManifest // sound event files (audiomanifest.go)
+	musicIntensity   float32       // smoothed, drives the music layers
+	textures         StageTextures // per-stage floor and wall textures (textures.go)
+	hazardTick       float32       // contact damage timer for moving hazards
+	autosave         Autosave
+	quit             bool    // Quit was picked; the main loop ends and shuts down
+	statsHold        float32 // how long TAB has been held during play
+	loot             BossLoot
+	skillView        SkillTreeView
+	charView         CharacterView
+	assetCheck       AssetCheck
+	armory           Armory // scrap and permanent buys (armory.go)
+	armoryView       ArmoryView
+	updates          UpdateCheck // updates.go
+	mastery          [baseWeaponCount]WeaponMastery
+	masteryNote      string
+	masteryNoteTimer float32
+	victoryTime      float32 // time on the ending screen
+	records          map[string]SeedRecord
+	pace             []int
+	ghost            [][]GhostFrame
+	newSeedBest      bool
+
+	loadoutError  string // why the last pasted build code was rejected
+	shareCardPath string // last share card saved from the game over screen
+
+	twitch *TwitchChat // nil unless -twitch is set
+	stream StreamFeed
+	remote *RemoteControl // nil unless -remote is set
+	arcade *Arcade        // nil unless -arcade is set
+
+	controls          Controls
+	controlsSelection int
+	controlsPlayer    int
+	controlsPreset    int
+	rebinding         bool
+
+	// Camera framing (camera.go)
+	stageHalf       float32 // half size of the playable floor
+	cameraDistance  float32
+	manualDistance  float32
+	manualZoomTimer float32
+	trauma          float32 // screen shake, 0-1
+
+	combo Combo // kill streak and score multiplier (combo.go)
+
+	// Spatial index of enemies, rebuilt every frame
+	enemyGrid  *SpatialGrid
+	blastHits  []int
+	burstHits  []int        // crit bursts (synergy.go)
+	burstQueue []rl.Vector3 // Chain Reaction bursts still to go off
+	neighbours []int        // separation queries (ai.go)
+
+	chunks *ChunkStreamer // streamed stage geometry
+
+	pads PadState // controller hot-plug and assignment (gamepad.go)
+
+	assets    *AssetCache // memory accounting for loaded assets
+	mixer     Mixer
+	showDebug bool
+	showPerf  bool
+	res       ResScaler
+	devices   DeviceWatch // minimize, display and audio device changes (devices.go)
+	latency   LatencyStats
+	perf      PerfStats
+
+	debugTools bool // -debug: inspector and other developer tools
+	inspector  Inspector
+	time       TimeControl
+
+	// Damage and death positions across runs (heatmap.go)
+	heatmap      map[string]*HeatGrid
+	heatmapDirty bool
+	showHeatmap  bool
+
+	nests      []Nest
+	portals    []Portal
+	floorZones []FloorZone
+	props      []Prop // scenery (props.go)
+	corpses    []Corpse
+	propModels [propKindCount]rl.Model
+	bossShells []BossShell
+	emitters   []BulletEmitter // boss bullet patterns (patterns.go)
+	telegraphs []Telegraph     // ground attack warnings (telegraph.go)
+
+	bossLib   bossLibrary   // scripted boss definitions (bosses.go)
+	layouts   []StageLayout // layouts from assets/stages (stagefiles.go)
+	layout    *StageLayout  // the current stage's, nil = built-in
+	bossFight BossFight
+
+	structures  []Structure // player-built defenses (defenses.go)
+	build       BuildMode
+	drones      []Drone      // companions (drone.go)
+	attachments []Attachment // ongoing effects on players and enemies (effects.go)
+	mutators    []Mutator    // rule changes for the current run (mutators.go)
+
+	// Daily run input replays (replay.go)
+	recording     *Replay
+	playback      *Replay
+	playbackFrame int
 }
 
 func NewGame() *Game {
@@ -208,19 +449,44 @@ func NewGame() *Game {
 		state:             StateMenu,
 		enemies:           make([]Enemy, maxEnemies),
 		bullets:           make([]Bullet, maxBullets),
+		grenades:          make([]Grenade, maxGrenades),
+		enemyBullets:      make([]EnemyBullet, maxEnemyBullets),
+		nests:             make([]Nest, maxNests),
+		portals:           make([]Portal, maxPortals),
+		floorZones:        make([]FloorZone, maxFloorZones),
+		structures:        make([]Structure, maxStructures),
+		bossShells:        make([]BossShell, maxBossShells),
+		emitters:          make([]BulletEmitter, maxEmitters),
+		telegraphs:        make([]Telegraph, maxTelegraphs),
 		particles:         make([]Particle, maxParticles),
 		powerUps:          make([]PowerUp, maxPowerUps),
 		obstacles:         make([]Obstacle, maxObstacles),
+		enemyGrid:         NewSpatialGrid(5.0),
+		assets:            NewAssetCache(defaultAssetBudgetMB),
+		mixer:             newMixer(),
+		pads:              newPadState(),
 		menuSelection:     0,
 		settingsSelection: 0,
 		currentStage:      StageBasic,
+		stageHalf:         defaultStageHalf,
 		settings: Settings{
 			soundEnabled: true,
 			musicEnabled: true,
-			soundVolume:  0.5,
-			musicVolume:  0.3,
-			difficulty:   1,
+			volumes: [busCount]float32{
+				BusMusic: 0.3,
+				BusSFX:   0.5,
+				BusUI:    0.5,
+				BusVoice: 0.8,
+			},
+			difficulty:  1,
+			inputBuffer: 0.15,
+			dynamicRes:  true,
+			autosave:    1,
+			postFX:      true,
+			shake:       2,
 		},
+		res:     ResScaler{scale: 1},
+		devices: newDeviceWatch(),
 	}
 
 	// Isometric camera setup
@@ -232,9 +498,31 @@ func NewGame() *Game {
 		Projection: rl.CameraPerspective,
 	}
 
+	g.newRunRNG(time.Now().UnixNano(), 0)
+	g.loadSettings()
+	recoverAutosave()
+	g.loadRecords()
+	g.loadMastery()
+	g.loadArmory()
+	g.loadUpdateCheck()
+	g.loadBossRushTimes()
+	g.loadHeatmap()
+	g.loadControls()
+	g.loadBosses()
+	g.loadStageLayouts()
+
 	// Load sounds and models
 	g.loadSounds()
 	g.loadModels()
+	g.loadModelAnimations()
+	g.loadFinalBossModel()
+	if !hasSuspendedRun() {
+		g.menuSelection = 1
+	}
+	g.loadWeaponModels()
+	g.loadPropModels()
+	g.loadLighting()
+	g.chunks = NewChunkStreamer(g.assets)
 
 	return g
 }
@@ -259,22 +547,22 @@ func (g *Game) loadModels() {
 	// Player model
 	playerLoaded := false
 	if fileExists("assets/models/player.glb") {
-		g.playerModel = rl.LoadModel("assets/models/player.glb")
+		g.playerModel = g.assets.loadModel("assets/models/player.glb")
 		playerLoaded = true
 		g.modelsLoaded = true
 		fmt.Println("✓ Loaded: player.glb")
 	} else if fileExists("assets/models/player.gltf") {
-		g.playerModel = rl.LoadModel("assets/models/player.gltf")
+		g.playerModel = g.assets.loadModel("assets/models/player.gltf")
 		playerLoaded = true
 		g.modelsLoaded = true
 		fmt.Println("✓ Loaded: player.gltf")
 	} else if fileExists("assets/models/player.fbx") {
-		g.playerModel = rl.LoadModel("assets/models/player.fbx")
+		g.playerModel = g.assets.loadModel("assets/models/player.fbx")
 		playerLoaded = true
 		g.modelsLoaded = true
 		fmt.Println("✓ Loaded: player.fbx")
 	} else if fileExists("assets/models/player.obj") {
-		g.playerModel = rl.LoadModel("assets/models/player.obj")
+		g.playerModel = g.assets.loadModel("assets/models/player.obj")
 		playerLoaded = true
 		g.modelsLoaded = true
 		fmt.Println("✓ Loaded: player.obj")
@@ -283,19 +571,19 @@ func (g *Game) loadModels() {
 	// Enemy model
 	enemyLoaded := false
 	if fileExists("assets/models/enemy.glb") {
-		g.enemyModel = rl.LoadModel("assets/models/enemy.glb")
+		g.enemyModel = g.assets.loadModel("assets/models/enemy.glb")
 		enemyLoaded = true
 		fmt.Println("✓ Loaded: enemy.glb")
 	} else if fileExists("assets/models/enemy.gltf") {
-		g.enemyModel = rl.LoadModel("assets/models/enemy.gltf")
+		g.enemyModel = g.assets.loadModel("assets/models/enemy.gltf")
 		enemyLoaded = true
 		fmt.Println("✓ Loaded: enemy.gltf")
 	} else if fileExists("assets/models/enemy.fbx") {
-		g.enemyModel = rl.LoadModel("assets/models/enemy.fbx")
+		g.enemyModel = g.assets.loadModel("assets/models/enemy.fbx")
 		enemyLoaded = true
 		fmt.Println("✓ Loaded: enemy.fbx")
 	} else if fileExists("assets/models/enemy.obj") {
-		g.enemyModel = rl.LoadModel("assets/models/enemy.obj")
+		g.enemyModel = g.assets.loadModel("assets/models/enemy.obj")
 		enemyLoaded = true
 		fmt.Println("✓ Loaded: enemy.obj")
 	}
@@ -303,19 +591,19 @@ func (g *Game) loadModels() {
 	// Boss model
 	bossLoaded := false
 	if fileExists("assets/models/boss.glb") {
-		g.bossModel = rl.LoadModel("assets/models/boss.glb")
+		g.bossModel = g.assets.loadModel("assets/models/boss.glb")
 		bossLoaded = true
 		fmt.Println("✓ Loaded: boss.glb")
 	} else if fileExists("assets/models/boss.gltf") {
-		g.bossModel = rl.LoadModel("assets/models/boss.gltf")
+		g.bossModel = g.assets.loadModel("assets/models/boss.gltf")
 		bossLoaded = true
 		fmt.Println("✓ Loaded: boss.gltf")
 	} else if fileExists("assets/models/boss.fbx") {
-		g.bossModel = rl.LoadModel("assets/models/boss.fbx")
+		g.bossModel = g.assets.loadModel("assets/models/boss.fbx")
 		bossLoaded = true
 		fmt.Println("✓ Loaded: boss.fbx")
 	} else if fileExists("assets/models/boss.obj") {
-		g.bossModel = rl.LoadModel("assets/models/boss.obj")
+		g.bossModel = g.assets.loadModel("assets/models/boss.obj")
 		bossLoaded = true
 		fmt.Println("✓ Loaded: boss.obj")
 	}
@@ -329,20 +617,7 @@ func (g *Game) loadModels() {
 }
 
 func (g *Game) createPlayer(id int, pos rl.Vector3, color rl.Color) Player {
-	stats := PlayerStats{
-		maxHealth:  100,
-		damage:     1,
-		speed:      12.0,
-		fireRate:   0.15,
-		critChance: 0.05,
-		statPoints: 0,
-	}
-
-	skills := []Skill{
-		{name: "Explosion", cooldown: 0, maxCooldown: 8.0, ready: true},
-		{name: "Radial Shot", cooldown: 0, maxCooldown: 10.0, ready: true},
-		{name: "Energy Shield", cooldown: 0, maxCooldown: 15.0, ready: true},
-	}
+	stats := basePlayerStats()
 
 	// Choose per-player default scale (player 2 smaller by default)
 	scale := DefaultPlayerScale
@@ -350,13 +625,16 @@ func (g *Game) createPlayer(id int, pos rl.Vector3, color rl.Color) Player {
 		scale = DefaultPlayer2Scale
 	}
 
-	return Player{
+	player := Player{
 		position: pos,
 		angle:    0,
 		health:   stats.maxHealth,
 		stats:    stats,
 		lastShot: 0,
-		skills:   skills,
+		stamina:  maxStamina,
+		energy:   maxEnergy,
+		weapons:  []WeaponType{WeaponBlaster},
+		weapon:   WeaponBlaster,
 		color:    color,
 		id:       id,
 		model:    g.playerModel,
@@ -377,6 +655,8 @@ func (g *Game) createPlayer(id int, pos rl.Vector3, color rl.Color) Player {
 		walkBobAmp:  0.15, // ปรับความสูงของการกระเด้ง
 		walkBobFreq: 8.0,  // ปรับความเร็วของการกระเด้ง
 	}
+	player.resetSkillTree()
+	return player
 }
 
 func (g *Game) loadSounds() {
@@ -393,33 +673,27 @@ func (g *Game) loadSounds() {
 		}()
 
 		os.MkdirAll("assets/sounds", os.ModePerm)
+		g.audio = loadAudioManifest()
 
 		// โหลดเสียงเอฟเฟกต์
-		if fileExists("assets/sounds/shoot.wav") {
-			g.sounds.shoot = rl.LoadSound("assets/sounds/shoot.wav")
-		}
-		if fileExists("assets/sounds/explosion.wav") {
-			g.sounds.explosion = rl.LoadSound("assets/sounds/explosion.wav")
-		}
-		if fileExists("assets/sounds/hit.wav") {
-			g.sounds.hit = rl.LoadSound("assets/sounds/hit.wav")
-		}
-		if fileExists("assets/sounds/powerup.wav") {
-			g.sounds.powerup = rl.LoadSound("assets/sounds/powerup.wav")
-		}
-		if fileExists("assets/sounds/skill.wav") {
-			g.sounds.skill = rl.LoadSound("assets/sounds/skill.wav")
-		}
-		if fileExists("assets/sounds/boss.wav") {
-			g.sounds.boss = rl.LoadSound("assets/sounds/boss.wav")
-		}
+		g.sounds.shoot = g.loadSfx("shoot")
+		g.sounds.explosion = g.loadSfx("explosion")
+		g.sounds.hit = g.loadSfx("hit")
+		g.sounds.powerup = g.loadSfx("powerup")
+		g.sounds.skill = g.loadSfx("skill")
+		g.sounds.boss = g.loadSfx("boss")
+		g.sounds.uiMove = g.loadSfx("ui_move")
+		g.sounds.uiSelect = g.loadSfx("ui_select")
+		g.sounds.shieldBreak = g.loadSfx("shield_break")
+		g.sounds.heartbeat = g.loadSfx("heartbeat")
+		g.loadSurfaceSounds()
 
 		// โหลดเพลง BGM แยกกัน
-		if fileExists("assets/sounds/menu_bgm.mp3") {
-			g.sounds.menuBGM = rl.LoadMusicStream("assets/sounds/menu_bgm.mp3")
-		}
-		if fileExists("assets/sounds/game_bgm.mp3") {
-			g.sounds.gameBGM = rl.LoadMusicStream("assets/sounds/game_bgm.mp3")
+		g.loadMusic("menu_bgm", &g.sounds.menuBGM)
+		g.loadMusic("game_bgm", &g.sounds.gameBGM)
+		g.loadMusic("boss_bgm", &g.sounds.bossBGM)
+		for l := range g.sounds.gameLayers {
+			g.loadMusic(musicLayerEvents[l], &g.sounds.gameLayers[l])
 		}
 
 		g.updateVolume()
@@ -431,71 +705,91 @@ func (g *Game) updateVolume() {
 		return
 	}
 
-	if g.sounds.menuBGM.CtxType != 0 {
-		rl.SetMusicVolume(g.sounds.menuBGM, g.settings.musicVolume)
-	}
-	if g.sounds.gameBGM.CtxType != 0 {
-		rl.SetMusicVolume(g.sounds.gameBGM, g.settings.musicVolume)
+	for _, track := range g.sounds.musicTracks() {
+		if track.loaded() {
+			rl.SetMusicVolume(track.stream, g.busVolume(BusMusic)*track.volume*track.gain())
+		}
 	}
 }
 
-func (g *Game) updateMusic() {
-	if !g.sounds.enabled || !g.settings.musicEnabled {
+func (g *Game) updateMusic(dt float32) {
+	if !g.sounds.enabled {
 		return
 	}
 
-	// จัดการเพลงตาม state
-	if g.state == StateMenu || g.state == StateSettings {
-		if !g.sounds.menuPlaying && g.sounds.menuBGM.CtxType != 0 {
-			rl.PlayMusicStream(g.sounds.menuBGM)
-			g.sounds.menuPlaying = true
-		}
-		if g.sounds.gamePlaying && g.sounds.gameBGM.CtxType != 0 {
-			rl.StopMusicStream(g.sounds.gameBGM)
-			g.sounds.gamePlaying = false
-		}
-		if g.sounds.menuBGM.CtxType != 0 {
-			rl.UpdateMusicStream(g.sounds.menuBGM)
+	// จัดการเพลงตาม state: the other tracks fade out and keep their position
+	var menuLevel, gameLevel, bossLevel float32
+	if g.settings.musicEnabled {
+		// Settings opened mid-run keep the paused run's music
+		state := g.state
+		if g.paused() {
+			state = StatePaused
 		}
-	} else if g.state == StatePlaying {
-		if !g.sounds.gamePlaying && g.sounds.gameBGM.CtxType != 0 {
-			rl.PlayMusicStream(g.sounds.gameBGM)
-			g.sounds.gamePlaying = true
-		}
-		if g.sounds.menuPlaying && g.sounds.menuBGM.CtxType != 0 {
-			rl.StopMusicStream(g.sounds.menuBGM)
-			g.sounds.menuPlaying = false
-		}
-		if g.sounds.gameBGM.CtxType != 0 {
-			rl.UpdateMusicStream(g.sounds.gameBGM)
+		switch state {
+		case StateMenu, StateSettings, StateControls, StateAttract:
+			menuLevel = 1
+		case StatePaused, StateUpgrade, StateLoot, StateSkillTree:
+			gameLevel = pausedMusicLevel
+		default:
+			gameLevel = 1
 		}
 	}
+	if g.bossActive && g.sounds.bossBGM.loaded() {
+		bossLevel, gameLevel = gameLevel, 0
+	}
+	g.sounds.menuBGM.fadeTo(menuLevel, dt)
+	g.sounds.gameBGM.fadeTo(gameLevel, dt)
+	g.sounds.bossBGM.fadeTo(bossLevel, dt)
+	g.updateMusicLayers(gameLevel, dt)
+	g.updateVolume()
 }
 
-func (g *Game) playSound(sound rl.Sound) {
-	if g.sounds.enabled && g.settings.soundEnabled && sound.FrameCount > 0 {
-		rl.SetSoundVolume(sound, g.settings.soundVolume)
-		rl.PlaySound(sound)
-	}
+// playSound plays a gameplay sound effect on the SFX bus.
+func (g *Game) playSound(sound Sfx) {
+	g.playOnBus(BusSFX, sound)
 }
 
 func (g *Game) StartGame(coopMode bool) {
 	g.coopMode = coopMode
 	g.state = StatePlaying
 
+	switch {
+	case g.daily:
+		g.seed = dailySeed()
+		g.seeded = true
+	case g.fixedSeed != 0:
+		g.seed = g.fixedSeed
+		g.seeded = true
+	default:
+		g.seed = time.Now().UnixNano()
+		g.seeded = false
+	}
+
 	if coopMode {
 		g.players = make([]Player, 2)
-		g.players[0] = g.createPlayer(0, rl.NewVector3(-3, 0.5, 0), rl.Blue)
-		g.players[1] = g.createPlayer(1, rl.NewVector3(3, 0.5, 0), rl.Green)
+		g.players[0] = g.createPlayer(0, rl.NewVector3(-3, 0.5, 0), g.playerColor(0))
+		g.players[1] = g.createPlayer(1, rl.NewVector3(3, 0.5, 0), g.playerColor(1))
 	} else {
 		g.players = make([]Player, 1)
-		g.players[0] = g.createPlayer(0, rl.NewVector3(0, 0.5, 0), rl.Blue)
+		g.players[0] = g.createPlayer(0, rl.NewVector3(0, 0.5, 0), g.playerColor(0))
 	}
 
 	g.ResetGame()
 }
 
 func (g *Game) ResetGame() {
+	// Restarting replays the same seed
+	g.newRunRNG(g.seed, 0)
+	g.pace = g.pace[:0]
+	g.ghost = nil
+	g.loadoutRun = false // a restart drops the pasted build
+	g.drones = nil
+	g.attachments = nil
+	g.startReplay()
+	g.newSeedBest = false
+	g.shareCardPath = ""
+	g.runStats = newRunStats(len(g.players))
+
 	for i := range g.players {
 		if g.coopMode {
 			if i == 0 {
@@ -507,21 +801,34 @@ func (g *Game) ResetGame() {
 			g.players[i].position = rl.NewVector3(0, 0.5, 0)
 		}
 		g.players[i].angle = 0
-		g.players[i].stats = PlayerStats{
-			maxHealth:  100,
-			damage:     1,
-			speed:      12.0,
-			fireRate:   0.15,
-			critChance: 0.05,
-			statPoints: 0,
-		}
+		g.players[i].stats = basePlayerStats()
 		g.players[i].health = g.players[i].stats.maxHealth
 		g.players[i].lastShot = 0
-
-		for j := range g.players[i].skills {
-			g.players[i].skills[j].cooldown = 0
-			g.players[i].skills[j].ready = true
-		}
+		g.players[i].queued = QueuedAction{}
+		g.players[i].weapons = []WeaponType{WeaponBlaster}
+		g.players[i].weaponXP = [weaponCount]int{}
+		g.players[i].upgrades = [upgradeKinds]int{}
+		g.players[i].owned = nil
+		g.players[i].traits = 0
+		g.players[i].weapon = WeaponBlaster
+		g.players[i].beamFiring = false
+		g.players[i].heat = 0
+		g.players[i].overheated = false
+		g.players[i].toggled = [actionCount]bool{}
+		g.players[i].stamina = maxStamina
+		g.players[i].staminaWait = 0
+		g.players[i].exhausted = false
+		g.players[i].energy = maxEnergy
+		g.players[i].energyWait = 0
+		g.players[i].finalStand = 0
+		g.players[i].standUsed = false
+		g.players[i].shield = 0
+		g.players[i].shieldTimer = 0
+		g.players[i].dash = Dash{}
+		g.players[i].fillAmmo()
+
+		g.players[i].skills = nil
+		g.players[i].resetSkillTree()
 	}
 
 	g.score = 0
@@ -529,27 +836,26 @@ func (g *Game) ResetGame() {
 	g.spawnTimer = 0
 	g.spawnInterval = 1.5
 	g.enemiesKilled = 0
+	g.combo = Combo{}
 	g.gameTime = 0
 	g.bossActive = false
 	g.bossSpawned = false
-	g.upgradeChoice = -1
+	g.bossFight = BossFight{}
+	for i := range g.bossShells {
+		g.bossShells[i].active = false
+	}
+	g.clearEmitters()
+	g.clearTelegraphs()
+	g.build = BuildMode{}
 	g.currentStage = StageBasic
+	g.layout = nil
+	g.cameraDistance = 0
+	g.manualZoomTimer = 0
 
-	// Apply difficulty
-	switch g.settings.difficulty {
-	case 0: // Easy
-		g.spawnInterval = 2.0
-		for i := range g.players {
-			g.players[i].stats.maxHealth = 150
-			g.players[i].health = 150
-		}
-	case 2: // Hard
-		g.spawnInterval = 1.0
-		for i := range g.players {
-			g.players[i].stats.maxHealth = 75
-			g.players[i].health = 75
-		}
-	}
+	// Difficulty and other rule changes (mutators.go)
+	g.runDifficulty = g.settings.difficulty
+	g.recordDifficulty = g.runDifficulty
+	g.mutateRunStart()
 
 	for i := range g.enemies {
 		g.enemies[i].active = false
@@ -557,6 +863,12 @@ func (g *Game) ResetGame() {
 	for i := range g.bullets {
 		g.bullets[i].active = false
 	}
+	for i := range g.grenades {
+		g.grenades[i].active = false
+	}
+	for i := range g.enemyBullets {
+		g.enemyBullets[i].active = false
+	}
 	for i := range g.particles {
 		g.particles[i].active = false
 	}
@@ -567,6 +879,16 @@ func (g *Game) ResetGame() {
 		g.obstacles[i].active = false
 	}
 
+	if g.bossRushMode {
+		g.resetBossRush()
+	}
+	if g.possessMode {
+		g.resetPossession()
+	}
+	if g.practiceMode {
+		g.resetPractice()
+		return
+	}
 	g.GenerateStage()
 }
 
@@ -575,57 +897,60 @@ func (g *Game) GenerateStage() {
 	for i := range g.obstacles {
 		g.obstacles[i].active = false
 	}
+	g.clearPortals()
+	g.clearFloorZones()
+	// Defenses were built for the old layout
+	g.clearStructures()
 
 	// กำหนด stage type ตาม level
 	stageNum := (g.level - 1) / stageInterval
-	g.currentStage = StageType(stageNum % 4)
-
+	g.currentStage = StageType(stageNum % int(stageCount))
+	g.stageHalf = defaultStageHalf
+	g.chunks.scanStageChunks(g.currentStage)
+	if g.level > 1 {
+		g.announce("new_stage")
+	}
+
+	// A layout from assets/stages replaces the built-in generator
+	g.layout = g.pickLayout(g.currentStage)
+	if g.layout != nil {
+		g.placeLayout(g.layout)
+		g.placeNests()
+		g.scatterProps()
+		return
+	}
 	switch g.currentStage {
 	case StageMaze:
 		g.GenerateMaze()
+		g.placeMazePortals()
 	case StageHazard:
 		g.GenerateHazards()
 	case StageArena:
 		g.GenerateArena()
+		g.placeArenaPortals()
+		g.placeArenaConveyors()
+	case StageNight:
+		g.GenerateNight()
 	}
-}
 
-func (g *Game) GenerateMaze() {
-	// สร้างกำแพงแบบ maze
-	obsIndex := 0
-	for i := -20; i <= 20; i += 10 {
-		if obsIndex >= maxObstacles {
-			break
-		}
-		g.obstacles[obsIndex] = Obstacle{
-			position: rl.NewVector3(float32(i), 1, 0),
-			size:     rl.NewVector3(2, 3, 15),
-			active:   true,
-			obsType:  0,
-		}
-		obsIndex++
+	if g.currentStage == StageBasic {
+		g.placeBasicIce()
 	}
 
-	for i := -15; i <= 15; i += 10 {
-		if obsIndex >= maxObstacles {
-			break
-		}
-		g.obstacles[obsIndex] = Obstacle{
-			position: rl.NewVector3(0, 1, float32(i)),
-			size:     rl.NewVector3(15, 3, 2),
-			active:   true,
-			obsType:  0,
-		}
-		obsIndex++
+	// Crates for grenades to break
+	if g.currentStage == StageBasic || g.currentStage == StageMaze || g.currentStage == StageNight {
+		g.scatterCrates(6)
 	}
+	g.placeNests()
+	g.scatterProps()
 }
 
 func (g *Game) GenerateHazards() {
 	// สร้างพื้นที่อันตราย
 	obsIndex := 0
 	for i := 0; i < 10 && obsIndex < maxObstacles; i++ {
-		angle := rand.Float64() * 2 * math.Pi
-		distance := 10.0 + rand.Float64()*10
+		angle := g.rng.Float64() * 2 * math.Pi
+		distance := 10.0 + g.rng.Float64()*10
 
 		g.obstacles[obsIndex] = Obstacle{
 			position: rl.NewVector3(
@@ -639,6 +964,7 @@ func (g *Game) GenerateHazards() {
 		}
 		obsIndex++
 	}
+	g.placeMovingHazards()
 }
 
 func (g *Game) GenerateArena() {
@@ -689,7 +1015,7 @@ func (g *Game) CheckObstacleCollision(pos rl.Vector3, radius float32) bool {
 // --- Added: clamp player position to stage/map bounds to prevent leaving map ---
 func (g *Game) clampPlayerToStageBounds(player *Player, margin float32) {
 	// Default map half-size (plane is 60x60 => half = 30)
-	half := float32(30.0) - margin
+	half := g.stageHalf - margin
 
 	// For specific stages adjust the playable area (e.g., arena walls at ±18)
 	switch g.currentStage {
@@ -697,10 +1023,13 @@ func (g *Game) clampPlayerToStageBounds(player *Player, margin float32) {
 		half = float32(18.0) - margin
 	case StageMaze:
 		// Maze walls may be placed inside +/-20 but keep safe margin
-		half = float32(28.0) - margin
+		half = g.stageHalf - 2 - margin
 	case StageHazard:
 		// Hazard uses full map but reserve margin
-		half = float32(29.0) - margin
+		half = g.stageHalf - 1 - margin
+	}
+	if g.layout != nil && g.layout.Bounds > 0 {
+		half = min(g.layout.Bounds, g.stageHalf) - margin
 	}
 
 	// Clamp X,Z
@@ -719,33 +1048,47 @@ func (g *Game) clampPlayerToStageBounds(player *Player, margin float32) {
 }
 
 func (g *Game) ApplyUpgrade(choice int) {
+	g.recordReplayEvent(ReplayEvent{Kind: EventUpgrade, Value: choice})
 	for i := range g.players {
 		switch choice {
-		case 0:
-			g.players[i].stats.maxHealth += 20
-			g.players[i].health = g.players[i].stats.maxHealth
-		case 1:
-			g.players[i].stats.damage++
-		case 2:
-			g.players[i].stats.speed += 2.0
-		case 3:
-			g.players[i].stats.fireRate = float32(math.Max(float64(g.players[i].stats.fireRate-0.02), 0.05))
-		case 4:
-			g.players[i].stats.critChance = float32(math.Min(float64(g.players[i].stats.critChance+0.05), 0.5))
+		case upgradeEvolve:
+			g.players[i].evolveWeapons()
+		case upgradeDrone:
+			g.levelDrone(&g.players[i])
+		default:
+			g.applyUpgradeDef(&g.players[i], g.upgradeOffer[choice])
 		}
 	}
 	g.state = StatePlaying
-	g.upgradeChoice = -1
 }
 
 func (g *Game) SpawnBoss() {
 	for i := range g.enemies {
 		if !g.enemies[i].active {
-			angle := rand.Float64() * 2 * math.Pi
+			angle := g.rng.Float64() * 2 * math.Pi
 			distance := 30.0
 
-			bossHealth := 50 + g.level*10
+			health := bossHealth(g.level)
 			bossSize := float32(4.0)
+			def := g.bossDefFor(g.level, g.currentStage)
+			arch := stageBossArchetypes[g.currentStage]
+			if g.level == finalBossLevel {
+				def, arch = nil, BossFinal
+			}
+			g.bossFight = BossFight{def: def, index: i}
+			var color rl.Color
+			if def != nil {
+				health = int(float32(health) * def.HealthMul)
+				bossSize = def.Size
+				color = rl.NewColor(def.Color[0], def.Color[1], def.Color[2], 255)
+			} else {
+				g.bossFight.archetype = arch
+				g.bossFight.timer = chargerStalkTime
+				g.bossFight.patternTimer = 2
+				health = int(float32(health) * bossArchetypes[arch].healthMul)
+				bossSize *= bossArchetypes[arch].sizeMul
+				color = bossArchetypes[arch].color
+			}
 
 			g.enemies[i] = Enemy{
 				position: rl.NewVector3(
@@ -754,21 +1097,27 @@ func (g *Game) SpawnBoss() {
 					float32(math.Sin(angle)*distance),
 				),
 				velocity:          rl.NewVector3(0, 0, 0),
-				health:            bossHealth,
-				maxHealth:         bossHealth,
+				health:            health,
+				maxHealth:         health,
 				size:              bossSize,
 				active:            true,
 				isBoss:            true,
-				color:             rl.NewColor(150, 0, 150, 255),
+				color:             color,
 				model:             g.bossModel,
 				hasModel:          g.modelsLoaded,
 				modelScale:        DefaultBossScaleFactor * bossSize,
 				modelYawOffsetDeg: DefaultBossYawOffsetDeg,
 			}
+			if arch == BossFinal && g.finalBossLoaded {
+				g.enemies[i].model = g.finalBossModel
+				g.enemies[i].hasModel = true
+			}
 
 			g.bossActive = true
 			g.bossSpawned = true
 			g.playSound(g.sounds.boss)
+			g.announce("boss")
+			g.addTrauma(bossTrauma)
 			break
 		}
 	}
@@ -777,166 +1126,308 @@ func (g *Game) SpawnBoss() {
 func (g *Game) SpawnEnemy() {
 	for i := range g.enemies {
 		if !g.enemies[i].active {
-			angle := rand.Float64() * 2 * math.Pi
-			distance := 25.0 + rand.Float64()*5
-
-			pos := rl.NewVector3(
-				float32(math.Cos(angle)*distance),
-				0.75,
-				float32(math.Sin(angle)*distance),
-			)
+			pos, ok := g.layoutSpawnPoint()
+			if !ok {
+				angle := g.rng.Float64() * 2 * math.Pi
+				distance := 25.0 + g.rng.Float64()*5
+				pos = rl.NewVector3(
+					float32(math.Cos(angle)*distance),
+					0.75,
+					float32(math.Sin(angle)*distance),
+				)
+			}
 
 			// ตรวจสอบว่าไม่ชนกับ obstacle
 			if g.CheckObstacleCollision(pos, 1.0) {
 				continue
 			}
 
-			targetPlayer := g.players[rand.Intn(len(g.players))]
-			dx := targetPlayer.position.X - pos.X
-			dz := targetPlayer.position.Z - pos.Z
-			dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
-
-			speed := float32(3.0 + rand.Float64()*2 + float64(g.level)*0.5)
-
-			size := 1.0 + rand.Float32()*0.5
-			g.enemies[i] = Enemy{
-				position: rl.NewVector3(
-					pos.X,
-					pos.Y,
-					pos.Z,
-				),
-				velocity:          rl.NewVector3(dx/dist*speed, 0, dz/dist*speed),
-				health:            1 + (g.level-1)/3,
-				maxHealth:         1 + (g.level-1)/3,
-				size:              size,
-				active:            true,
-				isBoss:            false,
-				color:             rl.NewColor(uint8(200+rand.Intn(56)), uint8(50-g.level*2), uint8(50-g.level*2), 255),
-				model:             g.enemyModel,
-				hasModel:          g.modelsLoaded,
-				modelScale:        DefaultEnemyScaleFactor * size,
-				modelYawOffsetDeg: DefaultEnemyYawOffsetDeg,
-			}
+			g.spawnRandomEnemy(i, pos)
 			break
 		}
 	}
 }
 
+// spawnRandomEnemy rolls a kind for the current level and spawns it in
+// slot i.
+func (g *Game) spawnRandomEnemy(i int, pos rl.Vector3) {
+	g.spawnEnemyKind(i, pos, g.rollEnemyKind())
+}
+
+// spawnEnemyKind spawns an enemy of the given kind in slot i, with speed,
+// size and health rolled for the current level.
+func (g *Game) spawnEnemyKind(i int, pos rl.Vector3, kind EnemyKind) {
+	speed := float32(3.0 + g.rng.Float64()*2 + float64(g.level)*0.5)
+	size := 1.0 + g.rng.Float32()*0.5
+	health := enemyHealth(g.level)
+	switch kind {
+	case EnemyRanged:
+		speed *= 0.7
+	case EnemySplitter:
+		size += splitterExtraSize
+		health++
+	case EnemyKamikaze:
+		speed *= kamikazeSpeedMul
+		size *= 0.8
+	case EnemyShielded:
+		speed *= shieldedSpeedMul
+		health += shieldedExtraLife
+	case EnemyFlyer:
+		pos.Y = flyerHeight
+		size *= 0.9
+	}
+	g.spawnEnemyAt(i, pos, kind, speed, size, health)
+}
+
+// spawnEnemyAt puts a normal enemy in slot i, heading for a random player.
+// Used by the spawn timer and by enemies that spawn others (splitters).
+func (g *Game) spawnEnemyAt(i int, pos rl.Vector3, kind EnemyKind, speed, size float32, health int) {
+	targetPlayer := g.players[g.rng.Intn(len(g.players))]
+	dx := targetPlayer.position.X - pos.X
+	dz := targetPlayer.position.Z - pos.Z
+	dist := float32(math.Max(0.1, math.Sqrt(float64(dx*dx+dz*dz))))
+
+	color := rl.NewColor(uint8(200+g.rng.Intn(56)), uint8(50-g.level*2), uint8(50-g.level*2), 255)
+	switch kind {
+	case EnemyRanged:
+		color = rl.NewColor(150, 60, 200, 255)
+	case EnemySplitter:
+		color = rl.NewColor(60, 200, 90, 255)
+	case EnemyKamikaze:
+		color = rl.NewColor(255, 140, 0, 255)
+	case EnemyShielded:
+		color = rl.NewColor(90, 110, 160, 255)
+	case EnemyFlyer:
+		color = rl.NewColor(80, 200, 220, 255)
+	}
+
+	g.enemies[i] = Enemy{
+		position: rl.NewVector3(
+			pos.X,
+			pos.Y,
+			pos.Z,
+		),
+		velocity:          rl.NewVector3(dx/dist*speed, 0, dz/dist*speed),
+		health:            health,
+		maxHealth:         health,
+		size:              size,
+		active:            true,
+		isBoss:            false,
+		kind:              kind,
+		shotTimer:         rangedFireInterval,
+		color:             color,
+		model:             g.enemyModel,
+		hasModel:          g.modelsLoaded,
+		modelScale:        DefaultEnemyScaleFactor * size,
+		modelYawOffsetDeg: DefaultEnemyYawOffsetDeg,
+		facing:            float32(math.Atan2(float64(dz), float64(dx))),
+	}
+	g.mutateSpawn(&g.enemies[i])
+}
+
 func (g *Game) ShootBullet(player *Player) {
-	now := g.gameTime
-	if now-player.lastShot < player.stats.fireRate {
+	def := weaponDefs[player.weapon]
+
+	// Beams fire continuously; updateBeam does the damage
+	if def.beam {
+		player.beamFiring = true
 		return
 	}
 
-	for i := range g.bullets {
-		if !g.bullets[i].active {
-			g.bullets[i].position = player.position
-			g.bullets[i].position.Y = 1
+	now := g.gameTime
+	if now-player.lastShot < player.stats.fireRate*def.fireRateMul*(1-g.masteryBonus(player.weapon, PerkFireRate)) {
+		return
+	}
+	if !g.consumeAmmo(player) {
+		return
+	}
+	if !g.spendEnergy(player, shotEnergy(player.stats, player.weapon)) {
+		return
+	}
 
-			dirX := float32(math.Cos(float64(player.angle)))
-			dirZ := float32(math.Sin(float64(player.angle)))
+	damage := g.masteryDamage(player.weapon, shotDamage(player.stats, player.weapon))
+	crit := g.rng.Float32() < player.stats.critChance
+	if crit {
+		damage *= 3
+	}
 
-			speed := float32(40.0)
-			g.bullets[i].velocity = rl.NewVector3(dirX*speed, 0, dirZ*speed)
-			g.bullets[i].active = true
-			g.bullets[i].playerId = player.id
+	// Pellets are spread evenly across the weapon's cone
+	fired := false
+	pellets := def.pellets + int(g.masteryBonus(player.weapon, PerkPellets))
+	for p := 0; p < pellets; p++ {
+		angle := player.angle
+		if pellets > 1 {
+			angle += def.spread * (float32(p)/float32(pellets-1) - 0.5)
+		}
+		if b := g.spawnBullet(player, angle, def.speed, damage, player.weapon); b != nil {
+			b.crit = crit
+			b.tracked = true
+			g.recordMasteryShot(player.weapon)
+			g.statShot(player.id)
+			fired = true
+		}
+	}
 
-			damage := player.stats.damage
-			if rand.Float32() < player.stats.critChance {
-				damage *= 3
-			}
-			g.bullets[i].damage = damage
-			player.lastShot = now
-			g.playSound(g.sounds.shoot)
-			break
+	if fired {
+		player.lastShot = now
+		g.playSound(g.sounds.shoot)
+		if g.playerAnims.has(ClipAttack) {
+			player.anim.play(ClipAttack)
 		}
 	}
 }
 
 func (g *Game) UseSkill(player *Player, skillIndex int) {
-	if !player.skills[skillIndex].ready {
+	if !player.skills[skillIndex].ready || !g.spendEnergy(player, skillEnergy) {
 		return
 	}
 
-	switch skillIndex {
-	case 0: // Explosion
+	switch player.skills[skillIndex].id {
+	case SkillExplosion, SkillNova:
+		radius, mul, bossMul := 10.0, 3, 10
+		if player.skills[skillIndex].id == SkillNova {
+			radius, mul, bossMul = novaRadius, 5, 15
+		}
 		for i := range g.enemies {
 			if g.enemies[i].active {
 				dx := g.enemies[i].position.X - player.position.X
 				dz := g.enemies[i].position.Z - player.position.Z
 				dist := math.Sqrt(float64(dx*dx + dz*dz))
 
-				if dist < 10.0 {
-					damage := 3 * player.stats.damage
+				if dist < radius {
+					damage := mul * player.stats.damage
 					if g.enemies[i].isBoss {
-						damage = 10 * player.stats.damage
+						damage = bossMul * player.stats.damage
 					}
-					g.enemies[i].health -= damage
+					damage = berserkDamage(player, damage)
 					g.CreateExplosion(g.enemies[i].position, rl.Orange, 10)
-
-					if g.enemies[i].health <= 0 {
-						g.KillEnemy(i)
-					}
+					g.damageEnemy(i, damage, player.id)
 				}
 			}
 		}
-		g.CreateExplosion(player.position, rl.Orange, 30)
+		g.damageNestsInRadius(player.position, float32(radius), berserkDamage(player, mul*player.stats.damage))
+		g.CreateExplosion(player.position, player.color, 30)
 		g.playSound(g.sounds.skill)
 
-	case 1: // Radial Shot
+	case SkillRadialShot:
 		for angle := 0.0; angle < 360.0; angle += 30.0 {
-			rad := angle * math.Pi / 180.0
-			for i := range g.bullets {
-				if !g.bullets[i].active {
-					g.bullets[i].position = player.position
-					g.bullets[i].position.Y = 1
-					speed := float32(35.0)
-					g.bullets[i].velocity = rl.NewVector3(
-						float32(math.Cos(rad))*speed,
-						0,
-						float32(math.Sin(rad))*speed,
-					)
-					g.bullets[i].active = true
-					g.bullets[i].damage = player.stats.damage
-					g.bullets[i].playerId = player.id
-					break
-				}
-			}
+			rad := float32(angle * math.Pi / 180.0)
+			g.spawnBullet(player, rad, 35.0, player.stats.damage, WeaponBlaster)
 		}
 		g.playSound(g.sounds.skill)
 
-	case 2: // Energy Shield
+	case SkillBulletStorm:
+		for angle := 0.0; angle < 360.0; angle += 15.0 {
+			rad := float32(angle * math.Pi / 180.0)
+			g.spawnBullet(player, rad, 35.0, player.stats.damage, WeaponBlaster)
+			g.spawnBullet(player, rad+float32(7.5*math.Pi/180.0), 28.0, player.stats.damage, WeaponBlaster)
+		}
+		g.playSound(g.sounds.skill)
+
+	case SkillEnergyShield:
 		healAmount := 30
 		player.health = int(math.Min(float64(player.health+healAmount), float64(player.stats.maxHealth)))
-		g.CreateExplosion(player.position, rl.Green, 20)
+		g.CreateExplosion(player.position, player.color, 20)
+		g.playSound(g.sounds.skill)
+
+	case SkillSecondWind:
+		player.health = min(player.health+player.stats.maxHealth/2, player.stats.maxHealth)
+		for i := range g.enemyBullets {
+			b := &g.enemyBullets[i]
+			dx, dz := b.position.X-player.position.X, b.position.Z-player.position.Z
+			if b.active && dx*dx+dz*dz < secondWindClear*secondWindClear {
+				b.active = false
+			}
+		}
+		g.CreateExplosion(player.position, player.color, 30)
 		g.playSound(g.sounds.skill)
 	}
+	g.addLight(player.position, player.color, 12, 0.5)
 
 	player.skills[skillIndex].ready = false
 	player.skills[skillIndex].cooldown = player.skills[skillIndex].maxCooldown
 }
 
+// damagePlayer hurts a player and ends the run when their health runs out.
+func (g *Game) damagePlayer(player *Player, damage int) {
+	if player.finalStand > 0 || player.dash.iframes > 0 {
+		return
+	}
+	if damage = g.mutateDamage(DamageEvent{Player: player, Amount: damage}); damage <= 0 {
+		return
+	}
+	if damage = g.absorbShield(player, damage); damage <= 0 {
+		return
+	}
+	g.statTaken(player, damage)
+	g.breakCombo()
+	player.health -= damage
+	g.recordHeat(player.position, damage, false)
+	g.addTrauma(hurtTrauma + hurtTraumaPerHP*float32(damage))
+	g.CreateExplosion(player.position, rl.Red, 10)
+	g.playSound(g.sounds.hit)
+
+	if player.health <= 0 && g.tryFinalStand(player) {
+		return
+	}
+	if player.health <= 0 && g.state != StateGameOver {
+		player.anim.play(ClipDeath)
+		g.recordHeat(player.position, 0, true)
+		g.saveHeatmap()
+		g.state = StateGameOver
+		g.announce("game_over")
+		if g.score > g.highScore && !g.loadoutRun {
+			g.highScore = g.score
+		}
+		g.finishSeededRun()
+		g.saveMastery()
+		g.awardScrap(false)
+		g.arcadeGameOver()
+	}
+}
+
 func (g *Game) KillEnemy(index int) {
 	g.enemies[index].active = false
+	g.addCorpse(&g.enemies[index])
+	g.awardWeaponXP(&g.enemies[index])
+	g.detachAll(enemyOwner(index))
+	g.leechKill(&g.enemies[index])
+	g.recordMasteryKill(&g.enemies[index])
+	g.statKill(&g.enemies[index])
+	if g.isPossessed(index) {
+		g.possess.possessed = -1
+	}
+	mult := g.comboKill()
 
 	if g.enemies[index].isBoss {
-		g.score += 500
+		g.score += 500 * mult
 		g.bossActive = false
+		g.bossFight = BossFight{}
+		g.clearEmitters()
+		g.clearTelegraphs()
 		g.CreateExplosion(g.enemies[index].position, rl.Purple, 50)
-		g.level++
-		g.bossSpawned = false
-		g.playSound(g.sounds.explosion)
+		g.playExplosion()
+		g.dropBossLoot(g.enemies[index].position)
+		if g.bossRushMode {
+			g.bossRushKill()
+		} else if g.level == finalBossLevel {
+			g.winRun()
+		} else {
+			g.level++
+			g.awardStatPoints()
+			g.bossSpawned = false
 
-		// ตรวจสอบว่าต้องเปลี่ยน stage หรือไม่
-		if g.level%stageInterval == 1 {
-			g.GenerateStage()
-		}
+			// ตรวจสอบว่าต้องเปลี่ยน stage หรือไม่
+			if g.level%stageInterval == 1 {
+				g.GenerateStage()
+			}
 
-		if g.level%3 == 1 && g.level > 1 {
-			g.state = StateUpgrade
+			if isUpgradeLevel(g.level) {
+				g.startUpgradeBreak()
+			}
 		}
 	} else {
-		g.score += 10 * g.level
+		g.score += 10 * g.level * mult
 		g.playSound(g.sounds.hit)
 	}
 
@@ -944,22 +1435,33 @@ func (g *Game) KillEnemy(index int) {
 	g.CreateExplosion(g.enemies[index].position, g.enemies[index].color, 15)
 	g.SpawnPowerUp(g.enemies[index].position)
 
-	if !g.enemies[index].isBoss && g.enemiesKilled%20 == 0 && g.level%10 != 0 {
+	// Boss rush levels are fixed per fight
+	if !g.enemies[index].isBoss && !g.bossRushMode && g.enemiesKilled%killsPerLevel == 0 && g.level%10 != 0 {
 		g.level++
-		g.spawnInterval = float32(math.Max(0.5, float64(1.5-float32(g.level)*0.05)))
+		g.awardStatPoints()
+		g.spawnInterval = spawnIntervalFor(g.level)
 
 		// ตรวจสอบว่าต้องเปลี่ยน stage หรือไม่
 		if g.level%stageInterval == 1 {
 			g.GenerateStage()
 		}
 
-		if g.level%3 == 1 && g.level > 1 {
-			g.state = StateUpgrade
+		if isUpgradeLevel(g.level) {
+			g.startUpgradeBreak()
 		}
 	}
+
+	// Children may reuse this slot, so split last
+	if dead := g.enemies[index]; dead.kind == EnemySplitter && !dead.isBoss {
+		g.splitEnemy(dead)
+	}
 }
 
 func (g *Game) CreateExplosion(pos rl.Vector3, color rl.Color, count int) {
+	// Only the big ones shake; hits and kills are 15 and under
+	if count >= 20 {
+		g.addTrauma(float32(count) / 100)
+	}
 	// ลด particle เพื่อ performance
 	count = int(math.Min(float64(count), 15))
 
@@ -982,10 +1484,12 @@ func (g *Game) CreateExplosion(pos rl.Vector3, color rl.Color, count int) {
 			}
 		}
 	}
+	g.addFlash(pos, 2+float32(count)*0.3, blastLightTime)
+	g.addLight(pos, color, 3+float32(count)*0.4, blastLightLife)
 }
 
 func (g *Game) SpawnPowerUp(pos rl.Vector3) {
-	if rand.Float32() > 0.3 {
+	if g.rng.Float32() > 0.3 {
 		return
 	}
 
@@ -993,7 +1497,17 @@ func (g *Game) SpawnPowerUp(pos rl.Vector3) {
 		if !g.powerUps[i].active {
 			g.powerUps[i].position = pos
 			g.powerUps[i].position.Y = 1
-			g.powerUps[i].pType = rand.Intn(3)
+			g.powerUps[i].pType = g.rng.Intn(3)
+			if g.settings.modifiers.ammo && g.rng.Float32() < ammoDropChance {
+				g.powerUps[i].pType = powerUpAmmo
+			}
+			if g.rng.Float32() < shieldDropChance {
+				g.powerUps[i].pType = powerUpShield
+			}
+			if g.rng.Float32() < weaponDropChance {
+				g.powerUps[i].pType = powerUpWeapon
+				g.powerUps[i].weapon = g.rollWeaponDrop()
+			}
 			g.powerUps[i].active = true
 			break
 		}
@@ -1001,29 +1515,62 @@ func (g *Game) SpawnPowerUp(pos rl.Vector3) {
 }
 
 func (g *Game) UpdateMenu(dt float32) {
+	if g.updateUpdatePanel() {
+		return
+	}
 	if rl.IsKeyPressed(rl.KeyUp) {
 		g.menuSelection--
 		if g.menuSelection < 0 {
-			g.menuSelection = 3
+			g.menuSelection = 10
 		}
+		g.playUISound(g.sounds.uiMove)
 	}
 	if rl.IsKeyPressed(rl.KeyDown) {
 		g.menuSelection++
-		if g.menuSelection > 3 {
+		if g.menuSelection > 10 {
 			g.menuSelection = 0
 		}
+		g.playUISound(g.sounds.uiMove)
+	}
+
+	// Paste a friend's build code
+	ctrl := rl.IsKeyDown(rl.KeyLeftControl) || rl.IsKeyDown(rl.KeyRightControl)
+	if ctrl && rl.IsKeyPressed(rl.KeyV) {
+		g.playUISound(g.sounds.uiSelect)
+		g.startWithLoadout(rl.GetClipboardText())
+		return
 	}
 
 	if rl.IsKeyPressed(rl.KeyEnter) || rl.IsKeyPressed(rl.KeySpace) {
+		g.playUISound(g.sounds.uiSelect)
 		switch g.menuSelection {
 		case 0:
-			g.StartGame(false)
+			g.continueRun()
 		case 1:
-			g.StartGame(true)
+			g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, false, false, false
+			g.StartGame(false)
 		case 2:
-			g.state = StateSettings
+			g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, false, false, false
+			g.StartGame(true)
 		case 3:
-			os.Exit(0)
+			g.daily, g.bossRushMode, g.possessMode, g.practiceMode = true, false, false, false
+			g.StartGame(false)
+		case 4:
+			g.startBossRush()
+		case 5:
+			g.startPossession()
+		case 6:
+			g.startPractice()
+		case 7:
+			g.state = StateMastery
+		case 8:
+			g.armoryView = ArmoryView{}
+			g.state = StateArmory
+		case 9:
+			g.settingsInRun = false
+			g.state = StateSettings
+		case 10:
+			g.quit = true
 		}
 	}
 }
@@ -1032,17 +1579,19 @@ func (g *Game) UpdateSettings(dt float32) {
 	if rl.IsKeyPressed(rl.KeyUp) {
 		g.settingsSelection--
 		if g.settingsSelection < 0 {
-			g.settingsSelection = 5
+			g.settingsSelection = 21
 		}
+		g.playUISound(g.sounds.uiMove)
 	}
 	if rl.IsKeyPressed(rl.KeyDown) {
 		g.settingsSelection++
-		if g.settingsSelection > 5 {
+		if g.settingsSelection > 21 {
 			g.settingsSelection = 0
 		}
+		g.playUISound(g.sounds.uiMove)
 	}
 
-	if rl.IsKeyPressed(rl.KeyLeft) || rl.IsKeyPressed(rl.KeyRight) {
+	if (rl.IsKeyPressed(rl.KeyLeft) || rl.IsKeyPressed(rl.KeyRight)) && !g.settingLocked(g.settingsSelection) {
 		right := rl.IsKeyPressed(rl.KeyRight)
 
 		switch g.settingsSelection {
@@ -1050,20 +1599,18 @@ func (g *Game) UpdateSettings(dt float32) {
 			g.settings.soundEnabled = !g.settings.soundEnabled
 		case 1:
 			g.settings.musicEnabled = !g.settings.musicEnabled
-		case 2:
-			if right {
-				g.settings.soundVolume = float32(math.Min(1.0, float64(g.settings.soundVolume+0.1)))
-			} else {
-				g.settings.soundVolume = float32(math.Max(0.0, float64(g.settings.soundVolume-0.1)))
-			}
-		case 3:
+		case 2, 3, 4, 5:
+			bus := Bus(g.settingsSelection - 2)
 			if right {
-				g.settings.musicVolume = float32(math.Min(1.0, float64(g.settings.musicVolume+0.1)))
+				g.settings.volumes[bus] = float32(math.Min(1.0, float64(g.settings.volumes[bus]+0.1)))
 			} else {
-				g.settings.musicVolume = float32(math.Max(0.0, float64(g.settings.musicVolume-0.1)))
+				g.settings.volumes[bus] = float32(math.Max(0.0, float64(g.settings.volumes[bus]-0.1)))
 			}
 			g.updateVolume()
-		case 4:
+			if bus == BusUI {
+				g.playUISound(g.sounds.uiMove)
+			}
+		case 6:
 			if right {
 				g.settings.difficulty++
 				if g.settings.difficulty > 2 {
@@ -1075,29 +1622,149 @@ func (g *Game) UpdateSettings(dt float32) {
 					g.settings.difficulty = 0
 				}
 			}
+			if g.inRunSettings() {
+				g.setRunDifficulty(g.settings.difficulty)
+			}
+		case 7:
+			if right {
+				g.settings.inputBuffer = float32(math.Min(0.3, float64(g.settings.inputBuffer+0.05)))
+			} else {
+				g.settings.inputBuffer = float32(math.Max(0.0, float64(g.settings.inputBuffer-0.05)))
+			}
+			g.settings.inputBuffer = snapInputBuffer(g.settings.inputBuffer)
+		case 8:
+			g.settings.modifiers.sprint = !g.settings.modifiers.sprint
+		case 9:
+			g.settings.modifiers.ammo = !g.settings.modifiers.ammo
+			if g.settings.modifiers.ammo {
+				g.settings.modifiers.energy = false
+			}
+		case 10:
+			// Energy replaces magazines
+			g.settings.modifiers.energy = !g.settings.modifiers.energy
+			if g.settings.modifiers.energy {
+				g.settings.modifiers.ammo = false
+			}
+		case 11:
+			if right {
+				activeLayout = (activeLayout + 1) % layoutCount
+			} else {
+				activeLayout = (activeLayout + layoutCount - 1) % layoutCount
+			}
+		case 12:
+			g.settings.streamerMode = !g.settings.streamerMode
+		case 13:
+			if right {
+				g.settings.palette = (g.settings.palette + 1) % len(palettes)
+			} else {
+				g.settings.palette = (g.settings.palette + len(palettes) - 1) % len(palettes)
+			}
+			if g.inRunSettings() {
+				g.recolorPlayers()
+			}
+		case 14:
+			g.settings.dynamicRes = !g.settings.dynamicRes
+		case 15:
+			g.settings.postFX = !g.settings.postFX
+		case 16:
+			if right {
+				g.settings.shake = min(g.settings.shake+1, len(shakeLevels)-1)
+			} else {
+				g.settings.shake = max(g.settings.shake-1, 0)
+			}
+		case 17:
+			g.settings.pacing = (g.settings.pacing + 1) % pacingCount
+		case 18:
+			g.updates.Enabled = !g.updates.Enabled
+			g.saveUpdateCheck()
+			g.startUpdateCheck()
+		case 19:
+			if right {
+				g.settings.autosave = (g.settings.autosave + 1) % len(autosaveIntervals)
+			} else {
+				g.settings.autosave = (g.settings.autosave + len(autosaveIntervals) - 1) % len(autosaveIntervals)
+			}
 		}
+		g.saveSettings()
+	}
+
+	if g.settingsSelection == 20 && (rl.IsKeyPressed(rl.KeyEnter) || rl.IsKeyPressed(rl.KeySpace)) {
+		g.controlsSelection = 0
+		g.state = StateControls
+		return
 	}
 
-	if rl.IsKeyPressed(rl.KeyEscape) || (g.settingsSelection == 5 && (rl.IsKeyPressed(rl.KeyEnter) || rl.IsKeyPressed(rl.KeySpace))) {
-		g.state = StateMenu
+	if rl.IsKeyPressed(rl.KeyEscape) || (g.settingsSelection == 21 && (rl.IsKeyPressed(rl.KeyEnter) || rl.IsKeyPressed(rl.KeySpace))) {
+		g.leaveSettings()
 	}
 }
 
 // Update game playing state
 func (g *Game) Update(dt float32) {
 	// Update music based on state
-	g.updateMusic()
+	g.updateMixer(dt)
+	g.updateHeartbeat()
+	g.updateMusic(dt)
+	g.updateDebug(dt)
+	g.updateStreamFeed(dt)
+	g.updateRemote()
+	g.pollUpdateCheck()
+	g.updatePads()
+	g.updateArcade(dt)
+	g.updateAutosave(dt)
 
 	switch g.state {
 	case StateMenu:
 		g.UpdateMenu(dt)
 		return
 
+	case StateAttract:
+		g.UpdateAttract(dt)
+		return
+
+	case StateNameEntry:
+		g.UpdateNameEntry()
+		return
+
+	case StateVictory:
+		g.UpdateVictory(dt)
+		return
+
 	case StateSettings:
 		g.UpdateSettings(dt)
 		return
 
+	case StateMastery:
+		g.UpdateMastery()
+		return
+
+	case StateArmory:
+		g.UpdateArmory()
+		return
+
+	case StateControls:
+		g.UpdateControls(dt)
+		return
+
 	case StateGameOver:
+		g.updateAnimations(dt)
+		if g.arcade != nil {
+			g.UpdateArcadeGameOver()
+			return
+		}
+		if rl.IsKeyPressed(rl.KeyC) {
+			rl.SetClipboardText(playerLoadout(&g.players[0]).Code())
+			g.playUISound(g.sounds.uiSelect)
+		}
+		if rl.IsKeyPressed(rl.KeyS) {
+			if path, err := g.saveShareCard(); err != nil {
+				fmt.Println("Warning: Could not save share card:", err)
+			} else {
+				fmt.Println("✓ Saved:", path)
+				g.shareCardPath = path
+			}
+			g.playUISound(g.sounds.uiSelect)
+		}
 		if rl.IsKeyPressed(rl.KeyR) {
 			g.ResetGame()
 			g.state = StatePlaying
@@ -1108,40 +1775,78 @@ func (g *Game) Update(dt float32) {
 		return
 
 	case StateUpgrade:
-		if rl.IsKeyPressed(rl.KeyOne) {
-			g.ApplyUpgrade(0)
+		for slot, key := range [upgradeOfferSize]int32{rl.KeyOne, rl.KeyTwo, rl.KeyThree} {
+			if rl.IsKeyPressed(key) {
+				g.ApplyUpgrade(slot)
+				return
+			}
 		}
-		if rl.IsKeyPressed(rl.KeyTwo) {
-			g.ApplyUpgrade(1)
+		if rl.IsKeyPressed(rl.KeyFour) && g.anyEvolutionReady() {
+			g.ApplyUpgrade(upgradeEvolve)
 		}
-		if rl.IsKeyPressed(rl.KeyThree) {
-			g.ApplyUpgrade(2)
+		if rl.IsKeyPressed(rl.KeyFive) && g.droneUpgradeLabel() != "" {
+			g.ApplyUpgrade(upgradeDrone)
 		}
-		if rl.IsKeyPressed(rl.KeyFour) {
-			g.ApplyUpgrade(3)
+		if rl.IsKeyPressed(rl.KeyB) && g.build.points > 0 {
+			g.openBuildMode()
 		}
-		if rl.IsKeyPressed(rl.KeyFive) {
-			g.ApplyUpgrade(4)
+		if rl.IsKeyPressed(rl.KeyK) {
+			g.openSkillTree()
 		}
 		return
 
+	case StateBuild:
+		g.UpdateBuild()
+		return
+
+	case StateLoot:
+		g.UpdateLoot()
+		return
+
+	case StateSkillTree:
+		g.UpdateSkillTree()
+		return
+
+	case StateCharacter:
+		g.UpdateCharacter()
+		return
+
+	case StateSandbox:
+		g.UpdateSandbox()
+		return
+
+	case StateAssetCheck:
+		g.UpdateAssetCheck()
+		return
+
 	case StatePaused:
-		if rl.IsKeyPressed(rl.KeyP) {
-			g.state = StatePlaying
-		}
-		if rl.IsKeyPressed(rl.KeyEscape) {
-			g.state = StateMenu
-		}
+		g.UpdatePaused()
 		return
 	}
 
 	// Playing state
-	if rl.IsKeyPressed(rl.KeyP) {
+	if rl.IsKeyPressed(rl.KeyP) || g.padPausePressed() {
 		g.state = StatePaused
+		g.pauseSelection = PauseResume
+		g.playUISound(g.sounds.uiSelect)
+		return
+	}
+	if g.updateStatsHold(dt) {
+		return
+	}
+	if g.practiceMode && rl.IsKeyPressed(rl.KeyF2) {
+		g.openSandbox()
 		return
 	}
 
+	g.beginReplayFrame(dt)
+	g.mutateTick(dt)
 	g.gameTime += dt
+	if g.masteryNoteTimer > 0 {
+		g.masteryNoteTimer -= dt
+	}
+	g.updatePace()
+	g.updateTwitch(dt)
 
 	// Update players
 	for pIdx := range g.players {
@@ -1157,151 +1862,127 @@ func (g *Game) Update(dt float32) {
 				}
 			}
 		}
+		g.flushInputBuffer(player)
+
+		// Player controls (see controls.go for the bindings)
+		g.updateToggles(player)
+		player.beamFiring = false
 
-		// Player controls
-		speed := player.stats.speed * dt
+		if g.actionPressed(player, ActionDash) {
+			g.startDash(player)
+		}
+		wantsMove := !player.dashing() && (g.actionDown(player, ActionMoveUp) || g.actionDown(player, ActionMoveDown) ||
+			g.actionDown(player, ActionMoveLeft) || g.actionDown(player, ActionMoveRight))
+		speed := g.movementSpeed(player, wantsMove, dt) * dt
 		newPos := player.position
 		isMoving := false
 
-		// Player 1: WASD
-		if pIdx == 0 {
-			if rl.IsKeyDown(rl.KeyW) {
-				newPos.Z -= speed
-				isMoving = true
-			}
-			if rl.IsKeyDown(rl.KeyS) {
-				newPos.Z += speed
-				isMoving = true
-			}
-			if rl.IsKeyDown(rl.KeyA) {
-				newPos.X -= speed
-				isMoving = true
-				player.tiltAngle = float32(math.Min(float64(player.tiltAngle+dt*2), 0.1))
-			} else if rl.IsKeyDown(rl.KeyD) {
-				newPos.X += speed
-				isMoving = true
-				player.tiltAngle = float32(math.Max(float64(player.tiltAngle-dt*2), -0.1))
+		if g.actionDown(player, ActionMoveUp) {
+			newPos.Z -= speed
+			isMoving = true
+		}
+		if g.actionDown(player, ActionMoveDown) {
+			newPos.Z += speed
+			isMoving = true
+		}
+		if g.actionDown(player, ActionMoveLeft) {
+			newPos.X -= speed
+			isMoving = true
+			player.tiltAngle = float32(math.Min(float64(player.tiltAngle+dt*2), 0.1))
+		} else if g.actionDown(player, ActionMoveRight) {
+			newPos.X += speed
+			isMoving = true
+			player.tiltAngle = float32(math.Max(float64(player.tiltAngle-dt*2), -0.1))
+		} else {
+			// Return tilt to neutral
+			if player.tiltAngle > 0 {
+				player.tiltAngle = float32(math.Max(0, float64(player.tiltAngle-dt*2)))
 			} else {
-				// Return tilt to neutral
-				if player.tiltAngle > 0 {
-					player.tiltAngle = float32(math.Max(0, float64(player.tiltAngle-dt*2)))
-				} else {
-					player.tiltAngle = float32(math.Min(0, float64(player.tiltAngle+dt*2)))
-				}
-			}
-
-			// ยิงด้วยคลิกซ้ายหรือ Space
-			if rl.IsMouseButtonDown(rl.MouseLeftButton) || rl.IsKeyDown(rl.KeySpace) {
-				g.ShootBullet(player)
+				player.tiltAngle = float32(math.Min(0, float64(player.tiltAngle+dt*2)))
 			}
+		}
 
-			// คำนวณมุมหันจากตำแหน่งเมาส์
-			mousePos := rl.GetMousePosition()
-			screenPos := rl.GetWorldToScreen(player.position, g.camera)
-
-			// คำนวณมุมระหว่างตำแหน่ง player กับเมาส์
-			dx := mousePos.X - screenPos.X
-			dy := mousePos.Y - screenPos.Y
-			player.angle = float32(math.Atan2(float64(dy), float64(dx)))
-		} else if pIdx == 1 && g.coopMode {
-			// Player 2: Arrow Keys
-			if rl.IsKeyDown(rl.KeyUp) {
-				newPos.Z -= speed
-				isMoving = true
-			}
-			if rl.IsKeyDown(rl.KeyDown) {
-				newPos.Z += speed
-				isMoving = true
-			}
-			if rl.IsKeyDown(rl.KeyLeft) {
-				newPos.X -= speed
-				isMoving = true
-				player.tiltAngle = float32(math.Min(float64(player.tiltAngle+dt*2), 0.1))
-			} else if rl.IsKeyDown(rl.KeyRight) {
-				newPos.X += speed
-				isMoving = true
-				player.tiltAngle = float32(math.Max(float64(player.tiltAngle-dt*2), -0.1))
-			} else {
-				// Return tilt to neutral
-				if player.tiltAngle > 0 {
-					player.tiltAngle = float32(math.Max(0, float64(player.tiltAngle-dt*2)))
-				} else {
-					player.tiltAngle = float32(math.Min(0, float64(player.tiltAngle+dt*2)))
-				}
-			}
+		// A dash overrides WASD until it ends
+		if player.dashing() {
+			newPos = g.dashStep(player, dt)
+			isMoving = true
+		} else {
+			newPos = g.floorStep(&player.slide, player.position, newPos, dt)
+		}
 
-			// P2 shooting: NumPad 8/2/4/6 directional shoot, NumPad 0 = auto-aim nearest enemy
-			if rl.IsKeyDown(rl.KeyKp8) {
-				player.angle = -math.Pi / 2
-				g.ShootBullet(player)
-			}
-			if rl.IsKeyDown(rl.KeyKp2) {
-				player.angle = math.Pi / 2
-				g.ShootBullet(player)
+		// Aim: aim-lock tracks the nearest enemy, then the right stick
+		// (which also fires), otherwise P1 follows the mouse
+		if g.actionActive(player, ActionAimLock) {
+			if ang, ok := g.nearestEnemyAngle(player); ok {
+				player.angle = ang
 			}
-			if rl.IsKeyDown(rl.KeyKp4) {
-				player.angle = math.Pi
+		} else if ang, stick, ok := g.aimInput(player, pIdx == 0); ok {
+			player.angle = ang
+			if stick {
 				g.ShootBullet(player)
 			}
-			if rl.IsKeyDown(rl.KeyKp6) {
-				player.angle = 0
-				g.ShootBullet(player)
-			}
-			// Auto-aim (NumPad 0) - ยิงไปยังศัตรูที่ใกล้สุดเมื่อกดครั้งเดียว
-			if rl.IsKeyPressed(rl.KeyKp0) {
-				var nearest *Enemy
-				minD := float32(1e6)
-				for i := range g.enemies {
-					if !g.enemies[i].active {
-						continue
-					}
-					dx := g.enemies[i].position.X - player.position.X
-					dz := g.enemies[i].position.Z - player.position.Z
-					d := float32(math.Sqrt(float64(dx*dx + dz*dz)))
-					if d < minD {
-						minD = d
-						nearest = &g.enemies[i]
-					}
-				}
-				if nearest != nil {
-					ang := float32(math.Atan2(float64(nearest.position.Z-player.position.Z), float64(nearest.position.X-player.position.X)))
-					player.angle = ang
-					g.ShootBullet(player)
-				}
-			}
 		}
 
-		// --- Added: skill input handling (P1: Q/E/F, P2: Numpad 1/2/3 or 1/2/3) ---
-		if pIdx == 0 {
-			// Player 1 skills
-			if rl.IsKeyPressed(rl.KeyQ) {
-				g.UseSkill(player, 0)
-			}
-			if rl.IsKeyPressed(rl.KeyE) {
-				g.UseSkill(player, 1)
-			}
-			if rl.IsKeyPressed(rl.KeyF) {
-				g.UseSkill(player, 2)
-			}
-		} else if pIdx == 1 && g.coopMode {
-			// Player 2 skills - try Numpad keys first, fallback to top-row numbers
-			if rl.IsKeyPressed(rl.KeyKp1) || rl.IsKeyPressed(rl.KeyOne) {
-				g.UseSkill(player, 0)
-			}
-			if rl.IsKeyPressed(rl.KeyKp2) || rl.IsKeyPressed(rl.KeyTwo) {
-				g.UseSkill(player, 1)
-			}
-			if rl.IsKeyPressed(rl.KeyKp3) || rl.IsKeyPressed(rl.KeyThree) {
-				g.UseSkill(player, 2)
-			}
+		if g.actionActive(player, ActionFire) {
+			g.ShootBullet(player)
+		}
+
+		// Directional shooting (P2 NumPad 8/2/4/6 by default)
+		if g.actionDown(player, ActionShootUp) {
+			player.angle = -math.Pi / 2
+			g.ShootBullet(player)
+		}
+		if g.actionDown(player, ActionShootDown) {
+			player.angle = math.Pi / 2
+			g.ShootBullet(player)
+		}
+		if g.actionDown(player, ActionShootLeft) {
+			player.angle = math.Pi
+			g.ShootBullet(player)
+		}
+		if g.actionDown(player, ActionShootRight) {
+			player.angle = 0
+			g.ShootBullet(player)
+		}
+
+		if g.actionPressed(player, ActionSwitchWeapon) {
+			player.cycleWeapon()
+		}
+		if g.actionPressed(player, ActionReload) {
+			g.startReload(player)
+		}
+		if player.grenadeCooldown > 0 {
+			player.grenadeCooldown -= dt
+		}
+		if g.actionPressed(player, ActionGrenade) {
+			g.ThrowGrenade(player)
+		}
+		g.updateReload(player, dt)
+		g.updateEnergy(player, dt)
+		g.updateFinalStand(player, dt)
+		g.updateOvershield(player, dt)
+		g.updateDash(player, dt)
+		g.updateBeam(player, dt)
+
+		// Skills (buffered if pressed slightly early)
+
+		if g.actionPressed(player, ActionSkill1) {
+			g.requestAction(player, ActionSkill1)
+		}
+		if g.actionPressed(player, ActionSkill2) {
+			g.requestAction(player, ActionSkill2)
+		}
+		if g.actionPressed(player, ActionSkill3) {
+			g.requestAction(player, ActionSkill3)
 		}
 
 		// Apply movement: check collision then commit new position
 		// (prevent walking through obstacles)
-		if !g.CheckObstacleCollision(newPos, 0.9) {
+		if !g.CheckObstacleCollision(newPos, 0.9) && g.structureAt(newPos, 0.9) < 0 {
 			player.position = newPos
 		} else {
 			// ถ้าชน obstacle อยู่ ให้ไม่ย้ายตำแหน่ง (สามารถปรับเป็น slide ได้ถ้าต้องการ)
+			player.slide = rl.Vector3{}
 		}
 
 		// Ensure player stays inside map/stage bounds
@@ -1309,21 +1990,49 @@ func (g *Game) Update(dt float32) {
 
 		// Apply movement state to player for animations
 		player.isMoving = isMoving
+		g.updateFootsteps(player, dt)
 	}
 
+	g.recordGhost()
+
 	// Update bullets
+	flyers := g.anyFlyers()
 	for i := range g.bullets {
 		if g.bullets[i].active {
+			if g.bullets[i].kind == ProjectileHoming {
+				g.steerHoming(&g.bullets[i], dt)
+			}
+			if flyers || g.bullets[i].position.Y != groundBulletY {
+				g.matchBulletHeight(&g.bullets[i], dt)
+			}
+
 			newPos := rl.Vector3{
 				X: g.bullets[i].position.X + g.bullets[i].velocity.X*dt,
 				Y: g.bullets[i].position.Y,
 				Z: g.bullets[i].position.Z + g.bullets[i].velocity.Z*dt,
 			}
 
-			// Check obstacle collision
-			if g.CheckObstacleCollision(newPos, 0.3) {
+			// Check obstacle collision (bullets climbing to a flyer pass over walls)
+			if hit := g.obstacleAt(newPos, 0.3); hit >= 0 && newPos.Y <= g.obstacleTop(hit) {
+				if g.bullets[i].kind == ProjectileRocket {
+					g.explodeRocket(&g.bullets[i])
+					continue
+				}
 				g.bullets[i].active = false
 				g.CreateExplosion(g.bullets[i].position, rl.Yellow, 5)
+				g.playImpact(hit)
+				continue
+			}
+
+			// Nests soak up bullets
+			if n := g.nestAt(newPos, 0.3); n >= 0 {
+				if g.bullets[i].kind == ProjectileRocket {
+					g.explodeRocket(&g.bullets[i])
+					continue
+				}
+				g.bullets[i].active = false
+				g.CreateExplosion(newPos, rl.Purple, 5)
+				g.damageNest(n, bulletDamage(&g.bullets[i]))
 				continue
 			}
 
@@ -1333,16 +2042,47 @@ func (g *Game) Update(dt float32) {
 				math.Abs(float64(g.bullets[i].position.X)) > 40 {
 				g.bullets[i].active = false
 			}
+
+			// Short-range weapons expire (rockets detonate at the end of their range)
+			if maxRange := weaponDefs[g.bullets[i].weapon].maxRange; maxRange > 0 && bulletTravel(&g.bullets[i]) > maxRange {
+				if g.bullets[i].kind == ProjectileRocket {
+					g.explodeRocket(&g.bullets[i])
+				} else {
+					g.bullets[i].active = false
+				}
+			}
 		}
 	}
 
+	g.updateGrenades(dt)
+	g.updateDrones(dt)
+	g.updateAttachments(dt)
+	g.updateMovingHazards(dt)
+	g.updatePortals(dt)
+	g.updateNight(dt)
+	g.updateLights(dt)
+	g.updateCombo(dt)
+	g.updateFloorZones(dt)
+	g.updateAnimations(dt)
+	g.updateNests(dt)
+	g.updateStructures(dt)
+	g.pollBossDefs(dt)
+	g.updateBossShells(dt)
+	g.updateEmitters(dt)
+	g.updateTelegraphs(dt)
+
+	g.rebuildEnemyGrid()
+
 	// Boss spawn check
-	if g.level%5 == 0 && !g.bossSpawned {
+	g.updateBossRush(dt)
+	if g.level%5 == 0 && !g.bossSpawned && !g.practiceMode {
 		g.SpawnBoss()
 	}
 
-	// Spawn enemies
-	if !g.bossActive {
+	// Spawn enemies; in possession mode Player 2 does it
+	g.updatePossession(dt)
+	g.updatePractice()
+	if !g.bossActive && !g.bossRushMode && !g.possessMode && !g.practiceMode {
 		g.spawnTimer += dt
 		if g.spawnTimer > g.spawnInterval {
 			g.spawnTimer = 0
@@ -1380,68 +2120,21 @@ func (g *Game) Update(dt float32) {
 		}
 
 		if g.enemies[i].isBoss {
-			// Boss: เคลื่อนที่ตรงไปหาผู้เล่น + วนรอบเล็กน้อย
-			dx := nearestPlayer.position.X - g.enemies[i].position.X
-			dz := nearestPlayer.position.Z - g.enemies[i].position.Z
-			dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
-
-			if dist > 5.0 { // ถ้าไกล ก็เข้าใกล้
-				speed := float32(6.0 + float64(g.level)*0.3)
-				newPos := rl.Vector3{
-					X: g.enemies[i].position.X + (dx/dist)*speed*dt,
-					Y: g.enemies[i].position.Y,
-					Z: g.enemies[i].position.Z + (dz/dist)*speed*dt,
-				}
-
-				if !g.CheckObstacleCollision(newPos, g.enemies[i].size/2) {
-					g.enemies[i].position = newPos
-				}
-			} else { // ถ้าใกล้แล้ว ก็วนรอบ
-				angle := g.gameTime * 1.0
-				radius := float32(8.0)
-				targetX := nearestPlayer.position.X + float32(math.Cos(float64(angle)))*radius
-				targetZ := nearestPlayer.position.Z + float32(math.Sin(float64(angle)))*radius
-
-				dx = targetX - g.enemies[i].position.X
-				dz = targetZ - g.enemies[i].position.Z
-				speed := float32(8.0)
-
-				newPos := rl.Vector3{
-					X: g.enemies[i].position.X + dx*speed*dt*0.1,
-					Y: g.enemies[i].position.Y,
-					Z: g.enemies[i].position.Z + dz*speed*dt*0.1,
-				}
-
-				if !g.CheckObstacleCollision(newPos, g.enemies[i].size/2) {
-					g.enemies[i].position = newPos
-				}
-			}
-		} else {
-			// Normal enemy: ไล่ตามผู้เล่น
-			dx := nearestPlayer.position.X - g.enemies[i].position.X
-			dz := nearestPlayer.position.Z - g.enemies[i].position.Z
-			dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
-
-			if dist > 0.1 {
-				speed := float32(math.Sqrt(float64(g.enemies[i].velocity.X*g.enemies[i].velocity.X +
-					g.enemies[i].velocity.Z*g.enemies[i].velocity.Z)))
-				g.enemies[i].velocity.X = dx / dist * speed
-				g.enemies[i].velocity.Z = dz / dist * speed
-			}
-
-			newPos := rl.Vector3{
-				X: g.enemies[i].position.X + g.enemies[i].velocity.X*dt,
-				Y: g.enemies[i].position.Y,
-				Z: g.enemies[i].position.Z + g.enemies[i].velocity.Z*dt,
-			}
-
-			if !g.CheckObstacleCollision(newPos, g.enemies[i].size/2) {
-				g.enemies[i].position = newPos
+			g.updateBoss(i, nearestPlayer, dt)
+		} else if !g.isPossessed(i) && !g.isDummy(i) {
+			g.runBehavior(i, nearestPlayer, dt)
+			// Kamikazes remove themselves when they go off
+			if !g.enemies[i].active {
+				continue
 			}
 		}
 
-		// Collision with players
+		// Collision with players (kamikazes detonate instead, flyers only
+		// touch down during a swoop)
 		for pIdx := range g.players {
+			if g.enemies[i].kind == EnemyKamikaze || !flyerLow(&g.enemies[i]) || g.isDummy(i) {
+				break
+			}
 			player := &g.players[pIdx]
 			dx := player.position.X - g.enemies[i].position.X
 			dz := player.position.Z - g.enemies[i].position.Z
@@ -1457,22 +2150,14 @@ func (g *Game) Update(dt float32) {
 				if g.enemies[i].isBoss {
 					damage = 30
 				}
-				player.health -= damage
-				g.CreateExplosion(player.position, rl.Red, 10)
-				g.playSound(g.sounds.hit)
+				g.damagePlayer(player, damage)
+				g.enemyAttacked(&g.enemies[i])
 
 				if playerDist > 0 {
 					pushDist := float32(3.0)
 					g.enemies[i].position.X += (g.enemies[i].position.X - player.position.X) / playerDist * pushDist
 					g.enemies[i].position.Z += (g.enemies[i].position.Z - player.position.Z) / playerDist * pushDist
 				}
-
-				if player.health <= 0 {
-					g.state = StateGameOver
-					if g.score > g.highScore {
-						g.highScore = g.score
-					}
-				}
 			}
 		}
 
@@ -1484,18 +2169,34 @@ func (g *Game) Update(dt float32) {
 				dist := math.Sqrt(float64(dx*dx + dz*dz))
 
 				if dist < float64(g.enemies[i].size) {
-					g.enemies[i].health -= g.bullets[j].damage
-					g.bullets[j].active = false
-					g.CreateExplosion(g.enemies[i].position, rl.Yellow, 5)
-
-					if g.enemies[i].health <= 0 {
-						g.KillEnemy(i)
+					// Bullets come from where they are heading away from
+					b := &g.bullets[j]
+					if b.passedThrough(i) {
+						continue
+					}
+					if g.enemies[i].kind == EnemyFlyer && math.Abs(float64(b.position.Y-g.enemies[i].position.Y)) > float64(g.enemies[i].size) {
+						continue
+					}
+					if b.kind != ProjectileRocket && g.shieldBlocks(i, b.position.X-b.velocity.X, b.position.Z-b.velocity.Z) {
+						b.active = false
+						g.deflect(b.position)
+						continue
+					}
+					if g.bullets[j].kind == ProjectileRocket {
+						g.explodeRocket(&g.bullets[j])
+					} else {
+						g.bulletHitEnemy(b, i)
+					}
+					if !g.enemies[i].active {
+						break
 					}
 				}
 			}
 		}
 	}
 
+	g.updateEnemyBullets(dt)
+
 	// Update particles
 	for i := range g.particles {
 		if g.particles[i].active {
@@ -1528,10 +2229,22 @@ func (g *Game) Update(dt float32) {
 					switch g.powerUps[i].pType {
 					case 0:
 						player.health = int(math.Min(float64(player.health+30), float64(player.stats.maxHealth)))
+						g.attach(playerOwner(player), EffectGlow, buffGlowTime, rl.Green)
 					case 1:
-						player.stats.speed = float32(math.Min(float64(player.stats.speed+2), 20))
+						// No hard cap: effectiveSpeed applies the soft cap
+						player.stats.speed += 2
+						g.attach(playerOwner(player), EffectGlow, buffGlowTime, rl.SkyBlue)
 					case 2:
 						player.stats.fireRate = float32(math.Max(float64(player.stats.fireRate-0.02), 0.05))
+						g.attach(playerOwner(player), EffectGlow, buffGlowTime, rl.Orange)
+					case powerUpAmmo:
+						player.pickUpAmmo()
+					case powerUpWeapon:
+						g.pickUpWeapon(player, g.powerUps[i].weapon)
+					case powerUpLoot:
+						g.openBossLoot(pIdx)
+					case powerUpShield:
+						g.addOvershield(player)
 					}
 
 					g.CreateExplosion(g.powerUps[i].position, rl.Green, 8)
@@ -1543,21 +2256,8 @@ func (g *Game) Update(dt float32) {
 	}
 
 	// Update camera
-	var centerX, centerZ float32
-	for _, player := range g.players {
-		centerX += player.position.X
-		centerZ += player.position.Z
-	}
-	centerX /= float32(len(g.players))
-	centerZ /= float32(len(g.players))
-
-	distance := float32(30.0)
-	g.camera.Position = rl.NewVector3(
-		centerX+distance*0.707,
-		distance*0.707,
-		centerZ+distance*0.707,
-	)
-	g.camera.Target = rl.NewVector3(centerX, 0, centerZ)
+	g.updateCamera(dt)
+	g.chunks.update(g.camera.Target.X, g.camera.Target.Z)
 }
 
 func (g *Game) DrawMenu() {
@@ -1565,34 +2265,49 @@ func (g *Game) DrawMenu() {
 
 	centerX := int32(screenWidth / 2)
 
-	rl.DrawText("3D SHOOTER", centerX-200, 100, 70, rl.Gold)
-	rl.DrawText("CO-OP EDITION", centerX-180, 180, 35, rl.Yellow)
+	drawText("3D SHOOTER", centerX-200, 100, 70, rl.Gold)
+	drawText("CO-OP EDITION", centerX-180, 180, 35, rl.Yellow)
 
 	menuItems := []string{
+		"Continue",
 		"Single Player",
 		"Co-op Mode",
+		"Daily Run",
+		"Boss Rush",
+		"Possession",
+		"Practice Range",
+		"Mastery",
+		"Armory",
 		"Settings",
 		"Quit",
 	}
 
 	for i, item := range menuItems {
-		y := int32(300 + i*60)
+		y := int32(270 + i*55)
 		color := rl.White
+		if i == 0 && !hasSuspendedRun() {
+			color = rl.DarkGray
+		}
 
 		if i == g.menuSelection {
 			color = rl.Yellow
 			rl.DrawRectangle(centerX-200, y-5, 400, 50, rl.NewColor(255, 255, 0, 50))
-			rl.DrawText(">", centerX-250, y, 40, rl.Yellow)
+			drawText(">", centerX-250, y, 40, rl.Yellow)
 		}
 
-		rl.DrawText(item, centerX-150, y, 40, color)
+		drawText(item, centerX-150, y, 40, color)
 	}
 
-	rl.DrawText("Use UP/DOWN arrows and ENTER to select", centerX-250, screenHeight-80, 20, rl.LightGray)
+	drawText("Use UP/DOWN arrows and ENTER to select", centerX-250, screenHeight-80, 20, rl.LightGray)
+	drawText("CTRL+V: play a pasted build code", centerX-250, screenHeight-110, 20, rl.Gray)
+	if g.loadoutError != "" {
+		drawText("Build code: "+g.loadoutError, centerX-250, screenHeight-140, 20, rl.Red)
+	}
 
 	if g.highScore > 0 {
-		rl.DrawText(fmt.Sprintf("High Score: %d", g.highScore), centerX-100, screenHeight-40, 25, rl.Gold)
+		drawText(fmt.Sprintf("High Score: %d", g.highScore), centerX-100, screenHeight-40, 25, rl.Gold)
 	}
+	g.drawUpdateToast()
 }
 
 func (g *Game) DrawSettings() {
@@ -1600,9 +2315,9 @@ func (g *Game) DrawSettings() {
 
 	centerX := int32(screenWidth / 2)
 
-	rl.DrawText("SETTINGS", centerX-120, 80, 50, rl.Gold)
+	drawText("SETTINGS", centerX-120, 80, 50, rl.Gold)
 
-	settingsY := int32(200)
+	settingsY := int32(180)
 
 	settings := []struct {
 		name  string
@@ -1620,8 +2335,10 @@ func (g *Game) DrawSettings() {
 			}
 			return "OFF"
 		}()},
-		{"Sound Volume", fmt.Sprintf("%.0f%%", g.settings.soundVolume*100)},
-		{"Music Volume", fmt.Sprintf("%.0f%%", g.settings.musicVolume*100)},
+		{"Music Volume", fmt.Sprintf("%.0f%%", g.settings.volumes[BusMusic]*100)},
+		{"SFX Volume", fmt.Sprintf("%.0f%%", g.settings.volumes[BusSFX]*100)},
+		{"UI Volume", fmt.Sprintf("%.0f%%", g.settings.volumes[BusUI]*100)},
+		{"Voice Volume", fmt.Sprintf("%.0f%%", g.settings.volumes[BusVoice]*100)},
 		{"Difficulty", func() string {
 			switch g.settings.difficulty {
 			case 0:
@@ -1634,51 +2351,110 @@ func (g *Game) DrawSettings() {
 				return "NORMAL"
 			}
 		}()}, // Fixed: Added missing parentheses and comma
+		{"Input Buffer", func() string {
+			if g.settings.inputBuffer <= 0 {
+				return "OFF"
+			}
+			return fmt.Sprintf("%.0f ms", g.settings.inputBuffer*1000)
+		}()},
+		{"Sprint Modifier", func() string {
+			if g.settings.modifiers.sprint {
+				return "ON"
+			}
+			return "OFF"
+		}()},
+		{"Ammo & Reload", func() string {
+			if g.settings.modifiers.ammo {
+				return "ON"
+			}
+			return "OFF"
+		}()},
+		{"Energy", func() string {
+			if g.settings.modifiers.energy {
+				return "ON"
+			}
+			return "OFF"
+		}()},
+		{"Keyboard Layout", layoutNames[activeLayout]},
+		{"Streamer Mode", func() string {
+			if g.settings.streamerMode {
+				return "ON"
+			}
+			return "OFF"
+		}()},
+		{"Team Colors", palettes[g.settings.palette].name},
+		{"Dynamic Resolution", func() string {
+			if g.settings.dynamicRes {
+				return "ON"
+			}
+			return "OFF"
+		}()},
+		{"Post Effects", func() string {
+			if g.settings.postFX {
+				return "ON"
+			}
+			return "OFF"
+		}()},
+		{"Screen Shake", shakeLevelNames[g.settings.shake]},
+		{"Frame Pacing", pacingNames[g.settings.pacing]},
+		{"Update Check", func() string {
+			if g.updates.Enabled {
+				return "ON"
+			}
+			return "OFF"
+		}()},
+		{"Autosave", autosaveNames[g.settings.autosave]},
+		{"Controls", ""},
 		{"Back", ""},
 	}
 
 	for i, setting := range settings {
-		y := settingsY + int32(i*60)
+		y := settingsY + int32(i*34)
 		color := rl.White
 
 		if i == g.settingsSelection {
 			color = rl.Yellow
-			rl.DrawRectangle(centerX-300, y-5, 600, 50, rl.NewColor(255, 255, 0, 50))
-			rl.DrawText(">", centerX-350, y, 35, rl.Yellow)
+			rl.DrawRectangle(centerX-300, y-4, 600, 34, rl.NewColor(255, 255, 0, 50))
+			drawText(">", centerX-350, y, 35, rl.Yellow)
 		}
 
-		rl.DrawText(setting.name, centerX-280, y, 35, color)
+		if g.settingLocked(i) {
+			color = rl.DarkGray
+		}
+
+		drawText(setting.name, centerX-280, y, 35, color)
 
 		if setting.value != "" {
 			valueColor := color
-			if i < 4 {
+			if i < 6 {
 				valueColor = rl.Lime
 			}
-			rl.DrawText(setting.value, centerX+100, y, 35, valueColor)
+			drawText(setting.value, centerX+100, y, 35, valueColor)
+		}
+		if setting.name == "Team Colors" {
+			g.drawPaletteSwatches(centerX+120+measureText(setting.value, 35), y+3)
 		}
 	}
 
-	rl.DrawText("Use UP/DOWN to navigate, LEFT/RIGHT to change", centerX-280, screenHeight-80, 20, rl.LightGray)
-	rl.DrawText("Press ESC or select Back to return", centerX-200, screenHeight-50, 20, rl.LightGray)
+	drawText("Use UP/DOWN to navigate, LEFT/RIGHT to change", centerX-280, screenHeight-80, 20, rl.LightGray)
+	drawText("Press ESC or select Back to return", centerX-200, screenHeight-50, 20, rl.LightGray)
+	if g.inRunSettings() {
+		note := "Run in progress: difficulty applies now, run rules are locked"
+		drawText(note, centerX-measureText(note, 20)/2, 142, 20, rl.Orange)
+	}
 }
 
 func (g *Game) DrawGame() {
-	rl.BeginMode3D(g.camera)
+	g.uploadLights()
+	g.beginScene()
 
-	// Draw floor with stage-specific color
-	floorColor := rl.NewColor(30, 30, 50, 255)
-	switch g.currentStage {
-	case StageMaze:
-		floorColor = rl.NewColor(40, 30, 50, 255)
-	case StageHazard:
-		floorColor = rl.NewColor(50, 30, 30, 255)
-	case StageArena:
-		floorColor = rl.NewColor(30, 40, 50, 255)
-	}
-	rl.DrawPlane(rl.NewVector3(0, 0, 0), rl.NewVector2(60, 60), floorColor)
+	// Draw floor with stage-specific texture or color
+	g.drawFloor()
+	g.drawStageLetterbox()
+	g.chunks.draw()
 
 	// Grid
-	gridSize := 60
+	gridSize := int(g.stageHalf * 2)
 	gridStep := 3
 	for i := -gridSize / 2; i <= gridSize/2; i += gridStep {
 		rl.DrawLine3D(
@@ -1693,20 +2469,40 @@ func (g *Game) DrawGame() {
 		)
 	}
 
+	g.drawFloorZones()
+	g.drawProps()
+	g.drawShadows()
+
 	// Draw obstacles
-	for i := range g.obstacles {
-		if g.obstacles[i].active {
-			if g.obstacles[i].obsType == 0 {
-				// Wall
-				rl.DrawCube(g.obstacles[i].position, g.obstacles[i].size.X, g.obstacles[i].size.Y, g.obstacles[i].size.Z, rl.NewColor(100, 100, 120, 255))
-				rl.DrawCubeWires(g.obstacles[i].position, g.obstacles[i].size.X, g.obstacles[i].size.Y, g.obstacles[i].size.Z, rl.White)
-			} else {
-				// Hazard
-				rl.DrawCube(g.obstacles[i].position, g.obstacles[i].size.X, g.obstacles[i].size.Y, g.obstacles[i].size.Z, rl.NewColor(200, 50, 50, 150))
-				rl.DrawCubeWires(g.obstacles[i].position, g.obstacles[i].size.X, g.obstacles[i].size.Y, g.obstacles[i].size.Z, rl.Red)
+	g.litPrimitives(func() {
+		for i := range g.obstacles {
+			if g.obstacles[i].active {
+				if g.obstacles[i].obsType == 0 {
+					g.drawWall(&g.obstacles[i])
+				} else if isMovingHazard(&g.obstacles[i]) {
+					g.drawMovingHazard(&g.obstacles[i])
+				} else if g.obstacles[i].obsType == obsCrate {
+					// Crate
+					rl.DrawCube(g.obstacles[i].position, g.obstacles[i].size.X, g.obstacles[i].size.Y, g.obstacles[i].size.Z, rl.NewColor(140, 95, 50, 255))
+					rl.DrawCubeWires(g.obstacles[i].position, g.obstacles[i].size.X, g.obstacles[i].size.Y, g.obstacles[i].size.Z, rl.Brown)
+				} else {
+					// Hazard
+					rl.DrawCube(g.obstacles[i].position, g.obstacles[i].size.X, g.obstacles[i].size.Y, g.obstacles[i].size.Z, rl.NewColor(200, 50, 50, 150))
+					rl.DrawCubeWires(g.obstacles[i].position, g.obstacles[i].size.X, g.obstacles[i].size.Y, g.obstacles[i].size.Z, rl.Red)
+				}
 			}
 		}
-	}
+	})
+
+	g.drawPortals()
+	g.drawNests()
+	g.drawStructures()
+	g.drawPossessCursor()
+	g.drawBossShells()
+	g.drawTelegraphs()
+
+	// Ghost of the best run on this seed
+	g.drawGhosts()
 
 	// Draw players
 	for _, player := range g.players {
@@ -1720,7 +2516,13 @@ func (g *Game) DrawGame() {
 			angleDeg := player.angle*180.0/math.Pi + player.modelYawOffsetDeg
 
 			position := player.position
-			position.Y += 0.5 // ยกโมเดลขึ้นเล็กน้อย
+			position.Y += 0.5 + player.walkBob() // ยกโมเดลขึ้นเล็กน้อย
+
+			base := ClipIdle
+			if player.isMoving {
+				base = ClipWalk
+			}
+			g.playerAnims.poseAnimator(player.model, &player.anim, base, g.gameTime)
 
 			rl.DrawModelEx(
 				player.model,
@@ -1744,47 +2546,46 @@ func (g *Game) DrawGame() {
 		)
 		rl.DrawLine3D(player.position, dirEnd, rl.Yellow)
 		rl.DrawSphere(dirEnd, 0.2, rl.Yellow)
+
+		g.drawBeam(player)
+		g.drawDashGhost(player)
 	}
+	g.drawDrones()
+	g.drawAttachments()
 
 	// Draw bullets
 	for i := range g.bullets {
 		if g.bullets[i].active {
-			bulletColor := rl.Yellow
-			if g.bullets[i].playerId == 1 {
-				bulletColor = rl.Lime
-			}
-			rl.DrawSphere(g.bullets[i].position, 0.3, bulletColor)
+			// Shots take the owner's colour whatever the weapon
+			rl.DrawSphere(g.bullets[i].position, weaponDefs[g.bullets[i].weapon].bulletSize, g.bulletColor(g.bullets[i].playerId))
 		}
 	}
+	g.drawGrenades()
+	g.drawEnemyBullets()
+	g.drawCorpses()
 
 	// Draw enemies
 	for i := range g.enemies {
 		if g.enemies[i].active {
+			color := g.enemyColor(&g.enemies[i])
 			if g.enemies[i].hasModel && g.enemies[i].model.MeshCount > 0 {
 				scale := g.enemies[i].modelScale
 				// Boss uses boss model assigned in SpawnBoss; others use enemyModel
+				g.animsFor(&g.enemies[i]).poseAnimator(g.enemies[i].model, &g.enemies[i].anim, ClipWalk, g.gameTime)
 				if g.enemies[i].isBoss {
-					rl.DrawModelEx(g.bossModel, g.enemies[i].position, rl.NewVector3(0, 1, 0), g.enemies[i].modelYawOffsetDeg, rl.NewVector3(scale, scale, scale), g.enemies[i].color)
+					rl.DrawModelEx(g.enemies[i].model, g.enemies[i].position, rl.NewVector3(0, 1, 0), g.enemies[i].modelYawOffsetDeg, rl.NewVector3(scale, scale, scale), color)
 				} else {
-					rl.DrawModelEx(g.enemyModel, g.enemies[i].position, rl.NewVector3(0, 1, 0), g.enemies[i].modelYawOffsetDeg, rl.NewVector3(scale, scale, scale), g.enemies[i].color)
+					rl.DrawModelEx(g.enemyModel, g.enemies[i].position, rl.NewVector3(0, 1, 0), g.enemies[i].modelYawOffsetDeg, rl.NewVector3(scale, scale, scale), color)
 				}
 			} else {
-				rl.DrawCube(g.enemies[i].position, g.enemies[i].size, g.enemies[i].size, g.enemies[i].size, g.enemies[i].color)
-				rl.DrawCubeWires(g.enemies[i].position, g.enemies[i].size, g.enemies[i].size, g.enemies[i].size, rl.Maroon)
+				e := &g.enemies[i]
+				g.litPrimitives(func() {
+					rl.DrawCube(e.position, e.size, e.size, e.size, color)
+					rl.DrawCubeWires(e.position, e.size, e.size, e.size, rl.Maroon)
+				})
 			}
-
-			// Boss HP bar
-			if g.enemies[i].isBoss {
-				healthPercent := float32(g.enemies[i].health) / float32(g.enemies[i].maxHealth)
-				barWidth := float32(5.0)
-				barHeight := float32(0.5)
-				barPos := g.enemies[i].position
-				barPos.Y += g.enemies[i].size + 1
-
-				rl.DrawCube(barPos, barWidth, barHeight, 0.1, rl.DarkGray)
-				healthBarPos := barPos
-				healthBarPos.X -= barWidth/2 - (barWidth*healthPercent)/2
-				rl.DrawCube(healthBarPos, barWidth*healthPercent, barHeight, 0.1, rl.Red)
+			if g.enemies[i].kind == EnemyShielded {
+				drawShield(&g.enemies[i])
 			}
 		}
 	}
@@ -1801,7 +2602,16 @@ func (g *Game) DrawGame() {
 	for i := range g.powerUps {
 		if g.powerUps[i].active {
 			pos := g.powerUps[i].position
-			pos.Y += float32(math.Sin(float64(g.gameTime*3))) * 0.3
+			pos.Y += g.powerUpBob()
+
+			if g.powerUps[i].pType == powerUpWeapon {
+				g.drawWeaponPickup(g.powerUps[i], pos)
+				continue
+			}
+			if g.powerUps[i].pType == powerUpLoot {
+				g.drawLootChest(pos)
+				continue
+			}
 
 			var color rl.Color
 			switch g.powerUps[i].pType {
@@ -1811,6 +2621,10 @@ func (g *Game) DrawGame() {
 				color = rl.SkyBlue
 			case 2:
 				color = rl.Magenta
+			case powerUpAmmo:
+				color = rl.Gold
+			case powerUpShield:
+				color = rl.Blue
 			}
 
 			rl.DrawCube(pos, 0.8, 0.8, 0.8, color)
@@ -1818,27 +2632,54 @@ func (g *Game) DrawGame() {
 		}
 	}
 
-	rl.EndMode3D()
+	g.drawInspectorMarker()
+	g.drawHeatmap()
+	g.endScene()
+	g.drawNightOverlay()
+	g.drawLowHealthVignette()
+
+	g.drawWeaponPickupLabels()
+	g.drawDummyLabels()
 
 	// UI
 	rl.DrawRectangle(10, 10, 450, 180, rl.NewColor(0, 0, 0, 150))
-	rl.DrawText(fmt.Sprintf("Score: %d", g.score), 20, 20, 25, rl.White)
-	rl.DrawText(fmt.Sprintf("Level: %d", g.level), 20, 50, 20, rl.Yellow)
+	drawText(fmt.Sprintf("Score: %d", g.score), 20, 20, 25, rl.White)
+
+	// Pace marker against the best run on this seed
+	if delta, ok := g.paceDelta(); ok {
+		paceColor := rl.Green
+		if delta < 0 {
+			paceColor = rl.Red
+		}
+		drawText(fmt.Sprintf("%+d vs best", delta), 240, 24, 20, paceColor)
+	}
+	drawText(fmt.Sprintf("Level: %d", g.level), 20, 50, 20, rl.Yellow)
+	g.drawWeaponIndicator(g.players[0], 160, 52)
 
 	// Stage indicator
-	stageNames := []string{"BASIC", "MAZE", "HAZARD", "ARENA"}
-	stageName := stageNames[int(g.currentStage)]
-	rl.DrawText(fmt.Sprintf("Stage: %s", stageName), 20, 75, 18, rl.NewColor(0, 255, 255, 255))
+	stageName := strings.ToUpper(stageNames[g.currentStage])
+	drawText(fmt.Sprintf("Stage: %s", stageName), 20, 75, 18, rl.NewColor(0, 255, 255, 255))
+	g.drawBossRushHUD()
+	g.drawBossBar()
+	g.drawPossessHUD()
+	g.drawPracticeHUD()
+	if g.statsShown() && g.state == StatePlaying {
+		g.drawRunStats(screenWidth/2-statsPanelW/2, 120)
+	}
+	g.drawCombo()
+	g.drawFinalStand()
+	g.drawMasteryNote()
+	g.drawStatPointHint()
 
 	if g.bossActive {
-		rl.DrawText("WARNING: BOSS FIGHT!", 20, 100, 22, rl.Red)
+		drawText("WARNING: "+strings.ToUpper(g.bossName())+"!", 20, 100, 22, rl.Red)
 	} else {
-		rl.DrawText(fmt.Sprintf("Enemies: %d", g.enemiesKilled), 20, 100, 18, rl.LightGray)
+		drawText(fmt.Sprintf("Enemies: %d", g.enemiesKilled), 20, 100, 18, rl.LightGray)
 	}
 
 	// Player stats
-	rl.DrawText(fmt.Sprintf("DMG: %d | SPD: %.0f | CRIT: %.0f%%",
-		g.players[0].stats.damage, g.players[0].stats.speed, g.players[0].stats.critChance*100), 20, 125, 16, rl.Lime)
+	drawText(fmt.Sprintf("DMG: %d | SPD: %.0f | CRIT: %.0f%%",
+		g.players[0].stats.damage, effectiveSpeed(g.players[0].stats.speed), g.players[0].stats.critChance*100), 20, 125, 16, rl.Lime)
 
 	// Health bars
 	healthBarY := int32(150)
@@ -1851,14 +2692,26 @@ func (g *Game) DrawGame() {
 			healthColor = rl.Orange
 		}
 
-		yPos := healthBarY + int32(pIdx*35)
+		yPos := healthBarY + int32(pIdx*40)
 
 		playerLabel := fmt.Sprintf("P%d", pIdx+1)
-		rl.DrawText(playerLabel, 20, yPos, 18, player.color)
+		drawText(playerLabel, 20, yPos, 18, player.color)
+		rl.DrawRectangle(44, yPos, 4, 25, player.color)
 
 		rl.DrawRectangle(50, yPos, 380, 25, rl.DarkGray)
 		rl.DrawRectangle(50, yPos, int32(380*healthPercent), 25, healthColor)
-		rl.DrawText(fmt.Sprintf("HP: %d/%d", player.health, player.stats.maxHealth), 55, yPos+3, 16, rl.White)
+		drawOvershieldBar(player, 50, yPos, 380, 25)
+		hpText := fmt.Sprintf("HP: %d/%d", player.health, player.stats.maxHealth)
+		if player.shield > 0 {
+			hpText += fmt.Sprintf(" +%d", player.shield)
+		}
+		drawText(hpText, 55, yPos+3, 16, rl.White)
+		g.drawEnergyBar(player, 50, yPos+27)
+		staminaY := yPos + 27
+		if g.settings.modifiers.energy {
+			staminaY += 6
+		}
+		g.drawStaminaBar(player, 50, staminaY)
 	}
 
 	// Skills UI
@@ -1867,19 +2720,18 @@ func (g *Game) DrawGame() {
 		skillY = 265
 	}
 	rl.DrawRectangle(10, skillY, 450, 140, rl.NewColor(0, 0, 0, 150))
-	rl.DrawText("=== P1 SKILLS ===", 20, skillY+10, 20, rl.Lime)
+	drawText("=== P1 SKILLS ===", 20, skillY+10, 20, rl.Lime)
 
-	skillKeys := []string{"Q", "E", "F"}
 	for i := range g.players[0].skills {
 		y := skillY + 40 + int32(i*30)
-		keyText := fmt.Sprintf("[%s]", skillKeys[i])
+		keyText := fmt.Sprintf("[%s]", bindingLabel(g.controls.binding(0, ActionSkill1+Action(i))))
 		skillName := g.players[0].skills[i].name
 
 		if g.players[0].skills[i].ready {
-			rl.DrawText(fmt.Sprintf("%s %s [READY]", keyText, skillName), 20, y, 18, rl.Green)
+			drawText(fmt.Sprintf("%s %s [READY]", keyText, skillName), 20, y, 18, rl.Green)
 		} else {
 			cooldownLeft := g.players[0].skills[i].cooldown
-			rl.DrawText(fmt.Sprintf("%s %s [%.1fs]", keyText, skillName, cooldownLeft), 20, y, 18, rl.Gray)
+			drawText(fmt.Sprintf("%s %s [%.1fs]", keyText, skillName, cooldownLeft), 20, y, 18, rl.Gray)
 
 			cdPercent := 1.0 - (cooldownLeft / g.players[0].skills[i].maxCooldown)
 			rl.DrawRectangle(240, y, 200, 15, rl.DarkGray)
@@ -1891,19 +2743,18 @@ func (g *Game) DrawGame() {
 	if g.coopMode {
 		skillY2 := int32(420)
 		rl.DrawRectangle(10, skillY2, 450, 180, rl.NewColor(0, 0, 0, 150))
-		rl.DrawText("=== P2 SKILLS ===", 20, skillY2+10, 20, rl.Lime)
+		drawText("=== P2 SKILLS ===", 20, skillY2+10, 20, rl.Lime)
 
-		skillKeys2 := []string{"Num1", "Num2", "Num3"}
 		for i := range g.players[1].skills {
 			y := skillY2 + 40 + int32(i*30)
-			keyText := fmt.Sprintf("[%s]", skillKeys2[i])
+			keyText := fmt.Sprintf("[%s]", bindingLabel(g.controls.binding(1, ActionSkill1+Action(i))))
 			skillName := g.players[1].skills[i].name
 
 			if g.players[1].skills[i].ready {
-				rl.DrawText(fmt.Sprintf("%s %s [READY]", keyText, skillName), 20, y, 18, rl.Green)
+				drawText(fmt.Sprintf("%s %s [READY]", keyText, skillName), 20, y, 18, rl.Green)
 			} else {
 				cooldownLeft := g.players[1].skills[i].cooldown
-				rl.DrawText(fmt.Sprintf("%s %s [%.1fs]", keyText, skillName, cooldownLeft), 20, y, 18, rl.Gray)
+				drawText(fmt.Sprintf("%s %s [%.1fs]", keyText, skillName, cooldownLeft), 20, y, 18, rl.Gray)
 
 				cdPercent := 1.0 - (cooldownLeft / g.players[1].skills[i].maxCooldown)
 				rl.DrawRectangle(240, y, 200, 15, rl.DarkGray)
@@ -1912,33 +2763,50 @@ func (g *Game) DrawGame() {
 		}
 
 		// P2 Shooting controls
-		rl.DrawText("NumPad 2468: Shoot | 0: Auto-aim", 20, skillY2+130, 14, rl.LightGray)
+		drawText("NumPad 2468: Shoot | 0: Fire | Enter: Auto-aim", 20, skillY2+130, 14, rl.LightGray)
+		g.drawWeaponIndicator(g.players[1], 20, skillY2+152)
 	}
 
-	// Controls
+	// Controls (named for the active keyboard layout)
 	if g.coopMode {
-		rl.DrawText("P1: WASD+QEF+Mouse | P2: Arrows+NumPad(2468=Shoot,123=Skills,0=Auto) | P: Pause", 10, screenHeight-30, 12, rl.LightGray)
+		drawText(fmt.Sprintf("P1: %s+%s+Mouse | P2: %s+%s+NumPad(2468=Shoot,0=Fire,Enter=Aim) | P: Pause",
+			g.moveKeysLabel(0), g.skillKeysLabel(0), g.moveKeysLabel(1), g.skillKeysLabel(1)), 10, screenHeight-30, 12, rl.LightGray)
 	} else {
-		rl.DrawText("WASD: Move | Mouse/Space: Shoot | Q/E/F: Skills | P: Pause", 10, screenHeight-30, 14, rl.LightGray)
+		drawText(fmt.Sprintf("%s: Move | %s: Shoot | %s: Skills | %s: Reload | P: Pause",
+			g.moveKeysLabel(0), bindingLabel(g.controls.binding(0, ActionFire)), g.skillKeysLabel(0),
+			bindingLabel(g.controls.binding(0, ActionReload))), 10, screenHeight-30, 14, rl.LightGray)
 	}
 
 	// Boss warning
 	if g.level%5 == 0 && !g.bossSpawned && g.level > 0 {
 		flashTime := int(g.gameTime * 3)
 		if flashTime%2 == 0 {
-			rl.DrawText("!!! BOSS INCOMING !!!", screenWidth/2-180, 100, 40, rl.Red)
+			drawText("!!! BOSS INCOMING !!!", screenWidth/2-180, 100, 40, rl.Red)
 		}
 	}
 
 	// Stage change warning
 	nextStageLevel := ((g.level / stageInterval) + 1) * stageInterval
 	if nextStageLevel-g.level <= 2 && nextStageLevel-g.level > 0 {
-		rl.DrawText(fmt.Sprintf("New Stage in %d levels!", nextStageLevel-g.level),
+		drawText(fmt.Sprintf("New Stage in %d levels!", nextStageLevel-g.level),
 			screenWidth/2-150, 150, 25, rl.Orange)
 	}
 
 	// FPS
-	rl.DrawText(fmt.Sprintf("FPS: %d", rl.GetFPS()), screenWidth-100, 10, 20, rl.Green)
+	drawText(fmt.Sprintf("FPS: %d", rl.GetFPS()), screenWidth-100, 10, 20, rl.Green)
+
+	g.drawStreamerHUD()
+
+	// Streamers hide the seed so viewers can't play the same run alongside
+	if g.seeded && !g.settings.streamerMode {
+		seedLabel := fmt.Sprintf("Seed: %d", g.seed)
+		if g.daily {
+			seedLabel = fmt.Sprintf("Daily: %d", g.seed)
+		}
+		drawText(seedLabel, screenWidth-220, 35, 18, rl.LightGray)
+	}
+
+	g.drawTwitch()
 }
 
 func (g *Game) DrawUpgrade() {
@@ -1947,72 +2815,106 @@ func (g *Game) DrawUpgrade() {
 	centerX := int32(screenWidth / 2)
 	centerY := int32(screenHeight / 2)
 
-	rl.DrawText("LEVEL UP!", centerX-150, centerY-200, 50, rl.Gold)
-	rl.DrawText("Choose an Upgrade:", centerX-180, centerY-140, 30, rl.White)
+	drawText("LEVEL UP!", centerX-150, centerY-200, 50, rl.Gold)
+	drawText("Choose an Upgrade:", centerX-180, centerY-140, 30, rl.White)
 
-	upgrades := []string{
-		"[1] Max Health +20",
-		"[2] Damage +1",
-		"[3] Speed +2",
-		"[4] Fire Rate +10%",
-		"[5] Crit Chance +5%",
+	const cardW, cardGap = 300, 40
+	cardsX := centerX - (upgradeOfferSize*cardW+(upgradeOfferSize-1)*cardGap)/2
+	for slot, idx := range g.upgradeOffer {
+		combo := g.players[0].completesSynergy(upgradePool[idx].trait)
+		drawUpgradeCard(upgradePool[idx], combo, slot+1, cardsX+int32(slot)*(cardW+cardGap), centerY-90)
 	}
 
-	for i, upgrade := range upgrades {
-		y := centerY - 80 + int32(i)*50
-		color := rl.White
-
-		if i == g.upgradeChoice {
-			color = rl.Yellow
-			rl.DrawRectangle(centerX-250, y-5, 500, 40, rl.NewColor(255, 255, 0, 50))
+	// Evolutions and the drone are offered on top of the draw
+	chooseHint := "Press 1-3 to choose"
+	extraY := centerY + 130
+	if evolutions := g.evolutionLabels(); len(evolutions) > 0 {
+		drawText("[4] Evolve: "+strings.Join(evolutions, ", "), centerX-240, extraY, 25, rl.Gold)
+		chooseHint = "Press 1-4 to choose"
+		extraY += 40
+	}
+	if drone := g.droneUpgradeLabel(); drone != "" {
+		drawText("[5] "+drone, centerX-240, extraY, 25, rl.SkyBlue)
+		chooseHint = "Press 1-5 to choose"
+		if !g.anyEvolutionReady() {
+			chooseHint = "Press 1-3 or 5 to choose"
 		}
-
-		rl.DrawText(upgrade, centerX-240, y, 25, color)
+		extraY += 40
 	}
+	hintY := extraY + 10
 
-	rl.DrawText("Press 1-5 to choose", centerX-150, centerY+150, 20, rl.LightGray)
+	drawText(chooseHint, centerX-150, hintY, 20, rl.LightGray)
+	if g.build.points > 0 {
+		drawText(fmt.Sprintf("Press B to build defenses (%d points)", g.build.points), centerX-150, hintY+30, 20, rl.Orange)
+	}
+	drawText(fmt.Sprintf("Press K for the skill tree (%d points)", g.players[0].skillPoints), centerX-150, hintY+60, 20, rl.SkyBlue)
 
 	// Current stats
 	statsY := int32(50)
 	rl.DrawRectangle(screenWidth-320, statsY, 310, 180, rl.NewColor(0, 0, 0, 150))
-	rl.DrawText("Current Stats:", screenWidth-310, statsY+10, 20, rl.Lime)
-	rl.DrawText(fmt.Sprintf("Max HP: %d", g.players[0].stats.maxHealth), screenWidth-310, statsY+40, 18, rl.White)
-	rl.DrawText(fmt.Sprintf("Damage: %d", g.players[0].stats.damage), screenWidth-310, statsY+65, 18, rl.White)
-	rl.DrawText(fmt.Sprintf("Speed: %.1f", g.players[0].stats.speed), screenWidth-310, statsY+90, 18, rl.White)
-	rl.DrawText(fmt.Sprintf("Fire Rate: %.2fs", g.players[0].stats.fireRate), screenWidth-310, statsY+115, 18, rl.White)
-	rl.DrawText(fmt.Sprintf("Crit: %.0f%%", g.players[0].stats.critChance*100), screenWidth-310, statsY+140, 18, rl.White)
-}
-
-func (g *Game) DrawPaused() {
-	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 150))
-	rl.DrawText("PAUSED", screenWidth/2-100, screenHeight/2-30, 40, rl.White)
-	rl.DrawText("Press P to Resume", screenWidth/2-120, screenHeight/2+20, 25, rl.Green)
-	rl.DrawText("Press ESC for Menu", screenWidth/2-120, screenHeight/2+55, 25, rl.Yellow)
+	drawText("Current Stats:", screenWidth-310, statsY+10, 20, rl.Lime)
+	drawText(fmt.Sprintf("Max HP: %d", g.players[0].stats.maxHealth), screenWidth-310, statsY+40, 18, rl.White)
+	drawText(fmt.Sprintf("Damage: %d", g.players[0].stats.damage), screenWidth-310, statsY+65, 18, rl.White)
+	drawText(fmt.Sprintf("Speed: %.1f", effectiveSpeed(g.players[0].stats.speed)), screenWidth-310, statsY+90, 18, rl.White)
+	drawText(fmt.Sprintf("Fire Rate: %.2fs", g.players[0].stats.fireRate), screenWidth-310, statsY+115, 18, rl.White)
+	drawText(fmt.Sprintf("Crit: %.0f%%", g.players[0].stats.critChance*100), screenWidth-310, statsY+140, 18, rl.White)
 }
 
 func (g *Game) DrawGameOver() {
 	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 180))
-	rl.DrawText("GAME OVER!", screenWidth/2-180, screenHeight/2-100, 60, rl.Red)
-	rl.DrawText(fmt.Sprintf("Final Score: %d", g.score), screenWidth/2-150, screenHeight/2-20, 35, rl.White)
+	if g.bossRushMode && g.rush.cleared {
+		drawText("RUSH CLEAR!", screenWidth/2-190, screenHeight/2-100, 60, rl.Gold)
+	} else {
+		drawText("GAME OVER!", screenWidth/2-180, screenHeight/2-100, 60, rl.Red)
+	}
+	if g.possessMode {
+		drawText("PLAYER 2 WINS", screenWidth/2-measureText("PLAYER 2 WINS", 30)/2, screenHeight/2-140, 30, rl.Magenta)
+	}
+	drawText(fmt.Sprintf("Final Score: %d", g.score), screenWidth/2-150, screenHeight/2-20, 35, rl.White)
+	g.drawScrapEarned(screenHeight/2 - 180)
 
-	rl.DrawText(fmt.Sprintf("Max Level: %d", g.level), screenWidth/2-120, screenHeight/2+25, 28, rl.Yellow)
-	rl.DrawText(fmt.Sprintf("Enemies Killed: %d", g.enemiesKilled), screenWidth/2-140, screenHeight/2+60, 25, rl.LightGray)
+	if g.bossRushMode {
+		g.drawBossRushResult(screenHeight/2 + 25)
+	} else {
+		drawText(fmt.Sprintf("Max Level: %d", g.level), screenWidth/2-120, screenHeight/2+25, 28, rl.Yellow)
+		drawText(fmt.Sprintf("Enemies Killed: %d", g.enemiesKilled), screenWidth/2-140, screenHeight/2+60, 25, rl.LightGray)
+	}
 	if g.highScore > 0 {
-		rl.DrawText(fmt.Sprintf("High Score: %d", g.highScore), screenWidth/2-130, screenHeight/2+95, 25, rl.Gold)
+		drawText(fmt.Sprintf("High Score: %d", g.highScore), screenWidth/2-130, screenHeight/2+95, 25, rl.Gold)
 	}
-	rl.DrawText("Press R to Restart", screenWidth/2-130, screenHeight/2+135, 28, rl.Green)
-	rl.DrawText("Press ESC for Menu", screenWidth/2-130, screenHeight/2+170, 28, rl.Yellow)
+	drawText("Press R to Restart", screenWidth/2-130, screenHeight/2+135, 28, rl.Green)
+	drawText("Press ESC for Menu", screenWidth/2-130, screenHeight/2+170, 28, rl.Yellow)
+
+	if g.newSeedBest {
+		drawText("NEW BEST FOR THIS SEED!", screenWidth/2-170, screenHeight/2+215, 28, rl.Gold)
+	} else if best, ok := g.bestRecord(); ok {
+		drawText(fmt.Sprintf("Seed Best: %d", best.BestScore), screenWidth/2-110, screenHeight/2+215, 25, rl.Gold)
+	}
+	g.drawRunStats(40, 120)
+	g.drawLoadoutCodes(screenHeight/2 + 260)
+	g.drawShareHint(screenHeight/2 + 260 + int32(len(g.players))*28 + 24)
 }
 
 func (g *Game) Draw() {
-
+	g.updateResScale(rl.GetFrameTime())
 	rl.BeginDrawing()
+	g.drawFrame()
+	rl.EndDrawing()
+}
 
+// drawFrame draws the current state; the caller owns the render target.
+func (g *Game) drawFrame() {
 	switch g.state {
 	case StateMenu:
 		g.DrawMenu()
 	case StateSettings:
 		g.DrawSettings()
+	case StateMastery:
+		g.DrawMastery()
+	case StateArmory:
+		g.DrawArmory()
+	case StateControls:
+		g.DrawControls()
 	case StatePlaying:
 		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
 		g.DrawGame()
@@ -2024,30 +2926,163 @@ func (g *Game) Draw() {
 		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
 		g.DrawGame()
 		g.DrawUpgrade()
+	case StateBuild:
+		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+		g.DrawGame()
+		g.DrawBuild()
+	case StateLoot:
+		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+		g.DrawGame()
+		g.DrawLoot()
+	case StateSkillTree:
+		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+		g.DrawGame()
+		g.DrawSkillTree()
+	case StateCharacter:
+		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+		g.DrawGame()
+		g.DrawCharacter()
+	case StateSandbox:
+		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+		g.DrawGame()
+		g.DrawSandbox()
+	case StateAssetCheck:
+		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+		g.DrawAssetCheck()
 	case StateGameOver:
 		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
 		g.DrawGame()
-		g.DrawGameOver()
+		if g.arcade != nil {
+			g.DrawArcadeGameOver()
+		} else {
+			g.DrawGameOver()
+		}
+	case StateAttract:
+		g.DrawAttract()
+	case StateNameEntry:
+		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+		g.DrawGame()
+		g.DrawNameEntry()
+	case StateVictory:
+		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+		g.DrawGame()
+		g.DrawVictory()
 	}
 
-	rl.EndDrawing()
+	g.drawDebugOverlay()
+	g.drawInspector()
+	g.drawPerfOverlay()
+	g.drawTimeControl()
+	g.drawHeatmapLabel()
 }
 
 func main() {
+	seed := flag.Int64("seed", 0, "play a fixed seed (0 = random)")
+	twitchChannel := flag.String("twitch", "", "let this Twitch channel's chat vote on spawns")
+	assetBudget := flag.Int("asset-budget", defaultAssetBudgetMB, "asset memory budget in MB before unused assets are unloaded")
+	visualTest := flag.String("visual-test", "", "render the visual regression scenes and compare them with the goldens in this directory")
+	updateGoldens := flag.Bool("update-goldens", false, "with -visual-test, overwrite the goldens instead of comparing")
+	debugTools := flag.Bool("debug", false, "enable developer tools (entity inspector in the F3 overlay, F5-F7 time controls, F8 damage heatmap)")
+	updateBalance := flag.Bool("update-balance", false, "rewrite "+balanceGolden+" from the current balance and exit")
+	loadout := flag.String("loadout", "", "start a solo run with this build code")
+	streamPort := flag.Int("stream-port", 0, "serve live run stats as JSON on this localhost port (0 = off)")
+	remote := flag.Bool("remote", false, "accept kiosk remote control on localhost (port and token in "+remoteFile+")")
+	arcade := flag.Bool("arcade", false, "run as an arcade cabinet: attract loop, coins and credits (see "+arcadeFile+")")
+	verify := flag.String("verify-replay", "", "re-simulate a daily run replay, check its claimed score and exit")
+	flag.Parse()
+
+	if *updateBalance {
+		if !writeBalanceGolden() {
+			os.Exit(1)
+		}
+		return
+	}
+
 	rand.Seed(time.Now().UnixNano())
+	locateAssets()
+	assetProblems := checkAssets()
 
+	if *visualTest != "" || *verify != "" {
+		rl.SetConfigFlags(rl.FlagWindowHidden)
+	}
+	// Set by the test modes; exits only after the deferred cleanup below
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
 	rl.InitWindow(screenWidth, screenHeight, "3D Co-op Shooter - Enhanced")
 	defer rl.CloseWindow()
+	// ESC is a back/resume key on every screen; quitting goes through
+	// the menu or the window close button.
+	rl.SetExitKey(rl.KeyNull)
+	if fileExists(windowIconFile) {
+		icon := rl.LoadImage(windowIconFile)
+		rl.SetWindowIcon(*icon)
+		rl.UnloadImage(icon)
+	}
 
-	rl.SetTargetFPS(60)
+	// Frames are paced in beginFrameInput (latency.go)
+	rl.SetTargetFPS(0)
+
+	loadFonts()
+	defer unloadFonts()
 
 	game := NewGame()
-	defer rl.CloseAudioDevice()
+	game.fixedSeed = *seed
+	game.debugTools = *debugTools
+	game.assets.setBudget(*assetBudget)
+	// The test modes return without shutdown, which would save settings
+	defer func() {
+		if rl.IsAudioDeviceReady() {
+			rl.CloseAudioDevice()
+		}
+	}()
+	if *visualTest != "" {
+		if runVisualTests(game, *visualTest, *updateGoldens) > 0 {
+			exitCode = 1
+		}
+		return
+	}
+	if *verify != "" {
+		if !runVerifyReplay(game, *verify) {
+			exitCode = 1
+		}
+		return
+	}
+	if *twitchChannel != "" {
+		game.twitch = NewTwitchChat(*twitchChannel)
+	}
+	if *loadout != "" {
+		game.startWithLoadout(*loadout)
+	}
+	if *streamPort != 0 {
+		game.serveStreamStats(*streamPort)
+	}
+	if *remote {
+		game.startRemoteControl()
+	}
+	if *arcade {
+		game.startArcade()
+	}
+	if len(assetProblems) > 0 {
+		game.showAssetProblems(assetProblems)
+	}
+	game.startUpdateCheck()
 
-	for !rl.WindowShouldClose() {
+	for !rl.WindowShouldClose() && !game.quit {
+		game.beginFrameInput()
 		dt := rl.GetFrameTime()
-		game.Update(dt)
+		game.watchDevices(dt)
+		if step, ok := game.simDelta(dt); ok {
+			game.Update(step)
+		} else {
+			// Frozen: overlays and the inspector keep working
+			game.updateDebug(dt)
+		}
 		game.Draw()
-
+		game.endFrameInput()
 	}
+	game.shutdown()
 }
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Weapon mastery. Kills, shots and hits are counted per weapon across all
+// runs (evolved forms count toward their base weapon) and stored in
+// save/mastery.json. Kill milestones unlock small permanent perks for that
+// weapon. Daily runs still count but play without perks so everyone gets
+// the same run.
+const (
+	masteryFile     = saveDir + "/mastery.json"
+	masteryNoteTime = float32(3.0)
+)
+
+// WeaponMastery is the lifetime record for one weapon.
+type WeaponMastery struct {
+	Kills int `json:"kills"`
+	Shots int `json:"shots"`
+	Hits  int `json:"hits"`
+}
+
+func (m WeaponMastery) accuracy() float32 {
+	if m.Shots == 0 {
+		return 0
+	}
+	return float32(m.Hits) / float32(m.Shots)
+}
+
+type PerkKind int
+
+const (
+	PerkDamage   PerkKind = iota // damage +amount (fraction)
+	PerkFireRate                 // time between shots -amount (fraction)
+	PerkPellets                  // +amount projectiles per shot
+)
+
+// MasteryPerk unlocks once the weapon has kills kills.
+type MasteryPerk struct {
+	kills  int
+	desc   string
+	kind   PerkKind
+	amount float32
+}
+
+var masteryPerks = [baseWeaponCount][]MasteryPerk{
+	WeaponBlaster: {
+		{100, "+10% damage", PerkDamage, 0.1},
+		{500, "10% faster fire", PerkFireRate, 0.1},
+	},
+	WeaponShotgun: {
+		{100, "+10% damage", PerkDamage, 0.1},
+		{500, "+1 pellet", PerkPellets, 1},
+	},
+	WeaponRocket: {
+		{100, "10% faster fire", PerkFireRate, 0.1},
+		{500, "+10% damage", PerkDamage, 0.1},
+	},
+	WeaponLaser: {
+		{100, "+10% damage", PerkDamage, 0.1},
+		{500, "+10% damage", PerkDamage, 0.1},
+	},
+	WeaponHoming: {
+		{100, "10% faster fire", PerkFireRate, 0.1},
+		{500, "+1 missile", PerkPellets, 1},
+	},
+}
+
+func (g *Game) loadMastery() {
+	data, err := os.ReadFile(masteryFile)
+	if err != nil {
+		return
+	}
+	var saved map[string]WeaponMastery
+	if err := json.Unmarshal(data, &saved); err != nil {
+		fmt.Println("Warning: Could not read weapon mastery:", err)
+		return
+	}
+	for w := WeaponType(0); w < baseWeaponCount; w++ {
+		g.mastery[w] = saved[weaponDefs[w].name]
+	}
+}
+
+func (g *Game) saveMastery() {
+	if g.playback != nil {
+		// Verifying a replay plays someone's run, not ours
+		return
+	}
+	saved := map[string]WeaponMastery{}
+	for w := WeaponType(0); w < baseWeaponCount; w++ {
+		saved[weaponDefs[w].name] = g.mastery[w]
+	}
+	os.MkdirAll(filepath.Dir(masteryFile), os.ModePerm)
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		fmt.Println("Warning: Could not encode weapon mastery:", err)
+		return
+	}
+	if err := writeFileAtomic(masteryFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save weapon mastery:", err)
+	}
+}
+
+// masteryBonus adds up the unlocked perks of one kind for a weapon.
+func (g *Game) masteryBonus(weapon WeaponType, kind PerkKind) float32 {
+	if g.daily {
+		return 0
+	}
+	base := baseWeapon(weapon)
+	bonus := float32(0)
+	for _, perk := range masteryPerks[base] {
+		if perk.kind == kind && g.mastery[base].Kills >= perk.kills {
+			bonus += perk.amount
+		}
+	}
+	return bonus
+}
+
+// masteryDamage applies a weapon's damage perks.
+func (g *Game) masteryDamage(weapon WeaponType, damage int) int {
+	bonus := g.masteryBonus(weapon, PerkDamage)
+	if bonus == 0 {
+		return damage
+	}
+	return int(math.Round(float64(float32(damage) * (1 + bonus))))
+}
+
+// recordMasteryShot counts projectiles fired from the weapon and beam
+// ticks; skill and drone bullets aren't the weapon's shots. The practice
+// range doesn't count, dummies are too easy to hit.
+func (g *Game) recordMasteryShot(weapon WeaponType) {
+	if g.practiceMode {
+		return
+	}
+	g.mastery[baseWeapon(weapon)].Shots++
+}
+
+// recordMasteryHit counts shots that hit at least one enemy, once per shot
+// however many enemies it pierces.
+func (g *Game) recordMasteryHit(weapon WeaponType) {
+	if g.practiceMode {
+		return
+	}
+	g.mastery[baseWeapon(weapon)].Hits++
+}
+
+// recordMasteryKill credits a kill to the weapon that landed the last hit
+// and announces any perk it unlocks. Called from KillEnemy.
+func (g *Game) recordMasteryKill(e *Enemy) {
+	if !e.hitCredited || e.lastHitBy < 0 || e.lastHitBy >= len(g.players) {
+		return
+	}
+	base := baseWeapon(e.lastWeapon)
+	g.mastery[base].Kills++
+	for _, perk := range masteryPerks[base] {
+		if g.mastery[base].Kills == perk.kills {
+			g.masteryNote = fmt.Sprintf("%s mastery: %s", weaponDefs[base].name, perk.desc)
+			g.masteryNoteTimer = masteryNoteTime
+			g.CreateExplosion(g.players[e.lastHitBy].position, rl.Gold, 20)
+			g.announce("mastery")
+		}
+	}
+}
+
+// drawMasteryNote shows a newly unlocked perk for a few seconds.
+func (g *Game) drawMasteryNote() {
+	if g.masteryNoteTimer <= 0 {
+		return
+	}
+	alpha := float32(math.Min(1, float64(g.masteryNoteTimer)))
+	w := measureText(g.masteryNote, 28)
+	drawText(g.masteryNote, screenWidth/2-w/2, screenHeight/2-180, 28, rl.Fade(rl.Gold, alpha))
+}
+
+func (g *Game) UpdateMastery() {
+	if rl.IsKeyPressed(rl.KeyEscape) || rl.IsKeyPressed(rl.KeyEnter) {
+		g.playUISound(g.sounds.uiSelect)
+		g.state = StateMenu
+	}
+}
+
+func (g *Game) DrawMastery() {
+	rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+	centerX := int32(screenWidth / 2)
+	drawText("WEAPON MASTERY", centerX-measureText("WEAPON MASTERY", 50)/2, 70, 50, rl.Gold)
+
+	x := centerX - 520
+	y := int32(170)
+	drawText("Weapon", x, y, 24, rl.LightGray)
+	drawText("Kills", x+220, y, 24, rl.LightGray)
+	drawText("Accuracy", x+340, y, 24, rl.LightGray)
+	drawText("Perks", x+500, y, 24, rl.LightGray)
+
+	for w := WeaponType(0); w < baseWeaponCount; w++ {
+		m := g.mastery[w]
+		rowY := y + 50 + int32(w)*110
+		rl.DrawRectangle(x-10, rowY-8, 1060, 96, rl.NewColor(0, 0, 0, 120))
+		drawText(weaponDefs[w].name, x, rowY, 30, weaponDefs[w].color)
+		drawText(fmt.Sprintf("%d", m.Kills), x+220, rowY, 30, rl.White)
+		drawText(fmt.Sprintf("%.0f%%", m.accuracy()*100), x+340, rowY, 30, rl.White)
+
+		for i, perk := range masteryPerks[w] {
+			perkY := rowY + int32(i)*40
+			if m.Kills >= perk.kills {
+				drawText(fmt.Sprintf("%d kills: %s", perk.kills, perk.desc), x+500, perkY, 24, rl.Gold)
+				continue
+			}
+			drawText(fmt.Sprintf("%d kills: %s", perk.kills, perk.desc), x+500, perkY, 24, rl.Gray)
+			rl.DrawRectangle(x+850, perkY+6, 180, 12, rl.DarkGray)
+			rl.DrawRectangle(x+850, perkY+6, int32(180*float32(m.Kills)/float32(perk.kills)), 12, rl.SkyBlue)
+		}
+	}
+
+	hint := "Perks are permanent and apply to evolved forms. Daily runs play without them."
+	drawText(hint, centerX-measureText(hint, 20)/2, screenHeight-110, 20, rl.LightGray)
+	drawText("Press ESC or ENTER to return", centerX-measureText("Press ESC or ENTER to return", 20)/2, screenHeight-70, 20, rl.LightGray)
+}
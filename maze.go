@@ -0,0 +1,216 @@
+package main
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+// Maze stages. The middle of the floor is a grid of mazeCells x mazeCells
+// rooms carved with a recursive backtracker on the run's rng, so each maze
+// stage is different but a seed always builds the same one. A perfect
+// maze is all dead ends, which enemies walking straight at players can't
+// get out of, so about mazeBraid of the walls left standing are knocked
+// out again to make loops. The outer ring stays open.
+//
+// Collinear wall pieces are merged into one obstacle and the total is kept
+// under mazeWallBudget so crates still fit. The finished layout is checked
+// by flood fill so no floor is sealed off; a layout that fails is rebuilt.
+const (
+	mazeCells      = 5
+	mazeCellSize   = float32(10.0)
+	mazeWallThick  = float32(1.5)
+	mazeWallHeight = float32(3.0)
+	mazeBraid      = 0.35
+	mazeWallBudget = maxObstacles - 6 // leave room for the crates
+	mazeTries      = 5
+	mazeProbe      = float32(0.9) // player radius for the flood fill
+)
+
+// mazeWalls holds which walls stand: east[x][z] is the wall on the +X side
+// of cell (x, z), south[x][z] the one on its +Z side. The outer edge has
+// no walls.
+type mazeWalls struct {
+	east  [mazeCells - 1][mazeCells]bool
+	south [mazeCells][mazeCells - 1]bool
+}
+
+func (g *Game) GenerateMaze() {
+	for try := 0; try < mazeTries; try++ {
+		g.placeMazeWalls(g.carveMaze())
+		if g.floorConnected() {
+			return
+		}
+	}
+	// Never seen in practice; an open floor is better than a sealed one
+	for i := range g.obstacles {
+		g.obstacles[i].active = false
+	}
+}
+
+// carveMaze runs the backtracker from the centre room, then braids.
+func (g *Game) carveMaze() mazeWalls {
+	var m mazeWalls
+	for x := range m.east {
+		for z := range m.east[x] {
+			m.east[x][z] = true
+		}
+	}
+	for x := range m.south {
+		for z := range m.south[x] {
+			m.south[x][z] = true
+		}
+	}
+
+	var visited [mazeCells][mazeCells]bool
+	stack := [][2]int{{mazeCells / 2, mazeCells / 2}}
+	visited[mazeCells/2][mazeCells/2] = true
+	for len(stack) > 0 {
+		cell := stack[len(stack)-1]
+		var next [][2]int
+		for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			x, z := cell[0]+d[0], cell[1]+d[1]
+			if x >= 0 && x < mazeCells && z >= 0 && z < mazeCells && !visited[x][z] {
+				next = append(next, [2]int{x, z})
+			}
+		}
+		if len(next) == 0 {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+		n := next[g.rng.Intn(len(next))]
+		m.open(cell, n)
+		visited[n[0]][n[1]] = true
+		stack = append(stack, n)
+	}
+
+	for x := range m.east {
+		for z := range m.east[x] {
+			if m.east[x][z] && g.rng.Float64() < mazeBraid {
+				m.east[x][z] = false
+			}
+		}
+	}
+	for x := range m.south {
+		for z := range m.south[x] {
+			if m.south[x][z] && g.rng.Float64() < mazeBraid {
+				m.south[x][z] = false
+			}
+		}
+	}
+	return m
+}
+
+// open removes the wall between two neighbouring cells.
+func (m *mazeWalls) open(a, b [2]int) {
+	if a[0] > b[0] || a[1] > b[1] {
+		a, b = b, a
+	}
+	if a[0] != b[0] {
+		m.east[a[0]][a[1]] = false
+	} else {
+		m.south[a[0]][a[1]] = false
+	}
+}
+
+// placeMazeWalls turns the wall grid into obstacles, one per straight run,
+// dropping random runs if there are more than the budget.
+func (g *Game) placeMazeWalls(m mazeWalls) {
+	for i := range g.obstacles {
+		g.obstacles[i].active = false
+	}
+	origin := -mazeCellSize * mazeCells / 2
+
+	var walls []Obstacle
+	addRun := func(alongX bool, line, from, to int) {
+		lineAt := origin + float32(line+1)*mazeCellSize
+		mid := origin + float32(from+to)*mazeCellSize/2
+		length := float32(to-from)*mazeCellSize + mazeWallThick
+		o := Obstacle{active: true, obsType: 0}
+		if alongX {
+			o.position = rl.NewVector3(mid, 1, lineAt)
+			o.size = rl.NewVector3(length, mazeWallHeight, mazeWallThick)
+		} else {
+			o.position = rl.NewVector3(lineAt, 1, mid)
+			o.size = rl.NewVector3(mazeWallThick, mazeWallHeight, length)
+		}
+		walls = append(walls, o)
+	}
+	for x := range m.east {
+		for z := 0; z < mazeCells; {
+			if !m.east[x][z] {
+				z++
+				continue
+			}
+			start := z
+			for z < mazeCells && m.east[x][z] {
+				z++
+			}
+			addRun(false, x, start, z)
+		}
+	}
+	for z := 0; z < mazeCells-1; z++ {
+		for x := 0; x < mazeCells; {
+			if !m.south[x][z] {
+				x++
+				continue
+			}
+			start := x
+			for x < mazeCells && m.south[x][z] {
+				x++
+			}
+			addRun(true, z, start, x)
+		}
+	}
+
+	for len(walls) > mazeWallBudget {
+		i := g.rng.Intn(len(walls))
+		walls = append(walls[:i], walls[i+1:]...)
+	}
+	copy(g.obstacles, walls)
+}
+
+// floorConnected flood fills the floor from the player start and reports
+// whether every spot a player fits was reached.
+func (g *Game) floorConnected() bool {
+	const step = float32(1.0)
+	half := g.stageHalf - 1
+	n := int(2*half/step) + 1
+	at := func(i, j int) rl.Vector3 {
+		return rl.NewVector3(-half+float32(i)*step, 0.5, -half+float32(j)*step)
+	}
+
+	open := make([]bool, n*n)
+	free := 0
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if !g.CheckObstacleCollision(at(i, j), mazeProbe) {
+				open[i*n+j] = true
+				free++
+			}
+		}
+	}
+	startI := n / 2
+	if !open[startI*n+startI] {
+		return false
+	}
+
+	seen := make([]bool, n*n)
+	queue := []int{startI*n + startI}
+	seen[queue[0]] = true
+	reached := 0
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		reached++
+		i, j := c/n, c%n
+		for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			ni, nj := i+d[0], j+d[1]
+			if ni < 0 || ni >= n || nj < 0 || nj >= n {
+				continue
+			}
+			k := ni*n + nj
+			if open[k] && !seen[k] {
+				seen[k] = true
+				queue = append(queue, k)
+			}
+		}
+	}
+	return reached == free
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Movement tuning: sprint/stamina (run modifier) and the speed soft cap.
+const (
+	speedSoftCap      = float32(16.0) // full value up to here
+	speedSoftCapRange = float32(6.0)  // speed above the cap approaches cap+range
+
+	sprintMultiplier   = float32(1.6)
+	maxStamina         = float32(100.0)
+	staminaDrain       = float32(35.0) // per second while sprinting
+	staminaRegen       = float32(25.0) // per second once recovered
+	staminaRecoverWait = float32(1.0)  // seconds after sprinting before regen
+)
+
+// RunModifiers are optional rules chosen before a run.
+type RunModifiers struct {
+	sprint bool // hold Shift to sprint, limited by stamina
+	ammo   bool // weapons use magazines and need reloading
+	energy bool // shots and skills share a regenerating energy pool
+}
+
+// effectiveSpeed applies diminishing returns above speedSoftCap so stacked
+// speed bonuses stay controllable and the camera can keep up.
+func effectiveSpeed(raw float32) float32 {
+	if raw <= speedSoftCap {
+		return raw
+	}
+	over := float64((raw - speedSoftCap) / speedSoftCapRange)
+	return speedSoftCap + speedSoftCapRange*float32(1-math.Exp(-over))
+}
+
+// movementSpeed returns the player's speed for this frame, handling sprint
+// and stamina when the modifier is on.
+func (g *Game) movementSpeed(player *Player, moving bool, dt float32) float32 {
+	raw := player.stats.speed
+
+	if !g.settings.modifiers.sprint {
+		return effectiveSpeed(raw)
+	}
+
+	sprinting := moving && !player.exhausted && g.actionActive(player, ActionSprint)
+	if sprinting {
+		player.stamina -= staminaDrain * dt
+		player.staminaWait = staminaRecoverWait
+		if player.stamina <= 0 {
+			player.stamina = 0
+			// Out of breath: no sprinting until fully recovered
+			player.exhausted = true
+		}
+		raw *= sprintMultiplier
+	} else if player.staminaWait > 0 {
+		player.staminaWait -= dt
+	} else if player.stamina < maxStamina {
+		player.stamina = float32(math.Min(float64(maxStamina), float64(player.stamina+staminaRegen*dt)))
+		if player.stamina >= maxStamina {
+			player.exhausted = false
+		}
+	}
+
+	return effectiveSpeed(raw)
+}
+
+// drawStaminaBar draws a thin bar under a player's health bar.
+func (g *Game) drawStaminaBar(player Player, x, y int32) {
+	if !g.settings.modifiers.sprint {
+		return
+	}
+	color := rl.SkyBlue
+	if player.exhausted {
+		color = rl.Gray
+	}
+	rl.DrawRectangle(x, y, 380, 5, rl.DarkGray)
+	rl.DrawRectangle(x, y, int32(380*player.stamina/maxStamina), 5, color)
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Moving hazards. Crushers are wall blocks that slide back and forth
+// between two points, shoving anyone in their way and hurting whoever they
+// pin; rotating lasers are a short pillar sweeping a beam around itself.
+// Both live in g.obstacles like the static hazards (so bullets, collision
+// and the save file keep working) and carry their motion with them;
+// updateMovingHazards moves them once per simulated frame.
+//
+// Contact hurts players and enemies alike, once every hazardTickTime, so
+// standing in a beam costs a few hits a second rather than one per frame.
+// Flyers pass over both.
+const (
+	obsCrusher = 3
+	obsLaser   = 4
+
+	hazardTickTime = float32(0.4)
+	crusherDamage  = 15
+	laserDamage    = 10
+	laserWidth     = float32(0.35)
+	laserHeight    = float32(1.0) // beam height above the floor
+
+	// byHazard is the damageEnemy source for stage hazards; it counts for
+	// nobody in the run stats.
+	byHazard = -2
+)
+
+// HazardMotion is how a moving obstacle moves. Crushers ping-pong from
+// From to To, Speed being cycles per second; lasers turn Speed radians a
+// second, negative for clockwise.
+type HazardMotion struct {
+	From   rl.Vector3 `json:"from"`
+	To     rl.Vector3 `json:"to"`
+	Speed  float32    `json:"speed"`
+	Phase  float32    `json:"phase"`
+	Length float32    `json:"length,omitempty"` // laser beam reach from the pillar
+}
+
+func isMovingHazard(obs *Obstacle) bool {
+	return obs.obsType == obsCrusher || obs.obsType == obsLaser
+}
+
+// crusherAt is where a crusher is at a point of its cycle. The ease keeps
+// it lingering at both ends before it slams across.
+func crusherAt(m *HazardMotion) rl.Vector3 {
+	t := 0.5 - 0.5*float32(math.Cos(float64(m.Phase)*2*math.Pi))
+	t = t * t * (3 - 2*t)
+	return rl.Vector3Lerp(m.From, m.To, t)
+}
+
+// laserEnd is the far end of a laser's beam.
+func laserEnd(obs *Obstacle) rl.Vector3 {
+	m := &obs.motion
+	return rl.NewVector3(
+		obs.position.X+float32(math.Cos(float64(m.Phase)))*m.Length,
+		laserHeight,
+		obs.position.Z+float32(math.Sin(float64(m.Phase)))*m.Length,
+	)
+}
+
+// placeMovingHazards adds crushers and lasers to the Hazard stage around
+// the pools GenerateHazards already placed. A hazard whose sweep would run
+// into something is tried elsewhere, and dropped after a few tries.
+func (g *Game) placeMovingHazards() {
+	crushers := 2 + min(g.level/stageInterval/4, 2)
+	lasers := 2
+	for n := 0; n < crushers+lasers; n++ {
+		slot := g.freeObstacleSlot()
+		if slot < 0 {
+			return
+		}
+		for try := 0; try < 20; try++ {
+			angle := g.rng.Float64() * 2 * math.Pi
+			distance := 12.0 + g.rng.Float64()*8
+			center := rl.NewVector3(float32(math.Cos(angle)*distance), 1, float32(math.Sin(angle)*distance))
+			var obs Obstacle
+			if n < crushers {
+				obs = g.newCrusher(center)
+			} else {
+				obs = g.newLaser(center)
+			}
+			if g.hazardSweepBlocked(&obs) {
+				continue
+			}
+			g.obstacles[slot] = obs
+			break
+		}
+	}
+}
+
+func (g *Game) newCrusher(center rl.Vector3) Obstacle {
+	half := rl.NewVector3(6, 0, 0)
+	if g.rng.Intn(2) == 0 {
+		half = rl.NewVector3(0, 0, 6)
+	}
+	m := HazardMotion{
+		From:  rl.Vector3Subtract(center, half),
+		To:    rl.Vector3Add(center, half),
+		Speed: 0.25 + g.rng.Float32()*0.15,
+		Phase: g.rng.Float32(),
+	}
+	return Obstacle{
+		position: crusherAt(&m),
+		size:     rl.NewVector3(2.5, 2, 2.5),
+		active:   true,
+		obsType:  obsCrusher,
+		motion:   m,
+	}
+}
+
+func (g *Game) newLaser(center rl.Vector3) Obstacle {
+	speed := 0.8 + g.rng.Float32()*0.4
+	if g.rng.Intn(2) == 0 {
+		speed = -speed
+	}
+	return Obstacle{
+		position: center,
+		size:     rl.NewVector3(1, 2, 1),
+		active:   true,
+		obsType:  obsLaser,
+		motion: HazardMotion{
+			Speed:  speed,
+			Phase:  g.rng.Float32() * 2 * math.Pi,
+			Length: 7,
+		},
+	}
+}
+
+// hazardSweepBlocked reports whether anything already placed sits in the
+// area a moving hazard sweeps. Lasers only need their pillar clear and the
+// beam on the floor; the beam passes over the pools.
+func (g *Game) hazardSweepBlocked(obs *Obstacle) bool {
+	m := &obs.motion
+	if obs.obsType == obsLaser {
+		reach := g.stageHalf - 1 - m.Length
+		return g.CheckObstacleCollision(obs.position, 1.5) ||
+			float32(math.Abs(float64(obs.position.X))) > reach ||
+			float32(math.Abs(float64(obs.position.Z))) > reach
+	}
+	radius := obs.size.X/2 + 1
+	for k := 0; k <= 6; k++ {
+		p := rl.Vector3Lerp(m.From, m.To, float32(k)/6)
+		if g.CheckObstacleCollision(p, radius) || g.outsideStage(p, radius) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) outsideStage(p rl.Vector3, radius float32) bool {
+	return float32(math.Abs(float64(p.X)))+radius > g.stageHalf-1 ||
+		float32(math.Abs(float64(p.Z)))+radius > g.stageHalf-1
+}
+
+func (g *Game) freeObstacleSlot() int {
+	for i := range g.obstacles {
+		if !g.obstacles[i].active {
+			return i
+		}
+	}
+	return -1
+}
+
+// updateMovingHazards moves the crushers and lasers and applies contact
+// damage. Runs once per simulated frame.
+func (g *Game) updateMovingHazards(dt float32) {
+	g.hazardTick -= dt
+	tick := g.hazardTick <= 0
+	if tick {
+		g.hazardTick = hazardTickTime
+	}
+	for i := range g.obstacles {
+		obs := &g.obstacles[i]
+		if !obs.active || !isMovingHazard(obs) {
+			continue
+		}
+		obs.motion.Phase += obs.motion.Speed * dt
+		if obs.obsType == obsCrusher {
+			obs.motion.Phase = float32(math.Mod(float64(obs.motion.Phase), 1))
+			g.moveCrusher(i, tick)
+		} else {
+			obs.motion.Phase = float32(math.Mod(float64(obs.motion.Phase), 2*math.Pi))
+			if tick {
+				g.sweepLaser(obs)
+			}
+		}
+	}
+}
+
+// moveCrusher slides a crusher to its new spot and shoves what it runs
+// into out in front of it. Anything that can't be shoved (pinned against a
+// wall, or a boss) stays put inside the crusher and is hurt until it pulls
+// back.
+func (g *Game) moveCrusher(index int, tick bool) {
+	obs := &g.obstacles[index]
+	next := crusherAt(&obs.motion)
+	delta := rl.Vector3Subtract(next, obs.position)
+	obs.position = next
+
+	for i := range g.players {
+		p := &g.players[i]
+		if p.health <= 0 || !g.insideObstacle(obs, p.position, 0.9) {
+			continue
+		}
+		g.shove(index, delta, &p.position, 0.9)
+		if tick {
+			g.damagePlayer(p, crusherDamage)
+		}
+	}
+	for i := range g.enemies {
+		e := &g.enemies[i]
+		if !e.active || e.position.Y > g.obstacleTop(index) || !g.insideObstacle(obs, e.position, e.size/2) {
+			continue
+		}
+		if !e.isBoss {
+			g.shove(index, delta, &e.position, e.size/2)
+		}
+		if tick {
+			g.damageEnemy(i, crusherDamage, byHazard)
+		}
+	}
+}
+
+// shove moves pos out of the leading face of a crusher that moved by
+// delta. Something beside or behind the crusher isn't moved, and neither
+// is something with nowhere to go.
+func (g *Game) shove(index int, delta rl.Vector3, pos *rl.Vector3, radius float32) {
+	obs := &g.obstacles[index]
+	pushed := *pos
+	const gap = 0.01
+	switch {
+	case delta.X > 0 && pos.X > obs.position.X:
+		pushed.X = obs.position.X + obs.size.X/2 + radius + gap
+	case delta.X < 0 && pos.X < obs.position.X:
+		pushed.X = obs.position.X - obs.size.X/2 - radius - gap
+	case delta.Z > 0 && pos.Z > obs.position.Z:
+		pushed.Z = obs.position.Z + obs.size.Z/2 + radius + gap
+	case delta.Z < 0 && pos.Z < obs.position.Z:
+		pushed.Z = obs.position.Z - obs.size.Z/2 - radius - gap
+	default:
+		return
+	}
+	if !g.outsideStage(pushed, radius) && !g.blockedExcept(index, pushed, radius) {
+		*pos = pushed
+	}
+}
+
+// sweepLaser hurts everything the beam crosses.
+func (g *Game) sweepLaser(obs *Obstacle) {
+	end := laserEnd(obs)
+	for i := range g.players {
+		p := &g.players[i]
+		if p.health > 0 && distToBeam(obs.position, end, p.position) < laserWidth+0.9 {
+			g.damagePlayer(p, laserDamage)
+		}
+	}
+	for i := range g.enemies {
+		e := &g.enemies[i]
+		if e.active && e.position.Y < flyerHeight && distToBeam(obs.position, end, e.position) < laserWidth+e.size/2 {
+			g.damageEnemy(i, laserDamage, byHazard)
+		}
+	}
+}
+
+// distToBeam is the distance on the floor from p to the segment a-b.
+func distToBeam(a, b, p rl.Vector3) float32 {
+	abx, abz := b.X-a.X, b.Z-a.Z
+	t := ((p.X-a.X)*abx + (p.Z-a.Z)*abz) / (abx*abx + abz*abz)
+	t = max(0, min(1, t))
+	dx := p.X - (a.X + abx*t)
+	dz := p.Z - (a.Z + abz*t)
+	return float32(math.Sqrt(float64(dx*dx + dz*dz)))
+}
+
+func (g *Game) insideObstacle(obs *Obstacle, pos rl.Vector3, radius float32) bool {
+	return pos.X+radius > obs.position.X-obs.size.X/2 &&
+		pos.X-radius < obs.position.X+obs.size.X/2 &&
+		pos.Z+radius > obs.position.Z-obs.size.Z/2 &&
+		pos.Z-radius < obs.position.Z+obs.size.Z/2
+}
+
+// blockedExcept is CheckObstacleCollision ignoring one obstacle.
+func (g *Game) blockedExcept(skip int, pos rl.Vector3, radius float32) bool {
+	for i := range g.obstacles {
+		if i != skip && g.obstacles[i].active && g.insideObstacle(&g.obstacles[i], pos, radius) {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) drawMovingHazard(obs *Obstacle) {
+	pos, size := obs.position, obs.size
+	switch obs.obsType {
+	case obsCrusher:
+		rl.DrawCube(pos, size.X, size.Y, size.Z, rl.NewColor(120, 70, 60, 255))
+		rl.DrawCubeWires(pos, size.X, size.Y, size.Z, rl.Orange)
+		// Its track, so players can read where it goes
+		m := &obs.motion
+		rl.DrawLine3D(rl.NewVector3(m.From.X, 0.05, m.From.Z), rl.NewVector3(m.To.X, 0.05, m.To.Z), rl.Fade(rl.Orange, 0.6))
+	case obsLaser:
+		rl.DrawCube(pos, size.X, size.Y, size.Z, rl.NewColor(90, 90, 100, 255))
+		rl.DrawCubeWires(pos, size.X, size.Y, size.Z, rl.Red)
+		start := rl.NewVector3(pos.X, laserHeight, pos.Z)
+		end := laserEnd(obs)
+		rl.DrawCylinderEx(start, end, laserWidth/2, laserWidth/2, 6, rl.Fade(rl.Red, 0.8))
+		rl.DrawCircle3D(rl.NewVector3(pos.X, 0.03, pos.Z), obs.motion.Length, rl.NewVector3(1, 0, 0), 90, rl.Fade(rl.Red, 0.25))
+	}
+}
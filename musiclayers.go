@@ -0,0 +1,81 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Adaptive game music. On top of game_bgm, optional layer stems
+// (game_bgm_drums, game_bgm_lead, the same length as the base) fade in as
+// the fight heats up: an intensity between 0 and 1 is worked out from the
+// enemies on screen, the level and whether a boss is out, smoothed so the
+// music doesn't flicker, and each layer has a band of intensity over which
+// it fades from silent to full. Layers only play while the game track
+// does and are kept in step with it: whenever one drifts, it is seeked
+// back to the base's position.
+type MusicLayer int
+
+const (
+	LayerDrums MusicLayer = iota
+	LayerLead
+	musicLayerCount
+)
+
+var musicLayerEvents = [musicLayerCount]string{"game_bgm_drums", "game_bgm_lead"}
+
+// musicLayerBands is the intensity over which each layer fades in.
+var musicLayerBands = [musicLayerCount][2]float32{
+	LayerDrums: {0.25, 0.45},
+	LayerLead:  {0.6, 0.8},
+}
+
+const (
+	intensityEnemies   = 30 // active enemies that count as full intensity
+	intensityLevels    = 50 // levels until the level alone adds its full share
+	intensitySmoothing = float32(0.5)
+	musicSyncTolerance = float32(0.15) // seconds a layer may drift from the base, about a stream buffer
+)
+
+// targetIntensity is how hot the fight is right now, 0-1.
+func (g *Game) targetIntensity() float32 {
+	if g.state != StatePlaying && !g.paused() {
+		return 0
+	}
+	if g.bossActive {
+		return 1
+	}
+	enemies := 0
+	for i := range g.enemies {
+		if g.enemies[i].active {
+			enemies++
+		}
+	}
+	crowd := min(1, float32(enemies)/intensityEnemies)
+	depth := min(1, float32(g.level)/intensityLevels)
+	return min(1, crowd*0.8+depth*0.3)
+}
+
+// updateMusicLayers fades the layers to the current intensity under the
+// game track's level and keeps them in step with it.
+func (g *Game) updateMusicLayers(gameLevel, dt float32) {
+	target := g.targetIntensity()
+	g.musicIntensity += (target - g.musicIntensity) * min(1, intensitySmoothing*dt)
+
+	base := &g.sounds.gameBGM
+	for l := range g.sounds.gameLayers {
+		layer := &g.sounds.gameLayers[l]
+		band := musicLayerBands[l]
+		share := rl.Clamp((g.musicIntensity-band[0])/(band[1]-band[0]), 0, 1)
+		wasSilent := !layer.started || layer.paused
+		layer.fadeTo(gameLevel*share, dt)
+
+		if !layer.loaded() || !layer.started || layer.paused || !base.started || base.paused {
+			continue
+		}
+		at := rl.GetMusicTimePlayed(base.stream)
+		if wasSilent || float32(math.Abs(float64(rl.GetMusicTimePlayed(layer.stream)-at))) > musicSyncTolerance {
+			rl.SeekMusicStream(layer.stream, at)
+		}
+	}
+}
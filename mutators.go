@@ -0,0 +1,137 @@
+package main
+
+// Mutators. Anything that bends the rules of a run (difficulty, armory
+// buffs, practice dummies; run curses, weekly modifiers, challenges and
+// mods later) is a Mutator hooked in at the same few points instead of a
+// special case in the code it changes. The active list is built when a
+// run starts and the hooks run in that order:
+//
+//   - OnRunStart when a run (re)starts, once the players are reset
+//   - OnSpawn after a normal enemy is placed
+//   - OnDamage before damage lands on a player or an enemy; the returned
+//     amount is passed on to the next mutator and then applied
+//   - OnUpgradeOffer after an upgrade break draws its offer
+//   - OnTick once per simulated frame
+//
+// Mutators may use g.rng; anything else random would break seeded runs
+// and replays. The methods are exported so a scripting layer can wrap a
+// script in a Mutator.
+type Mutator interface {
+	Name() string
+	OnRunStart(g *Game)
+	OnSpawn(g *Game, e *Enemy)
+	OnDamage(g *Game, d DamageEvent) int
+	OnUpgradeOffer(g *Game, offer []int)
+	OnTick(g *Game, dt float32)
+}
+
+// DamageEvent is damage about to land. Exactly one of Player and Enemy is
+// set.
+type DamageEvent struct {
+	Player *Player
+	Enemy  *Enemy
+	Amount int
+}
+
+// BaseMutator implements every hook as a no-op; embed it and override the
+// hooks you need.
+type BaseMutator struct{}
+
+func (BaseMutator) OnRunStart(g *Game)                  {}
+func (BaseMutator) OnSpawn(g *Game, e *Enemy)           {}
+func (BaseMutator) OnDamage(g *Game, d DamageEvent) int { return d.Amount }
+func (BaseMutator) OnUpgradeOffer(g *Game, offer []int) {}
+func (BaseMutator) OnTick(g *Game, dt float32)          {}
+
+// difficultyMutator sets the starting spawn interval and health for the
+// chosen difficulty.
+type difficultyMutator struct {
+	BaseMutator
+	level int
+}
+
+func (m difficultyMutator) Name() string { return difficultyNames[m.level] }
+
+func (m difficultyMutator) OnRunStart(g *Game) {
+	interval, maxHealth := difficultyStart(m.level)
+	g.spawnInterval = interval
+	for i := range g.players {
+		g.players[i].stats.maxHealth = maxHealth
+		g.players[i].health = maxHealth
+	}
+}
+
+// setRunDifficulty moves a run in progress to another difficulty: the
+// difficulty's share of max health is swapped and, before the first
+// level-up, the spawn interval too. Boss rush times go under the lowest
+// difficulty the run was played on (recordDifficulty).
+func (g *Game) setRunDifficulty(level int) {
+	old := g.runDifficulty
+	if level == old {
+		return
+	}
+	oldInterval, oldHealth := difficultyStart(old)
+	interval, maxHealth := difficultyStart(level)
+	if g.level == 1 && g.spawnInterval == oldInterval {
+		g.spawnInterval = interval
+	}
+	for i := range g.players {
+		p := &g.players[i]
+		p.stats.maxHealth = max(p.stats.maxHealth+maxHealth-oldHealth, 1)
+		if p.health > 0 {
+			p.health = min(max(p.health+maxHealth-oldHealth, 1), p.stats.maxHealth)
+		}
+	}
+	for i, m := range g.mutators {
+		if _, ok := m.(difficultyMutator); ok {
+			g.mutators[i] = difficultyMutator{level: level}
+		}
+	}
+	g.runDifficulty = level
+	g.recordDifficulty = min(g.recordDifficulty, level)
+	g.recordReplayEvent(ReplayEvent{Kind: EventDifficulty, Value: level})
+}
+
+// runMutators builds the mutator list for a new run.
+func (g *Game) runMutators() []Mutator {
+	mutators := []Mutator{difficultyMutator{level: g.runDifficulty}}
+	if !g.daily && g.arcade == nil && !g.practiceMode {
+		mutators = append(mutators, armoryMutator{})
+	}
+	if g.practiceMode {
+		mutators = append(mutators, practiceMutator{})
+	}
+	return mutators
+}
+
+func (g *Game) mutateRunStart() {
+	g.mutators = g.runMutators()
+	for _, m := range g.mutators {
+		m.OnRunStart(g)
+	}
+}
+
+func (g *Game) mutateSpawn(e *Enemy) {
+	for _, m := range g.mutators {
+		m.OnSpawn(g, e)
+	}
+}
+
+func (g *Game) mutateDamage(d DamageEvent) int {
+	for _, m := range g.mutators {
+		d.Amount = m.OnDamage(g, d)
+	}
+	return d.Amount
+}
+
+func (g *Game) mutateUpgradeOffer() {
+	for _, m := range g.mutators {
+		m.OnUpgradeOffer(g, g.upgradeOffer[:])
+	}
+}
+
+func (g *Game) mutateTick(dt float32) {
+	for _, m := range g.mutators {
+		m.OnTick(g, dt)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Enemy nests. GenerateStage places a few stationary nests that spawn an
+// enemy every nestSpawnInterval on top of the normal spawn timer until
+// they are shot down. Destroying one is worth nestScore x level.
+const (
+	maxNests          = 4
+	nestRadius        = float32(1.2)
+	nestHeight        = float32(1.5)
+	nestSpawnInterval = float32(5.0)
+	nestEnemyCap      = 40 // nests pause while this many enemies are alive
+	nestScore         = 100
+)
+
+type Nest struct {
+	position   rl.Vector3
+	health     int
+	maxHealth  int
+	spawnTimer float32
+	active     bool
+}
+
+func nestHealth(level int) int { return 20 + level*2 }
+
+// placeNests clears the nests of the previous stage and places new ones
+// away from the centre and off obstacles.
+func (g *Game) placeNests() {
+	for i := range g.nests {
+		g.nests[i].active = false
+	}
+	if g.bossRushMode {
+		return
+	}
+
+	count := 2
+	if g.currentStage != StageBasic {
+		count = 3
+	}
+	for n := 0; n < count && n < len(g.nests); n++ {
+		for try := 0; try < 20; try++ {
+			angle := g.rng.Float64() * 2 * math.Pi
+			distance := 12.0 + g.rng.Float64()*10
+			pos := rl.NewVector3(float32(math.Cos(angle)*distance), nestHeight/2, float32(math.Sin(angle)*distance))
+			if g.CheckObstacleCollision(pos, nestRadius+0.5) || g.nestAt(pos, nestRadius*2) >= 0 || g.portalAt(pos, nestRadius) >= 0 {
+				continue
+			}
+			health := nestHealth(g.level)
+			g.nests[n] = Nest{
+				position:   pos,
+				health:     health,
+				maxHealth:  health,
+				spawnTimer: nestSpawnInterval * (0.5 + g.rng.Float32()*0.5),
+				active:     true,
+			}
+			break
+		}
+	}
+}
+
+// nestAt returns the nest overlapping a circle at pos, or -1.
+func (g *Game) nestAt(pos rl.Vector3, radius float32) int {
+	for i := range g.nests {
+		n := &g.nests[i]
+		if !n.active {
+			continue
+		}
+		dx := pos.X - n.position.X
+		dz := pos.Z - n.position.Z
+		if dx*dx+dz*dz < (nestRadius+radius)*(nestRadius+radius) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (g *Game) updateNests(dt float32) {
+	alive := 0
+	for i := range g.enemies {
+		if g.enemies[i].active {
+			alive++
+		}
+	}
+
+	for i := range g.nests {
+		n := &g.nests[i]
+		if !n.active {
+			continue
+		}
+		n.spawnTimer -= dt
+		if n.spawnTimer > 0 || alive >= nestEnemyCap {
+			continue
+		}
+		n.spawnTimer = nestSpawnInterval
+
+		for slot := range g.enemies {
+			if g.enemies[slot].active {
+				continue
+			}
+			angle := g.rng.Float64() * 2 * math.Pi
+			pos := rl.NewVector3(
+				n.position.X+float32(math.Cos(angle))*(nestRadius+1),
+				0.75,
+				n.position.Z+float32(math.Sin(angle))*(nestRadius+1),
+			)
+			if g.CheckObstacleCollision(pos, 0.5) {
+				pos = rl.NewVector3(n.position.X, 0.75, n.position.Z)
+			}
+			g.spawnRandomEnemy(slot, pos)
+			g.CreateExplosion(pos, rl.DarkPurple, 6)
+			alive++
+			break
+		}
+	}
+}
+
+// damageNest hurts a nest and destroys it when its health runs out.
+func (g *Game) damageNest(index int, damage int) {
+	n := &g.nests[index]
+	n.health -= damage
+	if n.health > 0 {
+		return
+	}
+	n.active = false
+	g.score += nestScore * g.level
+	g.CreateExplosion(n.position, rl.DarkPurple, 40)
+	g.playExplosion()
+}
+
+// damageNestsInRadius applies blast damage to every nest in range.
+func (g *Game) damageNestsInRadius(center rl.Vector3, radius float32, damage int) {
+	for i := range g.nests {
+		n := &g.nests[i]
+		if !n.active {
+			continue
+		}
+		dx := center.X - n.position.X
+		dz := center.Z - n.position.Z
+		if dx*dx+dz*dz < (nestRadius+radius)*(nestRadius+radius) {
+			g.damageNest(i, damage)
+		}
+	}
+}
+
+func (g *Game) drawNests() {
+	for i := range g.nests {
+		n := g.nests[i]
+		if !n.active {
+			continue
+		}
+		// Pulse faster as the next spawn gets close
+		pulse := 0.5 + 0.5*float32(math.Sin(float64(g.gameTime*(4+8*(1-n.spawnTimer/nestSpawnInterval)))))
+		base := rl.NewVector3(n.position.X, 0, n.position.Z)
+		rl.DrawCylinder(base, nestRadius*0.6, nestRadius, nestHeight, 10, rl.NewColor(90, 30, 70, 255))
+		rl.DrawCylinderWires(base, nestRadius*0.6, nestRadius, nestHeight, 10, rl.NewColor(uint8(150+105*pulse), 60, 160, 255))
+
+		// Health bar
+		healthPercent := float32(n.health) / float32(n.maxHealth)
+		barPos := rl.NewVector3(n.position.X, nestHeight+0.8, n.position.Z)
+		rl.DrawCube(barPos, 2.5, 0.25, 0.1, rl.DarkGray)
+		fill := barPos
+		fill.X -= 1.25 - 1.25*healthPercent
+		rl.DrawCube(fill, 2.5*healthPercent, 0.25, 0.1, rl.Purple)
+	}
+}
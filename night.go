@@ -0,0 +1,169 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Night stage. The floor is scattered with ruined walls and almost
+// nothing can be seen: after the 3D pass a full screen overlay darkens
+// everything except a radius around each player and short flashes where
+// shots are fired and things explode. The overlay is a shader that gets
+// the lights as screen space circles, projected from world space every
+// frame so they keep their size as the camera moves.
+//
+// Lights: players first, then the newest flashes, up to maxNightLights.
+const (
+	maxNightLights   = 16
+	nightDarkness    = float32(0.95) // overlay alpha where nothing is lit
+	playerLightRange = float32(8.0)
+	muzzleLightRange = float32(4.0)
+	muzzleLightTime  = float32(0.08)
+	blastLightTime   = float32(0.3)
+	maxNightFlashes  = 32
+	nightRuins       = 14
+)
+
+// darknessShader darkens everything outside the lights. lights[i] is the
+// centre in window pixels (bottom-up, like gl_FragCoord) and the radius in
+// pixels; a radius of 0 is an unused slot.
+const darknessShader = `#version 330
+in vec2 fragTexCoord;
+in vec4 fragColor;
+uniform vec3 lights[16];
+uniform float darkness;
+out vec4 finalColor;
+
+void main() {
+    float lit = 0.0;
+    for (int i = 0; i < 16; i++) {
+        if (lights[i].z <= 0.0) continue;
+        float d = distance(gl_FragCoord.xy, lights[i].xy);
+        lit = max(lit, 1.0 - smoothstep(lights[i].z * 0.5, lights[i].z, d));
+    }
+    finalColor = vec4(0.0, 0.0, 0.03, darkness * (1.0 - lit));
+}
+`
+
+type NightLight struct {
+	position rl.Vector3
+	radius   float32
+	life     float32
+	maxLife  float32
+}
+
+type Night struct {
+	flashes   []NightLight
+	shader    rl.Shader
+	lightsLoc int32
+	darkLoc   int32
+	loaded    bool
+}
+
+// GenerateNight scatters short ruined walls, keeping the middle clear for
+// the players to start in.
+func (g *Game) GenerateNight() {
+	placed := 0
+	for try := 0; try < nightRuins*5 && placed < nightRuins; try++ {
+		angle := g.rng.Float64() * 2 * math.Pi
+		distance := 8.0 + g.rng.Float64()*16
+		length := 3 + g.rng.Float32()*5
+		size := rl.NewVector3(length, 2.5, 1.2)
+		if g.rng.Intn(2) == 0 {
+			size.X, size.Z = size.Z, size.X
+		}
+		pos := rl.NewVector3(float32(math.Cos(angle)*distance), 1, float32(math.Sin(angle)*distance))
+		if g.CheckObstacleCollision(pos, max(size.X, size.Z)/2+1.5) {
+			continue
+		}
+		slot := g.freeObstacleSlot()
+		if slot < 0 {
+			return
+		}
+		g.obstacles[slot] = Obstacle{position: pos, size: size, active: true, obsType: 0}
+		placed++
+	}
+}
+
+// addFlash lights up pos for a moment. Only kept on the Night stage.
+func (g *Game) addFlash(pos rl.Vector3, radius, life float32) {
+	if g.currentStage != StageNight {
+		return
+	}
+	n := &g.night
+	if len(n.flashes) >= maxNightFlashes {
+		n.flashes = append(n.flashes[:0], n.flashes[1:]...)
+	}
+	n.flashes = append(n.flashes, NightLight{position: pos, radius: radius, life: life, maxLife: life})
+}
+
+func (g *Game) updateNight(dt float32) {
+	n := &g.night
+	kept := n.flashes[:0]
+	for _, f := range n.flashes {
+		f.life -= dt
+		if f.life > 0 {
+			kept = append(kept, f)
+		}
+	}
+	n.flashes = kept
+}
+
+// screenLight projects a world space light into the shader's pixel space.
+func (g *Game) screenLight(pos rl.Vector3, radius float32) (x, y, r float32) {
+	centre := rl.GetWorldToScreen(pos, g.camera)
+	edge := rl.GetWorldToScreen(rl.NewVector3(pos.X+radius, pos.Y, pos.Z), g.camera)
+	r = rl.Vector2Distance(centre, edge)
+	return centre.X, screenHeight - centre.Y, r
+}
+
+// drawNightOverlay darkens the scene on the Night stage. Called right
+// after the 3D pass, before the HUD.
+func (g *Game) drawNightOverlay() {
+	if g.currentStage != StageNight {
+		return
+	}
+	n := &g.night
+	if !n.loaded {
+		n.shader = rl.LoadShaderFromMemory("", darknessShader)
+		n.lightsLoc = rl.GetShaderLocation(n.shader, "lights")
+		n.darkLoc = rl.GetShaderLocation(n.shader, "darkness")
+		n.loaded = true
+	}
+
+	lights := make([]float32, 0, maxNightLights*3)
+	add := func(pos rl.Vector3, radius float32) {
+		if len(lights) < cap(lights) {
+			x, y, r := g.screenLight(pos, radius)
+			lights = append(lights, x, y, r)
+		}
+	}
+	for i := range g.players {
+		if g.players[i].health > 0 {
+			add(g.players[i].position, playerLightRange)
+		}
+	}
+	for i := len(n.flashes) - 1; i >= 0; i-- {
+		f := &n.flashes[i]
+		add(f.position, f.radius*(0.5+0.5*f.life/f.maxLife))
+	}
+	for len(lights) < cap(lights) {
+		lights = append(lights, 0, 0, 0)
+	}
+
+	rl.SetShaderValueV(n.shader, n.lightsLoc, lights, rl.ShaderUniformVec3, maxNightLights)
+	rl.SetShaderValue(n.shader, n.darkLoc, []float32{nightDarkness}, rl.ShaderUniformFloat)
+	rl.BeginShaderMode(n.shader)
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.White)
+	rl.EndShaderMode()
+}
+
+// release frees the darkness shader; the next Night frame loads it again.
+func (n *Night) release() {
+	if n.loaded {
+		rl.UnloadShader(n.shader)
+		n.loaded = false
+	}
+	n.flashes = n.flashes[:0]
+}
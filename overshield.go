@@ -0,0 +1,83 @@
+package main
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+// Overshield. Enemies sometimes drop a shield pickup that gives temporary
+// shield HP on top of health. Damage comes off the shield first; when it
+// breaks there is a burst and a break sound. Unused shield wears off after
+// a while. The bubble is an effect attachment (effects.go) that flashes
+// when it soaks a hit. The HUD shows it as a blue segment after the health
+// fill.
+
+// powerUpShield is the pType of overshield pickups.
+const powerUpShield = 6
+
+const (
+	shieldDropChance = float32(0.1) // share of power-up drops that are shields
+	overshieldAmount = 40
+	overshieldMax    = 60 // stacking cap
+	overshieldTime   = float32(20.0)
+)
+
+func (g *Game) addOvershield(player *Player) {
+	player.shield = min(player.shield+overshieldAmount, overshieldMax)
+	player.shieldTimer = overshieldTime
+	g.attach(playerOwner(player), EffectShield, overshieldTime, rl.SkyBlue)
+}
+
+// absorbShield takes damage off a player's overshield and returns what is
+// left for their health. Called first thing in damagePlayer.
+func (g *Game) absorbShield(player *Player, damage int) int {
+	if player.shield <= 0 {
+		return damage
+	}
+	absorbed := min(player.shield, damage)
+	player.shield -= absorbed
+	if player.shield == 0 {
+		g.breakShield(player)
+	} else {
+		g.pulseEffect(playerOwner(player), EffectShield)
+	}
+	return damage - absorbed
+}
+
+func (g *Game) breakShield(player *Player) {
+	player.shield = 0
+	player.shieldTimer = 0
+	g.detach(playerOwner(player), EffectShield)
+	g.CreateExplosion(player.position, rl.SkyBlue, 25)
+	if g.sounds.shieldBreak.loaded() {
+		g.playSound(g.sounds.shieldBreak)
+	} else {
+		g.playSound(g.sounds.hit)
+	}
+}
+
+func (g *Game) updateOvershield(player *Player, dt float32) {
+	if player.shield <= 0 {
+		return
+	}
+	player.shieldTimer -= dt
+	if player.shieldTimer <= 0 {
+		player.shield = 0
+		player.shieldTimer = 0
+		g.detach(playerOwner(player), EffectShield)
+		g.CreateExplosion(player.position, rl.SkyBlue, 8)
+	}
+}
+
+// drawOvershieldBar draws the shield as a blue segment after the health
+// fill of a health bar width pixels wide. When health and shield together
+// are more than max health, the bar is rescaled to fit both: the shield
+// then covers the end of the health fill drawn at the normal scale.
+func drawOvershieldBar(player Player, x, y, width, height int32) {
+	if player.shield <= 0 {
+		return
+	}
+	health := float32(max(player.health, 0))
+	scale := max(float32(player.stats.maxHealth), health+float32(player.shield))
+	start := x + int32(float32(width)*health/scale)
+	end := x + int32(float32(width)*(health+float32(player.shield))/scale)
+	rl.DrawRectangle(start, y, end-start, height, rl.SkyBlue)
+	rl.DrawRectangleLines(start, y, end-start, height, rl.Blue)
+}
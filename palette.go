@@ -0,0 +1,72 @@
+package main
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+// Team palettes. Each player gets a colour from the selected palette for
+// their model, bullets, skill effects and HUD row, so it is always clear
+// whose shots are whose. Every palette is chosen to stay distinct under the
+// common kinds of colour blindness and has four colours, one per local
+// player slot.
+const paletteSize = 4
+
+type Palette struct {
+	name   string
+	colors [paletteSize]rl.Color
+}
+
+var palettes = []Palette{
+	{"Okabe-Ito", [paletteSize]rl.Color{
+		rl.NewColor(0, 114, 178, 255),   // blue
+		rl.NewColor(230, 159, 0, 255),   // orange
+		rl.NewColor(0, 158, 115, 255),   // bluish green
+		rl.NewColor(204, 121, 167, 255), // reddish purple
+	}},
+	{"Bright", [paletteSize]rl.Color{
+		rl.NewColor(68, 119, 170, 255),
+		rl.NewColor(238, 102, 119, 255),
+		rl.NewColor(34, 136, 51, 255),
+		rl.NewColor(204, 187, 68, 255),
+	}},
+	{"Vibrant", [paletteSize]rl.Color{
+		rl.NewColor(0, 119, 187, 255),
+		rl.NewColor(238, 119, 51, 255),
+		rl.NewColor(0, 153, 136, 255),
+		rl.NewColor(238, 51, 119, 255),
+	}},
+	{"High Contrast", [paletteSize]rl.Color{
+		rl.NewColor(240, 240, 240, 255),
+		rl.NewColor(240, 228, 66, 255),
+		rl.NewColor(86, 180, 233, 255),
+		rl.NewColor(213, 94, 0, 255),
+	}},
+}
+
+// playerColor is player id's colour in the selected palette.
+func (g *Game) playerColor(id int) rl.Color {
+	return palettes[g.settings.palette].colors[id%paletteSize]
+}
+
+// bulletColor is a lighter shade of the owner's colour so shots read well
+// against the floor.
+func (g *Game) bulletColor(playerID int) rl.Color {
+	color := g.playerColor(playerID)
+	if playerID >= 0 && playerID < len(g.players) {
+		color = g.players[playerID].color
+	}
+	return rl.ColorBrightness(color, 0.35)
+}
+
+// recolorPlayers repaints the players of a run after the palette changed.
+func (g *Game) recolorPlayers() {
+	for i := range g.players {
+		g.players[i].color = g.playerColor(g.players[i].id)
+	}
+}
+
+// drawPaletteSwatches draws the selected palette's colours in a row.
+func (g *Game) drawPaletteSwatches(x, y int32) {
+	for i, c := range palettes[g.settings.palette].colors {
+		rl.DrawRectangle(x+int32(i)*34, y, 28, 28, c)
+		rl.DrawRectangleLines(x+int32(i)*34, y, 28, 28, rl.White)
+	}
+}
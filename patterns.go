@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Boss bullet patterns. One-shot patterns (rings, fans) fire straight away;
+// spirals and aimed bursts run as emitters attached to the boss for a while.
+// Every pattern leaves a way out: rings have a gap, spirals rotate slowly
+// enough to walk between the arms and bursts re-aim between volleys, so
+// sidestepping after each one dodges them.
+const (
+	maxEmitters     = 8
+	ringGapDefault  = float32(40) // degrees
+	spiralSpinSpeed = float32(70) // degrees per second
+	spiralInterval  = float32(0.15)
+)
+
+type PatternKind int
+
+const (
+	PatternSpiral PatternKind = iota
+	PatternBurst
+)
+
+// BulletEmitter keeps firing a pattern from an enemy until it runs out or
+// the enemy dies.
+type BulletEmitter struct {
+	kind     PatternKind
+	source   int // enemy slot
+	timer    float32
+	interval float32
+	shots    int // volleys left
+	angle    float32
+	spin     float32 // radians per second (spiral)
+	arms     int     // bullets per volley
+	spread   float32 // radians (burst)
+	speed    float32
+	damage   int
+	active   bool
+}
+
+// fireRing fires count bullets evenly around from, leaving out the ones
+// inside a gap of gapDeg degrees centred on gapAngle.
+func (g *Game) fireRing(from rl.Vector3, count int, speed float32, damage int, gapAngle, gapDeg float32) {
+	halfGap := float64(gapDeg) * math.Pi / 360
+	for n := 0; n < count; n++ {
+		angle := float64(gapAngle) + 2*math.Pi*float64(n)/float64(count)
+		if math.Abs(float64(angleDiff(float32(angle), gapAngle))) < halfGap {
+			continue
+		}
+		g.fireEnemyBullet(from, float32(math.Cos(angle)), float32(math.Sin(angle)), speed, damage)
+	}
+}
+
+// fireFan fires count bullets spread over spread radians around aim.
+func (g *Game) fireFan(from rl.Vector3, aim float32, count int, spread, speed float32, damage int) {
+	for n := 0; n < count; n++ {
+		angle := float64(aim)
+		if count > 1 {
+			angle += float64(spread) * (float64(n)/float64(count-1) - 0.5)
+		}
+		g.fireEnemyBullet(from, float32(math.Cos(angle)), float32(math.Sin(angle)), speed, damage)
+	}
+}
+
+// aimAt is the angle from pos to the nearest player.
+func (g *Game) aimAt(pos rl.Vector3) float32 {
+	p := g.players[g.nearestPlayerIndex(pos)].position
+	return float32(math.Atan2(float64(p.Z-pos.Z), float64(p.X-pos.X)))
+}
+
+// startSpiral fires arms bullets every interval for duration seconds while
+// rotating at spin degrees per second.
+func (g *Game) startSpiral(source, arms int, duration, interval, spinDeg, speed float32, damage int) {
+	g.addEmitter(BulletEmitter{
+		kind:     PatternSpiral,
+		source:   source,
+		interval: interval,
+		shots:    int(duration / interval),
+		angle:    g.rng.Float32() * 2 * math.Pi,
+		spin:     spinDeg * math.Pi / 180,
+		arms:     max(arms, 1),
+		speed:    speed,
+		damage:   damage,
+	})
+}
+
+// startBurst fires volleys fans of count bullets at the nearest player,
+// interval apart.
+func (g *Game) startBurst(source, volleys, count int, interval, spreadDeg, speed float32, damage int) {
+	g.addEmitter(BulletEmitter{
+		kind:     PatternBurst,
+		source:   source,
+		interval: interval,
+		shots:    volleys,
+		arms:     max(count, 1),
+		spread:   spreadDeg * math.Pi / 180,
+		speed:    speed,
+		damage:   damage,
+	})
+}
+
+func (g *Game) addEmitter(em BulletEmitter) {
+	for i := range g.emitters {
+		if !g.emitters[i].active {
+			em.active = true
+			g.emitters[i] = em
+			return
+		}
+	}
+}
+
+func (g *Game) updateEmitters(dt float32) {
+	for i := range g.emitters {
+		em := &g.emitters[i]
+		if !em.active {
+			continue
+		}
+		src := &g.enemies[em.source]
+		if !src.active || em.shots <= 0 {
+			em.active = false
+			continue
+		}
+
+		em.angle += em.spin * dt
+		em.timer -= dt
+		if em.timer > 0 {
+			continue
+		}
+		em.timer += em.interval
+		em.shots--
+
+		switch em.kind {
+		case PatternSpiral:
+			for a := 0; a < em.arms; a++ {
+				angle := float64(em.angle) + 2*math.Pi*float64(a)/float64(em.arms)
+				g.fireEnemyBullet(src.position, float32(math.Cos(angle)), float32(math.Sin(angle)), em.speed, em.damage)
+			}
+		case PatternBurst:
+			g.fireFan(src.position, g.aimAt(src.position), em.arms, em.spread, em.speed, em.damage)
+		}
+	}
+}
+
+func (g *Game) clearEmitters() {
+	for i := range g.emitters {
+		g.emitters[i].active = false
+	}
+}
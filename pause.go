@@ -0,0 +1,149 @@
+package main
+
+import (
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Pause menu. Pausing a run opens a menu over the frozen game: resume,
+// the settings screen, restart the run or quit to the main menu. Settings
+// opened from here come back to the pause menu, and the run counts as in
+// progress and paused all the while, so music, autosave and saving on
+// exit treat it exactly like the pause menu itself. The rules a run was
+// started with (input buffer and the run modifiers) are locked until it
+// ends; difficulty changes the run straight away (setRunDifficulty).
+//
+// C still assigns controllers and Q still saves and quits. The arcade
+// cabinet keeps players out of the settings and doesn't restart for free.
+const (
+	PauseResume = iota
+	PauseSettings
+	PauseRestart
+	PauseQuit
+	pauseItemCount
+)
+
+var pauseItems = [pauseItemCount]string{"Resume", "Settings", "Restart Run", "Quit to Menu"}
+
+// pauseItemEnabled reports whether a pause menu entry can be chosen.
+func (g *Game) pauseItemEnabled(item int) bool {
+	switch item {
+	case PauseSettings, PauseRestart:
+		return g.arcade == nil
+	}
+	return true
+}
+
+// inRunSettings reports whether the settings screens were opened from the
+// pause menu of a run.
+func (g *Game) inRunSettings() bool {
+	return g.settingsInRun && (g.state == StateSettings || g.state == StateControls)
+}
+
+// paused reports whether a run is paused, in the pause menu or the
+// settings opened from it.
+func (g *Game) paused() bool {
+	return g.state == StatePaused || g.inRunSettings()
+}
+
+// settingLocked reports whether a settings row can't change right now:
+// the run's rules while settings are open mid-run.
+func (g *Game) settingLocked(row int) bool {
+	return g.inRunSettings() && row >= 7 && row <= 10
+}
+
+// leaveSettings returns from the settings screen to wherever it was
+// opened from.
+func (g *Game) leaveSettings() {
+	if g.inRunSettings() {
+		g.settingsInRun = false
+		g.state = StatePaused
+		return
+	}
+	g.state = StateMenu
+}
+
+func (g *Game) UpdatePaused() {
+	if g.pads.assigning >= 0 {
+		g.UpdatePadAssign()
+		return
+	}
+	if rl.IsKeyPressed(rl.KeyC) {
+		g.pads.assigning = 0
+		return
+	}
+	if rl.IsKeyPressed(rl.KeyQ) && g.canSuspend() {
+		g.playUISound(g.sounds.uiSelect)
+		g.suspendRun()
+		return
+	}
+	if rl.IsKeyPressed(rl.KeyP) || rl.IsKeyPressed(rl.KeyEscape) || g.padPausePressed() {
+		g.playUISound(g.sounds.uiSelect)
+		g.state = StatePlaying
+		return
+	}
+
+	if rl.IsKeyPressed(rl.KeyUp) {
+		g.pauseSelection = (g.pauseSelection + pauseItemCount - 1) % pauseItemCount
+		g.playUISound(g.sounds.uiMove)
+	}
+	if rl.IsKeyPressed(rl.KeyDown) {
+		g.pauseSelection = (g.pauseSelection + 1) % pauseItemCount
+		g.playUISound(g.sounds.uiMove)
+	}
+	if !rl.IsKeyPressed(rl.KeyEnter) && !rl.IsKeyPressed(rl.KeySpace) {
+		return
+	}
+	if !g.pauseItemEnabled(g.pauseSelection) {
+		return
+	}
+	g.playUISound(g.sounds.uiSelect)
+
+	switch g.pauseSelection {
+	case PauseResume:
+		g.state = StatePlaying
+	case PauseSettings:
+		g.settingsSelection = 0
+		g.settingsInRun = true
+		g.state = StateSettings
+	case PauseRestart:
+		g.saveMastery()
+		g.ResetGame()
+		g.pauseSelection = PauseResume
+		g.state = StatePlaying
+	case PauseQuit:
+		g.saveMastery()
+		g.pauseSelection = PauseResume
+		g.state = StateMenu
+	}
+}
+
+func (g *Game) DrawPaused() {
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 150))
+	centerX := int32(screenWidth / 2)
+	top := int32(screenHeight/2 - 160)
+	drawText("PAUSED", centerX-measureText("PAUSED", 50)/2, top, 50, rl.White)
+
+	for i, item := range pauseItems {
+		y := top + 90 + int32(i*55)
+		color := rl.White
+		if !g.pauseItemEnabled(i) {
+			color = rl.DarkGray
+		}
+		if i == g.pauseSelection {
+			color = rl.Yellow
+			rl.DrawRectangle(centerX-200, y-5, 400, 50, rl.NewColor(255, 255, 0, 50))
+			drawText(">", centerX-250, y, 40, rl.Yellow)
+		}
+		drawText(item, centerX-150, y, 40, color)
+	}
+
+	hintY := top + 90 + pauseItemCount*55 + 20
+	drawText("P / ESC: resume   C: assign controllers", centerX-200, hintY, 20, rl.LightGray)
+	if g.canSuspend() {
+		drawText("Q: save & quit", centerX-200, hintY+28, 20, rl.Orange)
+	}
+
+	if g.pads.assigning >= 0 {
+		g.DrawPadAssign()
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Performance overlay (F4): rolling frametime graph with average, 1% and
+// 0.1% lows, and entity counts. Frame times are recorded every frame, but
+// the lows are only recomputed a couple of times per second and the graph
+// is a row of batched rectangles, so turning the overlay on barely moves
+// the numbers it shows.
+const (
+	perfSamples       = 600 // ten seconds at 60 FPS
+	perfStatsInterval = float32(0.5)
+	perfGraphWidth    = 300
+	perfGraphHeight   = 80
+	perfGraphCeilMs   = float32(50) // frametime at the top of the graph
+)
+
+type PerfStats struct {
+	frames [perfSamples]float32 // frametimes in ms, ring buffer
+	next   int
+	count  int
+
+	avgMs   float32
+	low1    float32 // FPS of the slowest 1% of frames
+	low01   float32 // FPS of the slowest 0.1% of frames
+	sinceUp float32
+	scratch []float32
+}
+
+func (p *PerfStats) record(dt float32) {
+	p.frames[p.next] = dt * 1000
+	p.next = (p.next + 1) % perfSamples
+	if p.count < perfSamples {
+		p.count++
+	}
+
+	p.sinceUp += dt
+	if p.sinceUp >= perfStatsInterval {
+		p.sinceUp = 0
+		p.computeLows()
+	}
+}
+
+// computeLows sorts a copy of the window; the 99th and 99.9th percentile
+// frametimes give the 1% and 0.1% lows.
+func (p *PerfStats) computeLows() {
+	if p.count == 0 {
+		return
+	}
+	p.scratch = append(p.scratch[:0], p.frames[:p.count]...)
+	slices.Sort(p.scratch)
+
+	var sum float32
+	for _, ms := range p.scratch {
+		sum += ms
+	}
+	p.avgMs = sum / float32(p.count)
+	p.low1 = msToFPS(p.scratch[percentileIndex(p.count, 0.99)])
+	p.low01 = msToFPS(p.scratch[percentileIndex(p.count, 0.999)])
+}
+
+func percentileIndex(n int, q float32) int {
+	i := int(float32(n) * q)
+	if i >= n {
+		i = n - 1
+	}
+	return i
+}
+
+func msToFPS(ms float32) float32 {
+	if ms <= 0 {
+		return 0
+	}
+	return 1000 / ms
+}
+
+func (g *Game) countActive() (enemies, bullets, enemyBullets, grenades, particles, powerUps, obstacles int) {
+	for i := range g.enemies {
+		if g.enemies[i].active {
+			enemies++
+		}
+	}
+	for i := range g.bullets {
+		if g.bullets[i].active {
+			bullets++
+		}
+	}
+	for i := range g.enemyBullets {
+		if g.enemyBullets[i].active {
+			enemyBullets++
+		}
+	}
+	for i := range g.grenades {
+		if g.grenades[i].active {
+			grenades++
+		}
+	}
+	for i := range g.particles {
+		if g.particles[i].active {
+			particles++
+		}
+	}
+	for i := range g.powerUps {
+		if g.powerUps[i].active {
+			powerUps++
+		}
+	}
+	for i := range g.obstacles {
+		if g.obstacles[i].active {
+			obstacles++
+		}
+	}
+	return
+}
+
+// estimateDrawCalls approximates the draw submissions of the game scene.
+// raylib batches primitives internally and does not report real draw
+// calls, so this counts what the scene code asks for: a cube and its wires
+// per obstacle, a model or cube + wires per enemy, one shape per
+// projectile, particle and pickup, two per prop, and a blob shadow under
+// every player, enemy, projectile and pickup.
+func (g *Game) estimateDrawCalls(enemies, projectiles, particles, powerUps, obstacles int) int {
+	perEnemy := 2
+	if g.modelsLoaded && g.enemyModel.MeshCount > 0 {
+		perEnemy = int(g.enemyModel.MeshCount)
+	}
+	calls := obstacles*2 + enemies*perEnemy + projectiles + particles + powerUps + len(g.props)*2
+	calls += len(g.players) + enemies + projectiles + powerUps // shadows
+	for _, player := range g.players {
+		if g.modelsLoaded && player.model.MeshCount > 0 {
+			calls += int(player.model.MeshCount)
+		} else {
+			calls += 2
+		}
+	}
+	if g.chunks != nil {
+		calls += g.chunks.loaded
+	}
+	return calls
+}
+
+func (g *Game) drawPerfOverlay() {
+	if !g.showPerf {
+		return
+	}
+	p := &g.perf
+
+	x, y := int32(screenWidth-perfGraphWidth-20), int32(80)
+	rl.DrawRectangle(x-10, y-10, perfGraphWidth+20, perfGraphHeight+190, rl.NewColor(0, 0, 0, 180))
+
+	// Frametime graph, newest sample on the right. Reference lines mark
+	// 60 FPS and 30 FPS.
+	bottom := y + perfGraphHeight
+	rl.DrawRectangle(x, y, perfGraphWidth, perfGraphHeight, rl.NewColor(30, 30, 30, 200))
+	for _, ref := range []float32{1000.0 / 60, 1000.0 / 30} {
+		lineY := bottom - int32(ref/perfGraphCeilMs*perfGraphHeight)
+		rl.DrawRectangle(x, lineY, perfGraphWidth, 1, rl.DarkGray)
+	}
+	shown := min(p.count, perfGraphWidth)
+	for i := 0; i < shown; i++ {
+		ms := p.frames[(p.next-shown+i+perfSamples)%perfSamples]
+		h := int32(min(ms, perfGraphCeilMs) / perfGraphCeilMs * perfGraphHeight)
+		color := rl.Green
+		switch {
+		case ms > 1000.0/30:
+			color = rl.Red
+		case ms > 1000.0/60+1:
+			color = rl.Yellow
+		}
+		rl.DrawRectangle(x+int32(perfGraphWidth-shown+i), bottom-h, 1, h, color)
+	}
+
+	y = bottom + 10
+	line := func(text string, color rl.Color) {
+		drawText(text, x, y, 18, color)
+		y += 22
+	}
+
+	line(fmt.Sprintf("FPS %d  avg %.2f ms", rl.GetFPS(), p.avgMs), rl.Green)
+	line(fmt.Sprintf("1%% low %.0f  0.1%% low %.0f", p.low1, p.low01), rl.Yellow)
+	if g.settings.dynamicRes {
+		line(fmt.Sprintf("Render scale %.0f%%", g.res.scale*100), rl.SkyBlue)
+	}
+
+	enemies, bullets, enemyBullets, grenades, particles, powerUps, obstacles := g.countActive()
+	projectiles := bullets + enemyBullets + grenades
+	line(fmt.Sprintf("Draw calls ~%d", g.estimateDrawCalls(enemies, projectiles, particles, powerUps, obstacles)), rl.LightGray)
+	line(fmt.Sprintf("Enemies %d  Projectiles %d", enemies, projectiles), rl.LightGray)
+	line(fmt.Sprintf("Particles %d  Pickups %d", particles, powerUps), rl.LightGray)
+	line(fmt.Sprintf("Obstacles %d  Players %d", obstacles, len(g.players)), rl.LightGray)
+
+	// Network graph goes here once online multiplayer exists
+	line("Net: local play", rl.Gray)
+}
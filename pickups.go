@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Weapon drops. Players start with the Blaster and carry up to
+// maxCarriedWeapons; walking over a weapon pickup adds it, or swaps it for
+// the weapon in hand when both slots are full. Picking up a weapon already
+// carried refills its ammo instead.
+
+// powerUpWeapon is the pType of weapon pickups; PowerUp.weapon says which.
+const powerUpWeapon = 4
+
+const (
+	weaponDropChance  = float32(0.15) // share of power-up drops that are weapons
+	maxCarriedWeapons = 2
+)
+
+// rollWeaponDrop picks a base weapon for a pickup, never the starting Blaster.
+func (g *Game) rollWeaponDrop() WeaponType {
+	return WeaponType(1 + g.rng.Intn(int(baseWeaponCount)-1))
+}
+
+// pickUpWeapon gives the player a dropped weapon and equips it. A drop of a
+// weapon the player already carries in evolved form refills the evolved one.
+func (g *Game) pickUpWeapon(player *Player, weapon WeaponType) {
+	for _, w := range player.weapons {
+		if baseWeapon(w) == weapon {
+			player.ammo[w] = weaponDefs[w].magSize
+			player.reserve[w] = weaponDefs[w].reserveMax
+			player.weapon = w
+			player.reloading = false
+			return
+		}
+	}
+
+	if len(player.weapons) < maxCarriedWeapons {
+		player.weapons = append(player.weapons, weapon)
+	} else {
+		for i, w := range player.weapons {
+			if w == player.weapon {
+				player.weapons[i] = weapon
+			}
+		}
+	}
+	player.ammo[weapon] = weaponDefs[weapon].magSize
+	player.reserve[weapon] = weaponDefs[weapon].reserveMax
+	player.weapon = weapon
+	player.reloading = false
+	player.beamFiring = false
+}
+
+// loadWeaponModels loads optional pickup models from
+// assets/models/weapons/<name>.glb.
+func (g *Game) loadWeaponModels() {
+	for w := WeaponType(0); w < weaponCount; w++ {
+		name := strings.ReplaceAll(strings.ToLower(weaponDefs[w].name), " ", "_")
+		path := fmt.Sprintf("assets/models/weapons/%s.glb", name)
+		if fileExists(path) {
+			g.weaponModels[w] = g.assets.loadModel(path)
+			fmt.Println("✓ Loaded:", path)
+		}
+	}
+}
+
+// drawWeaponPickup draws a spinning weapon model, or a bar in the weapon's
+// colour when there is no model.
+func (g *Game) drawWeaponPickup(p PowerUp, pos rl.Vector3) {
+	def := weaponDefs[p.weapon]
+	if model := g.weaponModels[p.weapon]; model.MeshCount > 0 {
+		rl.DrawModelEx(model, pos, rl.NewVector3(0, 1, 0), p.rotation, rl.NewVector3(1, 1, 1), rl.White)
+		return
+	}
+
+	rad := float64(p.rotation * math.Pi / 180)
+	half := rl.NewVector3(float32(math.Cos(rad))*0.7, 0, float32(math.Sin(rad))*0.7)
+	start := rl.NewVector3(pos.X-half.X, pos.Y, pos.Z-half.Z)
+	end := rl.NewVector3(pos.X+half.X, pos.Y, pos.Z+half.Z)
+	rl.DrawCylinderEx(start, end, 0.25, 0.18, 8, def.color)
+	rl.DrawSphere(end, 0.2, rl.White)
+}
+
+// drawWeaponPickupLabels names weapon pickups on screen (call outside 3D mode).
+func (g *Game) drawWeaponPickupLabels() {
+	for i := range g.powerUps {
+		p := g.powerUps[i]
+		if !p.active || p.pType != powerUpWeapon {
+			continue
+		}
+		pos := p.position
+		pos.Y += 1.2
+		screen := rl.GetWorldToScreen(pos, g.camera)
+		name := weaponDefs[p.weapon].name
+		drawText(name, int32(screen.X)-measureText(name, 16)/2, int32(screen.Y), 16, weaponDefs[p.weapon].color)
+	}
+}
+
+// drawWeaponIndicator shows the weapon in hand with its ammo, then the
+// other carried weapons with the switch key and the grenade cooldown.
+func (g *Game) drawWeaponIndicator(player Player, x, y int32) {
+	def := weaponDefs[player.weapon]
+	rl.DrawRectangle(x, y+2, 14, 14, def.color)
+	x += 20
+
+	text := def.name
+	if ammo := g.ammoLabel(player); ammo != "" {
+		text += " " + ammo
+	}
+	drawText(text, x, y, 18, rl.Orange)
+	drawWeaponXP(player, x, y+19)
+	x += measureText(text, 18) + 12
+
+	drawHeatMeter(player, x, y+4)
+	if def.beam || player.heat > 0 {
+		x += 92
+	}
+
+	for _, w := range player.weapons {
+		if w == player.weapon {
+			continue
+		}
+		other := fmt.Sprintf("[%s] %s", bindingLabel(g.controls.binding(player.id, ActionSwitchWeapon)), weaponDefs[w].name)
+		drawText(other, x, y+2, 14, rl.Gray)
+		x += measureText(other, 14) + 10
+	}
+
+	grenade := fmt.Sprintf("[%s] Grenade", bindingLabel(g.controls.binding(player.id, ActionGrenade)))
+	color := rl.Gray
+	if player.grenadeCooldown > 0 {
+		grenade += fmt.Sprintf(" %.1fs", player.grenadeCooldown)
+		color = rl.DarkGray
+	}
+	drawText(grenade, x, y+2, 14, color)
+}
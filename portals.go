@@ -0,0 +1,187 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Teleporter portals. Portals come in pairs: walking into one puts a
+// player (or an enemy) on its partner, and player bullets fly through
+// keeping their heading and range. The maze and arena generators place a
+// pair, and stage layouts can list their own.
+//
+// Portals don't block anything. After a jump a player or enemy has to
+// step off the exit and wait portalCooldown before the next one; the
+// cooldown only runs while standing clear of every portal, so nobody
+// bounces back and forth by standing still.
+const (
+	maxPortals     = 4 // two pairs
+	portalRadius   = float32(1.3)
+	portalCooldown = float32(1.0)
+)
+
+// Pair colours, so which portal goes where can be read at a glance
+var portalColors = [maxPortals / 2]rl.Color{rl.SkyBlue, rl.Orange}
+
+type Portal struct {
+	position rl.Vector3
+	exit     int // index of the partner
+	active   bool
+}
+
+func (g *Game) clearPortals() {
+	for i := range g.portals {
+		g.portals[i].active = false
+	}
+}
+
+// placePortalPair links two portals at a and b. false when both pairs are
+// taken.
+func (g *Game) placePortalPair(a, b rl.Vector3) bool {
+	for i := 0; i+1 < len(g.portals); i += 2 {
+		if g.portals[i].active {
+			continue
+		}
+		a.Y, b.Y = 0, 0
+		g.portals[i] = Portal{position: a, exit: i + 1, active: true}
+		g.portals[i+1] = Portal{position: b, exit: i, active: true}
+		return true
+	}
+	return false
+}
+
+// placeMazePortals links two opposite corner rooms of the maze.
+func (g *Game) placeMazePortals() {
+	room := func(x, z int) rl.Vector3 {
+		origin := -mazeCellSize * mazeCells / 2
+		return rl.NewVector3(origin+(float32(x)+0.5)*mazeCellSize, 0, origin+(float32(z)+0.5)*mazeCellSize)
+	}
+	last := mazeCells - 1
+	if g.rng.Intn(2) == 0 {
+		g.placePortalPair(room(0, 0), room(last, last))
+	} else {
+		g.placePortalPair(room(0, last), room(last, 0))
+	}
+}
+
+// placeArenaPortals links two opposite sides inside the arena walls.
+func (g *Game) placeArenaPortals() {
+	if g.rng.Intn(2) == 0 {
+		g.placePortalPair(rl.NewVector3(-13, 0, 0), rl.NewVector3(13, 0, 0))
+	} else {
+		g.placePortalPair(rl.NewVector3(0, 0, -13), rl.NewVector3(0, 0, 13))
+	}
+}
+
+// portalAt returns the portal overlapping a circle at pos, or -1.
+func (g *Game) portalAt(pos rl.Vector3, radius float32) int {
+	for i := range g.portals {
+		p := &g.portals[i]
+		if !p.active {
+			continue
+		}
+		dx := pos.X - p.position.X
+		dz := pos.Z - p.position.Z
+		if dx*dx+dz*dz < (portalRadius+radius)*(portalRadius+radius) {
+			return i
+		}
+	}
+	return -1
+}
+
+// throughPortal moves pos to the partner of the portal it stands in, if
+// its cooldown allows. Returns the portal jumped from, or -1.
+func (g *Game) throughPortal(pos *rl.Vector3, cooldown *float32, dt float32) int {
+	in := g.portalAt(*pos, 0)
+	if in < 0 {
+		*cooldown = max(0, *cooldown-dt)
+		return -1
+	}
+	if *cooldown > 0 {
+		return -1
+	}
+	exit := g.portals[g.portals[in].exit].position
+	pos.X, pos.Z = exit.X, exit.Z
+	*cooldown = portalCooldown
+	return in
+}
+
+// updatePortals sends players, enemies and player bullets through the
+// portals. Runs once per simulated frame, after everything has moved.
+func (g *Game) updatePortals(dt float32) {
+	for i := range g.players {
+		p := &g.players[i]
+		if p.health <= 0 {
+			continue
+		}
+		if in := g.throughPortal(&p.position, &p.portalCooldown, dt); in >= 0 {
+			g.portalFlash(in)
+			g.playSound(g.sounds.skill)
+		}
+	}
+	for i := range g.enemies {
+		e := &g.enemies[i]
+		if !e.active || e.isBoss || e.position.Y >= flyerHeight || g.isDummy(i) {
+			continue
+		}
+		if in := g.throughPortal(&e.position, &e.portalCooldown, dt); in >= 0 {
+			g.portalFlash(in)
+		}
+	}
+	for i := range g.bullets {
+		b := &g.bullets[i]
+		if !b.active {
+			continue
+		}
+		in := g.portalAt(b.position, 0)
+		if in < 0 {
+			continue
+		}
+		// Come out past the exit's edge so it doesn't go straight back in
+		speed := rl.Vector3Length(rl.NewVector3(b.velocity.X, 0, b.velocity.Z))
+		if speed == 0 {
+			continue
+		}
+		exit := g.portals[g.portals[in].exit].position
+		next := rl.NewVector3(
+			exit.X+b.velocity.X/speed*(portalRadius+0.1),
+			b.position.Y,
+			exit.Z+b.velocity.Z/speed*(portalRadius+0.1),
+		)
+		// Keep the distance already flown for range and falloff
+		travelled := bulletTravel(b)
+		b.origin = rl.NewVector3(next.X-b.velocity.X/speed*travelled, b.origin.Y, next.Z-b.velocity.Z/speed*travelled)
+		b.position = next
+	}
+}
+
+// portalFlash bursts both ends of a jump.
+func (g *Game) portalFlash(in int) {
+	color := portalColors[in/2]
+	g.CreateExplosion(g.portals[in].position, color, 8)
+	g.CreateExplosion(g.portals[g.portals[in].exit].position, color, 8)
+}
+
+func (g *Game) drawPortals() {
+	t := float64(g.gameTime)
+	for i := range g.portals {
+		p := &g.portals[i]
+		if !p.active {
+			continue
+		}
+		color := portalColors[i/2]
+		base := rl.NewVector3(p.position.X, 0.03, p.position.Z)
+		rl.DrawCylinder(base, portalRadius, portalRadius, 0.02, 24, rl.Fade(color, 0.3))
+		// Two rings turning opposite ways
+		for k, dir := range []float64{1, -1} {
+			r := portalRadius * (0.55 + 0.35*float32(k))
+			for s := 0; s < 3; s++ {
+				angle := t*2*dir + float64(s)*2*math.Pi/3
+				at := rl.NewVector3(p.position.X+float32(math.Cos(angle))*r, 0.3+0.2*float32(k), p.position.Z+float32(math.Sin(angle))*r)
+				rl.DrawSphere(at, 0.12, color)
+			}
+		}
+		rl.DrawCylinderWires(rl.NewVector3(p.position.X, 0, p.position.Z), portalRadius, portalRadius*0.8, 2.2, 16, rl.Fade(color, 0.5))
+	}
+}
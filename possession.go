@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Possession (main menu). Player 2 plays the enemy side: instead of a hero
+// they move a cursor over the arena and spend points to spawn enemies or
+// take direct control of one, trying to kill Player 1. Points refill over
+// time, faster at higher levels. Normal spawning is off; bosses still come.
+// Player 2 uses their own bindings: move steers the cursor, Fire spawns,
+// Switch Weapon picks the kind and Skill 1 possesses or lets go.
+const (
+	possessStartPoints  = float32(30)
+	possessMaxPoints    = float32(100)
+	possessRegen        = float32(5.0)  // points per second at level 1
+	possessRegenPerLvl  = float32(0.5)  // extra points per second per level
+	possessCursorSpeed  = float32(22.0) // units per second
+	possessMinDistance  = float32(8.0)  // no spawning right on top of Player 1
+	possessCost         = float32(15)
+	possessSpeedMul     = float32(1.2) // possessed enemies move a little faster
+	possessShotCost     = float32(5)
+	possessShotCooldown = float32(0.6)
+	possessCursorRadius = float32(1.2)
+	possessGrabRange    = float32(4.0)
+)
+
+// possessKinds is what each kind costs to spawn and from which level.
+var possessKinds = [enemyKindCount]struct {
+	cost     float32
+	minLevel int
+}{
+	EnemyChaser:   {10, 1},
+	EnemyRanged:   {20, rangedMinLevel},
+	EnemySplitter: {25, splitterMinLevel},
+	EnemyKamikaze: {20, kamikazeMinLevel},
+	EnemyShielded: {30, shieldedMinLevel},
+	EnemyFlyer:    {25, flyerMinLevel},
+}
+
+// Possessor is Player 2's side of a possession run.
+type Possessor struct {
+	cursor    rl.Vector3
+	points    float32
+	kind      EnemyKind
+	possessed int // enemy index, -1 when not possessing
+	shotTimer float32
+	reason    string // why the last spawn failed
+}
+
+// startPossession starts a possession run. Like boss rush it doesn't count
+// toward seed records.
+func (g *Game) startPossession() {
+	g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, false, true, false
+	g.StartGame(false)
+	g.seeded = false
+}
+
+// resetPossession is called from ResetGame.
+func (g *Game) resetPossession() {
+	g.possess = Possessor{
+		cursor:    rl.NewVector3(0, 0, -15),
+		points:    possessStartPoints,
+		possessed: -1,
+	}
+}
+
+// possessController stands in for Player 2 so the binding queries work.
+var possessController = Player{id: 1}
+
+func (g *Game) updatePossession(dt float32) {
+	if !g.possessMode {
+		return
+	}
+	p := &g.possess
+	ctrl := &possessController
+
+	regen := possessRegen + possessRegenPerLvl*float32(g.level)
+	p.points = float32(math.Min(float64(possessMaxPoints), float64(p.points+regen*dt)))
+	if p.shotTimer > 0 {
+		p.shotTimer -= dt
+	}
+	if p.possessed >= 0 && !g.enemies[p.possessed].active {
+		p.possessed = -1
+	}
+
+	dirX, dirZ := float32(0), float32(0)
+	if g.actionDown(ctrl, ActionMoveUp) {
+		dirZ--
+	}
+	if g.actionDown(ctrl, ActionMoveDown) {
+		dirZ++
+	}
+	if g.actionDown(ctrl, ActionMoveLeft) {
+		dirX--
+	}
+	if g.actionDown(ctrl, ActionMoveRight) {
+		dirX++
+	}
+	if l := float32(math.Sqrt(float64(dirX*dirX + dirZ*dirZ))); l > 0 {
+		dirX, dirZ = dirX/l, dirZ/l
+	}
+
+	if p.possessed >= 0 {
+		g.steerPossessed(dirX, dirZ, dt)
+	} else {
+		half := g.stageHalf
+		p.cursor.X = rl.Clamp(p.cursor.X+dirX*possessCursorSpeed*dt, -half, half)
+		p.cursor.Z = rl.Clamp(p.cursor.Z+dirZ*possessCursorSpeed*dt, -half, half)
+	}
+
+	if g.actionPressed(ctrl, ActionSwitchWeapon) {
+		g.cyclePossessKind()
+	}
+	if g.actionPressed(ctrl, ActionSkill1) {
+		if p.possessed >= 0 {
+			p.possessed = -1
+		} else {
+			g.possessNearest()
+		}
+	}
+	if g.actionPressed(ctrl, ActionFire) {
+		if p.possessed >= 0 {
+			g.possessedShot()
+		} else {
+			g.possessSpawn()
+		}
+	}
+}
+
+// cyclePossessKind moves to the next kind unlocked at this level.
+func (g *Game) cyclePossessKind() {
+	p := &g.possess
+	for n := 0; n < int(enemyKindCount); n++ {
+		p.kind = (p.kind + 1) % enemyKindCount
+		if g.level >= possessKinds[p.kind].minLevel {
+			break
+		}
+	}
+	g.playUISound(g.sounds.uiMove)
+}
+
+// possessBlocked returns why the selected kind can't be spawned at the
+// cursor, or "" if it can.
+func (g *Game) possessBlocked() string {
+	p := &g.possess
+	def := possessKinds[p.kind]
+	target := g.players[0].position
+	dx, dz := p.cursor.X-target.X, p.cursor.Z-target.Z
+	switch {
+	case g.level < def.minLevel:
+		return fmt.Sprintf("Unlocks at level %d", def.minLevel)
+	case p.points < def.cost:
+		return "Not enough points"
+	case dx*dx+dz*dz < possessMinDistance*possessMinDistance:
+		return "Too close to Player 1"
+	case g.CheckObstacleCollision(p.cursor, 1.0) || g.structureAt(p.cursor, 1.0) >= 0:
+		return "Blocked"
+	}
+	return ""
+}
+
+func (g *Game) possessSpawn() {
+	p := &g.possess
+	if p.reason = g.possessBlocked(); p.reason != "" {
+		return
+	}
+	for i := range g.enemies {
+		if g.enemies[i].active {
+			continue
+		}
+		pos := rl.NewVector3(p.cursor.X, 0.75, p.cursor.Z)
+		g.spawnEnemyKind(i, pos, p.kind)
+		p.points -= possessKinds[p.kind].cost
+		g.CreateExplosion(pos, rl.Purple, 10)
+		return
+	}
+	p.reason = "Too many enemies"
+}
+
+// possessNearest takes over the normal enemy closest to the cursor.
+func (g *Game) possessNearest() {
+	p := &g.possess
+	if p.points < possessCost {
+		p.reason = "Not enough points"
+		return
+	}
+	best, bestDist := -1, possessGrabRange*possessGrabRange
+	for i := range g.enemies {
+		e := &g.enemies[i]
+		if !e.active || e.isBoss {
+			continue
+		}
+		dx, dz := e.position.X-p.cursor.X, e.position.Z-p.cursor.Z
+		if d := dx*dx + dz*dz; d < bestDist {
+			best, bestDist = i, d
+		}
+	}
+	if best < 0 {
+		p.reason = "No enemy under the cursor"
+		return
+	}
+	p.points -= possessCost
+	p.possessed = best
+	p.reason = ""
+	g.playSound(g.sounds.skill)
+}
+
+// steerPossessed moves the possessed enemy and keeps the cursor on it.
+func (g *Game) steerPossessed(dirX, dirZ, dt float32) {
+	p := &g.possess
+	e := &g.enemies[p.possessed]
+	speed := float32(math.Max(float64(enemySpeed(e)), 3)) * possessSpeedMul
+	newPos := rl.Vector3{X: e.position.X + dirX*speed*dt, Y: e.position.Y, Z: e.position.Z + dirZ*speed*dt}
+	if !g.CheckObstacleCollision(newPos, e.size/2) && g.structureAt(newPos, e.size/2) < 0 {
+		e.position = newPos
+	}
+	if dirX != 0 || dirZ != 0 {
+		e.facing = float32(math.Atan2(float64(dirZ), float64(dirX)))
+	}
+	half := g.stageHalf - e.size/2
+	e.position.X = rl.Clamp(e.position.X, -half, half)
+	e.position.Z = rl.Clamp(e.position.Z, -half, half)
+	p.cursor = rl.NewVector3(e.position.X, 0, e.position.Z)
+}
+
+// possessedShot fires an enemy bullet from the possessed enemy at Player 1.
+func (g *Game) possessedShot() {
+	p := &g.possess
+	if p.shotTimer > 0 || p.points < possessShotCost {
+		return
+	}
+	e := &g.enemies[p.possessed]
+	target := g.players[0].position
+	dx, dz := target.X-e.position.X, target.Z-e.position.Z
+	dist := float32(math.Max(0.01, math.Sqrt(float64(dx*dx+dz*dz))))
+	g.spawnEnemyBullet(e.position, dx/dist, dz/dist)
+	g.enemyAttacked(e)
+	p.points -= possessShotCost
+	p.shotTimer = possessShotCooldown
+}
+
+// isPossessed reports whether Player 2 is steering enemy i, so its
+// behaviour tree should be skipped.
+func (g *Game) isPossessed(i int) bool {
+	return g.possessMode && g.possess.possessed == i
+}
+
+// drawPossessCursor marks the cursor on the floor, green when a spawn
+// would work, and rings the possessed enemy.
+func (g *Game) drawPossessCursor() {
+	if !g.possessMode {
+		return
+	}
+	p := &g.possess
+	if p.possessed >= 0 {
+		e := &g.enemies[p.possessed]
+		rl.DrawCircle3D(rl.NewVector3(e.position.X, 0.05, e.position.Z), e.size, rl.NewVector3(1, 0, 0), 90, rl.Magenta)
+		return
+	}
+	color := rl.Green
+	if g.possessBlocked() != "" {
+		color = rl.Red
+	}
+	center := rl.NewVector3(p.cursor.X, 0.05, p.cursor.Z)
+	rl.DrawCircle3D(center, possessCursorRadius, rl.NewVector3(1, 0, 0), 90, color)
+	rl.DrawLine3D(rl.NewVector3(center.X-1, 0.05, center.Z), rl.NewVector3(center.X+1, 0.05, center.Z), color)
+	rl.DrawLine3D(rl.NewVector3(center.X, 0.05, center.Z-1), rl.NewVector3(center.X, 0.05, center.Z+1), color)
+}
+
+// drawPossessHUD shows Player 2's points and what they are about to spawn.
+func (g *Game) drawPossessHUD() {
+	if !g.possessMode {
+		return
+	}
+	p := &g.possess
+	x, y := int32(screenWidth-420), int32(20)
+	drawText("P2 - POSSESSOR", x, y, 20, rl.Magenta)
+	rl.DrawRectangle(x, y+28, 380, 14, rl.DarkGray)
+	rl.DrawRectangle(x, y+28, int32(380*p.points/possessMaxPoints), 14, rl.Magenta)
+	drawText(fmt.Sprintf("%d", int(p.points)), x+385, y+26, 18, rl.White)
+
+	def := possessKinds[p.kind]
+	kind := fmt.Sprintf("Spawn: %s (%d)", enemyKindNames[p.kind], int(def.cost))
+	if p.possessed >= 0 {
+		kind = fmt.Sprintf("Possessing %s - Fire shoots (%d)", enemyKindNames[g.enemies[p.possessed].kind], int(possessShotCost))
+	}
+	drawText(kind, x, y+50, 18, rl.White)
+	if p.reason != "" {
+		drawText(p.reason, x, y+72, 16, rl.Red)
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Post-processing (settings, on by default). The 3D scene always goes
+// through the offscreen target of resscale.go; before it is put on screen
+// its brightest parts (shots, explosions, lit walls) are cut out, blurred
+// at a quarter of the resolution and added back on top as bloom. After
+// the scene, a red vignette closes in while any player is below
+// lowHealthShare of their health, beating faster the lower it gets.
+const (
+	bloomDownscale = 4
+	bloomThreshold = float32(0.7)
+	bloomStrength  = float32(0.8)
+	lowHealthShare = float32(0.3)
+	vignetteMin    = float32(0.35) // strength at the threshold
+	vignetteMax    = float32(0.8)  // strength at 1 HP
+)
+
+// brightPassShader keeps what is brighter than threshold.
+const brightPassShader = `#version 330
+in vec2 fragTexCoord;
+uniform sampler2D texture0;
+uniform float threshold;
+out vec4 finalColor;
+
+void main() {
+    vec3 c = texture(texture0, fragTexCoord).rgb;
+    float peak = max(c.r, max(c.g, c.b));
+    finalColor = vec4(c * smoothstep(threshold, 1.0, peak), 1.0);
+}
+`
+
+// blurShader is one direction of a separable gaussian; dir is one texel
+// along the blur.
+const blurShader = `#version 330
+in vec2 fragTexCoord;
+uniform sampler2D texture0;
+uniform vec2 dir;
+out vec4 finalColor;
+
+const float weights[5] = float[](0.227, 0.195, 0.122, 0.054, 0.016);
+
+void main() {
+    vec3 sum = texture(texture0, fragTexCoord).rgb * weights[0];
+    for (int i = 1; i < 5; i++) {
+        sum += texture(texture0, fragTexCoord + dir * float(i)).rgb * weights[i];
+        sum += texture(texture0, fragTexCoord - dir * float(i)).rgb * weights[i];
+    }
+    finalColor = vec4(sum, 1.0);
+}
+`
+
+// vignetteShader reddens the edges of the window; size is the window in
+// pixels.
+const vignetteShader = `#version 330
+uniform vec2 size;
+uniform float strength;
+out vec4 finalColor;
+
+void main() {
+    vec2 uv = gl_FragCoord.xy / size;
+    float d = distance(uv, vec2(0.5));
+    finalColor = vec4(0.75, 0.0, 0.0, strength * smoothstep(0.3, 0.75, d));
+}
+`
+
+type PostFX struct {
+	bright       rl.Shader
+	blur         rl.Shader
+	vignette     rl.Shader
+	thresholdLoc int32
+	dirLoc       int32
+	sizeLoc      int32
+	strengthLoc  int32
+	bloomA       rl.RenderTexture2D
+	bloomB       rl.RenderTexture2D
+	loaded       bool
+}
+
+func (p *PostFX) load() {
+	if p.loaded {
+		return
+	}
+	p.bright = rl.LoadShaderFromMemory("", brightPassShader)
+	p.thresholdLoc = rl.GetShaderLocation(p.bright, "threshold")
+	p.blur = rl.LoadShaderFromMemory("", blurShader)
+	p.dirLoc = rl.GetShaderLocation(p.blur, "dir")
+	p.vignette = rl.LoadShaderFromMemory("", vignetteShader)
+	p.sizeLoc = rl.GetShaderLocation(p.vignette, "size")
+	p.strengthLoc = rl.GetShaderLocation(p.vignette, "strength")
+
+	w, h := int32(screenWidth/bloomDownscale), int32(screenHeight/bloomDownscale)
+	p.bloomA = rl.LoadRenderTexture(w, h)
+	p.bloomB = rl.LoadRenderTexture(w, h)
+	rl.SetTextureFilter(p.bloomA.Texture, rl.FilterBilinear)
+	rl.SetTextureFilter(p.bloomB.Texture, rl.FilterBilinear)
+	p.loaded = true
+}
+
+// release frees the shaders and textures; the next frame with effects
+// loads them again.
+func (p *PostFX) release() {
+	if !p.loaded {
+		return
+	}
+	rl.UnloadShader(p.bright)
+	rl.UnloadShader(p.blur)
+	rl.UnloadShader(p.vignette)
+	rl.UnloadRenderTexture(p.bloomA)
+	rl.UnloadRenderTexture(p.bloomB)
+	p.loaded = false
+}
+
+// postPass draws src through shader into dst. Render textures are stored
+// bottom-up, so every read flips.
+func postPass(src rl.Texture2D, dst rl.RenderTexture2D, shader rl.Shader) {
+	rl.BeginTextureMode(dst)
+	rl.ClearBackground(rl.Black)
+	rl.BeginShaderMode(shader)
+	rl.DrawTexturePro(src,
+		rl.NewRectangle(0, 0, float32(src.Width), -float32(src.Height)),
+		rl.NewRectangle(0, 0, float32(dst.Texture.Width), float32(dst.Texture.Height)),
+		rl.Vector2{}, 0, rl.White)
+	rl.EndShaderMode()
+	rl.EndTextureMode()
+}
+
+// prepareBloom cuts out and blurs the bright parts of the finished scene
+// texture. Called between the 3D pass and putting it on screen.
+func (p *PostFX) prepareBloom(scene rl.Texture2D) {
+	p.load()
+	rl.SetShaderValue(p.bright, p.thresholdLoc, []float32{bloomThreshold}, rl.ShaderUniformFloat)
+	postPass(scene, p.bloomA, p.bright)
+
+	w, h := float32(p.bloomA.Texture.Width), float32(p.bloomA.Texture.Height)
+	rl.SetShaderValue(p.blur, p.dirLoc, []float32{1 / w, 0}, rl.ShaderUniformVec2)
+	postPass(p.bloomA.Texture, p.bloomB, p.blur)
+	rl.SetShaderValue(p.blur, p.dirLoc, []float32{0, 1 / h}, rl.ShaderUniformVec2)
+	postPass(p.bloomB.Texture, p.bloomA, p.blur)
+}
+
+// drawBloom adds the blurred highlights over the scene on screen.
+func (p *PostFX) drawBloom() {
+	t := p.bloomA.Texture
+	rl.BeginBlendMode(rl.BlendAdditive)
+	rl.DrawTexturePro(t,
+		rl.NewRectangle(0, 0, float32(t.Width), -float32(t.Height)),
+		rl.NewRectangle(0, 0, screenWidth, screenHeight),
+		rl.Vector2{}, 0, rl.Fade(rl.White, bloomStrength))
+	rl.EndBlendMode()
+}
+
+// drawLowHealthVignette reddens the screen edges while a player is low.
+// Drawn after the scene and any overlay on it, before the HUD.
+func (g *Game) drawLowHealthVignette() {
+	if !g.settings.postFX {
+		return
+	}
+	lowest := float32(1)
+	for i := range g.players {
+		p := &g.players[i]
+		if p.health > 0 && p.stats.maxHealth > 0 {
+			lowest = min(lowest, float32(p.health)/float32(p.stats.maxHealth))
+		}
+	}
+	if lowest >= lowHealthShare {
+		return
+	}
+	danger := 1 - lowest/lowHealthShare
+	beat := 0.85 + 0.15*float32(math.Sin(float64(g.gameTime)*(4+6*float64(danger))))
+	strength := (vignetteMin + (vignetteMax-vignetteMin)*danger) * beat
+
+	p := &g.post
+	p.load()
+	rl.SetShaderValue(p.vignette, p.sizeLoc, []float32{screenWidth, screenHeight}, rl.ShaderUniformVec2)
+	rl.SetShaderValue(p.vignette, p.strengthLoc, []float32{strength}, rl.ShaderUniformFloat)
+	rl.BeginShaderMode(p.vignette)
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.White)
+	rl.EndShaderMode()
+}
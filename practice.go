@@ -0,0 +1,427 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Practice range (main menu). A solo run on an open floor with target
+// dummies instead of enemies: nothing spawns, no boss comes and the run
+// neither ends nor pays out. Every dummy shows the damage it took over the
+// last second and its average over the last dummyWindow seconds, so a build
+// can be measured instead of argued about. Dummies never die; one that is
+// emptied refills.
+//
+// F2 opens the sandbox panel, where the player's stats, weapon and
+// upgrades can be set to anything, and the dummies' armor (taken off every
+// hit) and health changed. Damage goes through practiceMutator, so
+// everything that hurts enemies is measured the same way.
+const (
+	dummyWindow     = float32(10.0)
+	dummySize       = float32(1.6)
+	dummyMoveRange  = float32(8.0) // moving dummies sway this far either side
+	dummyMoveSpeed  = float32(0.8) // radians per second
+	dummyStartHP    = 500
+	dummyMaxArmor   = 20
+	dummyLabelSize  = 18
+	practiceMinRate = float32(0.03) // fastest fire rate the panel allows
+)
+
+// practiceDummySpots are the anchors; the back row moves.
+var practiceDummySpots = []struct {
+	x, z   float32
+	moving bool
+}{
+	{-8, -10, false},
+	{0, -12, false},
+	{8, -10, false},
+	{-6, -22, true},
+	{6, -22, true},
+}
+
+type DummyHit struct {
+	at     float32 // gameTime
+	amount int
+}
+
+type PracticeDummy struct {
+	index  int // enemy slot
+	anchor rl.Vector3
+	moving bool
+	phase  float32
+	hits   []DummyHit // within the last dummyWindow seconds
+}
+
+type Practice struct {
+	dummies []PracticeDummy
+	armor   int
+	hp      int
+	moving  bool // back row sways
+	row     int  // sandbox panel
+	upgrade int  // upgradePool index the panel would grant
+	note    string
+}
+
+// startPractice opens the range. Like boss rush it doesn't count toward
+// seed records.
+func (g *Game) startPractice() {
+	g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, false, false, true
+	if g.practice.hp == 0 {
+		g.practice.hp, g.practice.moving = dummyStartHP, true
+	}
+	g.StartGame(false)
+	g.seeded = false
+}
+
+// resetPractice builds the range in place of a stage. Called from
+// ResetGame; the panel settings survive a restart.
+func (g *Game) resetPractice() {
+	for i := range g.obstacles {
+		g.obstacles[i].active = false
+	}
+	for i := range g.nests {
+		g.nests[i].active = false
+	}
+	g.clearPortals()
+	g.clearFloorZones()
+	g.props = g.props[:0]
+	g.clearStructures()
+	g.stageHalf = defaultStageHalf
+	g.chunks.scanStageChunks(g.currentStage)
+
+	pr := &g.practice
+	pr.dummies = pr.dummies[:0]
+	for i, spot := range practiceDummySpots {
+		if i >= len(g.enemies) {
+			break
+		}
+		anchor := rl.NewVector3(spot.x, dummySize/2, spot.z)
+		g.enemies[i] = Enemy{
+			position:  anchor,
+			health:    pr.hp,
+			maxHealth: pr.hp,
+			size:      dummySize,
+			active:    true,
+			kind:      EnemyChaser,
+			color:     rl.NewColor(190, 160, 110, 255),
+			lastHitBy: -1,
+		}
+		g.detachAll(enemyOwner(i))
+		pr.dummies = append(pr.dummies, PracticeDummy{
+			index:  i,
+			anchor: anchor,
+			moving: spot.moving,
+			phase:  float32(i),
+		})
+	}
+}
+
+func (g *Game) dummyAt(index int) *PracticeDummy {
+	if !g.practiceMode {
+		return nil
+	}
+	for i := range g.practice.dummies {
+		if g.practice.dummies[i].index == index {
+			return &g.practice.dummies[i]
+		}
+	}
+	return nil
+}
+
+// isDummy reports whether enemy i is a practice dummy, so its behaviour
+// tree and contact damage should be skipped.
+func (g *Game) isDummy(i int) bool {
+	return g.dummyAt(i) != nil
+}
+
+// updatePractice holds the dummies on their anchors (or sways the moving
+// ones) and forgets hits older than dummyWindow.
+func (g *Game) updatePractice() {
+	if !g.practiceMode {
+		return
+	}
+	pr := &g.practice
+	for i := range pr.dummies {
+		d := &pr.dummies[i]
+		e := &g.enemies[d.index]
+		pos := d.anchor
+		if d.moving && pr.moving {
+			sway := math.Sin(float64(g.gameTime*dummyMoveSpeed + d.phase))
+			pos.X += float32(sway) * dummyMoveRange
+		}
+		// Knockback doesn't move a dummy off its post
+		e.position, e.velocity = pos, rl.Vector3{}
+
+		kept := d.hits[:0]
+		for _, h := range d.hits {
+			if g.gameTime-h.at < dummyWindow {
+				kept = append(kept, h)
+			}
+		}
+		d.hits = kept
+	}
+}
+
+// dps returns the damage taken over the last second and the average over
+// the window. The average covers only the time since the first hit in the
+// window, so it is right after a few seconds instead of ten.
+func (d *PracticeDummy) dps(now float32) (float32, float32) {
+	if len(d.hits) == 0 {
+		return 0, 0
+	}
+	var last, total int
+	for _, h := range d.hits {
+		total += h.amount
+		if now-h.at < 1 {
+			last += h.amount
+		}
+	}
+	span := max(1, now-d.hits[0].at)
+	return float32(last), float32(total) / span
+}
+
+// practiceMutator applies dummy armor, records the hit and refills a dummy
+// the hit would empty.
+type practiceMutator struct {
+	BaseMutator
+}
+
+func (practiceMutator) Name() string { return "Practice" }
+
+func (practiceMutator) OnDamage(g *Game, d DamageEvent) int {
+	if d.Enemy == nil {
+		return d.Amount
+	}
+	dummy := g.dummyAt(g.enemyIndex(d.Enemy))
+	if dummy == nil {
+		return d.Amount
+	}
+	amount := max(0, d.Amount-g.practice.armor)
+	if amount > 0 {
+		dummy.hits = append(dummy.hits, DummyHit{at: g.gameTime, amount: amount})
+	}
+	if amount >= d.Enemy.health {
+		d.Enemy.health = d.Enemy.maxHealth + amount
+	}
+	return amount
+}
+
+// enemyIndex is the slot of an enemy pointer into g.enemies, -1 if it
+// isn't one.
+func (g *Game) enemyIndex(e *Enemy) int {
+	for i := range g.enemies {
+		if &g.enemies[i] == e {
+			return i
+		}
+	}
+	return -1
+}
+
+// drawDummyLabels shows each dummy's DPS and health (call outside 3D
+// mode).
+func (g *Game) drawDummyLabels() {
+	if !g.practiceMode {
+		return
+	}
+	for _, d := range g.practice.dummies {
+		e := &g.enemies[d.index]
+		pos := e.position
+		pos.Y += e.size + 0.6
+		screen := rl.GetWorldToScreen(pos, g.camera)
+		x, y := int32(screen.X), int32(screen.Y)
+
+		now, avg := d.dps(g.gameTime)
+		dps := fmt.Sprintf("%.0f DPS", now)
+		drawText(dps, x-measureText(dps, dummyLabelSize+4)/2, y-44, dummyLabelSize+4, rl.Yellow)
+		avgText := fmt.Sprintf("%.0fs avg %.1f", dummyWindow, avg)
+		drawText(avgText, x-measureText(avgText, dummyLabelSize)/2, y-20, dummyLabelSize, rl.White)
+
+		rl.DrawRectangle(x-40, y+2, 80, 6, rl.DarkGray)
+		rl.DrawRectangle(x-40, y+2, int32(80*float32(e.health)/float32(e.maxHealth)), 6, rl.Orange)
+	}
+}
+
+func (g *Game) drawPracticeHUD() {
+	if !g.practiceMode {
+		return
+	}
+	text := fmt.Sprintf("PRACTICE RANGE - armor %d, %d HP - F2: sandbox", g.practice.armor, g.practice.hp)
+	drawText(text, screenWidth/2-measureText(text, 20)/2, 20, 20, rl.Lime)
+}
+
+// Sandbox panel rows. LEFT/RIGHT change a value, ENTER runs the row.
+const (
+	sandboxWeapon = iota
+	sandboxHealth
+	sandboxDamage
+	sandboxSpeed
+	sandboxFireRate
+	sandboxCrit
+	sandboxUpgrade
+	sandboxArmor
+	sandboxHP
+	sandboxMoving
+	sandboxResetDPS
+	sandboxResetBuild
+	sandboxBack
+	sandboxRows
+)
+
+var sandboxRowNames = [sandboxRows]string{
+	"Weapon", "Max Health", "Damage", "Speed", "Fire Rate", "Crit Chance",
+	"Grant Upgrade", "Dummy Armor", "Dummy Health", "Moving Dummies",
+	"Reset DPS", "Reset Build", "Back",
+}
+
+func (g *Game) openSandbox() {
+	g.state = StateSandbox
+	g.practice.note = ""
+	g.playUISound(g.sounds.uiSelect)
+}
+
+// UpdateSandbox: up/down pick a row, LEFT/RIGHT change it, ENTER runs it,
+// F2 or ESC go back to the range.
+func (g *Game) UpdateSandbox() {
+	pr := &g.practice
+	switch {
+	case rl.IsKeyPressed(rl.KeyUp):
+		pr.row = (pr.row + sandboxRows - 1) % sandboxRows
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyDown):
+		pr.row = (pr.row + 1) % sandboxRows
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyLeft):
+		g.adjustSandbox(-1)
+	case rl.IsKeyPressed(rl.KeyRight):
+		g.adjustSandbox(1)
+	case rl.IsKeyPressed(rl.KeyEnter):
+		g.runSandboxRow()
+	case rl.IsKeyPressed(rl.KeyF2) || rl.IsKeyPressed(rl.KeyEscape):
+		g.state = StatePlaying
+		g.playUISound(g.sounds.uiSelect)
+	}
+}
+
+func (g *Game) adjustSandbox(dir int) {
+	pr := &g.practice
+	p := &g.players[0]
+	switch pr.row {
+	case sandboxWeapon:
+		w := WeaponType((int(p.weapon) + dir + int(weaponCount)) % int(weaponCount))
+		p.weapons = []WeaponType{w}
+		p.weapon = w
+		p.beamFiring, p.heat, p.overheated = false, 0, false
+		p.fillAmmo()
+	case sandboxHealth:
+		p.stats.maxHealth = max(10, p.stats.maxHealth+10*dir)
+		p.health = p.stats.maxHealth
+	case sandboxDamage:
+		p.stats.damage = max(1, p.stats.damage+dir)
+	case sandboxSpeed:
+		p.stats.speed = max(1, p.stats.speed+0.5*float32(dir))
+	case sandboxFireRate:
+		p.stats.fireRate = max(practiceMinRate, p.stats.fireRate+0.01*float32(dir))
+	case sandboxCrit:
+		p.stats.critChance = min(1, max(0, p.stats.critChance+0.01*float32(dir)))
+	case sandboxUpgrade:
+		pr.upgrade = (pr.upgrade + dir + len(upgradePool)) % len(upgradePool)
+	case sandboxArmor:
+		pr.armor = min(dummyMaxArmor, max(0, pr.armor+dir))
+	case sandboxHP:
+		pr.hp = max(50, pr.hp+50*dir)
+		for _, d := range pr.dummies {
+			e := &g.enemies[d.index]
+			e.health, e.maxHealth = pr.hp, pr.hp
+		}
+	case sandboxMoving:
+		pr.moving = !pr.moving
+	default:
+		return
+	}
+	g.playUISound(g.sounds.uiMove)
+}
+
+func (g *Game) runSandboxRow() {
+	pr := &g.practice
+	switch pr.row {
+	case sandboxUpgrade:
+		g.applyUpgradeDef(&g.players[0], pr.upgrade)
+		pr.note = "Granted " + upgradePool[pr.upgrade].name
+	case sandboxResetDPS:
+		for i := range pr.dummies {
+			pr.dummies[i].hits = nil
+		}
+		pr.note = "DPS reset"
+	case sandboxResetBuild:
+		g.ResetGame()
+		g.state = StateSandbox
+		pr.note = "Build reset"
+	case sandboxBack:
+		g.state = StatePlaying
+	default:
+		return
+	}
+	g.playUISound(g.sounds.uiSelect)
+}
+
+func (g *Game) sandboxValue(row int) string {
+	pr := &g.practice
+	p := &g.players[0]
+	switch row {
+	case sandboxWeapon:
+		return weaponDefs[p.weapon].name
+	case sandboxHealth:
+		return fmt.Sprintf("%d", p.stats.maxHealth)
+	case sandboxDamage:
+		return fmt.Sprintf("%d", p.stats.damage)
+	case sandboxSpeed:
+		return fmt.Sprintf("%.1f", p.stats.speed)
+	case sandboxFireRate:
+		return fmt.Sprintf("%.2fs", p.stats.fireRate)
+	case sandboxCrit:
+		return fmt.Sprintf("%.0f%%", p.stats.critChance*100)
+	case sandboxUpgrade:
+		def := upgradePool[pr.upgrade]
+		return def.name + " - " + def.desc
+	case sandboxArmor:
+		return fmt.Sprintf("%d", pr.armor)
+	case sandboxHP:
+		return fmt.Sprintf("%d", pr.hp)
+	case sandboxMoving:
+		if pr.moving {
+			return "ON"
+		}
+		return "OFF"
+	}
+	return ""
+}
+
+func (g *Game) DrawSandbox() {
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 190))
+	pr := &g.practice
+	centerX := int32(screenWidth / 2)
+	drawText("SANDBOX", centerX-measureText("SANDBOX", 50)/2, 70, 50, rl.Lime)
+
+	for row := 0; row < sandboxRows; row++ {
+		y := int32(160 + row*52)
+		color := rl.White
+		if row == pr.row {
+			color = rl.Yellow
+			rl.DrawRectangle(centerX-420, y-10, 840, 48, rl.NewColor(255, 255, 0, 40))
+		}
+		drawText(sandboxRowNames[row], centerX-400, y, 28, color)
+		drawText(g.sandboxValue(row), centerX-60, y, 28, color)
+	}
+
+	if owned := len(g.players[0].owned); owned > 0 {
+		text := fmt.Sprintf("%d upgrades granted", owned)
+		drawText(text, centerX-measureText(text, 22)/2, screenHeight-140, 22, rl.LightGray)
+	}
+	if pr.note != "" {
+		drawText(pr.note, centerX-measureText(pr.note, 24)/2, screenHeight-110, 24, rl.Gold)
+	}
+	hint := "UP/DOWN choose  LEFT/RIGHT change  ENTER apply  F2/ESC back"
+	drawText(hint, centerX-measureText(hint, 20)/2, screenHeight-70, 20, rl.LightGray)
+}
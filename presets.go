@@ -0,0 +1,103 @@
+package main
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+// Binding presets for the controls screen. A preset only rewrites keyboard
+// and mouse bindings; controller buttons are left alone. After applying one
+// every binding can still be changed by hand.
+type ControlPreset struct {
+	name  string
+	apply func(b *[actionCount]Binding)
+}
+
+var controlPresets = []ControlPreset{
+	{"Default", nil},
+	{"Left-Handed (Mouse + IJKL)", leftHandedPreset},
+	{"One-Handed (Left)", oneHandedLeftPreset},
+	{"One-Handed (Right)", oneHandedRightPreset},
+}
+
+// leftHandedPreset moves the keyboard cluster to IJKL for a mouse in the
+// left hand.
+func leftHandedPreset(b *[actionCount]Binding) {
+	b[ActionMoveUp].Keys = keys(rl.KeyI)
+	b[ActionMoveDown].Keys = keys(rl.KeyK)
+	b[ActionMoveLeft].Keys = keys(rl.KeyJ)
+	b[ActionMoveRight].Keys = keys(rl.KeyL)
+	b[ActionFire].Keys = keys(rl.KeyEnter)
+	b[ActionFire].Mouse = keys(int32(rl.MouseLeftButton))
+	b[ActionAimLock].Mouse = keys(int32(rl.MouseRightButton))
+	b[ActionSkill1].Keys = keys(rl.KeyU)
+	b[ActionSkill2].Keys = keys(rl.KeyO)
+	b[ActionSkill3].Keys = keys(rl.KeySemicolon) // P is pause
+	b[ActionSprint].Keys = keys(rl.KeyRightShift)
+	b[ActionSwitchWeapon].Keys = keys(rl.KeyN)
+	b[ActionReload].Keys = keys(rl.KeyH)
+	b[ActionGrenade].Keys = keys(rl.KeyM)
+	b[ActionDash].Keys = keys(rl.KeySpace)
+}
+
+// oneHandedLeftPreset plays from the left side of the keyboard alone: fire
+// and aim-lock are toggles, so one tap each gives auto-fire at the nearest
+// enemy while the hand stays on WASD.
+func oneHandedLeftPreset(b *[actionCount]Binding) {
+	b[ActionMoveUp].Keys = keys(rl.KeyW)
+	b[ActionMoveDown].Keys = keys(rl.KeyS)
+	b[ActionMoveLeft].Keys = keys(rl.KeyA)
+	b[ActionMoveRight].Keys = keys(rl.KeyD)
+	b[ActionFire] = Binding{Keys: keys(rl.KeySpace), Toggle: true}
+	b[ActionAimLock] = Binding{Keys: keys(rl.KeyLeftControl), Toggle: true}
+	b[ActionSkill1].Keys = keys(rl.KeyQ)
+	b[ActionSkill2].Keys = keys(rl.KeyE)
+	b[ActionSkill3].Keys = keys(rl.KeyF)
+	b[ActionSprint] = Binding{Keys: keys(rl.KeyLeftShift), Toggle: true}
+	b[ActionSwitchWeapon].Keys = keys(rl.KeyX)
+	b[ActionReload].Keys = keys(rl.KeyR)
+	b[ActionGrenade].Keys = keys(rl.KeyG)
+	b[ActionDash].Keys = keys(rl.KeyC)
+}
+
+// oneHandedRightPreset is the same idea on the arrow keys and numpad.
+func oneHandedRightPreset(b *[actionCount]Binding) {
+	b[ActionMoveUp].Keys = keys(rl.KeyUp)
+	b[ActionMoveDown].Keys = keys(rl.KeyDown)
+	b[ActionMoveLeft].Keys = keys(rl.KeyLeft)
+	b[ActionMoveRight].Keys = keys(rl.KeyRight)
+	b[ActionFire] = Binding{Keys: keys(rl.KeyKp0), Toggle: true}
+	b[ActionAimLock] = Binding{Keys: keys(rl.KeyKpEnter), Toggle: true}
+	b[ActionSkill1].Keys = keys(rl.KeyKp1)
+	b[ActionSkill2].Keys = keys(rl.KeyKp2)
+	b[ActionSkill3].Keys = keys(rl.KeyKp3)
+	b[ActionSprint] = Binding{Keys: keys(rl.KeyRightShift), Toggle: true}
+	b[ActionSwitchWeapon].Keys = keys(rl.KeyKp5)
+	b[ActionReload].Keys = keys(rl.KeyKp7)
+	b[ActionGrenade].Keys = keys(rl.KeyKp9)
+	b[ActionDash].Keys = keys(rl.KeyKpDecimal)
+}
+
+// applyPreset replaces a player's keyboard and mouse bindings with a preset.
+func (g *Game) applyPreset(playerID, preset int) {
+	defaults := defaultControls().players[playerID]
+	bindings := &g.controls.players[playerID]
+
+	pads := make([][]int32, actionCount)
+	for a := range bindings {
+		pads[a] = bindings[a].Pad
+	}
+
+	if apply := controlPresets[preset].apply; apply != nil {
+		*bindings = [actionCount]Binding{}
+		apply(bindings)
+	} else {
+		*bindings = defaults
+	}
+	for a := range bindings {
+		bindings[a].Pad = pads[a]
+	}
+
+	// Toggles from the previous layout would otherwise stay latched
+	if playerID < len(g.players) {
+		g.players[playerID].toggled = [actionCount]bool{}
+	}
+	g.saveControls()
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Saving and shutting down. Every save file goes through writeFileAtomic
+// (a temp file renamed over the old one), so a crash or power cut in the
+// middle of a write leaves the previous file rather than half of a new one.
+//
+// Settings live in save/settings.json and are written on every change. A
+// run in progress is also autosaved to save/autosave.json, with mastery,
+// every Settings > Autosave interval of play. The file is removed once the
+// run ends or is suspended, so finding one at startup means the game never
+// got to shut down, and its run becomes the one to Continue.
+//
+// Closing the window (or Quit) goes through shutdown: a run in progress is
+// suspended, the profile written, the music stopped and the assets and the
+// audio device released in order.
+const (
+	settingsFile = saveDir + "/settings.json"
+	autosaveFile = saveDir + "/autosave.json"
+)
+
+// Autosave intervals in seconds of play; 0 = off.
+var (
+	autosaveIntervals = []float32{0, 60, 300}
+	autosaveNames     = []string{"OFF", "1 MIN", "5 MIN"}
+)
+
+type Autosave struct {
+	timer   float32
+	written bool // autosaveFile holds the current run
+}
+
+// writeFileAtomic replaces path with data. The temp file sits next to the
+// target so the rename never crosses file systems.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// SavedSettings is what settings.json stores.
+type SavedSettings struct {
+	Sound       bool              `json:"sound"`
+	Music       bool              `json:"music"`
+	Volumes     [busCount]float32 `json:"volumes"`
+	Difficulty  int               `json:"difficulty"`
+	InputBuffer float32           `json:"inputBuffer"`
+	Sprint      bool              `json:"sprint"`
+	Ammo        bool              `json:"ammo"`
+	Energy      bool              `json:"energy"`
+	Layout      int               `json:"layout"`
+	Streamer    bool              `json:"streamer"`
+	Palette     int               `json:"palette"`
+	DynamicRes  bool              `json:"dynamicRes"`
+	Pacing      int               `json:"pacing"`
+	Autosave    int               `json:"autosave"`
+	PostFX      *bool             `json:"postFX"` // nil in files from before the setting
+	Shake       *int              `json:"shake"`
+}
+
+func (g *Game) saveSettings() {
+	s := g.settings
+	data, err := json.MarshalIndent(SavedSettings{
+		Sound: s.soundEnabled, Music: s.musicEnabled, Volumes: s.volumes,
+		Difficulty: s.difficulty, InputBuffer: s.inputBuffer,
+		Sprint: s.modifiers.sprint, Ammo: s.modifiers.ammo, Energy: s.modifiers.energy,
+		Layout: int(activeLayout), Streamer: s.streamerMode, Palette: s.palette,
+		DynamicRes: s.dynamicRes, Pacing: int(s.pacing), Autosave: s.autosave, PostFX: &s.postFX,
+		Shake: &s.shake,
+	}, "", "  ")
+	if err != nil {
+		fmt.Println("Warning: Could not encode settings:", err)
+		return
+	}
+	if err := writeFileAtomic(settingsFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save settings:", err)
+	}
+}
+
+// loadSettings applies settings.json over the defaults. Out of range
+// values keep the default.
+func (g *Game) loadSettings() {
+	data, err := os.ReadFile(settingsFile)
+	if err != nil {
+		return
+	}
+	var saved SavedSettings
+	if err := json.Unmarshal(data, &saved); err != nil {
+		fmt.Println("Warning: Could not read settings:", err)
+		return
+	}
+	s := &g.settings
+	s.soundEnabled, s.musicEnabled = saved.Sound, saved.Music
+	for bus, v := range saved.Volumes {
+		if v >= 0 && v <= 1 {
+			s.volumes[bus] = v
+		}
+	}
+	if saved.Difficulty >= 0 && saved.Difficulty <= 2 {
+		s.difficulty = saved.Difficulty
+	}
+	if saved.InputBuffer >= 0 && saved.InputBuffer <= 0.3 {
+		s.inputBuffer = snapInputBuffer(saved.InputBuffer)
+	}
+	s.modifiers = RunModifiers{sprint: saved.Sprint, ammo: saved.Ammo, energy: saved.Energy && !saved.Ammo}
+	if saved.Layout >= 0 && saved.Layout < int(layoutCount) {
+		activeLayout = KeyboardLayout(saved.Layout)
+	}
+	s.streamerMode = saved.Streamer
+	if saved.Palette >= 0 && saved.Palette < len(palettes) {
+		s.palette = saved.Palette
+	}
+	s.dynamicRes = saved.DynamicRes
+	if saved.Pacing >= 0 && saved.Pacing < int(pacingCount) {
+		s.pacing = FramePacing(saved.Pacing)
+	}
+	if saved.Autosave >= 0 && saved.Autosave < len(autosaveIntervals) {
+		s.autosave = saved.Autosave
+	}
+	if saved.PostFX != nil {
+		s.postFX = *saved.PostFX
+	}
+	if saved.Shake != nil && *saved.Shake >= 0 && *saved.Shake < len(shakeLevels) {
+		s.shake = *saved.Shake
+	}
+}
+
+// runInProgress reports whether the state belongs to a run that isn't over.
+func (g *Game) runInProgress() bool {
+	switch g.state {
+	case StatePlaying, StatePaused, StateUpgrade, StateBuild, StateLoot,
+		StateSkillTree, StateCharacter, StateSandbox:
+		return true
+	}
+	return g.inRunSettings()
+}
+
+// updateAutosave writes the run every autosave interval of play and drops
+// the file once the run is over. Called every frame.
+func (g *Game) updateAutosave(dt float32) {
+	a := &g.autosave
+	if !g.runInProgress() {
+		if a.written {
+			os.Remove(autosaveFile)
+			a.written = false
+		}
+		a.timer = 0
+		return
+	}
+	interval := autosaveIntervals[g.settings.autosave]
+	if interval == 0 || g.state != StatePlaying || !g.canSuspend() || g.playback != nil {
+		return
+	}
+	a.timer += dt
+	if a.timer < interval {
+		return
+	}
+	a.timer = 0
+	data, err := json.Marshal(g.snapshotRun())
+	if err != nil {
+		fmt.Println("Warning: Could not encode autosave:", err)
+		return
+	}
+	if err := writeFileAtomic(autosaveFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not autosave:", err)
+		return
+	}
+	a.written = true
+	g.saveMastery()
+}
+
+// recoverAutosave turns an autosave left by a session that didn't shut
+// down into the suspended run. A run suspended on purpose wins.
+func recoverAutosave() {
+	if !fileExists(autosaveFile) {
+		return
+	}
+	if hasSuspendedRun() {
+		os.Remove(autosaveFile)
+		return
+	}
+	if err := os.Rename(autosaveFile, suspendFile); err != nil {
+		fmt.Println("Warning: Could not recover autosave:", err)
+		return
+	}
+	fmt.Println("✓ Recovered the last run from", autosaveFile)
+}
+
+// shutdown saves everything and releases the devices. Called once the main
+// loop ends.
+func (g *Game) shutdown() {
+	if g.runInProgress() && g.canSuspend() && g.playback == nil {
+		g.suspendRun()
+	}
+	g.updateAutosave(0) // the run is suspended or over: drop the autosave
+	g.saveMastery()
+	g.saveHeatmap()
+	g.saveSettings()
+
+	for _, t := range g.sounds.musicTracks() {
+		if t.loaded() {
+			rl.StopMusicStream(t.stream)
+			rl.UnloadMusicStream(t.stream)
+		}
+	}
+	g.unloadModelAnimations()
+	g.unloadSfxAliases()
+	g.assets.unloadAll()
+	g.res.release()
+	g.night.release()
+	g.lights.release()
+	g.post.release()
+	if rl.IsAudioDeviceReady() {
+		rl.CloseAudioDevice()
+	}
+	fmt.Println("✓ Saved and shut down")
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Decoration props. GenerateStage scatters rocks, crates, pillars and
+// glowing crystals over the floor, picked from the stage's theme, so a
+// stage is more than a plane and a grid. Props are scenery only: nothing
+// collides with them, and they keep clear of obstacles, nests, portals,
+// conveyors and the middle of the floor so they never look like cover.
+//
+// Each kind draws assets/models/props/<name>.glb when there is one and a
+// few primitives otherwise. Placement has its own rng seeded from the run
+// seed and the stage number, so the same stage of a seed always looks the
+// same (also after Continue) without drawing from the run's rng.
+type PropKind int
+
+const (
+	PropRock PropKind = iota
+	PropCrate
+	PropPillar
+	PropCrystal
+	propKindCount
+)
+
+var propNames = [propKindCount]string{"rock", "crate", "pillar", "crystal"}
+
+const (
+	propsPerStage = 28
+	propClearance = float32(6.0) // keep the player start free
+)
+
+// stagePropThemes lists what each stage is decorated with; kinds listed
+// twice come up twice as often.
+var stagePropThemes = [...][]PropKind{
+	StageBasic:  {PropRock, PropRock, PropCrate},
+	StageMaze:   {PropPillar, PropRock, PropRock},
+	StageHazard: {PropCrystal, PropCrystal, PropRock},
+	StageArena:  {PropPillar, PropCrate, PropCrate},
+	StageNight:  {PropCrystal, PropRock, PropPillar},
+}
+
+type Prop struct {
+	kind     PropKind
+	position rl.Vector3
+	rotation float32 // degrees around Y
+	scale    float32
+}
+
+// loadPropModels loads the optional prop models.
+func (g *Game) loadPropModels() {
+	for k := PropKind(0); k < propKindCount; k++ {
+		path := fmt.Sprintf("assets/models/props/%s.glb", propNames[k])
+		if fileExists(path) {
+			g.propModels[k] = g.assets.loadModel(path)
+			fmt.Println("✓ Loaded:", path)
+		}
+	}
+}
+
+// scatterProps decorates the current stage. Called once everything that
+// props keep clear of has been placed.
+func (g *Game) scatterProps() {
+	g.props = g.props[:0]
+	if g.practiceMode {
+		return
+	}
+	stageNum := int64((g.level - 1) / stageInterval)
+	rng := rand.New(rand.NewSource(g.seed*31 + stageNum))
+	theme := stagePropThemes[g.currentStage]
+	for try := 0; try < propsPerStage*4 && len(g.props) < propsPerStage; try++ {
+		kind := theme[rng.Intn(len(theme))]
+		half := g.stageHalf - 1
+		pos := rl.NewVector3((rng.Float32()*2-1)*half, 0, (rng.Float32()*2-1)*half)
+		if !g.propFits(kind, pos) {
+			continue
+		}
+		g.props = append(g.props, Prop{
+			kind:     kind,
+			position: pos,
+			rotation: rng.Float32() * 360,
+			scale:    0.7 + rng.Float32()*0.6,
+		})
+	}
+}
+
+// propFits keeps props out of the way of anything that plays.
+func (g *Game) propFits(kind PropKind, pos rl.Vector3) bool {
+	if pos.X*pos.X+pos.Z*pos.Z < propClearance*propClearance {
+		return false
+	}
+	// Pillars are big enough to pass for cover; keep them to the rim
+	if kind == PropPillar && float32(math.Max(math.Abs(float64(pos.X)), math.Abs(float64(pos.Z)))) < g.stageHalf-8 {
+		return false
+	}
+	if g.CheckObstacleCollision(pos, 1.5) || g.nestAt(pos, 1) >= 0 || g.portalAt(pos, 1) >= 0 {
+		return false
+	}
+	if z := g.floorZoneAt(pos); z != nil && z.kind == ZoneConveyor {
+		return false
+	}
+	for i := range g.props {
+		dx := pos.X - g.props[i].position.X
+		dz := pos.Z - g.props[i].position.Z
+		if dx*dx+dz*dz < 4 {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Game) drawProps() {
+	for i := range g.props {
+		p := &g.props[i]
+		if model := g.propModels[p.kind]; model.MeshCount > 0 {
+			rl.DrawModelEx(model, p.position, rl.NewVector3(0, 1, 0), p.rotation, rl.NewVector3(p.scale, p.scale, p.scale), rl.White)
+			continue
+		}
+		g.drawPropShape(p)
+	}
+}
+
+// drawPropShape is the stand-in for a prop without a model.
+func (g *Game) drawPropShape(p *Prop) {
+	s := p.scale
+	pos := p.position
+	switch p.kind {
+	case PropRock:
+		rl.DrawSphereEx(rl.NewVector3(pos.X, 0.2*s, pos.Z), 0.6*s, 5, 6, rl.NewColor(85, 80, 75, 255))
+		rl.DrawSphereEx(rl.NewVector3(pos.X+0.5*s, 0.1*s, pos.Z+0.2*s), 0.35*s, 4, 5, rl.NewColor(70, 66, 62, 255))
+	case PropCrate:
+		c := rl.NewVector3(pos.X, 0.35*s, pos.Z)
+		rl.DrawCube(c, 0.7*s, 0.7*s, 0.7*s, rl.NewColor(110, 80, 45, 255))
+		rl.DrawCubeWires(c, 0.7*s, 0.7*s, 0.7*s, rl.NewColor(70, 50, 25, 255))
+	case PropPillar:
+		h := 3.5 * s
+		rl.DrawCylinder(pos, 0.5*s, 0.45*s, h, 8, rl.NewColor(120, 120, 130, 255))
+		rl.DrawCylinderWires(pos, 0.5*s, 0.45*s, h, 8, rl.NewColor(80, 80, 90, 255))
+		rl.DrawCube(rl.NewVector3(pos.X, h, pos.Z), 1.2*s, 0.25*s, 1.2*s, rl.NewColor(100, 100, 110, 255))
+	case PropCrystal:
+		glow := 0.6 + 0.4*float32(math.Sin(float64(g.gameTime)*2+float64(p.rotation)))
+		color := rl.NewColor(120, 220, 255, 255)
+		if g.currentStage == StageHazard {
+			color = rl.NewColor(150, 255, 120, 255)
+		}
+		rl.DrawCylinder(pos, 0.25*s, 0, 1.4*s, 5, rl.Fade(color, 0.5+0.4*glow))
+		rl.DrawCylinder(rl.NewVector3(pos.X+0.3*s, 0, pos.Z-0.2*s), 0.15*s, 0, 0.8*s, 5, rl.Fade(color, 0.5+0.4*glow))
+		rl.DrawCylinder(rl.NewVector3(pos.X, 0.01, pos.Z), 0.9*s, 0.9*s, 0.01, 12, rl.Fade(color, 0.15*glow))
+	}
+}
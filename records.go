@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Per-seed best results. Only seeded runs (daily or -seed) are recorded,
+// since a random seed can never be replayed.
+const (
+	saveDir         = "save"
+	recordsFile     = saveDir + "/records.json"
+	paceSampleEvery = float32(1.0) // seconds between score samples
+)
+
+// SeedRecord is the best result stored for one seed/mode combination.
+type SeedRecord struct {
+	BestScore int            `json:"bestScore"`
+	Level     int            `json:"level"`
+	Kills     int            `json:"kills"`
+	Time      float32        `json:"time"`
+	Date      string         `json:"date"`
+	Pace      []int          `json:"pace"`            // score sampled every paceSampleEvery seconds
+	Ghost     [][]GhostFrame `json:"ghost,omitempty"` // per-player position track
+}
+
+// dailySeed turns today's date into a seed so everyone plays the same run.
+func dailySeed() int64 {
+	now := time.Now()
+	return int64(now.Year()*10000 + int(now.Month())*100 + now.Day())
+}
+
+// recordKey separates solo and co-op results for the same seed.
+func (g *Game) recordKey() string {
+	mode := "solo"
+	if g.coopMode {
+		mode = "coop"
+	}
+	return fmt.Sprintf("%d/%s", g.seed, mode)
+}
+
+func (g *Game) loadRecords() {
+	g.records = map[string]SeedRecord{}
+
+	data, err := os.ReadFile(recordsFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &g.records); err != nil {
+		fmt.Println("Warning: Could not read seed records:", err)
+		g.records = map[string]SeedRecord{}
+	}
+}
+
+func (g *Game) saveRecords() {
+	os.MkdirAll(filepath.Dir(recordsFile), os.ModePerm)
+
+	data, err := json.MarshalIndent(g.records, "", "  ")
+	if err != nil {
+		fmt.Println("Warning: Could not encode seed records:", err)
+		return
+	}
+	if err := writeFileAtomic(recordsFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save seed records:", err)
+	}
+}
+
+// bestRecord returns the stored best for the current seed, if any.
+func (g *Game) bestRecord() (SeedRecord, bool) {
+	if !g.seeded {
+		return SeedRecord{}, false
+	}
+	rec, ok := g.records[g.recordKey()]
+	return rec, ok
+}
+
+// updatePace samples the score once per paceSampleEvery seconds of play.
+func (g *Game) updatePace() {
+	for float32(len(g.pace))*paceSampleEvery <= g.gameTime {
+		g.pace = append(g.pace, g.score)
+	}
+}
+
+// paceDelta compares the current score with the best run at the same time.
+func (g *Game) paceDelta() (int, bool) {
+	best, ok := g.bestRecord()
+	if !ok || len(best.Pace) == 0 || len(g.pace) == 0 {
+		return 0, false
+	}
+	idx := len(g.pace) - 1
+	if idx >= len(best.Pace) {
+		// The best run ended earlier; compare against its final score
+		idx = len(best.Pace) - 1
+	}
+	return g.score - best.Pace[idx], true
+}
+
+// finishSeededRun stores the run if it beat the previous best for the seed.
+func (g *Game) finishSeededRun() {
+	g.newSeedBest = false
+	if !g.seeded {
+		return
+	}
+
+	best, ok := g.bestRecord()
+	if ok && g.score <= best.BestScore {
+		return
+	}
+	if !g.finishReplay() {
+		return
+	}
+
+	g.updatePace()
+	g.recordGhost()
+	ghost := make([][]GhostFrame, len(g.ghost))
+	for i := range g.ghost {
+		ghost[i] = append([]GhostFrame(nil), g.ghost[i]...)
+	}
+
+	g.records[g.recordKey()] = SeedRecord{
+		BestScore: g.score,
+		Level:     g.level,
+		Kills:     g.enemiesKilled,
+		Time:      g.gameTime,
+		Date:      time.Now().Format("2006-01-02"),
+		Pace:      append([]int(nil), g.pace...),
+		Ghost:     ghost,
+	}
+	g.newSeedBest = true
+	g.saveRecords()
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Remote control for kiosk and demo setups (-remote). An HTTP API on
+// localhost lets an operator start and stop runs, change the difficulty and
+// read the game state. Every request needs the token from save/remote.json
+// as "Authorization: Bearer <token>"; the file is created with a random
+// token the first time. Handlers run on the server's goroutines, so they
+// only queue commands; the game applies them on the main loop.
+//
+//	GET  /state
+//	POST /run/start?mode=solo|coop|daily
+//	POST /run/stop
+//	POST /run/pause
+//	POST /difficulty?level=easy|normal|hard
+const (
+	remoteFile        = saveDir + "/remote.json"
+	remoteDefaultPort = 8765
+	remoteTimeout     = 2 * time.Second
+)
+
+type RemoteConfig struct {
+	Port  int    `json:"port"`
+	Token string `json:"token"`
+}
+
+// RemoteCommand is one API call waiting for the main loop.
+type RemoteCommand struct {
+	action string
+	arg    string
+	reply  chan RemoteReply
+}
+
+type RemoteReply struct {
+	Status int    `json:"-"`
+	Error  string `json:"error,omitempty"`
+	State  any    `json:"state,omitempty"`
+}
+
+type RemoteControl struct {
+	config   RemoteConfig
+	commands chan RemoteCommand
+}
+
+// loadRemoteConfig reads the config, writing a fresh one with a random
+// token if there is none.
+func loadRemoteConfig() (RemoteConfig, error) {
+	var cfg RemoteConfig
+	data, err := os.ReadFile(remoteFile)
+	if err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.Port == 0 {
+		cfg.Port = remoteDefaultPort
+	}
+	if cfg.Token != "" {
+		return cfg, nil
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return cfg, err
+	}
+	cfg.Token = hex.EncodeToString(token)
+	data, _ = json.MarshalIndent(cfg, "", "  ")
+	os.MkdirAll(saveDir, os.ModePerm)
+	if err := writeFileAtomic(remoteFile, data, 0600); err != nil {
+		return cfg, err
+	}
+	fmt.Println("✓ Created remote token in", remoteFile)
+	return cfg, nil
+}
+
+func (g *Game) startRemoteControl() {
+	cfg, err := loadRemoteConfig()
+	if err != nil {
+		fmt.Println("Warning: Could not set up remote control:", err)
+		return
+	}
+	rc := &RemoteControl{config: cfg, commands: make(chan RemoteCommand, 8)}
+	g.remote = rc
+
+	mux := http.NewServeMux()
+	route := func(path, method, action string) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			rc.handle(w, r, method, action)
+		})
+	}
+	route("/state", http.MethodGet, "state")
+	route("/run/start", http.MethodPost, "start")
+	route("/run/stop", http.MethodPost, "stop")
+	route("/run/pause", http.MethodPost, "pause")
+	route("/difficulty", http.MethodPost, "difficulty")
+
+	addr := fmt.Sprintf("127.0.0.1:%d", cfg.Port)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Println("Warning: Remote control stopped:", err)
+		}
+	}()
+	fmt.Printf("✓ Remote control on http://%s\n", addr)
+}
+
+func (rc *RemoteControl) handle(w http.ResponseWriter, r *http.Request, method, action string) {
+	reply := RemoteReply{Status: http.StatusOK}
+	defer func() {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(reply.Status)
+		json.NewEncoder(w).Encode(reply)
+	}()
+
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(rc.config.Token)) != 1 {
+		reply = RemoteReply{Status: http.StatusUnauthorized, Error: "bad token"}
+		return
+	}
+	if r.Method != method {
+		reply = RemoteReply{Status: http.StatusMethodNotAllowed, Error: "use " + method}
+		return
+	}
+
+	arg := r.URL.Query().Get("mode")
+	if action == "difficulty" {
+		arg = r.URL.Query().Get("level")
+	}
+	cmd := RemoteCommand{action: action, arg: arg, reply: make(chan RemoteReply, 1)}
+	select {
+	case rc.commands <- cmd:
+	case <-time.After(remoteTimeout):
+		reply = RemoteReply{Status: http.StatusServiceUnavailable, Error: "game busy"}
+		return
+	}
+	select {
+	case reply = <-cmd.reply:
+	case <-time.After(remoteTimeout):
+		reply = RemoteReply{Status: http.StatusServiceUnavailable, Error: "game busy"}
+	}
+}
+
+// updateRemote applies queued API calls. Called every frame.
+func (g *Game) updateRemote() {
+	if g.remote == nil {
+		return
+	}
+	for {
+		select {
+		case cmd := <-g.remote.commands:
+			cmd.reply <- g.runRemoteCommand(cmd)
+		default:
+			return
+		}
+	}
+}
+
+func (g *Game) runRemoteCommand(cmd RemoteCommand) RemoteReply {
+	ok := RemoteReply{Status: http.StatusOK}
+	switch cmd.action {
+	case "start":
+		switch cmd.arg {
+		case "", "solo":
+			g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, false, false, false
+			g.StartGame(false)
+		case "coop":
+			g.daily, g.bossRushMode, g.possessMode, g.practiceMode = false, false, false, false
+			g.StartGame(true)
+		case "daily":
+			g.daily, g.bossRushMode, g.possessMode, g.practiceMode = true, false, false, false
+			g.StartGame(false)
+		default:
+			return RemoteReply{Status: http.StatusBadRequest, Error: "mode must be solo, coop or daily"}
+		}
+
+	case "stop":
+		g.state = StateMenu
+
+	case "pause":
+		switch g.state {
+		case StatePlaying:
+			g.state = StatePaused
+		case StatePaused:
+			g.state = StatePlaying
+		default:
+			return RemoteReply{Status: http.StatusConflict, Error: "no run in progress"}
+		}
+
+	case "difficulty":
+		levels := map[string]int{"easy": 0, "normal": 1, "hard": 2}
+		d, found := levels[strings.ToLower(cmd.arg)]
+		if !found {
+			return RemoteReply{Status: http.StatusBadRequest, Error: "level must be easy, normal or hard"}
+		}
+		// Applies from the next run, like the settings menu outside a run
+		g.settings.difficulty = d
+	}
+
+	ok.State = g.remoteState()
+	return ok
+}
+
+func (g *Game) remoteState() any {
+	return struct {
+		StreamStats
+		Difficulty int  `json:"difficulty"`
+		Coop       bool `json:"coop"`
+	}{g.currentStreamStats(), g.settings.difficulty, g.coopMode}
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Input replays for daily runs. Every simulated frame records its frame
+// time and each player's answers to the input queries the simulation made
+// (actions down, pressed and active, plus the aim angle); choices made on
+// the in-run screens (upgrades, skill nodes, stat points, loot,
+// structures, difficulty) are stored as events between frames. The
+// simulation only draws from the run's rng, so feeding the same answers
+// back plays the same run.
+//
+// A new daily best is checked before it is stored: input faster than a
+// person can press a button, or frame times no real frame takes, reject
+// the run. The replay is written to save/replays/ with the claimed result,
+// and -verify-replay re-simulates it headless and compares the score; a
+// leaderboard server runs the same check on submitted runs.
+//
+// Runs with debug tools or Twitch voting, and runs resumed from a suspend,
+// are not recorded, so they can't be checked and never set a daily best.
+const (
+	replayDir     = saveDir + "/replays"
+	replayVersion = 1
+
+	maxPressesPerSecond = 20            // per action, over any one second of play
+	maxReplayStep       = float32(0.25) // longest frame a real run produces
+)
+
+// ActionMask is a set of actions.
+type ActionMask uint32
+
+func (m ActionMask) has(a Action) bool { return m&(1<<a) != 0 }
+func (m *ActionMask) set(a Action)     { *m |= 1 << a }
+
+// ReplayInput is one player's input for one frame.
+type ReplayInput struct {
+	Down    ActionMask `json:"d,omitempty"`
+	Pressed ActionMask `json:"p,omitempty"`
+	Active  ActionMask `json:"a,omitempty"`
+	Aim     float32    `json:"aim,omitempty"`
+	Stick   bool       `json:"s,omitempty"` // Aim came from the right stick
+}
+
+type ReplayFrame struct {
+	DT      float32       `json:"dt"`
+	Players []ReplayInput `json:"in"`
+}
+
+type ReplayEventKind int
+
+const (
+	EventUpgrade ReplayEventKind = iota
+	EventSkillNode
+	EventLoot
+	EventStructure
+	EventStatPoint
+	EventDifficulty
+)
+
+// ReplayEvent is a choice made on an in-run screen before frame Frame.
+type ReplayEvent struct {
+	Frame  int             `json:"f"`
+	Kind   ReplayEventKind `json:"k"`
+	Player int             `json:"p,omitempty"`
+	Value  int             `json:"v"`
+	X      float32         `json:"x,omitempty"`
+	Z      float32         `json:"z,omitempty"`
+}
+
+// ReplaySettings are the settings that change the simulation.
+type ReplaySettings struct {
+	Difficulty  int     `json:"difficulty"`
+	InputBuffer float32 `json:"inputBuffer"`
+	Sprint      bool    `json:"sprint"`
+	Ammo        bool    `json:"ammo"`
+	Energy      bool    `json:"energy"`
+}
+
+// Replay is a recorded daily run and the result it claims.
+type Replay struct {
+	Version  int            `json:"version"`
+	Seed     int64          `json:"seed"`
+	Coop     bool           `json:"coop"`
+	Settings ReplaySettings `json:"settings"`
+
+	Score int     `json:"score"`
+	Kills int     `json:"kills"`
+	Level int     `json:"level"`
+	Time  float32 `json:"time"`
+
+	Frames []ReplayFrame `json:"frames"`
+	Events []ReplayEvent `json:"events"`
+}
+
+// startReplay begins recording a run. Called from ResetGame, so restarts
+// record from scratch.
+func (g *Game) startReplay() {
+	g.recording = nil
+	if !g.daily || g.playback != nil || g.debugTools || g.twitch != nil {
+		return
+	}
+	s := g.settings
+	g.recording = &Replay{
+		Version: replayVersion,
+		Seed:    g.seed,
+		Coop:    g.coopMode,
+		Settings: ReplaySettings{
+			Difficulty:  s.difficulty,
+			InputBuffer: s.inputBuffer,
+			Sprint:      s.modifiers.sprint,
+			Ammo:        s.modifiers.ammo,
+			Energy:      s.modifiers.energy,
+		},
+	}
+}
+
+// beginReplayFrame opens the frame the following input queries belong to.
+// Called once per simulated frame, before the players update.
+func (g *Game) beginReplayFrame(dt float32) {
+	if g.recording == nil {
+		return
+	}
+	g.recording.Frames = append(g.recording.Frames, ReplayFrame{DT: dt, Players: make([]ReplayInput, len(g.players))})
+}
+
+// recordingInput returns the player's input slot in the frame being
+// recorded, or nil.
+func (g *Game) recordingInput(player *Player) *ReplayInput {
+	if g.recording == nil || len(g.recording.Frames) == 0 {
+		return nil
+	}
+	frame := &g.recording.Frames[len(g.recording.Frames)-1]
+	if player.id >= len(frame.Players) {
+		return nil
+	}
+	return &frame.Players[player.id]
+}
+
+// playbackInput returns the player's recorded input for the frame being
+// verified, or nil when no replay is playing.
+func (g *Game) playbackInput(player *Player) *ReplayInput {
+	if g.playback == nil || g.playbackFrame >= len(g.playback.Frames) {
+		return nil
+	}
+	frame := &g.playback.Frames[g.playbackFrame]
+	if player.id >= len(frame.Players) {
+		return &ReplayInput{}
+	}
+	return &frame.Players[player.id]
+}
+
+// recordReplayEvent stores an in-run choice.
+func (g *Game) recordReplayEvent(e ReplayEvent) {
+	if g.recording == nil {
+		return
+	}
+	e.Frame = len(g.recording.Frames)
+	g.recording.Events = append(g.recording.Events, e)
+}
+
+// checkReplayInput rejects input no person produces.
+func checkReplayInput(r *Replay) error {
+	if r.Version != replayVersion {
+		return fmt.Errorf("unsupported replay version %d", r.Version)
+	}
+	players := 1
+	if r.Coop {
+		players = 2
+	}
+	// Times of recent presses per player and action
+	presses := make([][actionCount][]float32, players)
+	now := float32(0)
+	for i, f := range r.Frames {
+		if f.DT <= 0 || f.DT > maxReplayStep {
+			return fmt.Errorf("frame %d: impossible frame time %.4fs", i, f.DT)
+		}
+		if len(f.Players) != players {
+			return fmt.Errorf("frame %d: input for %d players, want %d", i, len(f.Players), players)
+		}
+		now += f.DT
+		for p, in := range f.Players {
+			for a := Action(0); a < actionCount; a++ {
+				if !in.Pressed.has(a) {
+					continue
+				}
+				recent := presses[p][a]
+				for len(recent) > 0 && now-recent[0] >= 1 {
+					recent = recent[1:]
+				}
+				recent = append(recent, now)
+				if len(recent) > maxPressesPerSecond {
+					return fmt.Errorf("frame %d: player %d pressed %s %d times in one second", i, p+1, actionNames[a], len(recent))
+				}
+				presses[p][a] = recent
+			}
+		}
+	}
+	return nil
+}
+
+// finishReplay checks the recorded run and stores it with its result.
+// Returns false if the input was rejected or a daily run has no recording
+// to check.
+func (g *Game) finishReplay() bool {
+	r := g.recording
+	if r == nil {
+		return !g.daily
+	}
+	r.Score, r.Kills, r.Level, r.Time = g.score, g.enemiesKilled, g.level, g.gameTime
+	if err := checkReplayInput(r); err != nil {
+		fmt.Println("Warning: Run rejected:", err)
+		return false
+	}
+
+	os.MkdirAll(replayDir, os.ModePerm)
+	data, err := json.Marshal(r)
+	if err != nil {
+		fmt.Println("Warning: Could not encode replay:", err)
+		return true
+	}
+	path := filepath.Join(replayDir, strings.ReplaceAll(g.recordKey(), "/", "-")+".json")
+	if err := writeFileAtomic(path, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save replay:", err)
+	}
+	return true
+}
+
+// applyReplayEvents makes the choices recorded before frame.
+func (g *Game) applyReplayEvents(frame int) {
+	for _, e := range g.playback.Events {
+		if e.Frame != frame {
+			continue
+		}
+		switch e.Kind {
+		case EventUpgrade:
+			g.ApplyUpgrade(e.Value)
+		case EventSkillNode:
+			if e.Player < len(g.players) && g.players[e.Player].nodeAvailable(e.Value) {
+				g.players[e.Player].unlockNode(e.Value)
+			}
+		case EventLoot:
+			if e.Value >= 0 && e.Value < len(g.loot.choices) {
+				g.applyLoot(g.loot.choices[e.Value])
+			}
+		case EventStatPoint:
+			if e.Player < len(g.players) && e.Value >= 0 && e.Value < upgradeKinds {
+				g.players[e.Player].spendStatPoint(e.Value)
+			}
+		case EventDifficulty:
+			if e.Value >= 0 && e.Value < len(difficultyNames) {
+				g.setRunDifficulty(e.Value)
+			}
+		case EventStructure:
+			pos := rl.NewVector3(e.X, 0, e.Z)
+			if g.buildBlocked(pos, StructureKind(e.Value)) == "" {
+				g.placeStructure(StructureKind(e.Value), pos)
+			}
+		}
+	}
+}
+
+// verifyReplay re-simulates a replay and compares the result with the one
+// it claims.
+func (g *Game) verifyReplay(r *Replay) error {
+	if err := checkReplayInput(r); err != nil {
+		return err
+	}
+
+	g.settings.soundEnabled = false
+	g.settings.musicEnabled = false
+	g.settings.streamerMode = false
+	g.settings.difficulty = r.Settings.Difficulty
+	g.settings.inputBuffer = r.Settings.InputBuffer
+	g.settings.modifiers = RunModifiers{sprint: r.Settings.Sprint, ammo: r.Settings.Ammo, energy: r.Settings.Energy}
+	g.daily, g.bossRushMode, g.possessMode, g.practiceMode = true, false, false, false
+
+	g.playback, g.playbackFrame = r, 0
+	defer func() { g.playback = nil }()
+	g.StartGame(r.Coop)
+	g.seed = r.Seed
+	g.ResetGame()
+	// Keep the daily rules but leave seed records and ghosts out of it
+	g.seeded = false
+
+	for i, f := range r.Frames {
+		g.applyReplayEvents(i)
+		if g.state != StatePlaying {
+			return fmt.Errorf("frame %d: run stalls on the %s screen", i, stateNames[g.state])
+		}
+		g.playbackFrame = i
+		g.Update(f.DT)
+	}
+	g.applyReplayEvents(len(r.Frames))
+
+	if g.state != StateGameOver && g.state != StateVictory {
+		return fmt.Errorf("replay ends before the run does (%s)", stateNames[g.state])
+	}
+	if g.score != r.Score || g.enemiesKilled != r.Kills || g.level != r.Level {
+		return fmt.Errorf("claims score %d, %d kills, level %d; replay gives score %d, %d kills, level %d",
+			r.Score, r.Kills, r.Level, g.score, g.enemiesKilled, g.level)
+	}
+	return nil
+}
+
+// runVerifyReplay loads and verifies a replay file for -verify-replay.
+func runVerifyReplay(g *Game, path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("✗", err)
+		return false
+	}
+	var r Replay
+	if err := json.Unmarshal(data, &r); err != nil {
+		fmt.Println("✗ Could not read replay:", err)
+		return false
+	}
+	if err := g.verifyReplay(&r); err != nil {
+		fmt.Printf("✗ %s: %v\n", path, err)
+		return false
+	}
+	fmt.Printf("✓ %s: score %d verified\n", path, r.Score)
+	return true
+}
@@ -0,0 +1,166 @@
+package main
+
+import rl "github.com/gen2brain/raylib-go/raylib"
+
+// Dynamic resolution (settings, on by default). The 3D scene is drawn into
+// a render texture whose size follows recent frame times: sustained slow
+// frames drop the internal resolution a step, a few seconds back on target
+// raise it again, never below resMinScale. The texture is stretched to the
+// window with a light sharpening filter; the HUD is always drawn at full
+// resolution on top. With post-processing on (postfx.go) the scene goes
+// through the texture at any scale.
+const (
+	resTargetMs  = float32(1000.0 / 60)
+	resSlowMs    = resTargetMs * 1.1 // average above this counts as slow
+	resMinScale  = float32(0.5)
+	resStepDown  = float32(0.1)
+	resStepUp    = float32(0.05)
+	resSmoothing = float32(0.1) // weight of the newest frame in the average
+	resDownAfter = float32(0.5) // seconds of slow frames before dropping
+	resUpAfter   = float32(3.0) // seconds on target before raising
+)
+
+// sharpenShader is an unsharp mask; amount grows as the scale drops.
+const sharpenShader = `#version 330
+in vec2 fragTexCoord;
+in vec4 fragColor;
+uniform sampler2D texture0;
+uniform vec4 colDiffuse;
+uniform vec2 texel;
+uniform float amount;
+out vec4 finalColor;
+
+void main() {
+    vec3 c = texture(texture0, fragTexCoord).rgb;
+    vec3 n = texture(texture0, fragTexCoord + vec2(texel.x, 0.0)).rgb
+           + texture(texture0, fragTexCoord - vec2(texel.x, 0.0)).rgb
+           + texture(texture0, fragTexCoord + vec2(0.0, texel.y)).rgb
+           + texture(texture0, fragTexCoord - vec2(0.0, texel.y)).rgb;
+    vec3 sharp = c * (1.0 + 4.0 * amount) - n * amount;
+    finalColor = vec4(clamp(sharp, 0.0, 1.0), 1.0) * colDiffuse * fragColor;
+}
+`
+
+type ResScaler struct {
+	scale   float32
+	avgMs   float32
+	slowFor float32
+	fastFor float32
+
+	target       rl.RenderTexture2D
+	targetW      int32
+	targetH      int32
+	shader       rl.Shader
+	texelLoc     int32
+	amountLoc    int32
+	shaderLoaded bool
+	active       bool // the current frame's scene went through the texture
+}
+
+// updateResScale feeds the last frame time into the scaler. Called once
+// per drawn frame; only gameplay frames move the scale.
+func (g *Game) updateResScale(dt float32) {
+	r := &g.res
+	if !g.settings.dynamicRes {
+		r.scale = 1
+		return
+	}
+	ms := dt * 1000
+	if r.avgMs == 0 {
+		r.avgMs = ms
+	}
+	r.avgMs += (ms - r.avgMs) * resSmoothing
+	if g.state != StatePlaying {
+		return
+	}
+
+	if r.avgMs > resSlowMs {
+		r.fastFor = 0
+		r.slowFor += dt
+		if r.slowFor >= resDownAfter && r.scale > resMinScale {
+			r.scale = max(r.scale-resStepDown, resMinScale)
+			r.slowFor = 0
+		}
+		return
+	}
+	r.slowFor = 0
+	r.fastFor += dt
+	if r.fastFor >= resUpAfter && r.scale < 1 {
+		r.scale = min(r.scale+resStepUp, 1)
+		r.fastFor = 0
+	}
+}
+
+// ensureTarget (re)creates the render texture for the current scale.
+func (r *ResScaler) ensureTarget() {
+	w := int32(float32(screenWidth) * r.scale)
+	h := int32(float32(screenHeight) * r.scale)
+	if w == r.targetW && h == r.targetH {
+		return
+	}
+	if r.targetW > 0 {
+		rl.UnloadRenderTexture(r.target)
+	}
+	r.target = rl.LoadRenderTexture(w, h)
+	rl.SetTextureFilter(r.target.Texture, rl.FilterBilinear)
+	r.targetW, r.targetH = w, h
+
+	if !r.shaderLoaded {
+		r.shader = rl.LoadShaderFromMemory("", sharpenShader)
+		r.texelLoc = rl.GetShaderLocation(r.shader, "texel")
+		r.amountLoc = rl.GetShaderLocation(r.shader, "amount")
+		r.shaderLoaded = true
+	}
+}
+
+// release frees the render texture and shader; the next scaled frame
+// creates them again.
+func (r *ResScaler) release() {
+	if r.targetW > 0 {
+		rl.UnloadRenderTexture(r.target)
+		r.targetW, r.targetH = 0, 0
+	}
+	if r.shaderLoaded {
+		rl.UnloadShader(r.shader)
+		r.shaderLoaded = false
+	}
+}
+
+// beginScene starts the 3D pass, into the scaled texture when the scale
+// is below full resolution.
+func (g *Game) beginScene() {
+	r := &g.res
+	r.active = g.settings.dynamicRes && r.scale < 1 || g.settings.postFX
+	if r.active {
+		r.ensureTarget()
+		rl.BeginTextureMode(r.target)
+		rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+	}
+	rl.BeginMode3D(g.camera)
+}
+
+// endScene ends the 3D pass and, if it went through the texture, sharpens
+// and stretches it over the window with any bloom on top.
+func (g *Game) endScene() {
+	rl.EndMode3D()
+	r := &g.res
+	if !r.active {
+		return
+	}
+	rl.EndTextureMode()
+	if g.settings.postFX {
+		g.post.prepareBloom(r.target.Texture)
+	}
+
+	rl.SetShaderValue(r.shader, r.texelLoc, []float32{1 / float32(r.targetW), 1 / float32(r.targetH)}, rl.ShaderUniformVec2)
+	rl.SetShaderValue(r.shader, r.amountLoc, []float32{(1 - r.scale) * 0.5}, rl.ShaderUniformFloat)
+	rl.BeginShaderMode(r.shader)
+	// Render textures are stored bottom-up
+	src := rl.NewRectangle(0, 0, float32(r.targetW), -float32(r.targetH))
+	dst := rl.NewRectangle(0, 0, screenWidth, screenHeight)
+	rl.DrawTexturePro(r.target.Texture, src, dst, rl.Vector2{}, 0, rl.White)
+	rl.EndShaderMode()
+	if g.settings.postFX {
+		g.post.drawBloom()
+	}
+}
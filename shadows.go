@@ -0,0 +1,71 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Blob shadows. Everything that moves over the floor gets a dark disc on
+// the floor straight below it, so in the tilted view it is clear where a
+// player, enemy, shot or pickup actually is. The disc shrinks and fades
+// the higher its owner is above standing height, which is what makes
+// bobbing pickups and flyers read as off the ground.
+//
+// Shadows are drawn in one pass after the floor and before anything that
+// casts them, so they never cover a model.
+const (
+	shadowRestY   = float32(1.0) // centre height of things standing on the floor
+	shadowY       = float32(0.03)
+	shadowAlpha   = float32(120)
+	shadowFalloff = float32(0.25) // per unit of height
+)
+
+// drawBlobShadow draws the shadow of something radius wide centred at pos.
+func drawBlobShadow(pos rl.Vector3, radius float32) {
+	height := max(0, pos.Y-shadowRestY)
+	k := 1 / (1 + height*shadowFalloff)
+	r := radius * (0.6 + 0.4*k)
+	at := rl.NewVector3(pos.X, shadowY, pos.Z)
+	rl.DrawCylinder(at, r, r, 0.01, 16, rl.NewColor(0, 0, 0, uint8(shadowAlpha*k)))
+}
+
+// powerUpBob is how far pickups float above or below their resting spot.
+func (g *Game) powerUpBob() float32 {
+	return float32(math.Sin(float64(g.gameTime*3))) * 0.3
+}
+
+func (g *Game) drawShadows() {
+	for i := range g.players {
+		if p := &g.players[i]; p.health > 0 {
+			drawBlobShadow(p.position, 0.8)
+		}
+	}
+	for i := range g.enemies {
+		if e := &g.enemies[i]; e.active {
+			drawBlobShadow(e.position, e.size*0.5)
+		}
+	}
+	for i := range g.bullets {
+		if b := &g.bullets[i]; b.active {
+			drawBlobShadow(b.position, weaponDefs[b.weapon].bulletSize)
+		}
+	}
+	for i := range g.enemyBullets {
+		if b := &g.enemyBullets[i]; b.active {
+			drawBlobShadow(b.position, enemyBulletSize)
+		}
+	}
+	for i := range g.grenades {
+		if gr := &g.grenades[i]; gr.active {
+			drawBlobShadow(gr.position, grenadeSize)
+		}
+	}
+	for i := range g.powerUps {
+		if p := &g.powerUps[i]; p.active {
+			pos := p.position
+			pos.Y += g.powerUpBob()
+			drawBlobShadow(pos, 0.5)
+		}
+	}
+}
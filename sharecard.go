@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Share cards. S on the game over screen renders a summary of the run
+// (score, level, stage, seed and each player's build) offscreen and saves
+// it as a PNG under save/cards, sized for social media link previews.
+const (
+	cardsDir   = saveDir + "/cards"
+	cardWidth  = 1200
+	cardHeight = 630
+)
+
+var upgradeNames = [upgradeKinds]string{"HP", "DMG", "SPD", "ROF", "CRIT"}
+
+// saveShareCard renders the card and returns the file it was written to.
+func (g *Game) saveShareCard() (string, error) {
+	target := rl.LoadRenderTexture(cardWidth, cardHeight)
+	defer rl.UnloadRenderTexture(target)
+
+	rl.BeginTextureMode(target)
+	g.drawShareCard()
+	rl.EndTextureMode()
+
+	path := filepath.Join(cardsDir, time.Now().Format("run_20060102_150405")+".png")
+	if err := writePNG(path, captureTexture(target)); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func (g *Game) drawShareCard() {
+	rl.ClearBackground(rl.NewColor(10, 10, 25, 255))
+	rl.DrawRectangle(0, 0, cardWidth, 8, rl.Gold)
+	rl.DrawRectangle(0, cardHeight-8, cardWidth, 8, rl.Gold)
+
+	drawText("3D SHOOTER", 50, 40, 50, rl.Gold)
+	mode := "SOLO"
+	if g.coopMode {
+		mode = "CO-OP"
+	}
+	if g.daily {
+		mode += " DAILY"
+	}
+	drawText(mode, 50, 95, 24, rl.Yellow)
+
+	drawText(fmt.Sprintf("%d", g.score), 50, 150, 90, rl.White)
+	drawText("SCORE", 55, 240, 22, rl.LightGray)
+
+	stats := []string{
+		fmt.Sprintf("Level %d", g.level),
+		fmt.Sprintf("Stage %s", strings.ToUpper(stageNames[g.currentStage])),
+		fmt.Sprintf("%d kills", g.enemiesKilled),
+		fmt.Sprintf("%d:%02d", int(g.gameTime)/60, int(g.gameTime)%60),
+	}
+	for i, s := range stats {
+		drawText(s, 50, int32(290+i*40), 30, rl.SkyBlue)
+	}
+	if g.seeded && !g.settings.streamerMode {
+		drawText(fmt.Sprintf("Seed %d", g.seed), 50, 460, 22, rl.Gray)
+	}
+
+	// Builds, one column per player
+	for i := range g.players {
+		drawCardBuild(&g.players[i], int32(560+i*320), 60)
+	}
+}
+
+// drawShareHint tells the player about the share card on the game over
+// screen, or where the last one went.
+func (g *Game) drawShareHint(y int32) {
+	text := "Press S to save a share card"
+	if g.shareCardPath != "" {
+		text = "Saved " + g.shareCardPath
+	}
+	drawText(text, screenWidth/2-measureText(text, 18)/2, y, 18, rl.Gray)
+}
+
+// drawCardBuild draws a player's weapons as colour swatches, their upgrade
+// counts and the loadout code.
+func drawCardBuild(p *Player, x, y int32) {
+	drawText(fmt.Sprintf("P%d BUILD", p.id+1), x, y, 26, p.color)
+	y += 45
+
+	for _, w := range p.weapons {
+		def := weaponDefs[w]
+		rl.DrawRectangle(x, y, 28, 28, def.color)
+		if w == p.weapon {
+			rl.DrawRectangleLines(x-3, y-3, 34, 34, rl.White)
+		}
+		drawText(def.name, x+40, y+4, 22, rl.White)
+		y += 40
+	}
+
+	y += 10
+	for i, n := range p.upgrades {
+		drawText(upgradeNames[i], x, y, 20, rl.LightGray)
+		for pip := 0; pip < min(n, 10); pip++ {
+			rl.DrawRectangle(x+70+int32(pip*18), y+2, 14, 14, rl.Gold)
+		}
+		if n > 10 {
+			drawText(fmt.Sprintf("+%d", n-10), x+255, y, 20, rl.Gold)
+		}
+		y += 28
+	}
+
+	drawText(playerLoadout(p).Code(), x, cardHeight-60, 24, rl.SkyBlue)
+}
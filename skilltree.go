@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Skill tree. Each of the three skill keys has a branch: the starting skill
+// at the root, then alternating passive nodes and a stronger active skill
+// that takes over the key. Every upgrade break gives each player skill
+// points to spend on the K screen. Unlocks last for the run.
+
+// SkillID is an active skill that can sit on a skill key.
+type SkillID int
+
+const (
+	SkillExplosion SkillID = iota
+	SkillRadialShot
+	SkillEnergyShield
+	SkillNova
+	SkillBulletStorm
+	SkillSecondWind
+	skillIDCount
+	noSkill SkillID = -1
+)
+
+var skillDefs = [skillIDCount]struct {
+	name     string
+	cooldown float32
+}{
+	SkillExplosion:    {"Explosion", 8},
+	SkillRadialShot:   {"Radial Shot", 10},
+	SkillEnergyShield: {"Energy Shield", 15},
+	SkillNova:         {"Nova", 10},
+	SkillBulletStorm:  {"Bullet Storm", 12},
+	SkillSecondWind:   {"Second Wind", 20},
+}
+
+type PassiveKind int
+
+const (
+	PassiveNone PassiveKind = iota
+	PassiveDamage
+	PassiveCooldown
+	PassiveCrit
+	PassiveHealth
+)
+
+const (
+	skillBranches       = 3 // one per skill key
+	skillPointsPerBreak = 1
+	cooldownNodeMul     = float32(0.85)
+	healthNodeBonus     = 25
+	novaRadius          = 14.0
+	secondWindClear     = float32(8.0) // enemy shots cleared within this range
+)
+
+// SkillNode is one node in the tree. Node i sits in branch i%3 at tier
+// i/3 and needs the node above it (i-3).
+type SkillNode struct {
+	name    string
+	desc    string
+	cost    int
+	active  SkillID
+	passive PassiveKind
+}
+
+var skillTree = [...]SkillNode{
+	{"Explosion", "Blast enemies around you", 0, SkillExplosion, PassiveNone},
+	{"Radial Shot", "Fire a ring of bullets", 0, SkillRadialShot, PassiveNone},
+	{"Energy Shield", "Restore 30 HP", 0, SkillEnergyShield, PassiveNone},
+	{"Power", "Damage +1", 1, noSkill, PassiveDamage},
+	{"Quick Hands", "Skill cooldowns -15%", 1, noSkill, PassiveCooldown},
+	{"Toughness", "Max HP +25", 1, noSkill, PassiveHealth},
+	{"Nova", "Replaces Explosion: wider, harder blast", 2, SkillNova, PassiveNone},
+	{"Bullet Storm", "Replaces Radial Shot: two dense rings", 2, SkillBulletStorm, PassiveNone},
+	{"Second Wind", "Replaces Energy Shield: heal half and clear nearby shots", 2, SkillSecondWind, PassiveNone},
+	{"Power II", "Damage +1", 2, noSkill, PassiveDamage},
+	{"Sharpshooter", "Crit chance +5%", 2, noSkill, PassiveCrit},
+	{"Vitality", "Max HP +25", 2, noSkill, PassiveHealth},
+}
+
+const skillNodeCount = len(skillTree)
+
+// SkillTreeView is the state of the skill tree screen.
+type SkillTreeView struct {
+	player int // index into players
+	branch int
+	tier   int
+	back   GameState // screen to return to
+}
+
+// resetSkillTree unlocks only the roots and puts the starting skills on
+// the keys.
+func (p *Player) resetSkillTree() {
+	p.unlocked = [skillNodeCount]bool{}
+	p.skillPoints = 0
+	for b := 0; b < skillBranches; b++ {
+		p.unlocked[b] = true
+	}
+	p.refreshSkills()
+}
+
+// refreshSkills puts the deepest unlocked active skill of each branch on
+// its key, with cooldowns scaled by the cooldown nodes taken. Skills that
+// don't change keep their cooldown.
+func (p *Player) refreshSkills() {
+	mul := float32(1)
+	for i, node := range skillTree {
+		if p.unlocked[i] && node.passive == PassiveCooldown {
+			mul *= cooldownNodeMul
+		}
+	}
+	if len(p.skills) != skillBranches {
+		p.skills = make([]Skill, skillBranches)
+	}
+	for b := 0; b < skillBranches; b++ {
+		id := noSkill
+		for i := b; i < skillNodeCount; i += skillBranches {
+			if p.unlocked[i] && skillTree[i].active != noSkill {
+				id = skillTree[i].active
+			}
+		}
+		skill := &p.skills[b]
+		if skill.id != id || skill.name == "" {
+			*skill = Skill{id: id, name: skillDefs[id].name, ready: true}
+		}
+		skill.maxCooldown = skillDefs[id].cooldown * mul
+		skill.cooldown = min(skill.cooldown, skill.maxCooldown)
+	}
+}
+
+// nodeAvailable reports whether node i can be bought right now.
+func (p *Player) nodeAvailable(i int) bool {
+	return !p.unlocked[i] && (i < skillBranches || p.unlocked[i-skillBranches]) && p.skillPoints >= skillTree[i].cost
+}
+
+func (p *Player) unlockNode(i int) {
+	node := skillTree[i]
+	p.skillPoints -= node.cost
+	p.unlocked[i] = true
+	switch node.passive {
+	case PassiveDamage:
+		p.stats.damage++
+	case PassiveCrit:
+		p.stats.critChance = min(p.stats.critChance+0.05, 0.5)
+	case PassiveHealth:
+		p.stats.maxHealth += healthNodeBonus
+		p.health += healthNodeBonus
+	}
+	p.refreshSkills()
+}
+
+// awardSkillPoints is called at every upgrade break.
+func (g *Game) awardSkillPoints() {
+	for i := range g.players {
+		g.players[i].skillPoints += skillPointsPerBreak
+	}
+}
+
+func (g *Game) openSkillTree() {
+	g.skillView = SkillTreeView{back: g.state}
+	g.state = StateSkillTree
+}
+
+// UpdateSkillTree: arrows move between nodes, ENTER buys, 1/2 switch player
+// in co-op, K or ESC go back.
+func (g *Game) UpdateSkillTree() {
+	v := &g.skillView
+	switch {
+	case rl.IsKeyPressed(rl.KeyLeft):
+		v.branch = (v.branch + skillBranches - 1) % skillBranches
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyRight):
+		v.branch = (v.branch + 1) % skillBranches
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyUp):
+		v.tier = max(v.tier-1, 0)
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyDown):
+		v.tier = min(v.tier+1, skillNodeCount/skillBranches-1)
+		g.playUISound(g.sounds.uiMove)
+	case rl.IsKeyPressed(rl.KeyOne):
+		v.player = 0
+	case rl.IsKeyPressed(rl.KeyTwo) && len(g.players) > 1:
+		v.player = 1
+	case rl.IsKeyPressed(rl.KeyEnter):
+		player := &g.players[v.player]
+		if i := v.tier*skillBranches + v.branch; player.nodeAvailable(i) {
+			g.recordReplayEvent(ReplayEvent{Kind: EventSkillNode, Player: v.player, Value: i})
+			player.unlockNode(i)
+			g.playUISound(g.sounds.uiSelect)
+		}
+	case rl.IsKeyPressed(rl.KeyK) || rl.IsKeyPressed(rl.KeyEscape):
+		g.state = v.back
+	}
+}
+
+func (g *Game) DrawSkillTree() {
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 220))
+	v := &g.skillView
+	player := &g.players[v.player]
+	centerX := int32(screenWidth / 2)
+
+	title := "SKILL TREE"
+	if len(g.players) > 1 {
+		title = fmt.Sprintf("SKILL TREE - P%d", v.player+1)
+	}
+	drawText(title, centerX-measureText(title, 50)/2, 60, 50, rl.Gold)
+	points := fmt.Sprintf("Points: %d", player.skillPoints)
+	drawText(points, centerX-measureText(points, 28)/2, 125, 28, rl.White)
+
+	const boxW, boxH, colGap, rowGap = 280, 70, 340, 140
+	nodePos := func(i int) (int32, int32) {
+		return centerX - colGap + int32(i%skillBranches)*colGap - boxW/2, 200 + int32(i/skillBranches)*rowGap
+	}
+	for i := skillBranches; i < skillNodeCount; i++ {
+		x, y := nodePos(i)
+		color := rl.DarkGray
+		if player.unlocked[i] {
+			color = rl.Gold
+		}
+		rl.DrawLine(x+boxW/2, y-rowGap+boxH, x+boxW/2, y, color)
+	}
+	for i, node := range skillTree {
+		x, y := nodePos(i)
+		fill, text := rl.NewColor(40, 40, 40, 255), rl.Gray
+		switch {
+		case player.unlocked[i]:
+			fill, text = rl.NewColor(110, 85, 20, 255), rl.Gold
+		case player.nodeAvailable(i):
+			fill, text = rl.NewColor(30, 60, 30, 255), rl.White
+		}
+		rl.DrawRectangle(x, y, boxW, boxH, fill)
+		if i == v.tier*skillBranches+v.branch {
+			rl.DrawRectangleLinesEx(rl.NewRectangle(float32(x-3), float32(y-3), boxW+6, boxH+6), 3, rl.Yellow)
+		}
+		kind := "Passive"
+		if node.active != noSkill {
+			kind = "Skill"
+		}
+		drawText(node.name, x+10, y+10, 24, text)
+		drawText(fmt.Sprintf("%s  cost %d", kind, node.cost), x+10, y+42, 16, rl.LightGray)
+	}
+
+	selected := skillTree[v.tier*skillBranches+v.branch]
+	drawText(selected.desc, centerX-measureText(selected.desc, 24)/2, screenHeight-150, 24, rl.White)
+	hint := "ARROWS: select   ENTER: unlock   K/ESC: back"
+	if len(g.players) > 1 {
+		hint += "   1/2: player"
+	}
+	drawText(hint, centerX-measureText(hint, 20)/2, screenHeight-100, 20, rl.LightGray)
+}
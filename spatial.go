@@ -0,0 +1,79 @@
+package main
+
+import "math"
+
+// SpatialGrid buckets active enemies into square cells on the XZ plane so
+// radius queries only look at nearby enemies. Rebuilt once per frame.
+type SpatialGrid struct {
+	cellSize float32
+	cells    map[[2]int32][]int
+}
+
+func NewSpatialGrid(cellSize float32) *SpatialGrid {
+	return &SpatialGrid{cellSize: cellSize, cells: map[[2]int32][]int{}}
+}
+
+func (s *SpatialGrid) cellOf(x, z float32) [2]int32 {
+	return [2]int32{
+		int32(math.Floor(float64(x / s.cellSize))),
+		int32(math.Floor(float64(z / s.cellSize))),
+	}
+}
+
+// rebuildEnemyGrid indexes every active enemy.
+func (g *Game) rebuildEnemyGrid() {
+	s := g.enemyGrid
+	for key, list := range s.cells {
+		s.cells[key] = list[:0]
+	}
+	for i := range g.enemies {
+		if !g.enemies[i].active {
+			continue
+		}
+		key := s.cellOf(g.enemies[i].position.X, g.enemies[i].position.Z)
+		s.cells[key] = append(s.cells[key], i)
+	}
+}
+
+// enemiesInBlast is enemiesInRadius for blasts. They go off partway
+// through a frame, after enemies have moved and spawned since the grid was
+// built, so every enemy is checked where it is now.
+func (g *Game) enemiesInBlast(x, z, radius float32, out []int) []int {
+	for i := range g.enemies {
+		e := &g.enemies[i]
+		if !e.active {
+			continue
+		}
+		dx := e.position.X - x
+		dz := e.position.Z - z
+		if dx*dx+dz*dz <= radius*radius {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// enemiesInRadius appends the indices of active enemies whose centre lies
+// within radius of (x, z) to out and returns it.
+func (g *Game) enemiesInRadius(x, z, radius float32, out []int) []int {
+	s := g.enemyGrid
+	min := s.cellOf(x-radius, z-radius)
+	max := s.cellOf(x+radius, z+radius)
+
+	for cx := min[0]; cx <= max[0]; cx++ {
+		for cz := min[1]; cz <= max[1]; cz++ {
+			for _, i := range s.cells[[2]int32{cx, cz}] {
+				e := &g.enemies[i]
+				if !e.active {
+					continue
+				}
+				dx := e.position.X - x
+				dz := e.position.Z - z
+				if dx*dx+dz*dz <= radius*radius {
+					out = append(out, i)
+				}
+			}
+		}
+	}
+	return out
+}
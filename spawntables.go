@@ -0,0 +1,98 @@
+package main
+
+// Per-stage spawn tables. Each stage lists the kinds it spawns with a
+// relative weight, the level a kind starts appearing and how many of it may
+// be alive at once (0 = no cap). rollEnemyKind draws from the table of the
+// current stage, skipping kinds that are gated or capped.
+type SpawnEntry struct {
+	kind     EnemyKind
+	weight   int
+	minLevel int
+	maxAlive int
+}
+
+var stageSpawnTables = [...][]SpawnEntry{
+	StageBasic: {
+		{EnemyChaser, 60, 1, 0},
+		{EnemyRanged, 20, rangedMinLevel, 6},
+		{EnemySplitter, 15, splitterMinLevel, 0},
+		{EnemyKamikaze, 12, kamikazeMinLevel, 4},
+		{EnemyShielded, 12, shieldedMinLevel, 4},
+	},
+	// Long sight lines down the corridors suit shooters and flyers
+	StageMaze: {
+		{EnemyChaser, 35, 1, 0},
+		{EnemyRanged, 35, rangedMinLevel, 12},
+		{EnemySplitter, 10, splitterMinLevel, 0},
+		{EnemyKamikaze, 8, kamikazeMinLevel, 4},
+		{EnemyShielded, 8, shieldedMinLevel, 4},
+		{EnemyFlyer, 20, flyerMinLevel, 6},
+	},
+	StageHazard: {
+		{EnemyChaser, 40, 1, 0},
+		{EnemyRanged, 15, rangedMinLevel, 6},
+		{EnemySplitter, 15, splitterMinLevel, 0},
+		{EnemyKamikaze, 20, kamikazeMinLevel, 6},
+		{EnemyShielded, 10, shieldedMinLevel, 4},
+		{EnemyFlyer, 15, flyerMinLevel, 6},
+	},
+	// Open floor: bruisers that want to be surrounded and flanked
+	StageArena: {
+		{EnemyChaser, 30, 1, 0},
+		{EnemyRanged, 10, rangedMinLevel, 4},
+		{EnemySplitter, 25, splitterMinLevel, 0},
+		{EnemyKamikaze, 15, kamikazeMinLevel, 6},
+		{EnemyShielded, 25, shieldedMinLevel, 8},
+		{EnemyFlyer, 10, flyerMinLevel, 4},
+	},
+	// In the dark the danger is what closes in unseen
+	StageNight: {
+		{EnemyChaser, 45, 1, 0},
+		{EnemyRanged, 8, rangedMinLevel, 3},
+		{EnemySplitter, 10, splitterMinLevel, 0},
+		{EnemyKamikaze, 25, kamikazeMinLevel, 8},
+		{EnemyShielded, 10, shieldedMinLevel, 4},
+		{EnemyFlyer, 15, flyerMinLevel, 6},
+	},
+}
+
+// rollEnemyKind picks the kind of a newly spawned enemy from the current
+// stage's table. Falls back to a chaser if everything is gated or capped.
+func (g *Game) rollEnemyKind() EnemyKind {
+	var alive [enemyKindCount]int
+	for i := range g.enemies {
+		if g.enemies[i].active && !g.enemies[i].isBoss {
+			alive[g.enemies[i].kind]++
+		}
+	}
+
+	table := stageSpawnTables[g.currentStage]
+	total := 0
+	for _, entry := range table {
+		if g.canSpawnKind(entry, alive) {
+			total += entry.weight
+		}
+	}
+	if total == 0 {
+		return EnemyChaser
+	}
+
+	roll := g.rng.Intn(total)
+	for _, entry := range table {
+		if !g.canSpawnKind(entry, alive) {
+			continue
+		}
+		if roll < entry.weight {
+			return entry.kind
+		}
+		roll -= entry.weight
+	}
+	return EnemyChaser
+}
+
+func (g *Game) canSpawnKind(entry SpawnEntry, alive [enemyKindCount]int) bool {
+	if g.level < entry.minLevel {
+		return false
+	}
+	return entry.maxAlive == 0 || alive[entry.kind] < entry.maxAlive
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Stage layouts from files. Every assets/stages/*.json describes one
+// hand-made layout for a stage type: its obstacles, where enemies come in,
+// portal pairs, ice and conveyor zones, the floor colour and optionally how
+// far players may walk. When a stage
+// starts GenerateStage picks one of the layouts for its type with the run's
+// rng; stage types without a layout keep their built-in generator (maze.go
+// and friends). Layouts are read once at startup, in file name order.
+//
+//	{
+//	  "name": "Crossroads",
+//	  "stage": "basic",
+//	  "floor": [30, 35, 45],
+//	  "bounds": 26,
+//	  "obstacles": [
+//	    { "type": "wall", "at": [0, -10], "size": [20, 1.5] },
+//	    { "type": "hazard", "at": [12, 12], "size": [3, 3] }
+//	  ],
+//	  "crates": 4,
+//	  "spawnZones": [ { "at": [-25, 0], "radius": 4 } ],
+//	  "portals": [ { "a": [-20, -20], "b": [20, 20] } ],
+//	  "zones": [ { "type": "conveyor", "at": [0, 8], "size": [20, 3], "dir": [1, 0] } ]
+//	}
+//
+// "at" is the centre on the floor (x, z) and "size" the footprint (width
+// along x, depth along z); walls are wallHeight tall. "crates" scatters that
+// many random crates on top of the listed obstacles. Without spawn zones
+// enemies come in on the usual ring around the middle.
+const stageDir = "assets/stages"
+
+type StageLayout struct {
+	Name       string           `json:"name"`
+	Stage      string           `json:"stage"`
+	Floor      [3]uint8         `json:"floor"`  // 0,0,0 = the stage's colour
+	Bounds     float32          `json:"bounds"` // walkable half size, 0 = the stage's
+	Obstacles  []LayoutObstacle `json:"obstacles"`
+	Crates     int              `json:"crates"`
+	SpawnZones []SpawnZone      `json:"spawnZones"`
+	Portals    []LayoutPortal   `json:"portals"`
+	Zones      []LayoutZone     `json:"zones"`
+
+	stage StageType
+}
+
+type LayoutObstacle struct {
+	Type string     `json:"type"` // "wall", "hazard" or "crate"
+	At   [2]float32 `json:"at"`
+	Size [2]float32 `json:"size"`
+}
+
+type SpawnZone struct {
+	At     [2]float32 `json:"at"`
+	Radius float32    `json:"radius"`
+}
+
+// LayoutZone is an ice or conveyor patch (floorzones.go). Only conveyors
+// have a direction.
+type LayoutZone struct {
+	Type string     `json:"type"` // "ice" or "conveyor"
+	At   [2]float32 `json:"at"`
+	Size [2]float32 `json:"size"`
+	Dir  [2]float32 `json:"dir"`
+}
+
+// LayoutPortal is a linked pair of portals (portals.go).
+type LayoutPortal struct {
+	A [2]float32 `json:"a"`
+	B [2]float32 `json:"b"`
+}
+
+const wallHeight = float32(3.0)
+
+// layoutObstacleTypes maps the file names to obsType and the height and
+// centre height each type is built with.
+var layoutObstacleTypes = map[string]struct {
+	obsType   int
+	height, y float32
+}{
+	"wall":   {0, wallHeight, 1},
+	"hazard": {1, 1, 0.5},
+	"crate":  {obsCrate, 1.6, 0.8},
+}
+
+// loadStageLayouts reads every layout in stageDir. A broken file is skipped
+// with a warning so one typo doesn't take the other layouts down.
+func loadStageLayouts() []StageLayout {
+	files, _ := filepath.Glob(filepath.Join(stageDir, "*.json"))
+	slices.Sort(files)
+
+	var layouts []StageLayout
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Println("Warning: Could not read stage layout:", err)
+			continue
+		}
+		var l StageLayout
+		if err := json.Unmarshal(data, &l); err != nil {
+			fmt.Printf("Warning: Could not parse stage layout %s: %v\n", file, err)
+			continue
+		}
+		if l.Name == "" {
+			l.Name = strings.TrimSuffix(filepath.Base(file), ".json")
+		}
+		if err := l.validate(); err != nil {
+			fmt.Printf("Warning: Invalid stage layout %s: %v\n", file, err)
+			continue
+		}
+		layouts = append(layouts, l)
+	}
+	return layouts
+}
+
+// validate resolves the stage type and rejects layouts that don't fit the
+// floor or the obstacle pool.
+func (l *StageLayout) validate() error {
+	stage := slices.IndexFunc(stageNames[:], func(s string) bool { return strings.EqualFold(s, l.Stage) })
+	if stage < 0 {
+		return fmt.Errorf("unknown stage %q", l.Stage)
+	}
+	l.stage = StageType(stage)
+	if len(l.Obstacles)+l.Crates > maxObstacles {
+		return fmt.Errorf("%d obstacles and crates, at most %d fit", len(l.Obstacles)+l.Crates, maxObstacles)
+	}
+	if l.Bounds < 0 || l.Bounds > defaultStageHalf {
+		return fmt.Errorf("bounds %.1f outside 0..%.0f", l.Bounds, defaultStageHalf)
+	}
+	for i, o := range l.Obstacles {
+		if _, ok := layoutObstacleTypes[o.Type]; !ok {
+			return fmt.Errorf("obstacle %d: unknown type %q", i+1, o.Type)
+		}
+		if o.Size[0] <= 0 || o.Size[1] <= 0 {
+			return fmt.Errorf("obstacle %d: size must be positive", i+1)
+		}
+		if outsideFloor(o.At, 0) {
+			return fmt.Errorf("obstacle %d: centre is off the floor", i+1)
+		}
+	}
+	for i, z := range l.SpawnZones {
+		if z.Radius <= 0 {
+			return fmt.Errorf("spawn zone %d: radius must be positive", i+1)
+		}
+		if outsideFloor(z.At, z.Radius) {
+			return fmt.Errorf("spawn zone %d: reaches off the floor", i+1)
+		}
+	}
+	if len(l.Portals) > maxPortals/2 {
+		return fmt.Errorf("%d portal pairs, at most %d fit", len(l.Portals), maxPortals/2)
+	}
+	for i, p := range l.Portals {
+		if outsideFloor(p.A, portalRadius) || outsideFloor(p.B, portalRadius) {
+			return fmt.Errorf("portal pair %d: reaches off the floor", i+1)
+		}
+	}
+	if len(l.Zones) > maxFloorZones {
+		return fmt.Errorf("%d zones, at most %d fit", len(l.Zones), maxFloorZones)
+	}
+	for i, z := range l.Zones {
+		if _, err := parseFloorZone(z); err != nil {
+			return fmt.Errorf("zone %d: %v", i+1, err)
+		}
+		if outsideFloor(z.At, 0) {
+			return fmt.Errorf("zone %d: centre is off the floor", i+1)
+		}
+	}
+	return nil
+}
+
+func outsideFloor(at [2]float32, radius float32) bool {
+	return float32(math.Abs(float64(at[0])))+radius > defaultStageHalf ||
+		float32(math.Abs(float64(at[1])))+radius > defaultStageHalf
+}
+
+func (g *Game) loadStageLayouts() {
+	g.layouts = loadStageLayouts()
+	if len(g.layouts) > 0 {
+		fmt.Printf("✓ Loaded: %d stage layouts\n", len(g.layouts))
+	}
+}
+
+// pickLayout chooses a layout for the stage, or nil for the built-in one.
+// The rng is only drawn from when there is a layout to pick, so runs
+// without layout files play exactly as before. Daily runs and replay
+// checks never use layout files: everyone's daily has to be the same run.
+func (g *Game) pickLayout(stage StageType) *StageLayout {
+	if g.daily || g.playback != nil {
+		return nil
+	}
+	var matching []*StageLayout
+	for i := range g.layouts {
+		if g.layouts[i].stage == stage {
+			matching = append(matching, &g.layouts[i])
+		}
+	}
+	if len(matching) == 0 {
+		return nil
+	}
+	return matching[g.rng.Intn(len(matching))]
+}
+
+func (g *Game) layoutName() string {
+	if g.layout == nil {
+		return ""
+	}
+	return g.layout.Name
+}
+
+// layoutNamed finds the layout a suspended run was on. A layout that was
+// removed since leaves the run on its saved obstacles with the stage's
+// floor and spawn ring.
+func (g *Game) layoutNamed(name string) *StageLayout {
+	if name == "" {
+		return nil
+	}
+	for i := range g.layouts {
+		if g.layouts[i].Name == name {
+			return &g.layouts[i]
+		}
+	}
+	return nil
+}
+
+// placeLayout builds the layout's obstacles. GenerateStage has cleared the
+// old ones.
+func (g *Game) placeLayout(l *StageLayout) {
+	for i, o := range l.Obstacles {
+		t := layoutObstacleTypes[o.Type]
+		g.obstacles[i] = Obstacle{
+			position: rl.NewVector3(o.At[0], t.y, o.At[1]),
+			size:     rl.NewVector3(o.Size[0], t.height, o.Size[1]),
+			active:   true,
+			obsType:  t.obsType,
+		}
+	}
+	for _, p := range l.Portals {
+		g.placePortalPair(rl.NewVector3(p.A[0], 0, p.A[1]), rl.NewVector3(p.B[0], 0, p.B[1]))
+	}
+	for _, lz := range l.Zones {
+		z, _ := parseFloorZone(lz) // checked by validate
+		g.addFloorZone(z)
+	}
+	g.scatterCrates(l.Crates)
+}
+
+// layoutSpawnPoint picks a spot in one of the layout's spawn zones. false
+// when the stage has no zones.
+func (g *Game) layoutSpawnPoint() (rl.Vector3, bool) {
+	if g.layout == nil || len(g.layout.SpawnZones) == 0 {
+		return rl.Vector3{}, false
+	}
+	z := g.layout.SpawnZones[g.rng.Intn(len(g.layout.SpawnZones))]
+	angle := g.rng.Float64() * 2 * math.Pi
+	distance := float64(z.Radius) * math.Sqrt(g.rng.Float64())
+	return rl.NewVector3(
+		z.At[0]+float32(math.Cos(angle)*distance),
+		0.75,
+		z.At[1]+float32(math.Sin(angle)*distance),
+	), true
+}
+
+// floorColor is the layout's floor colour, or the stage's own.
+func (g *Game) floorColor() rl.Color {
+	if g.layout != nil && g.layout.Floor != [3]uint8{} {
+		return rl.NewColor(g.layout.Floor[0], g.layout.Floor[1], g.layout.Floor[2], 255)
+	}
+	switch g.currentStage {
+	case StageMaze:
+		return rl.NewColor(40, 30, 50, 255)
+	case StageHazard:
+		return rl.NewColor(50, 30, 30, 255)
+	case StageArena:
+		return rl.NewColor(30, 40, 50, 255)
+	case StageNight:
+		return rl.NewColor(20, 22, 30, 255)
+	}
+	return rl.NewColor(30, 30, 50, 255)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Run statistics. Kills by enemy kind and, per player, shots, hits, damage
+// dealt and taken are counted as the run goes. Holding TAB during play
+// shows them over the game (a quick tap still opens the character screen)
+// and the game over and victory screens draw the same panel, so the two
+// never disagree. Shots and hits count the way mastery does: every
+// projectile fired from a weapon and every beam tick is a shot, and a shot
+// is a hit once however many enemies it pierces. Skill and drone bullets
+// are neither.
+const (
+	statsHoldTime = float32(0.25) // TAB held this long shows the stats instead
+	statsPanelW   = 460
+)
+
+type RunStats struct {
+	Kills    [enemyKindCount]int `json:"kills"`
+	Bosses   int                 `json:"bosses"`
+	Defenses int                 `json:"defenses"` // damage dealt by turrets
+	Players  []PlayerRunStats    `json:"players"`
+}
+
+type PlayerRunStats struct {
+	Shots int `json:"shots"`
+	Hits  int `json:"hits"`
+	Dealt int `json:"dealt"`
+	Taken int `json:"taken"`
+}
+
+func newRunStats(players int) RunStats {
+	return RunStats{Players: make([]PlayerRunStats, players)}
+}
+
+// player returns the counters for a player id, nil for anything else.
+func (s *RunStats) player(id int) *PlayerRunStats {
+	if id < 0 || id >= len(s.Players) {
+		return nil
+	}
+	return &s.Players[id]
+}
+
+func (g *Game) statShot(playerID int) {
+	if s := g.runStats.player(playerID); s != nil {
+		s.Shots++
+	}
+}
+
+func (g *Game) statHit(playerID int) {
+	if s := g.runStats.player(playerID); s != nil {
+		s.Hits++
+	}
+}
+
+// statDealt counts damage done to an enemy by a player, or by the
+// defenses when by is -1. Stage hazards (byHazard) count for nobody.
+// Damage past the enemy's remaining health is overkill and not counted.
+func (g *Game) statDealt(by, amount, health int) {
+	amount = min(amount, max(health, 0))
+	if s := g.runStats.player(by); s != nil {
+		s.Dealt += amount
+	} else if by == -1 {
+		g.runStats.Defenses += amount
+	}
+}
+
+func (g *Game) statTaken(player *Player, amount int) {
+	if s := g.runStats.player(player.id); s != nil {
+		s.Taken += min(amount, max(player.health, 0))
+	}
+}
+
+func (g *Game) statKill(e *Enemy) {
+	if e.isBoss {
+		g.runStats.Bosses++
+	} else {
+		g.runStats.Kills[e.kind]++
+	}
+}
+
+func (s *PlayerRunStats) accuracy() float32 {
+	if s.Shots == 0 {
+		return 0
+	}
+	return float32(s.Hits) / float32(s.Shots) * 100
+}
+
+// updateStatsHold works TAB during play: held past statsHoldTime it shows
+// the stats until released, a shorter tap opens the character screen.
+// Returns true when the character screen was opened.
+// Only a press made during play counts, so the TAB that closes the
+// character screen doesn't open it again on release.
+func (g *Game) updateStatsHold(dt float32) bool {
+	switch {
+	case rl.IsKeyPressed(rl.KeyTab):
+		g.statsHold = dt
+	case g.statsHold == 0:
+	case rl.IsKeyDown(rl.KeyTab):
+		g.statsHold += dt
+	default:
+		held := g.statsHold
+		g.statsHold = 0
+		if held < statsHoldTime {
+			g.openCharacter()
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Game) statsShown() bool {
+	return g.statsHold >= statsHoldTime
+}
+
+// drawRunStats draws the stats panel with its top left corner at x, y.
+func (g *Game) drawRunStats(x, y int32) {
+	s := &g.runStats
+	upgrades := make([][]string, len(g.players))
+	h := int32(30 + 10 + (4+enemyKindCount)*26)
+	for i := range g.players {
+		if names := ownedUpgradeList(g.players[i].owned); names != "" {
+			upgrades[i] = wrapText(names, 16, statsPanelW-60)
+		}
+		h += 10 + 5*26 + int32(len(upgrades[i]))*20
+	}
+	if s.Defenses > 0 {
+		h += 10 + 26
+	}
+	rl.DrawRectangle(x, y, statsPanelW, h, rl.NewColor(0, 0, 0, 190))
+	rl.DrawRectangleLinesEx(rl.NewRectangle(float32(x), float32(y), statsPanelW, float32(h)), 2, rl.Gold)
+
+	x += 20
+	y += 15
+	line := func(label, value string, color rl.Color) {
+		drawText(label, x, y, 20, color)
+		if value != "" {
+			drawText(value, x+statsPanelW-40-measureText(value, 20), y, 20, color)
+		}
+		y += 26
+	}
+
+	line("RUN STATS", formatRushTime(g.gameTime), rl.Gold)
+	line(fmt.Sprintf("Level %d", g.level), fmt.Sprintf("Score %d", g.score), rl.White)
+	y += 10
+	line("Kills", fmt.Sprintf("%d", g.enemiesKilled), rl.Yellow)
+	for kind := EnemyKind(0); kind < enemyKindCount; kind++ {
+		line("  "+enemyKindNames[kind], fmt.Sprintf("%d", s.Kills[kind]), rl.LightGray)
+	}
+	line("  Bosses", fmt.Sprintf("%d", s.Bosses), rl.LightGray)
+
+	for i := range g.players {
+		p := &g.players[i]
+		ps := s.player(i)
+		if ps == nil {
+			continue
+		}
+		y += 10
+		line(fmt.Sprintf("Player %d", i+1), "", p.color)
+		line("  Accuracy", fmt.Sprintf("%.0f%% (%d/%d)", ps.accuracy(), ps.Hits, ps.Shots), rl.LightGray)
+		line("  Damage dealt", fmt.Sprintf("%d", ps.Dealt), rl.LightGray)
+		line("  Damage taken", fmt.Sprintf("%d", ps.Taken), rl.LightGray)
+		line("  Upgrades", fmt.Sprintf("%d", len(p.owned)), rl.LightGray)
+		for _, l := range upgrades[i] {
+			drawText(l, x+20, y, 16, rl.Gray)
+			y += 20
+		}
+	}
+	if s.Defenses > 0 {
+		y += 10
+		line("Defenses dealt", fmt.Sprintf("%d", s.Defenses), rl.LightGray)
+	}
+}
+
+// ownedUpgradeList lists taken upgrades, repeats counted: "Power x2, Leech".
+func ownedUpgradeList(owned []int) string {
+	counts := map[int]int{}
+	var order []int
+	for _, idx := range owned {
+		if counts[idx] == 0 {
+			order = append(order, idx)
+		}
+		counts[idx]++
+	}
+	names := make([]string, 0, len(order))
+	for _, idx := range order {
+		name := upgradePool[idx].name
+		if counts[idx] > 1 {
+			name += fmt.Sprintf(" x%d", counts[idx])
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Streamer mode (Settings). Hides the seed so viewers can't snipe the run,
+// draws score and level large at the top of the screen and writes live
+// stats to save/stream for OBS text sources. With -stream-port the same
+// stats are served as JSON on localhost for browser overlays.
+const (
+	streamDir      = saveDir + "/stream"
+	streamTextFile = streamDir + "/stats.txt"
+	streamJSONFile = streamDir + "/stats.json"
+	streamInterval = float32(1.0) // seconds between file updates
+)
+
+// StreamStats is what overlays get to see.
+type StreamStats struct {
+	State   string `json:"state"`
+	Score   int    `json:"score"`
+	Level   int    `json:"level"`
+	Stage   string `json:"stage"`
+	Kills   int    `json:"kills"`
+	Time    int    `json:"time"` // seconds
+	Boss    string `json:"boss,omitempty"`
+	Players []int  `json:"health"`
+}
+
+// StreamFeed holds the latest stats for the file writer and the HTTP server.
+type StreamFeed struct {
+	mu    sync.Mutex
+	stats StreamStats
+	timer float32
+	last  string // last JSON written, to skip unchanged writes
+}
+
+var stateNames = map[GameState]string{
+	StateMenu:       "menu",
+	StatePlaying:    "playing",
+	StatePaused:     "paused",
+	StateUpgrade:    "upgrade",
+	StateBuild:      "building",
+	StateGameOver:   "game over",
+	StateAttract:    "attract",
+	StateNameEntry:  "name entry",
+	StateVictory:    "victory",
+	StateLoot:       "loot",
+	StateSkillTree:  "skill tree",
+	StateCharacter:  "character",
+	StateMastery:    "mastery",
+	StateArmory:     "armory",
+	StateAssetCheck: "asset check",
+	StateSandbox:    "sandbox",
+}
+
+func (g *Game) currentStreamStats() StreamStats {
+	s := StreamStats{
+		State: stateNames[g.state],
+		Score: g.score,
+		Level: g.level,
+		Stage: stageNames[g.currentStage],
+		Kills: g.enemiesKilled,
+		Time:  int(g.gameTime),
+	}
+	if s.State == "" {
+		s.State = "menu"
+	}
+	if g.bossActive {
+		s.Boss = g.bossName()
+	}
+	for i := range g.players {
+		s.Players = append(s.Players, max(g.players[i].health, 0))
+	}
+	return s
+}
+
+// updateStreamFeed refreshes the stats once a second and writes them out in
+// streamer mode.
+func (g *Game) updateStreamFeed(dt float32) {
+	f := &g.stream
+	f.timer -= dt
+	if f.timer > 0 {
+		return
+	}
+	f.timer = streamInterval
+
+	stats := g.currentStreamStats()
+	f.mu.Lock()
+	f.stats = stats
+	f.mu.Unlock()
+
+	if !g.settings.streamerMode {
+		return
+	}
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil || string(data) == f.last {
+		return
+	}
+	f.last = string(data)
+
+	os.MkdirAll(streamDir, os.ModePerm)
+	if err := os.WriteFile(streamJSONFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not write stream stats:", err)
+		return
+	}
+	text := fmt.Sprintf("Score: %d\nLevel: %d\nStage: %s\n", stats.Score, stats.Level, strings.ToUpper(stats.Stage))
+	os.WriteFile(streamTextFile, []byte(text), 0644)
+}
+
+// serveStreamStats serves the live stats on localhost:port until the game
+// exits.
+func (g *Game) serveStreamStats(port int) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, r *http.Request) {
+		g.stream.mu.Lock()
+		stats := g.stream.stats
+		g.stream.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		// Browser sources load overlays from file:// or other ports
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		json.NewEncoder(w).Encode(stats)
+	})
+
+	addr := fmt.Sprintf("127.0.0.1:%d", port)
+	go func() {
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			fmt.Println("Warning: Stream stats server stopped:", err)
+		}
+	}()
+	fmt.Printf("✓ Stream stats on http://%s/stats\n", addr)
+}
+
+// drawStreamerHUD draws the numbers viewers care about large enough to read
+// on a scaled-down stream.
+func (g *Game) drawStreamerHUD() {
+	if !g.settings.streamerMode {
+		return
+	}
+	score := fmt.Sprintf("%d", g.score)
+	drawText(score, screenWidth/2-measureText(score, 56)/2, 10, 56, rl.White)
+	level := fmt.Sprintf("LEVEL %d", g.level)
+	drawText(level, screenWidth/2-measureText(level, 28)/2, 68, 28, rl.Yellow)
+}
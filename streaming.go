@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Stage geometry streaming. Large stages can ship their scenery as chunk
+// models in assets/stages/<stage>/chunks/<cx>_<cz>.glb (one per
+// chunkSize x chunkSize cell). Only chunks around the camera are kept in
+// memory: files are pre-read on a background goroutine, the GPU upload
+// happens on the main thread (raylib requirement) at most a few per frame,
+// and chunks unload once they fall outside a larger radius (hysteresis) so
+// walking along a border doesn't thrash. Models go through the asset cache,
+// so chunks released here stay resident until the memory budget needs the
+// space back. Over maxLoadedChunks, no new reads start and read chunks wait
+// for room; a chunk that can't be read is retried after a growing delay.
+const (
+	chunkSize         = float32(20.0)
+	chunkLoadRadius   = 2 // chunks around the camera target to keep loaded
+	chunkUnloadRadius = 3
+	chunkUploadBudget = 1 // GPU uploads per frame
+	maxLoadedChunks   = 36
+	chunkRetryDelay   = time.Second
+	chunkMaxRetry     = 30 * time.Second
+)
+
+var stageDirNames = map[StageType]string{
+	StageBasic:  "basic",
+	StageMaze:   "maze",
+	StageHazard: "hazard",
+	StageArena:  "arena",
+	StageNight:  "night",
+}
+
+type chunkKey struct{ x, z int }
+
+type chunkState int
+
+const (
+	chunkOnDisk chunkState = iota
+	chunkReading
+	chunkReady // bytes in the OS cache, waiting for upload
+	chunkLoaded
+	chunkFailed // read failed, retried at retryAt
+)
+
+type StageChunk struct {
+	path     string
+	state    chunkState
+	model    rl.Model
+	failures int
+	retryAt  time.Time
+}
+
+// chunkRead is a finished background read.
+type chunkRead struct {
+	key chunkKey
+	err error
+}
+
+type ChunkStreamer struct {
+	assets *AssetCache
+	chunks map[chunkKey]*StageChunk
+	loaded int
+	ready  chan chunkRead
+}
+
+func NewChunkStreamer(assets *AssetCache) *ChunkStreamer {
+	return &ChunkStreamer{
+		assets: assets,
+		chunks: map[chunkKey]*StageChunk{},
+		ready:  make(chan chunkRead, 1),
+	}
+}
+
+// scanStageChunks indexes the chunk files of a stage, releasing the previous
+// stage's chunks. Nothing is loaded here so stage changes stay fast.
+func (s *ChunkStreamer) scanStageChunks(stage StageType) {
+	s.unloadAll()
+	s.chunks = map[chunkKey]*StageChunk{}
+
+	dir := filepath.Join("assets/stages", stageDirNames[stage], "chunks")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		ext := filepath.Ext(name)
+		if entry.IsDir() || (ext != ".glb" && ext != ".gltf" && ext != ".obj") {
+			continue
+		}
+		var key chunkKey
+		if _, err := fmt.Sscanf(strings.TrimSuffix(name, ext), "%d_%d", &key.x, &key.z); err != nil {
+			continue
+		}
+		s.chunks[key] = &StageChunk{path: filepath.Join(dir, name)}
+	}
+	// A chunk has at most one read in flight, so readers never block on a
+	// channel this big. A new channel means stale reads from the old stage
+	// are never seen.
+	s.ready = make(chan chunkRead, max(len(s.chunks), 1))
+	if len(s.chunks) > 0 {
+		fmt.Printf("📦 Stage %s: %d streamable chunks\n", stageDirNames[stage], len(s.chunks))
+	}
+}
+
+// update requests chunks near the focus point, uploads ready ones within
+// the per-frame budget and unloads distant ones.
+func (s *ChunkStreamer) update(focusX, focusZ float32) {
+	if len(s.chunks) == 0 {
+		return
+	}
+
+	cx := int(math.Floor(float64(focusX / chunkSize)))
+	cz := int(math.Floor(float64(focusZ / chunkSize)))
+
+	// Unload outside the hysteresis radius
+	for key, c := range s.chunks {
+		if c.state == chunkLoaded && chunkDist(key, cx, cz) > chunkUnloadRadius {
+			s.assets.release(c.path)
+			c.model = rl.Model{}
+			c.state = chunkOnDisk
+			s.loaded--
+		}
+	}
+
+	// Collect finished reads
+	for done := false; !done; {
+		select {
+		case r := <-s.ready:
+			c, ok := s.chunks[r.key]
+			if !ok || c.state != chunkReading {
+				continue
+			}
+			if r.err != nil {
+				fmt.Println("Warning: Could not read stage chunk:", c.path, r.err)
+				c.state = chunkFailed
+				c.retryAt = time.Now().Add(min(chunkRetryDelay<<c.failures, chunkMaxRetry))
+				c.failures++
+				continue
+			}
+			c.failures = 0
+			c.state = chunkReady
+		default:
+			done = true
+		}
+	}
+
+	// Request nearby chunks (background read) while there is room for them
+	busy := s.loaded
+	for _, c := range s.chunks {
+		if c.state == chunkReading || c.state == chunkReady {
+			busy++
+		}
+	}
+	now := time.Now()
+	for dx := -chunkLoadRadius; dx <= chunkLoadRadius && busy < maxLoadedChunks; dx++ {
+		for dz := -chunkLoadRadius; dz <= chunkLoadRadius && busy < maxLoadedChunks; dz++ {
+			key := chunkKey{cx + dx, cz + dz}
+			c, ok := s.chunks[key]
+			if !ok || !(c.state == chunkOnDisk || c.state == chunkFailed && now.After(c.retryAt)) {
+				continue
+			}
+			c.state = chunkReading
+			busy++
+			go prefetchChunk(c.path, key, s.ready)
+		}
+	}
+
+	// Upload a limited number of prefetched chunks per frame
+	budget := chunkUploadBudget
+	for key, c := range s.chunks {
+		if c.state != chunkReady {
+			continue
+		}
+		if chunkDist(key, cx, cz) > chunkUnloadRadius {
+			// Moved away while reading
+			c.state = chunkOnDisk
+			continue
+		}
+		if budget == 0 || s.loaded >= maxLoadedChunks {
+			continue // stays read until there is room
+		}
+		c.model = s.assets.loadModel(c.path)
+		c.state = chunkLoaded
+		s.loaded++
+		budget--
+	}
+}
+
+// prefetchChunk reads the file so the main-thread load hits the OS cache.
+func prefetchChunk(path string, key chunkKey, ready chan<- chunkRead) {
+	_, err := os.ReadFile(path)
+	ready <- chunkRead{key, err}
+}
+
+func chunkDist(key chunkKey, cx, cz int) int {
+	dx := key.x - cx
+	if dx < 0 {
+		dx = -dx
+	}
+	dz := key.z - cz
+	if dz < 0 {
+		dz = -dz
+	}
+	if dx > dz {
+		return dx
+	}
+	return dz
+}
+
+func (s *ChunkStreamer) unloadAll() {
+	for _, c := range s.chunks {
+		if c.state == chunkLoaded {
+			s.assets.release(c.path)
+		}
+	}
+	s.loaded = 0
+}
+
+// draw renders loaded chunks; chunk models are authored relative to the
+// chunk's corner.
+func (s *ChunkStreamer) draw() {
+	for key, c := range s.chunks {
+		if c.state != chunkLoaded {
+			continue
+		}
+		origin := rl.NewVector3(float32(key.x)*chunkSize, 0, float32(key.z)*chunkSize)
+		rl.DrawModel(c.model, origin, 1.0, rl.White)
+	}
+}
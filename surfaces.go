@@ -0,0 +1,124 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Surface types decide which footstep and bullet impact sounds play.
+// Sounds are optional: the events surfaces/step_<surface> and
+// surfaces/impact_<surface> (by default assets/sounds/surfaces/...wav).
+type SurfaceType int
+
+const (
+	SurfaceStone SurfaceType = iota
+	SurfaceMetal
+	SurfaceGoo
+	surfaceCount
+)
+
+var surfaceNames = [surfaceCount]string{"stone", "metal", "goo"}
+
+// Floor surface of each stage
+var stageSurfaces = map[StageType]SurfaceType{
+	StageBasic:  SurfaceStone,
+	StageMaze:   SurfaceMetal,
+	StageHazard: SurfaceGoo,
+	StageArena:  SurfaceStone,
+	StageNight:  SurfaceStone,
+}
+
+const gooSplashRange = float32(1.2) // distance from a hazard edge that still counts as goo
+
+func (g *Game) loadSurfaceSounds() {
+	for s := SurfaceType(0); s < surfaceCount; s++ {
+		g.sounds.steps[s] = g.loadSfx("surfaces/step_" + surfaceNames[s])
+		g.sounds.impacts[s] = g.loadSfx("surfaces/impact_" + surfaceNames[s])
+	}
+}
+
+// obstacleSurface is the material of an obstacle: hazards are goo,
+// crushers and lasers metal, walls match the stage floor (metal in the
+// maze, stone elsewhere).
+func (g *Game) obstacleSurface(obs *Obstacle) SurfaceType {
+	if obs.obsType == 1 {
+		return SurfaceGoo
+	}
+	if stageSurfaces[g.currentStage] == SurfaceMetal || isMovingHazard(obs) {
+		return SurfaceMetal
+	}
+	return SurfaceStone
+}
+
+// surfaceAt is what a player standing at pos walks on. Walking along the
+// edge of a hazard pool counts as goo.
+func (g *Game) surfaceAt(pos rl.Vector3) SurfaceType {
+	for i := range g.obstacles {
+		obs := &g.obstacles[i]
+		if !obs.active || obs.obsType != 1 {
+			continue
+		}
+		dx := float32(math.Abs(float64(pos.X-obs.position.X))) - obs.size.X/2
+		dz := float32(math.Abs(float64(pos.Z-obs.position.Z))) - obs.size.Z/2
+		if dx < gooSplashRange && dz < gooSplashRange {
+			return SurfaceGoo
+		}
+	}
+	return stageSurfaces[g.currentStage]
+}
+
+// obstacleAt returns the index of the obstacle overlapping pos, or -1.
+func (g *Game) obstacleAt(pos rl.Vector3, radius float32) int {
+	for i := range g.obstacles {
+		obs := &g.obstacles[i]
+		if !obs.active {
+			continue
+		}
+		if pos.X+radius > obs.position.X-obs.size.X/2 &&
+			pos.X-radius < obs.position.X+obs.size.X/2 &&
+			pos.Z+radius > obs.position.Z-obs.size.Z/2 &&
+			pos.Z-radius < obs.position.Z+obs.size.Z/2 {
+			return i
+		}
+	}
+	return -1
+}
+
+// obstacleTop is the height of the top face of an obstacle.
+func (g *Game) obstacleTop(i int) float32 {
+	return g.obstacles[i].position.Y + g.obstacles[i].size.Y/2
+}
+
+// updateFootsteps advances the walk cycle while the player is moving and
+// plays the step sound for the surface underfoot each time the bob comes
+// down, twice per cycle. Standing still restarts the cycle.
+func (g *Game) updateFootsteps(player *Player, dt float32) {
+	if !player.isMoving {
+		player.animTime = 0
+		return
+	}
+	before := player.stepCount()
+	player.animTime += dt
+	if player.stepCount() != before {
+		g.playSound(g.sounds.steps[g.surfaceAt(player.position)])
+	}
+}
+
+// stepCount is how many times the bob has come down this walk.
+func (p *Player) stepCount() int {
+	return int(p.animTime * p.walkBobFreq / math.Pi)
+}
+
+// walkBob is how far the walk cycle lifts the player's model.
+func (p *Player) walkBob() float32 {
+	return float32(math.Abs(math.Sin(float64(p.animTime*p.walkBobFreq)))) * p.walkBobAmp
+}
+
+// playImpact plays the bullet impact sound of the obstacle that was hit.
+func (g *Game) playImpact(obstacle int) {
+	if obstacle < 0 {
+		return
+	}
+	g.playSound(g.sounds.impacts[g.obstacleSurface(&g.obstacles[obstacle])])
+}
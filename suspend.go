@@ -0,0 +1,734 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Suspended runs. "Save & Quit" on the pause screen writes the whole run to
+// save/suspend.json and goes back to the menu; "Continue" loads it and
+// deletes the file, so a run can be put down but never reloaded to undo a
+// death. Only one run is kept. Particles are the only thing not saved; an
+// upgrade or loot pick left open comes back open, with the same offer.
+const (
+	suspendFile    = saveDir + "/suspend.json"
+	suspendVersion = 1
+)
+
+// countingSource is the run's random source. It counts draws so a restored
+// run can be brought back to the same point in the sequence.
+type countingSource struct {
+	src   rand.Source64
+	draws uint64
+}
+
+func (s *countingSource) Int63() int64    { s.draws++; return s.src.Int63() }
+func (s *countingSource) Uint64() uint64  { s.draws++; return s.src.Uint64() }
+func (s *countingSource) Seed(seed int64) { s.src.Seed(seed); s.draws = 0 }
+
+// newRunRNG seeds the run's random numbers and skips the first skip draws.
+func (g *Game) newRunRNG(seed int64, skip uint64) {
+	g.rngSource = &countingSource{src: rand.NewSource(seed).(rand.Source64)}
+	for n := uint64(0); n < skip; n++ {
+		g.rngSource.Uint64()
+	}
+	g.rng = rand.New(g.rngSource)
+}
+
+// The saved run. Game structs keep their fields unexported, so the run is
+// copied into these first.
+type SuspendedRun struct {
+	Version       int     `json:"version"`
+	Coop          bool    `json:"coop"`
+	Daily         bool    `json:"daily"`
+	BossRush      bool    `json:"bossRush"`
+	Possession    bool    `json:"possession"`
+	Seed          int64   `json:"seed"`
+	Seeded        bool    `json:"seeded"`
+	Loadout       bool    `json:"loadout,omitempty"` // started from a build code
+	Draws         uint64  `json:"draws"`
+	Difficulty    int     `json:"difficulty"`
+	Lowest        *int    `json:"lowestDifficulty,omitempty"` // records go under it
+	Sprint        bool    `json:"sprint"`
+	Ammo          bool    `json:"ammo"`
+	Energy        bool    `json:"energy"`
+	Score         int     `json:"score"`
+	Level         int     `json:"level"`
+	Kills         int     `json:"kills"`
+	GameTime      float32 `json:"gameTime"`
+	SpawnTimer    float32 `json:"spawnTimer"`
+	SpawnInterval float32 `json:"spawnInterval"`
+	BossActive    bool    `json:"bossActive"`
+	BossSpawned   bool    `json:"bossSpawned"`
+	Stage         int     `json:"stage"`
+	StageHalf     float32 `json:"stageHalf"`
+	Layout        string  `json:"layout"` // stage layout file, "" = built-in
+	Pace          []int   `json:"pace"`
+	BuildPoints   int     `json:"buildPoints"`
+	RushFight     int     `json:"rushFight"`
+	RushPause     float32 `json:"rushPause"`
+	PossessPoints float32 `json:"possessPoints"`
+
+	Boss         SavedBossFight     `json:"boss"`
+	Players      []SavedPlayer      `json:"players"`
+	Enemies      []SavedEnemy       `json:"enemies"`
+	Bullets      []SavedBullet      `json:"bullets"`
+	EnemyBullets []SavedEnemyBullet `json:"enemyBullets"`
+	Grenades     []SavedGrenade     `json:"grenades"`
+	PowerUps     []SavedPowerUp     `json:"powerUps"`
+	Obstacles    []SavedObstacle    `json:"obstacles"`
+	Nests        []SavedNest        `json:"nests"`
+	Portals      [][2]rl.Vector3    `json:"portals,omitempty"` // linked pairs
+	FloorZones   []SavedFloorZone   `json:"floorZones,omitempty"`
+	Structures   []SavedStructure   `json:"structures"`
+	Telegraphs   []SavedTelegraph   `json:"telegraphs"`
+	Shells       []SavedShell       `json:"shells"`
+	Emitters     []SavedEmitter     `json:"emitters"`
+	Drones       []SavedDrone       `json:"drones"`
+	Stats        RunStats           `json:"stats"`
+	Choice       *SavedChoice       `json:"choice,omitempty"` // upgrade or loot screen the run was waiting on
+}
+
+type SavedBossFight struct {
+	Def          string  `json:"def"` // scripted definition name, "" for archetypes
+	Index        int     `json:"index"`
+	Phase        int     `json:"phase"`
+	Clock        float32 `json:"clock"`
+	Step         int     `json:"step"`
+	Archetype    int     `json:"archetype"`
+	State        int     `json:"state"`
+	Timer        float32 `json:"timer"`
+	DirX         float32 `json:"dirX"`
+	DirZ         float32 `json:"dirZ"`
+	PatternTimer float32 `json:"patternTimer"`
+	PatternStep  int     `json:"patternStep"`
+}
+
+type SavedPlayer struct {
+	Position        rl.Vector3           `json:"position"`
+	Angle           float32              `json:"angle"`
+	Health          int                  `json:"health"`
+	MaxHealth       int                  `json:"maxHealth"`
+	Damage          int                  `json:"damage"`
+	Speed           float32              `json:"speed"`
+	FireRate        float32              `json:"fireRate"`
+	CritChance      float32              `json:"critChance"`
+	StatPoints      int                  `json:"statPoints"`
+	LastShot        float32              `json:"lastShot"`
+	Cooldowns       []float32            `json:"cooldowns"`
+	Color           rl.Color             `json:"color"`
+	Weapons         []WeaponType         `json:"weapons"`
+	Weapon          WeaponType           `json:"weapon"`
+	Heat            float32              `json:"heat"`
+	Overheated      bool                 `json:"overheated"`
+	Ammo            [weaponCount]int     `json:"ammo"`
+	Reserve         [weaponCount]int     `json:"reserve"`
+	Reloading       bool                 `json:"reloading"`
+	ReloadTimer     float32              `json:"reloadTimer"`
+	GrenadeCooldown float32              `json:"grenadeCooldown"`
+	WeaponXP        [weaponCount]int     `json:"weaponXP"`
+	Upgrades        [upgradeKinds]int    `json:"upgrades"`
+	Stamina         float32              `json:"stamina"`
+	StaminaWait     float32              `json:"staminaWait"`
+	Exhausted       bool                 `json:"exhausted"`
+	EnergyPool      float32              `json:"energy"`
+	EnergyWait      float32              `json:"energyWait"`
+	FinalStand      float32              `json:"finalStand"`
+	StandUsed       bool                 `json:"standUsed"`
+	Toggled         [actionCount]bool    `json:"toggled"`
+	Unlocked        [skillNodeCount]bool `json:"unlocked"`
+	SkillPoints     int                  `json:"skillPoints"`
+	Owned           []int                `json:"owned"`
+	Shield          int                  `json:"shield"`
+	ShieldTimer     float32              `json:"shieldTimer"`
+	DashCooldown    float32              `json:"dashCooldown"`
+}
+
+type SavedEnemy struct {
+	Slot       int        `json:"slot"`
+	Position   rl.Vector3 `json:"position"`
+	Velocity   rl.Vector3 `json:"velocity"`
+	Health     int        `json:"health"`
+	MaxHealth  int        `json:"maxHealth"`
+	Size       float32    `json:"size"`
+	Color      rl.Color   `json:"color"`
+	IsBoss     bool       `json:"isBoss"`
+	Kind       EnemyKind  `json:"kind"`
+	Phase      EnemyPhase `json:"phase"`
+	PhaseTimer float32    `json:"phaseTimer"`
+	Facing     float32    `json:"facing"`
+	ShotTimer  float32    `json:"shotTimer"`
+	LastHitBy  int        `json:"lastHitBy"`
+	LastWeapon WeaponType `json:"lastWeapon"`
+	Credited   bool       `json:"credited"`
+}
+
+type SavedBullet struct {
+	Slot     int            `json:"slot"`
+	Position rl.Vector3     `json:"position"`
+	Velocity rl.Vector3     `json:"velocity"`
+	Origin   rl.Vector3     `json:"origin"`
+	Damage   int            `json:"damage"`
+	PlayerID int            `json:"playerId"`
+	Weapon   WeaponType     `json:"weapon"`
+	Kind     ProjectileKind `json:"kind"`
+	Target   int            `json:"target"`
+	Crit     bool           `json:"crit"`
+	Pierce   int            `json:"pierce"`
+	Hits     []int          `json:"hits,omitempty"` // enemies a piercing bullet passed through
+	Tracked  bool           `json:"tracked,omitempty"`
+	Landed   bool           `json:"landed,omitempty"`
+}
+
+type SavedEnemyBullet struct {
+	Position rl.Vector3 `json:"position"`
+	Velocity rl.Vector3 `json:"velocity"`
+	Damage   int        `json:"damage"`
+	Lifetime float32    `json:"lifetime"`
+}
+
+type SavedGrenade struct {
+	Position rl.Vector3 `json:"position"`
+	Velocity rl.Vector3 `json:"velocity"`
+	Fuse     float32    `json:"fuse"`
+	PlayerID int        `json:"playerId"`
+	Damage   int        `json:"damage"`
+}
+
+type SavedPowerUp struct {
+	Position rl.Vector3 `json:"position"`
+	Type     int        `json:"type"`
+	Rotation float32    `json:"rotation"`
+	Weapon   WeaponType `json:"weapon"`
+}
+
+type SavedObstacle struct {
+	Position rl.Vector3    `json:"position"`
+	Size     rl.Vector3    `json:"size"`
+	Type     int           `json:"type"`
+	Motion   *HazardMotion `json:"motion,omitempty"` // crushers and lasers
+}
+
+type SavedFloorZone struct {
+	Kind     FloorZoneKind `json:"kind"`
+	Position rl.Vector3    `json:"position"`
+	Size     rl.Vector2    `json:"size"`
+	Dir      rl.Vector2    `json:"dir"`
+}
+
+type SavedNest struct {
+	Position   rl.Vector3 `json:"position"`
+	Health     int        `json:"health"`
+	MaxHealth  int        `json:"maxHealth"`
+	SpawnTimer float32    `json:"spawnTimer"`
+}
+
+type SavedStructure struct {
+	Kind     StructureKind `json:"kind"`
+	Position rl.Vector3    `json:"position"`
+	Health   float32       `json:"health"`
+	Timer    float32       `json:"timer"`
+	Aim      float32       `json:"aim"`
+}
+
+type SavedTelegraph struct {
+	Position rl.Vector3 `json:"position"`
+	Radius   float32    `json:"radius"`
+	Delay    float32    `json:"delay"`
+	Timer    float32    `json:"timer"`
+	Damage   int        `json:"damage"`
+}
+
+type SavedDrone struct {
+	Owner int     `json:"owner"`
+	Level int     `json:"level"`
+	Angle float32 `json:"angle"`
+	Timer float32 `json:"timer"`
+}
+
+// SavedChoice is a pick the run was stopped in the middle of. The offer is
+// saved as drawn so continuing can't reroll it.
+type SavedChoice struct {
+	Screen string      `json:"screen"` // "upgrade" or "loot"
+	Offer  []int       `json:"offer,omitempty"`
+	Player int         `json:"player,omitempty"` // who opened the loot chest
+	Loot   []SavedLoot `json:"loot,omitempty"`
+}
+
+type SavedLoot struct {
+	Kind   LootKind   `json:"kind"`
+	Stat   int        `json:"stat"`
+	Weapon WeaponType `json:"weapon"`
+}
+
+type SavedShell struct {
+	From   rl.Vector3 `json:"from"`
+	Target rl.Vector3 `json:"target"`
+	Timer  float32    `json:"timer"`
+}
+
+type SavedEmitter struct {
+	Kind     PatternKind `json:"kind"`
+	Source   int         `json:"source"`
+	Timer    float32     `json:"timer"`
+	Interval float32     `json:"interval"`
+	Shots    int         `json:"shots"`
+	Angle    float32     `json:"angle"`
+	Spin     float32     `json:"spin"`
+	Arms     int         `json:"arms"`
+	Spread   float32     `json:"spread"`
+	Speed    float32     `json:"speed"`
+	Damage   int         `json:"damage"`
+}
+
+// canSuspend reports whether the run on screen can be saved. Arcade runs
+// are paid for with credits, so they can't.
+func (g *Game) canSuspend() bool {
+	return g.arcade == nil && !g.practiceMode && len(g.players) > 0
+}
+
+// hasSuspendedRun reports whether there is a run to continue.
+func hasSuspendedRun() bool {
+	return fileExists(suspendFile)
+}
+
+// suspendRun saves the run and goes back to the menu.
+func (g *Game) suspendRun() {
+	data, err := json.Marshal(g.snapshotRun())
+	if err != nil {
+		fmt.Println("Warning: Could not encode suspended run:", err)
+		return
+	}
+	os.MkdirAll(saveDir, os.ModePerm)
+	if err := writeFileAtomic(suspendFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save suspended run:", err)
+		return
+	}
+	fmt.Println("✓ Saved:", suspendFile)
+	g.saveMastery()
+	g.state = StateMenu
+	g.menuSelection = 0
+}
+
+// continueRun loads the suspended run and deletes the file.
+func (g *Game) continueRun() {
+	data, err := os.ReadFile(suspendFile)
+	if err != nil {
+		return
+	}
+	// Gone before the run starts, so quitting mid-run can't bring it back
+	os.Remove(suspendFile)
+
+	var run SuspendedRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		fmt.Println("Warning: Could not read suspended run:", err)
+		return
+	}
+	if run.Version != suspendVersion {
+		fmt.Println("Warning: Suspended run is from another version, discarded")
+		return
+	}
+	g.restoreRun(&run)
+	g.state = StatePaused
+	g.restoreChoice(run.Choice)
+}
+
+// pendingChoice saves the upgrade break or loot chest the run is waiting
+// on, nil if there is none. Build mode and a skill tree opened from the
+// upgrade screen still have the break pending.
+func (g *Game) pendingChoice() *SavedChoice {
+	switch {
+	case g.state == StateUpgrade || g.state == StateBuild ||
+		(g.state == StateSkillTree && g.skillView.back == StateUpgrade):
+		return &SavedChoice{Screen: "upgrade", Offer: g.upgradeOffer[:]}
+	case g.state == StateLoot:
+		c := &SavedChoice{Screen: "loot", Player: g.loot.player}
+		for _, l := range g.loot.choices {
+			c.Loot = append(c.Loot, SavedLoot{l.kind, l.stat, l.weapon})
+		}
+		return c
+	}
+	return nil
+}
+
+// restoreChoice reopens a saved upgrade break or loot chest.
+func (g *Game) restoreChoice(c *SavedChoice) {
+	if c == nil {
+		return
+	}
+	switch c.Screen {
+	case "upgrade":
+		if len(c.Offer) != upgradeOfferSize {
+			return
+		}
+		for slot, idx := range c.Offer {
+			if idx < 0 || idx >= len(upgradePool) {
+				return
+			}
+			g.upgradeOffer[slot] = idx
+		}
+		g.state = StateUpgrade
+	case "loot":
+		if len(c.Loot) != int(lootKindCount) || c.Player < 0 || c.Player >= len(g.players) {
+			return
+		}
+		g.loot = BossLoot{player: c.Player}
+		for i, l := range c.Loot {
+			if l.Kind < 0 || l.Kind >= lootKindCount || l.Stat < 0 || l.Stat >= upgradeKinds || l.Weapon < 0 || int(l.Weapon) >= len(weaponDefs) {
+				return
+			}
+			g.loot.choices[i] = LootChoice{kind: l.Kind, stat: l.Stat, weapon: l.Weapon}
+		}
+		g.state = StateLoot
+	}
+}
+
+func (g *Game) snapshotRun() *SuspendedRun {
+	run := &SuspendedRun{
+		Version:       suspendVersion,
+		Coop:          g.coopMode,
+		Daily:         g.daily,
+		BossRush:      g.bossRushMode,
+		Possession:    g.possessMode,
+		Seed:          g.seed,
+		Seeded:        g.seeded,
+		Loadout:       g.loadoutRun,
+		Draws:         g.rngSource.draws,
+		Difficulty:    g.runDifficulty,
+		Lowest:        &g.recordDifficulty,
+		Sprint:        g.settings.modifiers.sprint,
+		Ammo:          g.settings.modifiers.ammo,
+		Energy:        g.settings.modifiers.energy,
+		Score:         g.score,
+		Level:         g.level,
+		Kills:         g.enemiesKilled,
+		GameTime:      g.gameTime,
+		SpawnTimer:    g.spawnTimer,
+		SpawnInterval: g.spawnInterval,
+		BossActive:    g.bossActive,
+		BossSpawned:   g.bossSpawned,
+		Stage:         int(g.currentStage),
+		StageHalf:     g.stageHalf,
+		Layout:        g.layoutName(),
+		Pace:          g.pace,
+		BuildPoints:   g.build.points,
+		RushFight:     g.rush.fight,
+		RushPause:     g.rush.pause,
+		PossessPoints: g.possess.points,
+		Stats:         g.runStats,
+	}
+
+	f := g.bossFight
+	run.Boss = SavedBossFight{
+		Index: f.index, Phase: f.phase, Clock: f.clock, Step: f.step,
+		Archetype: int(f.archetype), State: f.state, Timer: f.timer, DirX: f.dirX, DirZ: f.dirZ,
+		PatternTimer: f.patternTimer, PatternStep: f.patternStep,
+	}
+	if f.def != nil {
+		run.Boss.Def = f.def.Name
+	}
+
+	for _, p := range g.players {
+		saved := SavedPlayer{
+			Position: p.position, Angle: p.angle, Health: p.health,
+			MaxHealth: p.stats.maxHealth, Damage: p.stats.damage, Speed: p.stats.speed,
+			FireRate: p.stats.fireRate, CritChance: p.stats.critChance, StatPoints: p.stats.statPoints,
+			LastShot: p.lastShot, Color: p.color, Weapons: p.weapons, Weapon: p.weapon,
+			Heat: p.heat, Overheated: p.overheated, Ammo: p.ammo, Reserve: p.reserve,
+			Reloading: p.reloading, ReloadTimer: p.reloadTimer, GrenadeCooldown: p.grenadeCooldown,
+			WeaponXP: p.weaponXP, Upgrades: p.upgrades, Stamina: p.stamina, StaminaWait: p.staminaWait,
+			Exhausted: p.exhausted, EnergyPool: p.energy, EnergyWait: p.energyWait,
+			FinalStand: p.finalStand, StandUsed: p.standUsed, Toggled: p.toggled, Unlocked: p.unlocked,
+			SkillPoints: p.skillPoints, Owned: p.owned,
+			Shield: p.shield, ShieldTimer: p.shieldTimer, DashCooldown: p.dash.cooldown,
+		}
+		for _, s := range p.skills {
+			saved.Cooldowns = append(saved.Cooldowns, s.cooldown)
+		}
+		run.Players = append(run.Players, saved)
+	}
+
+	for i, e := range g.enemies {
+		if !e.active {
+			continue
+		}
+		run.Enemies = append(run.Enemies, SavedEnemy{
+			Slot: i, Position: e.position, Velocity: e.velocity, Health: e.health, MaxHealth: e.maxHealth,
+			Size: e.size, Color: e.color, IsBoss: e.isBoss, Kind: e.kind, Phase: e.phase,
+			PhaseTimer: e.phaseTimer, Facing: e.facing, ShotTimer: e.shotTimer,
+			LastHitBy: e.lastHitBy, LastWeapon: e.lastWeapon, Credited: e.hitCredited,
+		})
+	}
+	// Slots matter for bullets too: homing targets and boss patterns refer to them
+	for i, b := range g.bullets {
+		if b.active {
+			run.Bullets = append(run.Bullets, SavedBullet{
+				Slot: i, Position: b.position, Velocity: b.velocity, Origin: b.origin, Damage: b.damage,
+				PlayerID: b.playerId, Weapon: b.weapon, Kind: b.kind, Target: b.target,
+				Crit: b.crit, Pierce: b.pierce, Hits: slices.Clone(b.hit[:b.hits]),
+				Tracked: b.tracked, Landed: b.landed,
+			})
+		}
+	}
+	for _, b := range g.enemyBullets {
+		if b.active {
+			run.EnemyBullets = append(run.EnemyBullets, SavedEnemyBullet{b.position, b.velocity, b.damage, b.lifetime})
+		}
+	}
+	for _, gr := range g.grenades {
+		if gr.active {
+			run.Grenades = append(run.Grenades, SavedGrenade{gr.position, gr.velocity, gr.fuse, gr.playerId, gr.damage})
+		}
+	}
+	for _, p := range g.powerUps {
+		if p.active {
+			run.PowerUps = append(run.PowerUps, SavedPowerUp{p.position, p.pType, p.rotation, p.weapon})
+		}
+	}
+	for _, o := range g.obstacles {
+		if o.active {
+			saved := SavedObstacle{Position: o.position, Size: o.size, Type: o.obsType}
+			if isMovingHazard(&o) {
+				saved.Motion = &o.motion
+			}
+			run.Obstacles = append(run.Obstacles, saved)
+		}
+	}
+	for _, n := range g.nests {
+		if n.active {
+			run.Nests = append(run.Nests, SavedNest{n.position, n.health, n.maxHealth, n.spawnTimer})
+		}
+	}
+	for i := 0; i+1 < len(g.portals); i += 2 {
+		if g.portals[i].active {
+			run.Portals = append(run.Portals, [2]rl.Vector3{g.portals[i].position, g.portals[i+1].position})
+		}
+	}
+	for _, z := range g.floorZones {
+		if z.active {
+			run.FloorZones = append(run.FloorZones, SavedFloorZone{z.kind, z.position, z.size, z.dir})
+		}
+	}
+	for _, s := range g.structures {
+		if s.active {
+			run.Structures = append(run.Structures, SavedStructure{s.kind, s.position, s.health, s.timer, s.aim})
+		}
+	}
+	for _, t := range g.telegraphs {
+		if t.active {
+			run.Telegraphs = append(run.Telegraphs, SavedTelegraph{t.position, t.radius, t.delay, t.timer, t.damage})
+		}
+	}
+	for _, d := range g.drones {
+		run.Drones = append(run.Drones, SavedDrone{d.owner, d.level, d.angle, d.timer})
+	}
+	for _, s := range g.bossShells {
+		if s.active {
+			run.Shells = append(run.Shells, SavedShell{s.from, s.target, s.timer})
+		}
+	}
+	for _, e := range g.emitters {
+		if e.active {
+			run.Emitters = append(run.Emitters, SavedEmitter{
+				e.kind, e.source, e.timer, e.interval, e.shots, e.angle, e.spin, e.arms, e.spread, e.speed, e.damage,
+			})
+		}
+	}
+	run.Choice = g.pendingChoice()
+	return run
+}
+
+// restoreRun rebuilds the run from a snapshot. It starts from a reset game
+// of the same mode and seed and then overwrites everything that was saved.
+func (g *Game) restoreRun(run *SuspendedRun) {
+	g.daily, g.bossRushMode, g.possessMode, g.practiceMode = run.Daily, run.BossRush, run.Possession, false
+	g.settings.difficulty = run.Difficulty
+	g.settings.modifiers = RunModifiers{sprint: run.Sprint, ammo: run.Ammo, energy: run.Energy}
+	g.StartGame(run.Coop)
+	// The replay would have to start where the run was suspended
+	g.recording = nil
+
+	g.seed, g.seeded = run.Seed, run.Seeded
+	g.loadoutRun = run.Loadout
+	if run.Lowest != nil {
+		g.recordDifficulty = min(*run.Lowest, g.runDifficulty)
+	}
+	g.newRunRNG(run.Seed, run.Draws)
+	g.score, g.level, g.enemiesKilled, g.gameTime = run.Score, run.Level, run.Kills, run.GameTime
+	g.spawnTimer, g.spawnInterval = run.SpawnTimer, run.SpawnInterval
+	g.bossActive, g.bossSpawned = run.BossActive, run.BossSpawned
+	g.currentStage, g.stageHalf = StageType(run.Stage), run.StageHalf
+	g.layout = g.layoutNamed(run.Layout)
+	g.chunks.scanStageChunks(g.currentStage)
+	g.pace = append(g.pace[:0], run.Pace...)
+	g.build.points = run.BuildPoints
+	g.rush.fight, g.rush.pause = run.RushFight, run.RushPause
+	g.possess.points = run.PossessPoints
+	// Runs saved before stats were kept start counting from here
+	g.runStats = run.Stats
+	for len(g.runStats.Players) < len(g.players) {
+		g.runStats.Players = append(g.runStats.Players, PlayerRunStats{})
+	}
+
+	b := run.Boss
+	g.bossFight = BossFight{
+		index: b.Index, phase: b.Phase, clock: b.Clock, step: b.Step,
+		archetype: BossArchetype(b.Archetype), state: b.State, timer: b.Timer, dirX: b.DirX, dirZ: b.DirZ,
+		patternTimer: b.PatternTimer, patternStep: b.PatternStep,
+	}
+	for i := range g.bossLib.defs {
+		if b.Def != "" && g.bossLib.defs[i].Name == b.Def {
+			g.bossFight.def = &g.bossLib.defs[i]
+			g.bossFight.phase = min(g.bossFight.phase, len(g.bossLib.defs[i].Phases)-1)
+		}
+	}
+
+	for i, s := range run.Players {
+		if i >= len(g.players) {
+			break
+		}
+		p := &g.players[i]
+		p.position, p.angle, p.health = s.Position, s.Angle, s.Health
+		p.stats = PlayerStats{s.MaxHealth, s.Damage, s.Speed, s.FireRate, s.CritChance, s.StatPoints}
+		p.lastShot, p.color, p.weapons, p.weapon = s.LastShot, s.Color, s.Weapons, s.Weapon
+		p.heat, p.overheated, p.ammo, p.reserve = s.Heat, s.Overheated, s.Ammo, s.Reserve
+		p.reloading, p.reloadTimer, p.grenadeCooldown = s.Reloading, s.ReloadTimer, s.GrenadeCooldown
+		p.weaponXP, p.upgrades = s.WeaponXP, s.Upgrades
+		// Stats from owned upgrades are saved already; traits are not
+		p.owned = s.Owned
+		for _, idx := range p.owned {
+			if idx >= 0 && idx < len(upgradePool) {
+				p.traits |= upgradePool[idx].trait
+			}
+		}
+		p.stamina, p.staminaWait, p.exhausted = s.Stamina, s.StaminaWait, s.Exhausted
+		p.energy, p.energyWait = s.EnergyPool, s.EnergyWait
+		p.finalStand, p.standUsed, p.toggled = s.FinalStand, s.StandUsed, s.Toggled
+		p.shield, p.shieldTimer = s.Shield, s.ShieldTimer
+		p.dash = Dash{cooldown: s.DashCooldown}
+		// Passives are already in the saved stats; only the skill keys
+		// need rebuilding. Roots are always unlocked.
+		p.unlocked, p.skillPoints = s.Unlocked, s.SkillPoints
+		for b := 0; b < skillBranches; b++ {
+			p.unlocked[b] = true
+		}
+		p.refreshSkills()
+		for j := range p.skills {
+			if j < len(s.Cooldowns) {
+				p.skills[j].cooldown = s.Cooldowns[j]
+				p.skills[j].ready = s.Cooldowns[j] <= 0
+			}
+		}
+	}
+	g.reattachPlayerEffects()
+
+	for i := range g.obstacles {
+		g.obstacles[i].active = false
+	}
+	for i, o := range run.Obstacles {
+		if i < len(g.obstacles) {
+			g.obstacles[i] = Obstacle{position: o.Position, size: o.Size, obsType: o.Type, active: true}
+			if o.Motion != nil {
+				g.obstacles[i].motion = *o.Motion
+			}
+		}
+	}
+	for i := range g.nests {
+		g.nests[i].active = false
+	}
+	for i, n := range run.Nests {
+		if i < len(g.nests) {
+			g.nests[i] = Nest{position: n.Position, health: n.Health, maxHealth: n.MaxHealth, spawnTimer: n.SpawnTimer, active: true}
+		}
+	}
+	g.clearPortals()
+	for _, pair := range run.Portals {
+		g.placePortalPair(pair[0], pair[1])
+	}
+	g.clearFloorZones()
+	for _, z := range run.FloorZones {
+		g.addFloorZone(FloorZone{kind: z.Kind, position: z.Position, size: z.Size, dir: z.Dir})
+	}
+	g.clearStructures()
+	for i, s := range run.Structures {
+		if i < len(g.structures) {
+			g.structures[i] = Structure{kind: s.Kind, position: s.Position, health: s.Health, timer: s.Timer, aim: s.Aim, active: true}
+		}
+	}
+	g.scatterProps() // scenery isn't saved; the seed places it again
+
+	for _, s := range run.Enemies {
+		if s.Slot < 0 || s.Slot >= len(g.enemies) {
+			continue
+		}
+		model, scale, yaw, hasModel := g.enemyModel, DefaultEnemyScaleFactor*s.Size, DefaultEnemyYawOffsetDeg, g.modelsLoaded
+		if s.IsBoss {
+			model, scale, yaw = g.bossModel, DefaultBossScaleFactor*s.Size, DefaultBossYawOffsetDeg
+			if g.bossFight.archetype == BossFinal && g.finalBossLoaded {
+				model, hasModel = g.finalBossModel, true
+			}
+		}
+		g.enemies[s.Slot] = Enemy{
+			position: s.Position, velocity: s.Velocity, active: true, health: s.Health, maxHealth: s.MaxHealth,
+			size: s.Size, color: s.Color, isBoss: s.IsBoss, kind: s.Kind, phase: s.Phase,
+			phaseTimer: s.PhaseTimer, facing: s.Facing, shotTimer: s.ShotTimer,
+			model: model, hasModel: hasModel,
+			modelScale: scale, modelYawOffsetDeg: yaw,
+			hitCredited: s.Credited, lastHitBy: s.LastHitBy, lastWeapon: s.LastWeapon,
+		}
+	}
+	for _, s := range run.Bullets {
+		if s.Slot >= 0 && s.Slot < len(g.bullets) {
+			g.bullets[s.Slot] = Bullet{
+				position: s.Position, velocity: s.Velocity, origin: s.Origin, active: true, damage: s.Damage,
+				playerId: s.PlayerID, weapon: s.Weapon, kind: s.Kind, target: s.Target,
+				crit: s.Crit, pierce: s.Pierce, tracked: s.Tracked, landed: s.Landed,
+			}
+			b := &g.bullets[s.Slot]
+			b.hits = copy(b.hit[:], s.Hits)
+		}
+	}
+	for i, s := range run.EnemyBullets {
+		if i < len(g.enemyBullets) {
+			g.enemyBullets[i] = EnemyBullet{position: s.Position, velocity: s.Velocity, damage: s.Damage, lifetime: s.Lifetime, active: true}
+		}
+	}
+	for i, s := range run.Grenades {
+		if i < len(g.grenades) {
+			g.grenades[i] = Grenade{position: s.Position, velocity: s.Velocity, fuse: s.Fuse, playerId: s.PlayerID, damage: s.Damage, active: true}
+		}
+	}
+	for i, s := range run.PowerUps {
+		if i < len(g.powerUps) {
+			g.powerUps[i] = PowerUp{position: s.Position, pType: s.Type, rotation: s.Rotation, weapon: s.Weapon, active: true}
+		}
+	}
+	for i, s := range run.Telegraphs {
+		if i < len(g.telegraphs) {
+			g.telegraphs[i] = Telegraph{position: s.Position, radius: s.Radius, delay: s.Delay, timer: s.Timer, damage: s.Damage, active: true}
+		}
+	}
+	for _, s := range run.Drones {
+		if s.Owner < len(g.players) {
+			g.drones = append(g.drones, Drone{owner: s.Owner, level: s.Level, angle: s.Angle, timer: s.Timer, position: g.players[s.Owner].position})
+		}
+	}
+	for i, s := range run.Shells {
+		if i < len(g.bossShells) {
+			g.bossShells[i] = BossShell{from: s.From, target: s.Target, timer: s.Timer, active: true}
+		}
+	}
+	for i, s := range run.Emitters {
+		if i < len(g.emitters) {
+			g.emitters[i] = BulletEmitter{
+				kind: s.Kind, source: s.Source, timer: s.Timer, interval: s.Interval, shots: s.Shots, angle: s.Angle,
+				spin: s.Spin, arms: s.Arms, spread: s.Spread, speed: s.Speed, damage: s.Damage, active: true,
+			}
+		}
+	}
+}
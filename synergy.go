@@ -0,0 +1,153 @@
+package main
+
+import (
+	"slices"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Traits and synergies. Some upgrades give a trait instead of stats; the
+// combat code asks the player for traits rather than for upgrade names.
+// Owning every trait of a synergy turns on an extra effect, so the order
+// upgrades are picked in starts to matter:
+//
+//   - Piercing Rounds: bullets pass through pierceCount more enemies
+//   - Volatile Crits: critical bullet hits burst, hurting enemies nearby
+//   - Leech: kills heal the player who landed the last hit
+//   - Chain Reaction (Piercing + Volatile): burst kills burst again
+//   - Blood Burst (Leech + Volatile): bursts heal for every enemy they hit
+//
+// Beams and drone shots never crit into bursts; a beam would burst ten
+// times a second.
+type Trait uint8
+
+const (
+	TraitPierce Trait = 1 << iota
+	TraitVolatile
+	TraitLeech
+)
+
+const (
+	pierceCount      = 2
+	burstRadius      = float32(3.0)
+	burstDamageMul   = float32(0.5) // of the hit that set the burst off
+	chainMaxBursts   = 6            // bursts one crit can set off in total
+	leechHeal        = 2
+	bloodBurstHeal   = 1
+	burstParticleNum = 8
+)
+
+type Synergy struct {
+	name  string
+	desc  string
+	needs Trait
+}
+
+var synergies = []Synergy{
+	{name: "Chain Reaction", desc: "Burst kills burst again", needs: TraitPierce | TraitVolatile},
+	{name: "Blood Burst", desc: "Bursts heal per enemy hit", needs: TraitLeech | TraitVolatile},
+}
+
+func (p *Player) hasTraits(t Trait) bool {
+	return p.traits&t == t
+}
+
+// activeSynergies lists the synergies the player has completed.
+func (p *Player) activeSynergies() []Synergy {
+	var out []Synergy
+	for _, s := range synergies {
+		if p.hasTraits(s.needs) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// completesSynergy returns the synergy a trait would finish for the
+// player, or "" if it finishes none.
+func (p *Player) completesSynergy(t Trait) string {
+	if t == 0 || p.hasTraits(t) {
+		return ""
+	}
+	for _, s := range synergies {
+		if s.needs&t != 0 && !p.hasTraits(s.needs) && (p.traits|t)&s.needs == s.needs {
+			return s.name
+		}
+	}
+	return ""
+}
+
+// bulletHitEnemy applies a non-rocket bullet hitting enemy i, then either
+// spends a pierce or retires the bullet.
+func (g *Game) bulletHitEnemy(b *Bullet, i int) {
+	g.CreateExplosion(g.enemies[i].position, rl.Yellow, 5)
+	g.creditHit(i, b.playerId, b.weapon)
+	if b.tracked && !b.landed {
+		g.recordMasteryHit(b.weapon)
+		g.statHit(b.playerId)
+	}
+	b.landed = true
+	damage := bulletDamage(b)
+	at := g.enemies[i].position
+	g.damageEnemy(i, damage, b.playerId)
+
+	if b.playerId < len(g.players) && b.crit && g.players[b.playerId].hasTraits(TraitVolatile) {
+		g.volatileBurst(&g.players[b.playerId], at, damage, b.weapon)
+	}
+	if b.pierce > 0 {
+		b.pierce--
+		if b.hits < len(b.hit) {
+			b.hit[b.hits] = i
+			b.hits++
+		}
+		return
+	}
+	b.active = false
+}
+
+// passedThrough reports whether a piercing bullet already hit enemy i.
+func (b *Bullet) passedThrough(i int) bool {
+	return slices.Contains(b.hit[:b.hits], i)
+}
+
+// volatileBurst sets off a crit burst at center. With Chain Reaction every
+// enemy a burst kills bursts in turn, up to chainMaxBursts.
+func (g *Game) volatileBurst(player *Player, center rl.Vector3, damage int, weapon WeaponType) {
+	chain := player.hasTraits(TraitPierce | TraitVolatile)
+	heal := player.hasTraits(TraitLeech | TraitVolatile)
+	damage = max(1, int(float32(damage)*burstDamageMul))
+
+	g.burstQueue = append(g.burstQueue[:0], center)
+	for n := 0; n < len(g.burstQueue) && n < chainMaxBursts; n++ {
+		pos := g.burstQueue[n]
+		g.CreateExplosion(pos, rl.Orange, burstParticleNum)
+		g.playExplosion()
+		g.burstHits = g.enemiesInRadius(pos.X, pos.Z, burstRadius, g.burstHits[:0])
+		for _, i := range g.burstHits {
+			e := &g.enemies[i]
+			if !e.active {
+				continue
+			}
+			at := e.position
+			g.creditHit(i, player.id, weapon)
+			g.damageEnemy(i, damage, player.id)
+			if heal && player.health > 0 {
+				player.health = min(player.health+bloodBurstHeal, player.stats.maxHealth)
+			}
+			if chain && !e.active {
+				g.burstQueue = append(g.burstQueue, at)
+			}
+		}
+	}
+}
+
+// leechKill heals the player credited with a kill.
+func (g *Game) leechKill(e *Enemy) {
+	if !e.hitCredited || e.lastHitBy < 0 || e.lastHitBy >= len(g.players) {
+		return
+	}
+	p := &g.players[e.lastHitBy]
+	if p.hasTraits(TraitLeech) && p.health > 0 {
+		p.health = min(p.health+leechHeal, p.stats.maxHealth)
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Telegraphed ground attacks. A red circle is marked on the floor and fills
+// in over the warning time; anyone still inside when it lands takes the
+// damage. Bosses use them to force players to move (the Brute's slam and
+// the "aoe" attack in boss scripts); artillery shells share the marker.
+const (
+	maxTelegraphs    = 24
+	telegraphDelay   = float32(1.5) // seconds of warning
+	telegraphRadius  = float32(3.0)
+	telegraphDamage  = 25
+	telegraphScatter = float32(5.0) // how far extra circles land from a player
+)
+
+type Telegraph struct {
+	position rl.Vector3
+	radius   float32
+	delay    float32
+	timer    float32
+	damage   int
+	active   bool
+}
+
+// telegraphAoE marks a circle that deals damage after delay seconds.
+func (g *Game) telegraphAoE(pos rl.Vector3, radius, delay float32, damage int) {
+	for i := range g.telegraphs {
+		if g.telegraphs[i].active {
+			continue
+		}
+		pos.Y = 0
+		g.telegraphs[i] = Telegraph{position: pos, radius: radius, delay: delay, timer: delay, damage: damage, active: true}
+		return
+	}
+}
+
+// telegraphAtPlayers puts one circle under each player and scatters the
+// rest around them, so standing still is never safe.
+func (g *Game) telegraphAtPlayers(count int, radius, delay float32, damage int) {
+	for n := 0; n < count; n++ {
+		pos := g.players[n%len(g.players)].position
+		if n >= len(g.players) {
+			pos.X += (g.rng.Float32()*2 - 1) * telegraphScatter
+			pos.Z += (g.rng.Float32()*2 - 1) * telegraphScatter
+		}
+		g.telegraphAoE(pos, radius, delay, damage)
+	}
+}
+
+func (g *Game) updateTelegraphs(dt float32) {
+	for i := range g.telegraphs {
+		t := &g.telegraphs[i]
+		if !t.active {
+			continue
+		}
+		t.timer -= dt
+		if t.timer > 0 {
+			continue
+		}
+		t.active = false
+		g.aoeDamage(t.position, t.radius, t.damage)
+	}
+}
+
+// aoeDamage lands a ground blast, hurting every player inside radius.
+func (g *Game) aoeDamage(center rl.Vector3, radius float32, damage int) {
+	g.CreateExplosion(center, rl.Orange, 20)
+	g.playExplosion()
+	for pIdx := range g.players {
+		player := &g.players[pIdx]
+		dx := player.position.X - center.X
+		dz := player.position.Z - center.Z
+		if dx*dx+dz*dz < radius*radius {
+			g.damagePlayer(player, damage)
+		}
+	}
+}
+
+func (g *Game) clearTelegraphs() {
+	for i := range g.telegraphs {
+		g.telegraphs[i].active = false
+	}
+}
+
+// drawFloorMarker draws a warning circle whose inside fills up as fill goes
+// from 0 to 1.
+func drawFloorMarker(center rl.Vector3, radius, fill float32) {
+	marker := rl.NewVector3(center.X, 0.03, center.Z)
+	// The rim pulses faster as the hit gets closer
+	pulse := 0.6 + 0.4*float32(math.Sin(float64(fill*fill)*40))
+	rl.DrawCylinderWires(marker, radius, radius, 0.01, 24, rl.Fade(rl.Red, pulse))
+	rl.DrawCylinder(marker, radius*fill, radius*fill, 0.01, 24, rl.NewColor(255, 60, 30, 120))
+}
+
+func (g *Game) drawTelegraphs() {
+	for i := range g.telegraphs {
+		t := &g.telegraphs[i]
+		if t.active {
+			drawFloorMarker(t.position, t.radius, 1-t.timer/t.delay)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"unicode"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Text rendering. All UI text goes through drawText and measureText, which
+// split a string into runs by font: each character is drawn with the first
+// face in fontChain that covers it, so Latin, Thai and CJK can share a
+// line. Faces are optional font files in assets/fonts; without them, and
+// for characters no face covers, raylib's built-in font is used as before
+// (it only has Latin-1).
+//
+// Lines that start with Hebrew or Arabic are laid out right to left: the
+// right-to-left stretches are reversed and the line is read from the right,
+// while numbers and Latin words inside keep their own order. Arabic letters
+// are drawn in the form the font maps each base character to; contextual
+// joining is not done yet.
+const (
+	fontDir      = "assets/fonts"
+	fontLoadSize = 48 // glyph atlas size; text is scaled from it
+)
+
+// CodeRange is an inclusive range of code points.
+type CodeRange struct{ lo, hi rune }
+
+// FontFace is one font in the fallback chain.
+type FontFace struct {
+	name   string
+	file   string
+	ranges []CodeRange
+	// onDemand faces cover too many characters to load up front (CJK):
+	// glyphs are added as text needs them and the atlas is rebuilt.
+	onDemand bool
+
+	font   rl.Font
+	loaded bool
+	glyphs map[rune]bool
+}
+
+var fontChain = []*FontFace{
+	{name: "Latin", file: "NotoSans-Regular.ttf", ranges: []CodeRange{{0x20, 0x24F}, {0x370, 0x3FF}, {0x400, 0x4FF}, {0x2000, 0x206F}, {0x20A0, 0x20CF}}},
+	{name: "Thai", file: "NotoSansThai-Regular.ttf", ranges: []CodeRange{{0x0E00, 0x0E7F}}},
+	{name: "Hebrew", file: "NotoSansHebrew-Regular.ttf", ranges: []CodeRange{{0x0590, 0x05FF}, {0xFB1D, 0xFB4F}}},
+	{name: "Arabic", file: "NotoSansArabic-Regular.ttf", ranges: []CodeRange{{0x0600, 0x06FF}, {0x0750, 0x077F}, {0xFB50, 0xFDFF}, {0xFE70, 0xFEFF}}},
+	{name: "CJK", file: "NotoSansCJK-Regular.otf", onDemand: true, ranges: []CodeRange{
+		{0x3000, 0x30FF}, // punctuation, hiragana, katakana
+		{0x3400, 0x4DBF},
+		{0x4E00, 0x9FFF},
+		{0xAC00, 0xD7AF}, // hangul
+		{0xFF00, 0xFFEF}, // full-width forms
+	}},
+}
+
+// rtlRanges are the scripts written right to left.
+var rtlRanges = []CodeRange{{0x0590, 0x08FF}, {0xFB1D, 0xFDFF}, {0xFE70, 0xFEFF}}
+
+// mirrored brackets swap sides inside right-to-left text.
+var mirrored = map[rune]rune{'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '<': '>', '>': '<'}
+
+func inRanges(r rune, ranges []CodeRange) bool {
+	for _, cr := range ranges {
+		if r >= cr.lo && r <= cr.hi {
+			return true
+		}
+	}
+	return false
+}
+
+// loadFonts loads the faces present in assets/fonts. Called once after the
+// window opens.
+func loadFonts() {
+	for _, f := range fontChain {
+		path := fontDir + "/" + f.file
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if f.onDemand {
+			// Nothing to load until text asks for a glyph
+			f.glyphs = map[rune]bool{}
+			f.loaded = true
+			continue
+		}
+		var codepoints []rune
+		for _, cr := range f.ranges {
+			for r := cr.lo; r <= cr.hi; r++ {
+				codepoints = append(codepoints, r)
+			}
+		}
+		f.font = rl.LoadFontEx(path, fontLoadSize, codepoints)
+		if !rl.IsFontValid(f.font) {
+			fmt.Println("Warning: Could not load font:", path)
+			continue
+		}
+		rl.SetTextureFilter(f.font.Texture, rl.FilterBilinear)
+		f.loaded = true
+	}
+}
+
+func unloadFonts() {
+	for _, f := range fontChain {
+		if f.loaded && rl.IsFontValid(f.font) {
+			rl.UnloadFont(f.font)
+		}
+		f.loaded = false
+	}
+}
+
+// ensureGlyphs rebuilds an on-demand face's atlas when text needs glyphs
+// it does not have yet.
+func (f *FontFace) ensureGlyphs(text []rune) {
+	missing := false
+	for _, r := range text {
+		if !f.glyphs[r] {
+			f.glyphs[r] = true
+			missing = true
+		}
+	}
+	if !missing {
+		return
+	}
+	codepoints := make([]rune, 0, len(f.glyphs))
+	for r := range f.glyphs {
+		codepoints = append(codepoints, r)
+	}
+	sort.Slice(codepoints, func(i, j int) bool { return codepoints[i] < codepoints[j] })
+	if rl.IsFontValid(f.font) {
+		rl.UnloadFont(f.font)
+	}
+	f.font = rl.LoadFontEx(fontDir+"/"+f.file, fontLoadSize, codepoints)
+	rl.SetTextureFilter(f.font.Texture, rl.FilterBilinear)
+}
+
+// faceFor returns the face that draws r, or nil for the built-in font.
+func faceFor(r rune) *FontFace {
+	for _, f := range fontChain {
+		if f.loaded && inRanges(r, f.ranges) {
+			return f
+		}
+	}
+	return nil
+}
+
+// textRun is a stretch of text drawn with one face.
+type textRun struct {
+	face *FontFace
+	text []rune
+}
+
+// plainText reports whether the built-in font draws the whole string as
+// raylib would, so the common case skips splitting.
+func plainText(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			return false
+		}
+	}
+	return !fontChain[0].loaded
+}
+
+// textRuns puts the string in display order and splits it by face.
+func textRuns(text string) []textRun {
+	var runs []textRun
+	for _, r := range visualOrder([]rune(text)) {
+		face := faceFor(r)
+		if n := len(runs); n > 0 && runs[n-1].face == face {
+			runs[n-1].text = append(runs[n-1].text, r)
+			continue
+		}
+		runs = append(runs, textRun{face: face, text: []rune{r}})
+	}
+	for _, run := range runs {
+		if run.face != nil && run.face.onDemand {
+			run.face.ensureGlyphs(run.text)
+		}
+	}
+	return runs
+}
+
+// textMetrics matches rl.DrawText: at least 10 px, spacing a tenth of the
+// size.
+func textMetrics(fontSize int32) (size, spacing float32) {
+	size = float32(max(fontSize, 10))
+	return size, float32(int32(size) / 10)
+}
+
+func runFont(run textRun) rl.Font {
+	if run.face == nil {
+		return rl.GetFontDefault()
+	}
+	return run.face.font
+}
+
+// drawText is rl.DrawText with font fallback and right-to-left layout.
+func drawText(text string, posX, posY, fontSize int32, color rl.Color) {
+	if plainText(text) {
+		rl.DrawText(text, posX, posY, fontSize, color)
+		return
+	}
+	size, spacing := textMetrics(fontSize)
+	pos := rl.NewVector2(float32(posX), float32(posY))
+	for _, run := range textRuns(text) {
+		s := string(run.text)
+		font := runFont(run)
+		rl.DrawTextEx(font, s, pos, size, spacing, color)
+		pos.X += rl.MeasureTextEx(font, s, size, spacing).X + spacing
+	}
+}
+
+// measureText is rl.MeasureText for text drawn with drawText.
+func measureText(text string, fontSize int32) int32 {
+	if plainText(text) {
+		return rl.MeasureText(text, fontSize)
+	}
+	size, spacing := textMetrics(fontSize)
+	width := float32(0)
+	runs := textRuns(text)
+	for i, run := range runs {
+		width += rl.MeasureTextEx(runFont(run), string(run.text), size, spacing).X
+		if i > 0 {
+			width += spacing
+		}
+	}
+	return int32(width)
+}
+
+type textDir int
+
+const (
+	dirNeutral textDir = iota
+	dirLTR
+	dirRTL
+)
+
+// direction classifies a character for layout. Digits count as
+// left-to-right: numbers read the same way in every script.
+func direction(r rune) textDir {
+	switch {
+	case inRanges(r, rtlRanges):
+		return dirRTL
+	case unicode.IsLetter(r) || unicode.IsDigit(r):
+		return dirLTR
+	}
+	return dirNeutral
+}
+
+// visualOrder reorders a line for display. A line whose first strong
+// character is right to left is mirrored as a whole, then its
+// left-to-right stretches are flipped back; otherwise only the
+// right-to-left stretches are reversed. Spaces and punctuation between two
+// characters of the same direction go with them, anything else follows the
+// line.
+func visualOrder(text []rune) []rune {
+	base := dirNeutral
+	hasRTL := false
+	for _, r := range text {
+		d := direction(r)
+		if base == dirNeutral {
+			base = d
+		}
+		if d == dirRTL {
+			hasRTL = true
+		}
+	}
+	if !hasRTL {
+		return text
+	}
+	if base == dirNeutral {
+		base = dirLTR
+	}
+
+	// Resolve neutrals: between two characters of one direction they take
+	// it, otherwise the line's
+	dirs := make([]textDir, len(text))
+	for i, r := range text {
+		dirs[i] = direction(r)
+	}
+	for i := 0; i < len(dirs); {
+		if dirs[i] != dirNeutral {
+			i++
+			continue
+		}
+		j := i
+		for j < len(dirs) && dirs[j] == dirNeutral {
+			j++
+		}
+		d := base
+		if i > 0 && j < len(dirs) && dirs[i-1] == dirs[j] {
+			d = dirs[j]
+		}
+		for k := i; k < j; k++ {
+			dirs[k] = d
+		}
+		i = j
+	}
+
+	out := append([]rune(nil), text...)
+	if base == dirRTL {
+		reverseRunes(out)
+		reverseDirs(dirs)
+	}
+	// Flip the stretches that run against the line
+	for i := 0; i < len(out); {
+		j := i
+		for j < len(out) && dirs[j] == dirs[i] {
+			j++
+		}
+		if dirs[i] != base {
+			reverseRunes(out[i:j])
+		}
+		i = j
+	}
+	for i, r := range out {
+		if dirs[i] == dirRTL {
+			if m, ok := mirrored[r]; ok {
+				out[i] = m
+			}
+		}
+	}
+	return out
+}
+
+func reverseRunes(s []rune) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+func reverseDirs(s []textDir) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Stage textures. Each stage can have a floor and a wall texture in
+// assets/textures/<stage>_floor.png and <stage>_wall.png (stage directory
+// names as in streaming.go). They tile by world size rather than stretch,
+// so a wall looks the same whatever its length. A stage without the files
+// keeps the flat colours. Textures go through the asset cache and are
+// swapped when the stage changes.
+const (
+	floorTile = float32(4.0) // world units per floor texture repeat
+	wallTile  = float32(2.0)
+)
+
+type StageTextures struct {
+	stage     StageType
+	loaded    bool // stage's textures have been looked up
+	floor     rl.Texture2D
+	wall      rl.Texture2D
+	floorPath string
+	wallPath  string
+}
+
+// stageTextures returns the current stage's textures, looking them up the
+// first time a stage is drawn. ID 0 means none.
+func (g *Game) stageTextures() *StageTextures {
+	t := &g.textures
+	if t.loaded && t.stage == g.currentStage {
+		return t
+	}
+	t.release(g.assets)
+	t.stage, t.loaded = g.currentStage, true
+	name := stageDirNames[g.currentStage]
+	t.floor, t.floorPath = loadTiledTexture(g.assets, fmt.Sprintf("assets/textures/%s_floor.png", name))
+	t.wall, t.wallPath = loadTiledTexture(g.assets, fmt.Sprintf("assets/textures/%s_wall.png", name))
+	return t
+}
+
+// loadTiledTexture loads a repeating texture, or returns ID 0 and no path
+// when the file is missing.
+func loadTiledTexture(assets *AssetCache, path string) (rl.Texture2D, string) {
+	if !fileExists(path) {
+		return rl.Texture2D{}, ""
+	}
+	tex := assets.loadTexture(path)
+	if tex.ID == 0 {
+		fmt.Println("Warning: Could not load", path)
+		return tex, ""
+	}
+	rl.SetTextureWrap(tex, rl.WrapRepeat)
+	rl.SetTextureFilter(tex, rl.FilterBilinear)
+	fmt.Println("✓ Loaded:", path)
+	return tex, path
+}
+
+// release hands the textures back to the cache.
+func (t *StageTextures) release(assets *AssetCache) {
+	for _, path := range []string{t.floorPath, t.wallPath} {
+		if path != "" {
+			assets.release(path)
+		}
+	}
+	*t = StageTextures{}
+}
+
+// drawFloor draws the stage floor, textured when the stage has one.
+func (g *Game) drawFloor() {
+	size := g.stageHalf * 2
+	tex := g.stageTextures().floor
+	if tex.ID == 0 {
+		rl.DrawPlane(rl.NewVector3(0, 0, 0), rl.NewVector2(size, size), g.floorColor())
+		return
+	}
+	half, uv := g.stageHalf, size/floorTile
+	rl.SetTexture(tex.ID)
+	rl.Begin(rl.Quads)
+	rl.Color4ub(255, 255, 255, 255)
+	rl.Normal3f(0, 1, 0)
+	rl.TexCoord2f(0, 0)
+	rl.Vertex3f(-half, 0, -half)
+	rl.TexCoord2f(0, uv)
+	rl.Vertex3f(-half, 0, half)
+	rl.TexCoord2f(uv, uv)
+	rl.Vertex3f(half, 0, half)
+	rl.TexCoord2f(uv, 0)
+	rl.Vertex3f(half, 0, -half)
+	rl.End()
+	rl.SetTexture(0)
+}
+
+// drawWall draws a wall obstacle, textured when the stage has one.
+func (g *Game) drawWall(obs *Obstacle) {
+	tex := g.stageTextures().wall
+	if tex.ID == 0 {
+		rl.DrawCube(obs.position, obs.size.X, obs.size.Y, obs.size.Z, rl.NewColor(100, 100, 120, 255))
+		rl.DrawCubeWires(obs.position, obs.size.X, obs.size.Y, obs.size.Z, rl.White)
+		return
+	}
+	drawTiledCube(tex, obs.position, obs.size, wallTile)
+}
+
+// drawTiledCube draws a box with tex repeated every tile world units on
+// each face.
+func drawTiledCube(tex rl.Texture2D, pos, size rl.Vector3, tile float32) {
+	x0, x1 := pos.X-size.X/2, pos.X+size.X/2
+	y0, y1 := pos.Y-size.Y/2, pos.Y+size.Y/2
+	z0, z1 := pos.Z-size.Z/2, pos.Z+size.Z/2
+	u, v, w := size.X/tile, size.Y/tile, size.Z/tile
+
+	face := func(n rl.Vector3, corners [4][3]float32, su, sv float32) {
+		rl.Normal3f(n.X, n.Y, n.Z)
+		uvs := [4][2]float32{{0, sv}, {su, sv}, {su, 0}, {0, 0}}
+		for i, c := range corners {
+			rl.TexCoord2f(uvs[i][0], uvs[i][1])
+			rl.Vertex3f(c[0], c[1], c[2])
+		}
+	}
+
+	rl.SetTexture(tex.ID)
+	rl.Begin(rl.Quads)
+	rl.Color4ub(255, 255, 255, 255)
+	// Corners counter-clockwise seen from outside, bottom left first
+	face(rl.NewVector3(0, 0, 1), [4][3]float32{{x0, y0, z1}, {x1, y0, z1}, {x1, y1, z1}, {x0, y1, z1}}, u, v)
+	face(rl.NewVector3(0, 0, -1), [4][3]float32{{x1, y0, z0}, {x0, y0, z0}, {x0, y1, z0}, {x1, y1, z0}}, u, v)
+	face(rl.NewVector3(1, 0, 0), [4][3]float32{{x1, y0, z1}, {x1, y0, z0}, {x1, y1, z0}, {x1, y1, z1}}, w, v)
+	face(rl.NewVector3(-1, 0, 0), [4][3]float32{{x0, y0, z0}, {x0, y0, z1}, {x0, y1, z1}, {x0, y1, z0}}, w, v)
+	face(rl.NewVector3(0, 1, 0), [4][3]float32{{x0, y1, z1}, {x1, y1, z1}, {x1, y1, z0}, {x0, y1, z0}}, u, w)
+	face(rl.NewVector3(0, -1, 0), [4][3]float32{{x0, y0, z0}, {x1, y0, z0}, {x1, y0, z1}, {x0, y0, z1}}, u, w)
+	rl.End()
+	rl.SetTexture(0)
+}
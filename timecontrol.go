@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Debug time controls (-debug only, while playing). F5 freezes the
+// simulation but keeps rendering, F6 advances one fixed tick, F7 cycles the
+// speed. Useful for watching collisions and AI frame by frame.
+const debugTick = float32(1.0 / 60)
+
+var timeScales = []float32{1, 0.25, 4}
+
+type TimeControl struct {
+	frozen bool
+	scale  int // index into timeScales
+}
+
+// simDelta returns the timestep to simulate this frame, or false to skip
+// the update entirely.
+func (g *Game) simDelta(dt float32) (float32, bool) {
+	if !g.debugTools || g.state != StatePlaying {
+		return dt, true
+	}
+	tc := &g.time
+
+	if rl.IsKeyPressed(rl.KeyF5) {
+		tc.frozen = !tc.frozen
+	}
+	if rl.IsKeyPressed(rl.KeyF7) {
+		tc.scale = (tc.scale + 1) % len(timeScales)
+	}
+	if rl.IsKeyPressed(rl.KeyF6) {
+		tc.frozen = true
+		return debugTick, true
+	}
+
+	if tc.frozen {
+		return 0, false
+	}
+	return dt * timeScales[tc.scale], true
+}
+
+// simFrozen reports whether the simulation, and with it the game's
+// Update, is held by F5.
+func (g *Game) simFrozen() bool {
+	return g.debugTools && g.state == StatePlaying && g.time.frozen
+}
+
+func (g *Game) drawTimeControl() {
+	if !g.debugTools || (g.state != StatePlaying && g.state != StatePaused) {
+		return
+	}
+	tc := g.time
+	if !tc.frozen && tc.scale == 0 {
+		return
+	}
+
+	text := fmt.Sprintf("SIM %.2gx", timeScales[tc.scale])
+	if tc.frozen {
+		text = "SIM FROZEN  F5 resume | F6 step"
+	}
+	drawText(text, screenWidth/2-measureText(text, 24)/2, 60, 24, rl.Orange)
+}
@@ -0,0 +1,320 @@
+// Command release builds distributable zips of the game, one per platform:
+//
+//	go run ./tools/release -version 1.4.0
+//	go run ./tools/release -version 1.4.0 -platforms linux/amd64
+//
+// Each zip holds the binary, the assets folder with a manifest.json the
+// game checks on first start (assetcheck.go), and the platform's icon:
+//
+//   - windows: shutorary.exe with the icon embedded when go-winres is
+//     installed (go install github.com/tc-hib/go-winres@latest)
+//   - darwin: Shutorary.app with assets and icon.icns in Contents/Resources
+//   - linux: the binary, a .desktop entry and icon.png
+//
+// Icons are read from assets/icon (icon.png, icon.icns); a missing icon only
+// warns. raylib needs cgo, so building for another OS needs a C cross
+// compiler in CC for that target (zig cc works for all three).
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	appName    = "Shutorary"
+	binaryName = "shutorary"
+	assetsDir  = "assets"
+	iconDir    = "assets/icon"
+)
+
+// Kept in step with AssetManifest in assetcheck.go
+type manifest struct {
+	Version string                   `json:"version"`
+	Files   map[string]manifestEntry `json:"files"`
+}
+
+type manifestEntry struct {
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+func main() {
+	version := flag.String("version", "dev", "version stamped into the binary and the zip names")
+	platforms := flag.String("platforms", "windows/amd64,darwin/arm64,darwin/amd64,linux/amd64", "comma-separated GOOS/GOARCH list")
+	out := flag.String("out", "dist", "output directory")
+	flag.Parse()
+
+	if _, err := os.Stat(assetsDir); err != nil {
+		fail("run from the repository root: %v", err)
+	}
+	files, err := hashAssets(*version)
+	if err != nil {
+		fail("hashing assets: %v", err)
+	}
+
+	failed := 0
+	for _, platform := range strings.Split(*platforms, ",") {
+		goos, goarch, ok := strings.Cut(strings.TrimSpace(platform), "/")
+		if !ok {
+			fail("bad platform %q, want GOOS/GOARCH", platform)
+		}
+		zipPath, err := packagePlatform(goos, goarch, *version, *out, files)
+		if err != nil {
+			fmt.Printf("✗ %s/%s: %v\n", goos, goarch, err)
+			failed++
+			continue
+		}
+		fmt.Println("✓ Packaged:", zipPath)
+	}
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func fail(format string, args ...any) {
+	fmt.Printf("✗ "+format+"\n", args...)
+	os.Exit(1)
+}
+
+// hashAssets builds the manifest for everything under assets/.
+func hashAssets(version string) (manifest, error) {
+	m := manifest{Version: version, Files: map[string]manifestEntry{}}
+	err := filepath.WalkDir(assetsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() == "manifest.json" {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		n, err := io.Copy(h, f)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(assetsDir, path)
+		m.Files[filepath.ToSlash(rel)] = manifestEntry{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}
+		return nil
+	})
+	return m, err
+}
+
+// packagePlatform builds the binary for one platform and zips it with the
+// assets laid out the way that platform expects.
+func packagePlatform(goos, goarch, version, out string, assets manifest) (string, error) {
+	stage, err := os.MkdirTemp("", "release-")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(stage)
+
+	// root is the folder inside the zip; resources is where assets go
+	root := fmt.Sprintf("%s-%s-%s-%s", binaryName, version, goos, goarch)
+	binDir := filepath.Join(stage, root)
+	resources := binDir
+	exe := binaryName
+	switch goos {
+	case "windows":
+		exe += ".exe"
+	case "darwin":
+		app := filepath.Join(binDir, appName+".app", "Contents")
+		binDir, resources = filepath.Join(app, "MacOS"), filepath.Join(app, "Resources")
+		if err := writeInfoPlist(app, version); err != nil {
+			return "", err
+		}
+	}
+
+	if err := build(goos, goarch, version, filepath.Join(binDir, exe)); err != nil {
+		return "", err
+	}
+	if err := copyTree(assetsDir, filepath.Join(resources, assetsDir)); err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(assets, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(resources, assetsDir, "manifest.json"), data, 0644); err != nil {
+		return "", err
+	}
+
+	switch goos {
+	case "darwin":
+		copyIcon("icon.icns", filepath.Join(resources, "icon.icns"))
+	case "linux":
+		copyIcon("icon.png", filepath.Join(binDir, binaryName+".png"))
+		desktop := fmt.Sprintf("[Desktop Entry]\nType=Application\nName=%s\nExec=%s\nIcon=%s\nCategories=Game;\n", appName, binaryName, binaryName)
+		if err := os.WriteFile(filepath.Join(binDir, binaryName+".desktop"), []byte(desktop), 0644); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.MkdirAll(out, os.ModePerm); err != nil {
+		return "", err
+	}
+	zipPath := filepath.Join(out, root+".zip")
+	return zipPath, zipTree(stage, zipPath)
+}
+
+// build compiles the game. On Windows the icon resource is generated next
+// to the sources for the build and removed afterwards.
+func build(goos, goarch, version, exe string) error {
+	ldflags := "-s -w -X main.version=" + version
+	if goos == "windows" {
+		ldflags += " -H windowsgui"
+		cleanup, err := windowsResources(goarch, version)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+
+	cmd := exec.Command("go", "build", "-trimpath", "-ldflags", ldflags, "-o", exe, ".")
+	cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=1")
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go build: %w (cross builds need CC set to a C compiler for %s/%s)", err, goos, goarch)
+	}
+	return nil
+}
+
+// windowsResources writes rsrc_windows_<arch>.syso with the icon and
+// version info. Without go-winres or an icon the exe keeps the default icon.
+func windowsResources(goarch, version string) (func(), error) {
+	icon := filepath.Join(iconDir, "icon.png")
+	if _, err := os.Stat(icon); err != nil {
+		fmt.Println("Warning: No", icon, "- the exe will have the default icon")
+		return func() {}, nil
+	}
+	if _, err := exec.LookPath("go-winres"); err != nil {
+		fmt.Println("Warning: go-winres not installed - the exe will have the default icon")
+		return func() {}, nil
+	}
+	cmd := exec.Command("go-winres", "simply", "--arch", goarch, "--icon", icon,
+		"--product-name", appName, "--product-version", version, "--file-version", version, "--manifest", "gui")
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("go-winres: %w", err)
+	}
+	syso := "rsrc_windows_" + goarch + ".syso"
+	return func() { os.Remove(syso) }, nil
+}
+
+func writeInfoPlist(contents, version string) error {
+	plist := `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleName</key><string>` + appName + `</string>
+	<key>CFBundleExecutable</key><string>` + binaryName + `</string>
+	<key>CFBundleIdentifier</key><string>com.newmoodlev.shutorary</string>
+	<key>CFBundleIconFile</key><string>icon.icns</string>
+	<key>CFBundlePackageType</key><string>APPL</string>
+	<key>CFBundleShortVersionString</key><string>` + version + `</string>
+	<key>NSHighResolutionCapable</key><true/>
+</dict>
+</plist>
+`
+	if err := os.MkdirAll(contents, os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(contents, "Info.plist"), []byte(plist), 0644)
+}
+
+func copyIcon(name, dst string) {
+	src := filepath.Join(iconDir, name)
+	if err := copyFile(src, dst); err != nil {
+		fmt.Println("Warning: No", src, "- packaging without an icon")
+	}
+}
+
+func copyTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), os.ModePerm)
+		}
+		return copyFile(path, filepath.Join(dst, rel))
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), os.ModePerm); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// zipTree zips everything under dir. Binaries keep their execute bit so
+// the game starts straight after unzipping on macOS and Linux.
+func zipTree(dir, zipPath string) error {
+	f, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := zip.NewWriter(f)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(dir, path)
+		header.Name = filepath.ToSlash(rel)
+		header.Method = zip.Deflate
+		if base := filepath.Base(path); base == binaryName || base == binaryName+".exe" {
+			header.SetMode(0755)
+		}
+		dst, err := w.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(dst, src)
+		return err
+	})
+	if err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
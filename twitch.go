@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"strings"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Twitch chat integration (optional, enabled with -twitch <channel>).
+// Chat is read anonymously over IRC; viewers vote with !swarm, !hazard or
+// !powerup and the winning option is applied when the voting round closes.
+// Chat picks its targets with its own rng and sits out seeded and daily
+// runs, so a seed always plays the same run. Its hazard pads go away again
+// after chatHazardLife.
+const (
+	twitchAddr        = "irc.chat.twitch.tv:6667"
+	twitchVoteWindow  = float32(15.0) // seconds a voting round stays open
+	twitchActionDelay = float32(20.0) // minimum seconds between chat actions
+	twitchUserDelay   = 10 * time.Second
+	maxAttributions   = 4
+	chatHazardLife    = float32(20.0)
+)
+
+// ChatVote is a single vote parsed from chat.
+type ChatVote struct {
+	user   string
+	option string
+}
+
+type ChatAttribution struct {
+	text     string
+	lifetime float32
+}
+
+type TwitchChat struct {
+	channel string
+	votes   chan ChatVote
+
+	tally       map[string]int
+	firstVoter  map[string]string
+	voted       map[string]bool
+	lastVote    map[string]time.Time
+	roundOpen   bool
+	roundTimer  float32
+	sinceAction float32
+
+	attributions []ChatAttribution
+
+	rng     *rand.Rand // never the run's rng
+	hazards []ChatHazard
+}
+
+// ChatHazard is a hazard pad chat dropped, in obstacle slot slot.
+type ChatHazard struct {
+	slot     int
+	position rl.Vector3
+	life     float32
+}
+
+// chatOptions lists the vote commands and what they do.
+var chatOptions = map[string]func(g *Game){
+	"swarm":   (*Game).chatSpawnSwarm,
+	"hazard":  (*Game).chatSpawnHazard,
+	"powerup": (*Game).chatDropPowerUp,
+}
+
+func NewTwitchChat(channel string) *TwitchChat {
+	t := &TwitchChat{
+		channel:     strings.ToLower(strings.TrimPrefix(channel, "#")),
+		votes:       make(chan ChatVote, 64),
+		tally:       map[string]int{},
+		firstVoter:  map[string]string{},
+		voted:       map[string]bool{},
+		lastVote:    map[string]time.Time{},
+		sinceAction: twitchActionDelay,
+		rng:         rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	go t.run()
+	return t
+}
+
+// run keeps a read-only IRC connection alive, reconnecting with backoff.
+func (t *TwitchChat) run() {
+	backoff := time.Second
+	for {
+		err := t.listen()
+		fmt.Println("Warning: Twitch chat disconnected:", err)
+		time.Sleep(backoff)
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
+
+func (t *TwitchChat) listen() error {
+	conn, err := net.DialTimeout("tcp", twitchAddr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// justinfan* is Twitch's anonymous read-only login
+	fmt.Fprintf(conn, "NICK justinfan%d\r\n", 10000+time.Now().Unix()%80000)
+	fmt.Fprintf(conn, "JOIN #%s\r\n", t.channel)
+	fmt.Println("✓ Twitch chat: joined #" + t.channel)
+
+	reader := bufio.NewReader(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(6 * time.Minute))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if strings.HasPrefix(line, "PING") {
+			fmt.Fprintf(conn, "PONG%s\r\n", strings.TrimPrefix(line, "PING"))
+			continue
+		}
+		if vote, ok := parseChatVote(line); ok {
+			select {
+			case t.votes <- vote:
+			default: // game is not draining votes; drop instead of blocking
+			}
+		}
+	}
+}
+
+// parseChatVote extracts a vote from ":user!user@host PRIVMSG #chan :!option".
+func parseChatVote(line string) (ChatVote, bool) {
+	if !strings.HasPrefix(line, ":") {
+		return ChatVote{}, false
+	}
+	parts := strings.SplitN(line[1:], " ", 4)
+	if len(parts) < 4 || parts[1] != "PRIVMSG" {
+		return ChatVote{}, false
+	}
+
+	user := parts[0]
+	if bang := strings.Index(user, "!"); bang >= 0 {
+		user = user[:bang]
+	}
+	msg := strings.TrimPrefix(parts[3], ":")
+	if !strings.HasPrefix(msg, "!") {
+		return ChatVote{}, false
+	}
+
+	option := strings.ToLower(strings.Fields(msg[1:] + " ")[0])
+	if _, ok := chatOptions[option]; !ok {
+		return ChatVote{}, false
+	}
+	return ChatVote{user: user, option: option}, true
+}
+
+// updateTwitch drains chat votes and resolves voting rounds.
+func (g *Game) updateTwitch(dt float32) {
+	t := g.twitch
+	if t == nil {
+		return
+	}
+
+	for i := len(t.attributions) - 1; i >= 0; i-- {
+		t.attributions[i].lifetime -= dt
+		if t.attributions[i].lifetime <= 0 {
+			t.attributions = append(t.attributions[:i], t.attributions[i+1:]...)
+		}
+	}
+
+	t.sinceAction += dt
+	g.expireChatHazards(dt)
+
+drain:
+	for {
+		select {
+		case vote := <-t.votes:
+			g.castChatVote(vote)
+		default:
+			break drain
+		}
+	}
+
+	if !t.roundOpen {
+		return
+	}
+	t.roundTimer -= dt
+	if t.roundTimer > 0 {
+		return
+	}
+
+	// Close the round and apply the most voted option
+	winner, best := "", 0
+	for option, count := range t.tally {
+		if count > best || (count == best && option < winner) {
+			winner, best = option, count
+		}
+	}
+	if winner != "" && !g.seeded {
+		chatOptions[winner](g)
+		g.addChatAttribution(fmt.Sprintf("Chat voted %s (%d) - first: %s",
+			strings.ToUpper(winner), best, t.firstVoter[winner]))
+	}
+
+	t.roundOpen = false
+	t.sinceAction = 0
+	t.tally = map[string]int{}
+	t.firstVoter = map[string]string{}
+	t.voted = map[string]bool{}
+}
+
+// castChatVote applies the rate limits and counts a vote.
+func (g *Game) castChatVote(vote ChatVote) {
+	t := g.twitch
+	if g.state != StatePlaying || g.seeded {
+		return
+	}
+
+	// Per-user cooldown and one vote per round
+	now := time.Now()
+	if now.Sub(t.lastVote[vote.user]) < twitchUserDelay || t.voted[vote.user] {
+		return
+	}
+
+	// Global cooldown between chat actions
+	if !t.roundOpen {
+		if t.sinceAction < twitchActionDelay {
+			return
+		}
+		t.roundOpen = true
+		t.roundTimer = twitchVoteWindow
+		g.addChatAttribution(fmt.Sprintf("%s started a vote: !swarm !hazard !powerup", vote.user))
+	}
+
+	t.lastVote[vote.user] = now
+	t.voted[vote.user] = true
+	t.tally[vote.option]++
+	if _, ok := t.firstVoter[vote.option]; !ok {
+		t.firstVoter[vote.option] = vote.user
+	}
+}
+
+func (g *Game) addChatAttribution(text string) {
+	t := g.twitch
+	t.attributions = append(t.attributions, ChatAttribution{text: text, lifetime: 6.0})
+	if len(t.attributions) > maxAttributions {
+		t.attributions = t.attributions[1:]
+	}
+}
+
+// chatSpawnSwarm brings in five enemies through the normal spawner.
+func (g *Game) chatSpawnSwarm() {
+	for i := 0; i < 5; i++ {
+		g.SpawnEnemy()
+	}
+}
+
+// chatSpawnHazard drops a hazard pad near a random player.
+func (g *Game) chatSpawnHazard() {
+	t := g.twitch
+	target := g.players[t.rng.Intn(len(g.players))]
+	for i := range g.obstacles {
+		if g.obstacles[i].active {
+			continue
+		}
+		angle := t.rng.Float64() * 2 * math.Pi
+		g.obstacles[i] = Obstacle{
+			position: rl.NewVector3(
+				target.position.X+float32(math.Cos(angle))*6,
+				0.5,
+				target.position.Z+float32(math.Sin(angle))*6,
+			),
+			size:    rl.NewVector3(3, 1, 3),
+			active:  true,
+			obsType: 1,
+		}
+		t.hazards = append(t.hazards, ChatHazard{slot: i, position: g.obstacles[i].position, life: chatHazardLife})
+		return
+	}
+}
+
+// expireChatHazards removes chat's hazard pads once their time is up. A
+// slot the stage has reused since is left alone.
+func (g *Game) expireChatHazards(dt float32) {
+	t := g.twitch
+	for i := len(t.hazards) - 1; i >= 0; i-- {
+		h := &t.hazards[i]
+		if h.life -= dt; h.life > 0 {
+			continue
+		}
+		if obs := &g.obstacles[h.slot]; obs.active && obs.obsType == 1 && obs.position == h.position {
+			obs.active = false
+		}
+		t.hazards = append(t.hazards[:i], t.hazards[i+1:]...)
+	}
+}
+
+// chatDropPowerUp places a power-up next to a random player.
+func (g *Game) chatDropPowerUp() {
+	t := g.twitch
+	target := g.players[t.rng.Intn(len(g.players))]
+	for i := range g.powerUps {
+		if !g.powerUps[i].active {
+			g.powerUps[i].position = target.position
+			g.powerUps[i].position.X += 4
+			g.powerUps[i].position.Y = 1
+			g.powerUps[i].pType = t.rng.Intn(3)
+			g.powerUps[i].active = true
+			return
+		}
+	}
+}
+
+// drawTwitch shows the open vote and recent chat attributions.
+func (g *Game) drawTwitch() {
+	t := g.twitch
+	if t == nil {
+		return
+	}
+
+	x := int32(screenWidth - 560)
+	y := int32(70)
+
+	if t.roundOpen {
+		rl.DrawRectangle(x-10, y-5, 550, 30, rl.NewColor(100, 65, 165, 180))
+		drawText(fmt.Sprintf("CHAT VOTE %.0fs  swarm:%d  hazard:%d  powerup:%d",
+			t.roundTimer, t.tally["swarm"], t.tally["hazard"], t.tally["powerup"]), x, y, 18, rl.White)
+		y += 35
+	}
+
+	for _, a := range t.attributions {
+		alpha := float32(math.Min(1.0, float64(a.lifetime)))
+		drawText(a.text, x, y, 16, rl.Fade(rl.NewColor(185, 145, 255, 255), alpha))
+		y += 22
+	}
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Update checker (opt-in, Settings > Update Check). On launch the latest
+// release is fetched from updateURL in the background; if it is newer than
+// this build a toast appears on the main menu and U opens its release
+// notes. Nothing is downloaded or installed. The choice is kept in
+// save/updates.json. Builds from source are "dev" and never check.
+const (
+	updateURL       = "https://api.github.com/repos/NewmoodLev/Shutorary/releases/latest"
+	updatesFile     = saveDir + "/updates.json"
+	updateTimeout   = 10 * time.Second
+	updateNoteWidth = 1000 // pixels of release notes per line
+	updateNoteRows  = 16
+	updateNoteSize  = 22
+)
+
+// Release is the part of the releases endpoint we read.
+type Release struct {
+	Tag   string `json:"tag_name"`
+	Notes string `json:"body"`
+	URL   string `json:"html_url"`
+}
+
+type UpdateCheck struct {
+	Enabled bool `json:"enabled"`
+
+	result    chan Release
+	available *Release
+	lines     []string // wrapped release notes
+	panelOpen bool
+	scroll    int
+}
+
+func (g *Game) loadUpdateCheck() {
+	data, err := os.ReadFile(updatesFile)
+	if err != nil {
+		return
+	}
+	if err := json.Unmarshal(data, &g.updates); err != nil {
+		fmt.Println("Warning: Could not read update settings:", err)
+	}
+}
+
+func (g *Game) saveUpdateCheck() {
+	os.MkdirAll(filepath.Dir(updatesFile), os.ModePerm)
+	data, err := json.MarshalIndent(g.updates, "", "  ")
+	if err != nil {
+		fmt.Println("Warning: Could not encode update settings:", err)
+		return
+	}
+	if err := writeFileAtomic(updatesFile, data, 0644); err != nil {
+		fmt.Println("Warning: Could not save update settings:", err)
+	}
+}
+
+// startUpdateCheck fetches the latest release in the background when the
+// player opted in.
+func (g *Game) startUpdateCheck() {
+	u := &g.updates
+	if !u.Enabled || version == "dev" || u.result != nil {
+		return
+	}
+	result := make(chan Release, 1)
+	u.result = result
+	go func() {
+		release, err := fetchLatestRelease()
+		if err != nil {
+			fmt.Println("Warning: Update check failed:", err)
+			return
+		}
+		result <- release
+	}()
+}
+
+func fetchLatestRelease() (Release, error) {
+	client := http.Client{Timeout: updateTimeout}
+	resp, err := client.Get(updateURL)
+	if err != nil {
+		return Release{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Release{}, fmt.Errorf("%s", resp.Status)
+	}
+	var release Release
+	err = json.NewDecoder(resp.Body).Decode(&release)
+	return release, err
+}
+
+// pollUpdateCheck picks up the background result. Called every frame.
+func (g *Game) pollUpdateCheck() {
+	u := &g.updates
+	if u.result == nil {
+		return
+	}
+	select {
+	case release := <-u.result:
+		u.result = nil
+		if newerVersion(release.Tag, version) {
+			u.available = &release
+			u.lines = wrapText(release.Notes, updateNoteSize, updateNoteWidth)
+			fmt.Println("✓ Update available:", release.Tag)
+		}
+	default:
+	}
+}
+
+// newerVersion reports whether tag is a later version than current. Both
+// are dotted numbers with an optional leading "v"; anything after a "-"
+// is ignored.
+func newerVersion(tag, current string) bool {
+	a, b := versionParts(tag), versionParts(current)
+	if a == nil || b == nil {
+		return false
+	}
+	for i := 0; i < max(len(a), len(b)); i++ {
+		x, y := 0, 0
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			return x > y
+		}
+	}
+	return false
+}
+
+func versionParts(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	v, _, _ = strings.Cut(v, "-")
+	var parts []int
+	for _, s := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// wrapText splits text into lines no wider than width at the given size.
+func wrapText(text string, size, width int32) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		line := ""
+		for _, word := range strings.Fields(para) {
+			next := word
+			if line != "" {
+				next = line + " " + word
+			}
+			if line != "" && measureText(next, size) > width {
+				lines = append(lines, line)
+				next = word
+			}
+			line = next
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// updateUpdatePanel handles the release notes panel on the main menu.
+// Returns true while the panel has the input.
+func (g *Game) updateUpdatePanel() bool {
+	u := &g.updates
+	if u.available == nil {
+		return false
+	}
+	if !u.panelOpen {
+		if rl.IsKeyPressed(rl.KeyU) {
+			u.panelOpen, u.scroll = true, 0
+			g.playUISound(g.sounds.uiSelect)
+			return true
+		}
+		return false
+	}
+
+	maxScroll := max(0, len(u.lines)-updateNoteRows)
+	switch {
+	case rl.IsKeyPressed(rl.KeyUp):
+		u.scroll--
+	case rl.IsKeyPressed(rl.KeyDown):
+		u.scroll++
+	case rl.IsKeyPressed(rl.KeyO):
+		rl.OpenURL(u.available.URL)
+	case rl.IsKeyPressed(rl.KeyEscape) || rl.IsKeyPressed(rl.KeyU) || rl.IsKeyPressed(rl.KeyEnter):
+		u.panelOpen = false
+		g.playUISound(g.sounds.uiSelect)
+	}
+	u.scroll -= int(rl.GetMouseWheelMove())
+	u.scroll = max(0, min(u.scroll, maxScroll))
+	return true
+}
+
+// drawUpdateToast shows the update notice, or the notes panel when open.
+func (g *Game) drawUpdateToast() {
+	u := &g.updates
+	if u.available == nil {
+		return
+	}
+	if !u.panelOpen {
+		text := fmt.Sprintf("Update available: %s (U: what's new)", u.available.Tag)
+		w := measureText(text, 20) + 30
+		x, y := int32(screenWidth)-w-20, int32(20)
+		rl.DrawRectangle(x, y, w, 40, rl.NewColor(20, 60, 20, 220))
+		rl.DrawRectangleLinesEx(rl.NewRectangle(float32(x), float32(y), float32(w), 40), 2, rl.Lime)
+		drawText(text, x+15, y+10, 20, rl.White)
+		return
+	}
+
+	const panelW, panelH = updateNoteWidth + 80, updateNoteRows*30 + 170
+	x := int32(screenWidth-panelW) / 2
+	y := int32(screenHeight-panelH) / 2
+	rl.DrawRectangle(0, 0, screenWidth, screenHeight, rl.NewColor(0, 0, 0, 160))
+	rl.DrawRectangle(x, y, panelW, panelH, rl.NewColor(20, 20, 40, 245))
+	rl.DrawRectangleLinesEx(rl.NewRectangle(float32(x), float32(y), panelW, panelH), 2, rl.Lime)
+
+	title := fmt.Sprintf("What's new in %s", u.available.Tag)
+	drawText(title, x+40, y+25, 36, rl.Lime)
+	drawText("You have "+version, x+40, y+70, 20, rl.LightGray)
+
+	for i := 0; i < updateNoteRows && u.scroll+i < len(u.lines); i++ {
+		drawText(u.lines[u.scroll+i], x+40, y+110+int32(i)*30, updateNoteSize, rl.White)
+	}
+	if len(u.lines) > updateNoteRows {
+		// Scroll bar
+		trackH := int32(updateNoteRows * 30)
+		thumbH := max(20, trackH*updateNoteRows/int32(len(u.lines)))
+		thumbY := (trackH - thumbH) * int32(u.scroll) / int32(len(u.lines)-updateNoteRows)
+		rl.DrawRectangle(x+panelW-24, y+110, 8, trackH, rl.DarkGray)
+		rl.DrawRectangle(x+panelW-24, y+110+thumbY, 8, thumbH, rl.Lime)
+	}
+
+	hint := "UP/DOWN or wheel scroll  O open download page  ESC close"
+	drawText(hint, x+panelW/2-measureText(hint, 20)/2, y+panelH-40, 20, rl.LightGray)
+}
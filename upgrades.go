@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Upgrade offers. Each upgrade break draws upgradeOfferSize different
+// upgrades from upgradePool with the run's rng: first a rarity by weight,
+// then an upgrade of that rarity. Rarer upgrades take a stat several steps
+// at once or do something the stat upgrades can't. Stat steps are the same
+// ones loadout codes count, so builds still share as before.
+const (
+	upgradeOfferSize = 3
+	upgradeEvolve    = upgradeOfferSize // ApplyUpgrade choice after the offers
+)
+
+type Rarity int
+
+const (
+	RarityCommon Rarity = iota
+	RarityRare
+	RarityEpic
+	rarityCount
+)
+
+var (
+	rarityNames   = [rarityCount]string{"Common", "Rare", "Epic"}
+	rarityColors  = [rarityCount]rl.Color{rl.LightGray, rl.SkyBlue, rl.Purple}
+	rarityWeights = [rarityCount]int{70, 25, 5}
+)
+
+// UpgradeDef is one entry in the pool. steps are stat upgrades by kind
+// (see upgradeStats); apply, if set, runs after them for each player.
+// trait is given for good (synergy.go), so those upgrades are offered only
+// until taken.
+type UpgradeDef struct {
+	name   string
+	desc   string
+	rarity Rarity
+	steps  [upgradeKinds]int
+	apply  func(g *Game, p *Player)
+	trait  Trait
+}
+
+var upgradePool = []UpgradeDef{
+	{name: "Vitality", desc: "Max Health +20", rarity: RarityCommon, steps: [upgradeKinds]int{1, 0, 0, 0, 0}},
+	{name: "Power", desc: "Damage +1", rarity: RarityCommon, steps: [upgradeKinds]int{0, 1, 0, 0, 0}},
+	{name: "Swift", desc: "Speed +2", rarity: RarityCommon, steps: [upgradeKinds]int{0, 0, 1, 0, 0}},
+	{name: "Trigger", desc: "Fire Rate +10%", rarity: RarityCommon, steps: [upgradeKinds]int{0, 0, 0, 1, 0}},
+	{name: "Precision", desc: "Crit Chance +5%", rarity: RarityCommon, steps: [upgradeKinds]int{0, 0, 0, 0, 1}},
+	{name: "Field Medic", desc: "Heal to full", rarity: RarityCommon, apply: func(g *Game, p *Player) {
+		p.health = p.stats.maxHealth
+	}},
+
+	{name: "Fortitude", desc: "Max Health +40", rarity: RarityRare, steps: [upgradeKinds]int{2, 0, 0, 0, 0}},
+	{name: "Heavy Rounds", desc: "Damage +2", rarity: RarityRare, steps: [upgradeKinds]int{0, 2, 0, 0, 0}},
+	{name: "Sprinter", desc: "Speed +4", rarity: RarityRare, steps: [upgradeKinds]int{0, 0, 2, 0, 0}},
+	{name: "Hair Trigger", desc: "Fire Rate +20%", rarity: RarityRare, steps: [upgradeKinds]int{0, 0, 0, 2, 0}},
+	{name: "Marksman", desc: "Crit Chance +10%", rarity: RarityRare, steps: [upgradeKinds]int{0, 0, 0, 0, 2}},
+	{name: "Aegis", desc: "Full overshield", rarity: RarityRare, apply: func(g *Game, p *Player) {
+		p.shield = overshieldMax
+		g.addOvershield(p)
+	}},
+	{name: "Quick Learner", desc: "+1 skill point", rarity: RarityRare, apply: func(g *Game, p *Player) {
+		p.skillPoints++
+	}},
+	{name: "Piercing Rounds", desc: "Bullets pierce 2 enemies", rarity: RarityRare, trait: TraitPierce},
+	{name: "Volatile Crits", desc: "Crits burst on impact", rarity: RarityRare, trait: TraitVolatile},
+	{name: "Leech", desc: "Kills heal 2", rarity: RarityRare, trait: TraitLeech},
+
+	{name: "Colossus", desc: "Max Health +60", rarity: RarityEpic, steps: [upgradeKinds]int{3, 0, 0, 0, 0}},
+	{name: "Devastator", desc: "Damage +3", rarity: RarityEpic, steps: [upgradeKinds]int{0, 3, 0, 0, 0}},
+	{name: "Bullet Hose", desc: "Fire Rate +30%", rarity: RarityEpic, steps: [upgradeKinds]int{0, 0, 0, 3, 0}},
+	{name: "Executioner", desc: "Crit Chance +15%", rarity: RarityEpic, steps: [upgradeKinds]int{0, 0, 0, 0, 3}},
+	{name: "Juggernaut", desc: "Max Health +40, Damage +1", rarity: RarityEpic, steps: [upgradeKinds]int{2, 1, 0, 0, 0}},
+}
+
+// drawUpgradeOffer picks the upgrades shown at this break.
+func (g *Game) drawUpgradeOffer() {
+	var taken []int
+	for slot := range g.upgradeOffer {
+		rarity := g.rollRarity()
+		var candidates []int
+		for rarity >= 0 && len(candidates) == 0 {
+			for i, def := range upgradePool {
+				if def.rarity == rarity && !containsInt(taken, i) && (def.trait == 0 || !g.players[0].hasTraits(def.trait)) {
+					candidates = append(candidates, i)
+				}
+			}
+			// Fall back a rarity if this one ran out
+			rarity--
+		}
+		pick := candidates[g.rng.Intn(len(candidates))]
+		g.upgradeOffer[slot] = pick
+		taken = append(taken, pick)
+	}
+	g.mutateUpgradeOffer()
+}
+
+func (g *Game) rollRarity() Rarity {
+	total := 0
+	for _, w := range rarityWeights {
+		total += w
+	}
+	roll := g.rng.Intn(total)
+	for r, w := range rarityWeights {
+		if roll < w {
+			return Rarity(r)
+		}
+		roll -= w
+	}
+	return RarityCommon
+}
+
+func containsInt(s []int, v int) bool {
+	for _, x := range s {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// applyUpgradeDef gives one player upgradePool[idx].
+func (g *Game) applyUpgradeDef(p *Player, idx int) {
+	def := upgradePool[idx]
+	p.owned = append(p.owned, idx)
+	if combo := p.completesSynergy(def.trait); combo != "" {
+		// Shares the mastery banner; nothing else uses it at a break
+		g.CreateExplosion(p.position, rl.Gold, 12)
+		g.masteryNote = "Synergy: " + combo
+		g.masteryNoteTimer = masteryNoteTime
+	}
+	p.traits |= def.trait
+	for kind, steps := range def.steps {
+		for n := 0; n < steps; n++ {
+			p.stats = upgradeStats(p.stats, kind)
+		}
+		p.upgrades[kind] += steps
+	}
+	if def.steps[0] > 0 {
+		p.health = p.stats.maxHealth
+	}
+	if def.apply != nil {
+		def.apply(g, p)
+	}
+}
+
+// drawUpgradeCard draws one offered upgrade. combo names the synergy it
+// would complete, if any.
+func drawUpgradeCard(def UpgradeDef, combo string, key int, x, y int32) {
+	const w, h = 300, 200
+	color := rarityColors[def.rarity]
+	rl.DrawRectangle(x, y, w, h, rl.NewColor(20, 20, 40, 230))
+	rl.DrawRectangleLinesEx(rl.NewRectangle(float32(x), float32(y), w, h), float32(2+def.rarity*2), color)
+
+	drawText(fmt.Sprintf("[%d]", key), x+15, y+15, 25, rl.Yellow)
+	rarity := rarityNames[def.rarity]
+	drawText(rarity, x+w-15-measureText(rarity, 20), y+18, 20, color)
+	drawText(def.name, x+w/2-measureText(def.name, 30)/2, y+70, 30, rl.White)
+	drawText(def.desc, x+w/2-measureText(def.desc, 20)/2, y+125, 20, color)
+	if combo != "" {
+		text := "Combo: " + combo
+		drawText(text, x+w/2-measureText(text, 20)/2, y+160, 20, rl.Gold)
+	}
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// Visual regression harness (-visual-test <dir>). Each scene starts a run
+// on a fixed seed, steps a fixed number of frames at a fixed timestep with
+// no input, renders offscreen and compares the frame with
+// <dir>/<scene>.png. Failing scenes write <scene>_actual.png and
+// <scene>_diff.png next to the golden. -update-goldens rewrites the goldens
+// instead; do that only after checking a visual change is intended.
+const (
+	visualTestStep       = float32(1.0 / 60)
+	visualChannelTol     = 12    // per-channel difference still counted as equal
+	visualMaxDiffedRatio = 0.002 // share of pixels allowed to differ
+)
+
+type VisualScene struct {
+	name   string
+	seed   int64
+	coop   bool
+	frames int
+	setup  func(g *Game) // runs after the run starts, before stepping
+}
+
+var visualScenes = []VisualScene{
+	{name: "basic_solo", seed: 1, frames: 120},
+	{name: "maze_coop", seed: 2, coop: true, frames: 120, setup: func(g *Game) {
+		g.level = stageInterval + 1
+		g.GenerateStage()
+	}},
+	{name: "hazard_solo", seed: 3, frames: 90, setup: func(g *Game) {
+		g.level = stageInterval*2 + 1
+		g.GenerateStage()
+	}},
+	{name: "arena_boss", seed: 4, frames: 60, setup: func(g *Game) {
+		g.level = stageInterval*3 + 1
+		g.GenerateStage()
+		g.SpawnBoss()
+	}},
+	{name: "night_solo", seed: 6, frames: 90, setup: func(g *Game) {
+		g.level = stageInterval*4 + 1
+		g.GenerateStage()
+	}},
+	{name: "upgrade_screen", seed: 5, frames: 30, setup: func(g *Game) {
+		g.startUpgradeBreak()
+	}},
+}
+
+// runVisualTests renders every scene and returns the number of failures.
+func runVisualTests(g *Game, dir string, update bool) int {
+	target := rl.LoadRenderTexture(screenWidth, screenHeight)
+	defer rl.UnloadRenderTexture(target)
+
+	failed := 0
+	for _, scene := range visualScenes {
+		pixels := g.renderVisualScene(scene, target)
+		golden := filepath.Join(dir, scene.name+".png")
+
+		if update {
+			if err := writePNG(golden, pixels); err != nil {
+				fmt.Println("Warning: Could not write golden:", err)
+				failed++
+				continue
+			}
+			fmt.Println("✓ Updated:", golden)
+			continue
+		}
+
+		want, err := readPNG(golden)
+		if err != nil {
+			fmt.Printf("✗ %s: no golden (%v), run with -update-goldens\n", scene.name, err)
+			failed++
+			continue
+		}
+		diff, ratio := diffImages(want, pixels)
+		if ratio <= visualMaxDiffedRatio {
+			fmt.Printf("✓ %s (%.3f%% differ)\n", scene.name, ratio*100)
+			continue
+		}
+
+		fmt.Printf("✗ %s: %.3f%% of pixels differ\n", scene.name, ratio*100)
+		writePNG(filepath.Join(dir, scene.name+"_actual.png"), pixels)
+		if diff != nil {
+			writePNG(filepath.Join(dir, scene.name+"_diff.png"), diff)
+		}
+		failed++
+	}
+	return failed
+}
+
+// renderVisualScene restarts the game on the scene's seed, plays it and
+// captures the last frame.
+func (g *Game) renderVisualScene(scene VisualScene, target rl.RenderTexture2D) *image.RGBA {
+	g.settings.soundEnabled = false
+	g.settings.musicEnabled = false
+	// Goldens are full resolution, and the scene is already drawn into a texture
+	g.settings.dynamicRes = false
+	g.settings.postFX = false
+	g.settings.shake = 0
+	g.fixedSeed = scene.seed
+	// Particles use the global source
+	rand.Seed(scene.seed)
+
+	g.StartGame(scene.coop)
+	// Keep the rng seeded but leave seed records and ghosts out of it
+	g.seeded = false
+	if scene.setup != nil {
+		scene.setup(g)
+	}
+	for i := 0; i < scene.frames; i++ {
+		g.Update(visualTestStep)
+	}
+
+	rl.BeginTextureMode(target)
+	g.drawFrame()
+	rl.EndTextureMode()
+	return captureTexture(target)
+}
+
+// captureTexture reads a render texture back into an image.
+func captureTexture(target rl.RenderTexture2D) *image.RGBA {
+	img := rl.LoadImageFromTexture(target.Texture)
+	defer rl.UnloadImage(img)
+	// Render textures are stored bottom-up
+	rl.ImageFlipVertical(img)
+
+	colors := rl.LoadImageColors(img)
+	defer rl.UnloadImageColors(colors)
+
+	out := image.NewRGBA(image.Rect(0, 0, int(img.Width), int(img.Height)))
+	for i, c := range colors {
+		out.Set(i%int(img.Width), i/int(img.Width), c)
+	}
+	return out
+}
+
+// diffImages returns an image marking differing pixels in red and the share
+// of pixels that differ. Images of different sizes differ entirely.
+func diffImages(want image.Image, got *image.RGBA) (*image.RGBA, float64) {
+	bounds := got.Bounds()
+	if want.Bounds() != bounds {
+		return nil, 1
+	}
+
+	diff := image.NewRGBA(bounds)
+	differing := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			a := color.RGBAModel.Convert(want.At(x, y)).(color.RGBA)
+			b := got.RGBAAt(x, y)
+			if channelDiff(a.R, b.R) > visualChannelTol || channelDiff(a.G, b.G) > visualChannelTol ||
+				channelDiff(a.B, b.B) > visualChannelTol || channelDiff(a.A, b.A) > visualChannelTol {
+				differing++
+				diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+			} else {
+				// Faded copy of the frame for context
+				diff.SetRGBA(x, y, color.RGBA{b.R / 4, b.G / 4, b.B / 4, 255})
+			}
+		}
+	}
+	return diff, float64(differing) / float64(bounds.Dx()*bounds.Dy())
+}
+
+func channelDiff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+func writePNG(path string, img image.Image) error {
+	os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return png.Encode(f, img)
+}
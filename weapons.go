@@ -0,0 +1,527 @@
+package main
+
+import (
+	"math"
+
+	rl "github.com/gen2brain/raylib-go/raylib"
+)
+
+// WeaponType selects how ShootBullet fires.
+type WeaponType int
+
+const (
+	WeaponBlaster WeaponType = iota
+	WeaponShotgun
+	WeaponRocket
+	WeaponLaser
+	WeaponHoming
+
+	// Evolved forms (evolution.go)
+	WeaponPulseRifle
+	WeaponAutoShotgun
+	WeaponBarrage
+	WeaponPrismBeam
+	WeaponSwarm
+	weaponCount
+)
+
+// baseWeaponCount is the number of weapons that can drop; the rest are
+// only reached through evolution.
+const baseWeaponCount = WeaponPulseRifle
+
+// ProjectileKind decides what happens when a projectile hits something.
+type ProjectileKind int
+
+const (
+	ProjectileBullet ProjectileKind = iota
+	ProjectileRocket                // explodes on impact, damaging an area
+	ProjectileHoming                // steers toward its target
+)
+
+// WeaponDef describes a weapon. Damage multipliers apply to the player's
+// damage stat; falloff scales damage down between falloffStart and maxRange.
+type WeaponDef struct {
+	name         string
+	color        rl.Color // HUD swatch and pickup colour
+	fireRateMul  float32  // multiplier on stats.fireRate
+	pellets      int      // projectiles per shot
+	spread       float32  // total cone angle in radians
+	speed        float32
+	damageMul    float32
+	falloffStart float32 // distance where damage starts dropping (0 = none)
+	maxRange     float32 // bullet expires after this distance (0 = off-map only)
+	minFalloff   float32 // damage factor at maxRange
+	bulletSize   float32
+
+	kind        ProjectileKind
+	blastRadius float32 // rockets: area damage radius
+	knockback   float32 // rockets: push distance at the blast centre
+
+	beam bool // continuous beam instead of projectiles (range = maxRange)
+
+	turnRate float32 // homing: max steering in radians per second
+
+	// Ammo modifier (see ammo.go). magSize 0 = never needs ammo,
+	// reserveMax 0 = unlimited reserve.
+	magSize    int
+	reserveMax int
+	reloadTime float32
+
+	// Evolution: kills needed before the weapon can become evolvesInto
+	// (0 = doesn't evolve)
+	evolveXP    int
+	evolvesInto WeaponType
+}
+
+var weaponDefs = [weaponCount]WeaponDef{
+	WeaponBlaster: {
+		name:        "Blaster",
+		color:       rl.Yellow,
+		fireRateMul: 1.0,
+		pellets:     1,
+		speed:       40.0,
+		damageMul:   1.0,
+		bulletSize:  0.3,
+		magSize:     30,
+		reloadTime:  1.2,
+		evolveXP:    60,
+		evolvesInto: WeaponPulseRifle,
+	},
+	WeaponShotgun: {
+		name:         "Shotgun",
+		color:        rl.Gold,
+		fireRateMul:  4.0,
+		pellets:      5,
+		spread:       0.6,
+		speed:        34.0,
+		damageMul:    2.0,
+		falloffStart: 6.0,
+		maxRange:     18.0,
+		minFalloff:   0.25,
+		bulletSize:   0.2,
+		magSize:      6,
+		reserveMax:   36,
+		reloadTime:   1.8,
+		evolveXP:     40,
+		evolvesInto:  WeaponAutoShotgun,
+	},
+	WeaponRocket: {
+		name:        "Rocket",
+		color:       rl.Orange,
+		fireRateMul: 6.0,
+		pellets:     1,
+		speed:       18.0,
+		damageMul:   3.0,
+		maxRange:    35.0,
+		bulletSize:  0.45,
+		kind:        ProjectileRocket,
+		blastRadius: 5.0,
+		knockback:   4.0,
+		magSize:     3,
+		reserveMax:  12,
+		reloadTime:  2.2,
+		evolveXP:    40,
+		evolvesInto: WeaponBarrage,
+	},
+	WeaponLaser: {
+		name:        "Laser",
+		color:       rl.Red,
+		damageMul:   1.0,
+		maxRange:    25.0,
+		beam:        true,
+		evolveXP:    50,
+		evolvesInto: WeaponPrismBeam,
+	},
+	WeaponHoming: {
+		name:        "Homing",
+		color:       rl.SkyBlue,
+		fireRateMul: 3.0,
+		pellets:     2,
+		spread:      1.2,
+		speed:       22.0,
+		damageMul:   1.5,
+		maxRange:    60.0,
+		bulletSize:  0.3,
+		kind:        ProjectileHoming,
+		turnRate:    4.0,
+		magSize:     8,
+		reserveMax:  40,
+		reloadTime:  2.0,
+		evolveXP:    40,
+		evolvesInto: WeaponSwarm,
+	},
+
+	WeaponPulseRifle: {
+		name:        "Pulse Rifle",
+		color:       rl.Lime,
+		fireRateMul: 0.6,
+		pellets:     1,
+		speed:       48.0,
+		damageMul:   1.3,
+		bulletSize:  0.3,
+		magSize:     45,
+		reloadTime:  1.0,
+	},
+	WeaponAutoShotgun: {
+		name:         "Auto-Shotgun",
+		color:        rl.Gold,
+		fireRateMul:  1.6,
+		pellets:      6,
+		spread:       0.7,
+		speed:        34.0,
+		damageMul:    2.0,
+		falloffStart: 6.0,
+		maxRange:     18.0,
+		minFalloff:   0.25,
+		bulletSize:   0.2,
+		magSize:      12,
+		reserveMax:   72,
+		reloadTime:   2.0,
+	},
+	WeaponBarrage: {
+		name:        "Barrage",
+		color:       rl.NewColor(255, 100, 0, 255),
+		fireRateMul: 5.0,
+		pellets:     3,
+		spread:      0.35,
+		speed:       18.0,
+		damageMul:   3.0,
+		maxRange:    35.0,
+		bulletSize:  0.45,
+		kind:        ProjectileRocket,
+		blastRadius: 5.0,
+		knockback:   4.0,
+		magSize:     6,
+		reserveMax:  24,
+		reloadTime:  2.4,
+	},
+	WeaponPrismBeam: {
+		name:      "Prism Beam",
+		color:     rl.Violet,
+		damageMul: 1.6,
+		maxRange:  32.0,
+		beam:      true,
+	},
+	WeaponSwarm: {
+		name:        "Swarm",
+		color:       rl.Blue,
+		fireRateMul: 2.5,
+		pellets:     4,
+		spread:      2.0,
+		speed:       24.0,
+		damageMul:   1.5,
+		maxRange:    60.0,
+		bulletSize:  0.3,
+		kind:        ProjectileHoming,
+		turnRate:    5.0,
+		magSize:     16,
+		reserveMax:  64,
+		reloadTime:  2.0,
+	},
+}
+
+// Laser beam tuning
+const (
+	beamTick        = float32(0.1)  // seconds between damage ticks
+	beamHeatRate    = float32(0.35) // heat per second while firing
+	beamCoolRate    = float32(0.5)  // heat lost per second while idle
+	beamRecoverHeat = float32(0.3)  // overheated beams unlock below this
+	beamWidth       = float32(0.15)
+)
+
+// spawnBullet takes a free bullet from the pool. Returns nil if the pool
+// is exhausted.
+func (g *Game) spawnBullet(player *Player, angle float32, speed float32, damage int, weapon WeaponType) *Bullet {
+	return g.spawnBulletFrom(player, player.position, angle, speed, damage, weapon)
+}
+
+// spawnBulletFrom is spawnBullet fired from pos instead of the player, for
+// companions. The shot still belongs to the player.
+func (g *Game) spawnBulletFrom(player *Player, from rl.Vector3, angle float32, speed float32, damage int, weapon WeaponType) *Bullet {
+	for i := range g.bullets {
+		if g.bullets[i].active {
+			continue
+		}
+		pos := from
+		pos.Y = 1
+
+		g.bullets[i] = Bullet{
+			position: pos,
+			origin:   pos,
+			velocity: rl.NewVector3(
+				float32(math.Cos(float64(angle)))*speed,
+				0,
+				float32(math.Sin(float64(angle)))*speed,
+			),
+			active:   true,
+			damage:   berserkDamage(player, damage),
+			playerId: player.id,
+			weapon:   weapon,
+			kind:     weaponDefs[weapon].kind,
+			target:   -1,
+		}
+		if weaponDefs[weapon].kind != ProjectileRocket && player.hasTraits(TraitPierce) {
+			g.bullets[i].pierce = pierceCount
+		}
+		g.addFlash(pos, muzzleLightRange, muzzleLightTime)
+		return &g.bullets[i]
+	}
+	return nil
+}
+
+// bulletTravel is how far a bullet has flown from where it was fired.
+func bulletTravel(b *Bullet) float32 {
+	dx := b.position.X - b.origin.X
+	dz := b.position.Z - b.origin.Z
+	return float32(math.Sqrt(float64(dx*dx + dz*dz)))
+}
+
+// bulletDamage applies the weapon's distance falloff.
+func bulletDamage(b *Bullet) int {
+	def := weaponDefs[b.weapon]
+	if def.falloffStart <= 0 || def.maxRange <= def.falloffStart {
+		return b.damage
+	}
+
+	travel := bulletTravel(b)
+	factor := float32(1.0)
+	if travel > def.falloffStart {
+		t := (travel - def.falloffStart) / (def.maxRange - def.falloffStart)
+		factor = 1 - (1-def.minFalloff)*float32(math.Min(1, float64(t)))
+	}
+	return int(math.Max(1, math.Round(float64(float32(b.damage)*factor))))
+}
+
+// cycleWeapon switches to the next weapon the player carries.
+func (p *Player) cycleWeapon() {
+	if len(p.weapons) == 0 {
+		return
+	}
+	idx := 0
+	for i, w := range p.weapons {
+		if w == p.weapon {
+			idx = i
+		}
+	}
+	p.weapon = p.weapons[(idx+1)%len(p.weapons)]
+	p.reloading = false
+}
+
+// damageEnemy applies damage from player by (-1 = the defenses) and kills
+// the enemy when its health runs out.
+func (g *Game) damageEnemy(index, damage, by int) {
+	if !g.enemies[index].active {
+		return
+	}
+	damage = g.mutateDamage(DamageEvent{Enemy: &g.enemies[index], Amount: damage})
+	g.statDealt(by, damage, g.enemies[index].health)
+	g.enemies[index].health -= damage
+	if g.enemies[index].health <= 0 {
+		g.KillEnemy(index)
+	}
+}
+
+// explodeRocket detonates a rocket: enemies inside the blast take damage
+// that falls off toward the edge and are knocked away from the centre.
+func (g *Game) explodeRocket(b *Bullet) {
+	def := weaponDefs[b.weapon]
+	b.active = false
+	center := b.position
+
+	g.CreateExplosion(center, rl.Orange, 15)
+	g.playExplosion()
+	g.addTrauma(blastTrauma)
+	g.destroyObstaclesInRadius(center, def.blastRadius)
+	g.damageNestsInRadius(center, def.blastRadius, b.damage)
+
+	g.blastHits = g.enemiesInBlast(center.X, center.Z, def.blastRadius, g.blastHits[:0])
+	if len(g.blastHits) > 0 && b.tracked {
+		g.recordMasteryHit(b.weapon)
+		g.statHit(b.playerId)
+	}
+	for _, i := range g.blastHits {
+		e := &g.enemies[i]
+		dx := e.position.X - center.X
+		dz := e.position.Z - center.Z
+		dist := float32(math.Sqrt(float64(dx*dx + dz*dz)))
+		closeness := 1 - dist/def.blastRadius
+
+		// Knockback (bosses are too heavy to move)
+		if !e.isBoss && dist > 0.01 {
+			push := def.knockback * closeness
+			newPos := e.position
+			newPos.X += dx / dist * push
+			newPos.Z += dz / dist * push
+			if !g.CheckObstacleCollision(newPos, e.size/2) {
+				e.position = newPos
+			}
+		}
+
+		if g.shieldBlocks(i, center.X, center.Z) {
+			continue
+		}
+		damage := int(math.Max(1, math.Round(float64(float32(b.damage)*(0.5+0.5*closeness)))))
+		g.creditHit(i, b.playerId, b.weapon)
+		g.damageEnemy(i, damage, b.playerId)
+	}
+}
+
+// updateBeam handles heat, damage ticks and the beam endpoint for a player
+// holding a beam weapon. Called once per frame after input.
+func (g *Game) updateBeam(player *Player, dt float32) {
+	def := weaponDefs[player.weapon]
+	firing := def.beam && player.beamFiring && !player.overheated
+
+	if !firing {
+		player.beamFiring = false
+		player.heat = float32(math.Max(0, float64(player.heat-beamCoolRate*dt)))
+		if player.overheated && player.heat <= beamRecoverHeat {
+			player.overheated = false
+		}
+		return
+	}
+
+	player.heat += beamHeatRate * dt
+	if player.heat >= 1 {
+		player.heat = 1
+		player.overheated = true
+	}
+
+	// March along the aim direction until a wall or the max range
+	dirX := float32(math.Cos(float64(player.angle)))
+	dirZ := float32(math.Sin(float64(player.angle)))
+	start := player.position
+	start.Y = 1
+	length := def.maxRange
+	for d := float32(0.5); d <= def.maxRange; d += 0.5 {
+		p := rl.NewVector3(start.X+dirX*d, start.Y, start.Z+dirZ*d)
+		if g.CheckObstacleCollision(p, 0.1) {
+			length = d
+			break
+		}
+	}
+	player.beamEnd = rl.NewVector3(start.X+dirX*length, start.Y, start.Z+dirZ*length)
+
+	player.beamTimer -= dt
+	if player.beamTimer > 0 {
+		return
+	}
+	player.beamTimer = beamTick
+	if !g.spendEnergy(player, shotEnergy(player.stats, player.weapon)) {
+		player.beamFiring = false
+		return
+	}
+
+	damage := berserkDamage(player, g.masteryDamage(player.weapon, shotDamage(player.stats, player.weapon)))
+	if g.rng.Float32() < player.stats.critChance {
+		damage *= 3
+	}
+	g.recordMasteryShot(player.weapon)
+	g.statShot(player.id)
+	g.addFlash(player.beamEnd, muzzleLightRange, beamTick)
+	hit := false
+
+	// Damage everything touching the segment
+	for i := range g.enemies {
+		e := &g.enemies[i]
+		if !e.active {
+			continue
+		}
+		ex := e.position.X - start.X
+		ez := e.position.Z - start.Z
+		along := ex*dirX + ez*dirZ
+		if along < 0 || along > length {
+			continue
+		}
+		perp := float32(math.Abs(float64(ex*dirZ - ez*dirX)))
+		if perp < e.size {
+			if g.shieldBlocks(i, start.X, start.Z) {
+				g.deflect(e.position)
+				continue
+			}
+			g.CreateExplosion(e.position, rl.Red, 2)
+			g.creditHit(i, player.id, player.weapon)
+			g.damageEnemy(i, damage, player.id)
+			hit = true
+		}
+	}
+	for i := range g.nests {
+		n := &g.nests[i]
+		if !n.active {
+			continue
+		}
+		nx := n.position.X - start.X
+		nz := n.position.Z - start.Z
+		along := nx*dirX + nz*dirZ
+		if along < 0 || along > length || float32(math.Abs(float64(nx*dirZ-nz*dirX))) >= nestRadius {
+			continue
+		}
+		g.CreateExplosion(n.position, rl.Purple, 2)
+		g.damageNest(i, damage)
+	}
+	if hit {
+		g.recordMasteryHit(player.weapon)
+		g.statHit(player.id)
+	}
+}
+
+// drawBeam renders an active beam as a glowing cylinder.
+func (g *Game) drawBeam(player Player) {
+	if !player.beamFiring || player.overheated {
+		return
+	}
+	start := player.position
+	start.Y = 1
+	rl.DrawCylinderEx(start, player.beamEnd, beamWidth*2, beamWidth*2, 8, rl.Fade(player.color, 0.4))
+	rl.DrawCylinderEx(start, player.beamEnd, beamWidth, beamWidth, 8, rl.NewColor(255, 220, 220, 255))
+}
+
+// drawHeatMeter shows beam heat next to the weapon name.
+func drawHeatMeter(player Player, x, y int32) {
+	if !weaponDefs[player.weapon].beam && player.heat <= 0 {
+		return
+	}
+	color := rl.Orange
+	if player.overheated {
+		color = rl.Red
+	}
+	rl.DrawRectangle(x, y, 80, 10, rl.DarkGray)
+	rl.DrawRectangle(x, y, int32(80*player.heat), 10, color)
+}
+
+// steerHoming turns a homing projectile toward its target, acquiring the
+// nearest enemy when it has none. Turning is capped by the weapon turn rate.
+func (g *Game) steerHoming(b *Bullet, dt float32) {
+	if b.target < 0 || !g.enemies[b.target].active {
+		b.target = -1
+		minD := float32(math.MaxFloat32)
+		for i := range g.enemies {
+			if !g.enemies[i].active {
+				continue
+			}
+			dx := g.enemies[i].position.X - b.position.X
+			dz := g.enemies[i].position.Z - b.position.Z
+			if d := dx*dx + dz*dz; d < minD {
+				minD = d
+				b.target = i
+			}
+		}
+		if b.target < 0 {
+			return
+		}
+	}
+
+	target := g.enemies[b.target].position
+	current := math.Atan2(float64(b.velocity.Z), float64(b.velocity.X))
+	desired := math.Atan2(float64(target.Z-b.position.Z), float64(target.X-b.position.X))
+
+	// Shortest signed angle between the two headings
+	diff := math.Remainder(desired-current, 2*math.Pi)
+	maxTurn := float64(weaponDefs[b.weapon].turnRate * dt)
+	diff = math.Max(-maxTurn, math.Min(maxTurn, diff))
+
+	heading := current + diff
+	speed := math.Sqrt(float64(b.velocity.X*b.velocity.X + b.velocity.Z*b.velocity.Z))
+	b.velocity.X = float32(math.Cos(heading) * speed)
+	b.velocity.Z = float32(math.Sin(heading) * speed)
+}